@@ -11,15 +11,23 @@ package main
 // @name Authorization
 
 import (
+	"context"
+	"flag"
+	_ "hotelbooking/docs"
 	"hotelbooking/internal/config"
+	"hotelbooking/internal/repository"
+	"hotelbooking/internal/repository/memory"
+	"hotelbooking/internal/repository/postgres"
 	"hotelbooking/internal/routes"
-	_ "hotelbooking/docs"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
 func main() {
+	storage := flag.String("storage", "supabase", "storage backend: supabase | memory | postgres")
+	flag.Parse()
+
 	// Inisialisasi Echo
 	e := echo.New()
 
@@ -27,13 +35,56 @@ func main() {
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 
-	// Hubungkan ke Supabase
-	if err := config.ConnectSupabase(); err != nil {
-		e.Logger.Fatalf("failed to connect to supabase: %v", err)
+	// Pilih backend storage untuk repository
+	var (
+		repos *repository.Repositories
+		uow   repository.UnitOfWork
+	)
+	switch *storage {
+	case "memory":
+		// Supabase tetap dipakai untuk Auth jika tersedia; data disimpan di memori
+		if err := config.ConnectSupabase(); err != nil {
+			e.Logger.Warnf("supabase auth unavailable, protected routes are disabled: %v", err)
+		}
+		store := memory.NewStore()
+		repos = memory.NewRepositories(store)
+		uow = memory.NewUnitOfWork(store)
+	case "postgres":
+		// Data disimpan langsung di Postgres (DATABASE_URL); Supabase hanya untuk Auth
+		if err := config.ConnectSupabase(); err != nil {
+			e.Logger.Warnf("supabase auth unavailable, protected routes are disabled: %v", err)
+		}
+		ctx := context.Background()
+		pool, err := config.ConnectPostgres(ctx)
+		if err != nil {
+			e.Logger.Fatalf("failed to connect to postgres: %v", err)
+		}
+		defer pool.Close()
+		if err := postgres.Migrate(ctx, pool); err != nil {
+			e.Logger.Fatalf("failed to migrate postgres schema: %v", err)
+		}
+		repos = postgres.NewRepositories(pool)
+		uow = postgres.NewUnitOfWork(pool)
+	case "supabase":
+		// Hubungkan ke Supabase
+		if err := config.ConnectSupabase(); err != nil {
+			e.Logger.Fatalf("failed to connect to supabase: %v", err)
+		}
+		repos = repository.NewSupabaseRepositories(config.SupabaseClient)
+		uow = repository.NewSupabaseUnitOfWork(repos)
+	default:
+		e.Logger.Fatalf("unknown storage backend %q (use supabase, memory or postgres)", *storage)
+	}
+
+	// Payment gateway: mock hanya untuk development (memori atau
+	// PAYMENT_MOCK_ENABLED), selain itu server menolak start
+	gateways, err := routes.NewPaymentGateways(*storage == "memory" || config.PaymentMockEnabled())
+	if err != nil {
+		e.Logger.Fatalf("failed to configure payment gateways: %v", err)
 	}
 
 	// Atur semua rute API
-	routes.SetupRoutes(e)
+	routes.SetupRoutes(e, repos, uow, gateways)
 
 	// Jalankan server di port 8080
 	e.Logger.Fatal(e.Start(":8080"))
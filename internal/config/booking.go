@@ -0,0 +1,35 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultBookingHoldTTL       = 15 * time.Minute
+	defaultBookingSweepInterval = time.Minute
+)
+
+// BookingHoldTTL adalah lama kamar ditahan untuk booking yang belum dibayar
+// (BOOKING_HOLD_TTL, contoh "15m"). Nilai 0 mematikan hold.
+func BookingHoldTTL() time.Duration {
+	loadEnv()
+
+	if !viper.IsSet("BOOKING_HOLD_TTL") {
+		return defaultBookingHoldTTL
+	}
+	return viper.GetDuration("BOOKING_HOLD_TTL")
+}
+
+// BookingSweepInterval adalah jeda antar putaran sweeper hold
+// (BOOKING_SWEEP_INTERVAL, contoh "1m").
+func BookingSweepInterval() time.Duration {
+	loadEnv()
+
+	interval := viper.GetDuration("BOOKING_SWEEP_INTERVAL")
+	if interval <= 0 {
+		return defaultBookingSweepInterval
+	}
+	return interval
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// PaymentMockEnabled menandakan mock gateway boleh dipakai
+// (PAYMENT_MOCK_ENABLED=true). Mock mengonfirmasi pembayaran tanpa dana
+// sungguhan, jadi hanya untuk development; --storage=memory selalu
+// memakainya.
+func PaymentMockEnabled() bool {
+	loadEnv()
+
+	return viper.GetBool("PAYMENT_MOCK_ENABLED")
+}
+
+// PaymentMockOutcome adalah hasil charge di mock gateway
+// (PAYMENT_MOCK_OUTCOME: success atau failure, default success).
+func PaymentMockOutcome() string {
+	loadEnv()
+
+	outcome := strings.ToLower(viper.GetString("PAYMENT_MOCK_OUTCOME"))
+	if outcome == "" {
+		return "success"
+	}
+	return outcome
+}
+
+// PaymentMockDelay adalah lama charge mock tetap pending sebelum selesai
+// (PAYMENT_MOCK_DELAY, contoh "30s"). Default 0: selesai saat dicek.
+func PaymentMockDelay() time.Duration {
+	loadEnv()
+
+	delay := viper.GetDuration("PAYMENT_MOCK_DELAY")
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// PaymentWebhookSecret adalah secret HMAC webhook untuk provider
+// (PAYMENT_WEBHOOK_SECRET_<PROVIDER>, contoh PAYMENT_WEBHOOK_SECRET_MOCK).
+// Kosong berarti semua webhook provider tersebut ditolak.
+func PaymentWebhookSecret(provider string) string {
+	loadEnv()
+
+	return viper.GetString("PAYMENT_WEBHOOK_SECRET_" + strings.ToUpper(provider))
+}
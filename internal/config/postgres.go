@@ -0,0 +1,32 @@
+package config
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/viper"
+)
+
+// ConnectPostgres membuka connection pool ke DATABASE_URL untuk backend
+// --storage=postgres (misalnya Postgres lokal di CI).
+func ConnectPostgres(ctx context.Context) (*pgxpool.Pool, error) {
+	loadEnv()
+
+	dsn := viper.GetString("DATABASE_URL")
+	if dsn == "" {
+		return nil, fmt.Errorf("DATABASE_URL is not set")
+	}
+
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize postgres pool: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to reach postgres: %w", err)
+	}
+
+	fmt.Println("Success connected to Postgres.... ")
+	return pool, nil
+}
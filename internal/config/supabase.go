@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"log"
+	"sync"
 
 	"github.com/spf13/viper"
 	"github.com/supabase-community/supabase-go"
@@ -10,14 +11,23 @@ import (
 
 var SupabaseClient *supabase.Client
 
-func ConnectSupabase() error {
+var envOnce sync.Once
 
-	viper.SetConfigFile(".env")
-	viper.AutomaticEnv()
+// loadEnv membaca .env dan environment variable sekali saja, walaupun
+// beberapa koneksi (Supabase, Postgres) diinisialisasi.
+func loadEnv() {
+	envOnce.Do(func() {
+		viper.SetConfigFile(".env")
+		viper.AutomaticEnv()
 
-	if err := viper.ReadInConfig(); err != nil {
-		log.Printf("No .env file found")
-	}
+		if err := viper.ReadInConfig(); err != nil {
+			log.Printf("No .env file found")
+		}
+	})
+}
+
+func ConnectSupabase() error {
+	loadEnv()
 
 	supaURL := viper.GetString("SUPABASE_URL")
 	if supaURL == "" {
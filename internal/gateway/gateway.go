@@ -0,0 +1,125 @@
+// Package gateway berisi abstraksi payment gateway. Booking tidak pernah
+// ditandai lunas atas klaim tamu: service membuat charge di gateway, lalu
+// status charge dari gateway yang menentukan payment lunas atau tidak.
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownProvider = errors.New("payment provider tidak dikenal")
+	ErrChargeNotFound  = errors.New("charge tidak ditemukan")
+)
+
+// ChargeStatus adalah status charge menurut gateway.
+type ChargeStatus string
+
+const (
+	// ChargePending menunggu tamu menyelesaikan pembayaran (redirect/VA).
+	ChargePending ChargeStatus = "pending"
+	// ChargeAuthorized berarti dana sudah ditahan dan menunggu capture.
+	ChargeAuthorized ChargeStatus = "authorized"
+	ChargeCaptured   ChargeStatus = "captured"
+	ChargeFailed     ChargeStatus = "failed"
+)
+
+// ChargeRequest adalah permintaan tagihan ke gateway. OrderID adalah id
+// payment di sistem kita sehingga gateway bisa mengirimnya kembali.
+type ChargeRequest struct {
+	OrderID     string
+	Amount      float64
+	Currency    string
+	Description string
+}
+
+// Charge adalah tagihan di sisi gateway. RedirectURL atau VANumber
+// diberikan ke tamu untuk menyelesaikan pembayaran.
+type Charge struct {
+	Provider       string       `json:"provider"`
+	Reference      string       `json:"reference"`
+	OrderID        string       `json:"order_id"`
+	Amount         float64      `json:"amount"`
+	CapturedAmount float64      `json:"captured_amount"`
+	RefundedAmount float64      `json:"refunded_amount"`
+	Currency       string       `json:"currency"`
+	Status         ChargeStatus `json:"status"`
+	RedirectURL    string       `json:"redirect_url,omitempty"`
+	VANumber       string       `json:"va_number,omitempty"`
+	FailureReason  string       `json:"failure_reason,omitempty"`
+	ExpiresAt      *time.Time   `json:"expires_at,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// Refund adalah pengembalian dana atas charge yang sudah di-capture.
+type Refund struct {
+	Provider        string    `json:"provider"`
+	Reference       string    `json:"reference"`
+	ChargeReference string    `json:"charge_reference"`
+	Amount          float64   `json:"amount"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// PaymentGateway adalah kontrak satu payment provider.
+type PaymentGateway interface {
+	// Name adalah kunci provider, sama dengan Payment.Provider.
+	Name() string
+	CreateCharge(req ChargeRequest) (*Charge, error)
+	// Capture menarik dana charge yang sudah authorized.
+	Capture(reference string, amount float64) (*Charge, error)
+	Refund(reference string, amount float64) (*Refund, error)
+	// Status mengambil status terbaru charge dari gateway.
+	Status(reference string) (*Charge, error)
+}
+
+// Registry memetakan nama provider ke gateway-nya.
+type Registry struct {
+	mu       sync.RWMutex
+	gateways map[string]PaymentGateway
+}
+
+func NewRegistry(gateways ...PaymentGateway) *Registry {
+	r := &Registry{gateways: make(map[string]PaymentGateway)}
+	for _, gateway := range gateways {
+		r.Register(gateway)
+	}
+	return r
+}
+
+// Register menambah atau mengganti gateway untuk provider gateway.Name().
+func (r *Registry) Register(gateway PaymentGateway) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gateways[normalizeProvider(gateway.Name())] = gateway
+}
+
+func (r *Registry) Get(provider string) (PaymentGateway, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	gateway, ok := r.gateways[normalizeProvider(provider)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, provider)
+	}
+	return gateway, nil
+}
+
+// Providers mengembalikan nama provider terdaftar, terurut.
+func (r *Registry) Providers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]string, 0, len(r.gateways))
+	for name := range r.gateways {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func normalizeProvider(provider string) string {
+	return strings.ToLower(strings.TrimSpace(provider))
+}
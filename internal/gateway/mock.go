@@ -0,0 +1,255 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const MockProvider = "mock"
+
+// MockOutcome menentukan hasil akhir charge di mock gateway.
+type MockOutcome string
+
+const (
+	MockOutcomeSuccess MockOutcome = "success"
+	MockOutcomeFailure MockOutcome = "failure"
+)
+
+// MockConfig mengatur perilaku mock gateway. Charge tetap pending selama
+// Delay sejak dibuat, lalu menjadi authorized (success) atau failed
+// (failure) saat statusnya ditanyakan.
+type MockConfig struct {
+	Outcome MockOutcome
+	Delay   time.Duration
+	// WebhookSecret dipakai menandatangani dan memverifikasi webhook mock.
+	WebhookSecret string
+	// Now dipakai sebagai jam gateway; nil berarti time.Now.
+	Now func() time.Time
+}
+
+type mockCharge struct {
+	charge   Charge
+	outcome  MockOutcome
+	settleAt time.Time
+}
+
+// MockGateway adalah gateway lokal tanpa jaringan untuk development dan
+// demo. Semua charge disimpan di memori proses.
+type MockGateway struct {
+	mu      sync.Mutex
+	cfg     MockConfig
+	charges map[string]*mockCharge
+	seq     int
+}
+
+func NewMockGateway(cfg MockConfig) *MockGateway {
+	g := &MockGateway{charges: make(map[string]*mockCharge)}
+	g.SetConfig(cfg)
+	return g
+}
+
+// SetConfig mengganti konfigurasi untuk charge yang dibuat setelahnya.
+func (g *MockGateway) SetConfig(cfg MockConfig) {
+	if cfg.Outcome == "" {
+		cfg.Outcome = MockOutcomeSuccess
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.cfg = cfg
+}
+
+func (g *MockGateway) Name() string { return MockProvider }
+
+func (g *MockGateway) CreateCharge(req ChargeRequest) (*Charge, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("nominal charge harus lebih dari 0")
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.seq++
+	now := g.cfg.Now()
+	expiresAt := now.Add(24 * time.Hour)
+	reference := "mock_ch_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:16]
+	currency := req.Currency
+	if currency == "" {
+		currency = "IDR"
+	}
+	charge := Charge{
+		Provider:    MockProvider,
+		Reference:   reference,
+		OrderID:     req.OrderID,
+		Amount:      req.Amount,
+		Currency:    currency,
+		Status:      ChargePending,
+		RedirectURL: "mock://checkout/" + reference,
+		VANumber:    fmt.Sprintf("8808%010d", g.seq),
+		ExpiresAt:   &expiresAt,
+		CreatedAt:   now,
+	}
+	g.charges[reference] = &mockCharge{charge: charge, outcome: g.cfg.Outcome, settleAt: now.Add(g.cfg.Delay)}
+	out := charge
+	return &out, nil
+}
+
+// settle menjalankan hasil yang dikonfigurasi begitu delay lewat.
+func (g *MockGateway) settle(c *mockCharge) {
+	if c.charge.Status != ChargePending || g.cfg.Now().Before(c.settleAt) {
+		return
+	}
+	if c.outcome == MockOutcomeFailure {
+		c.charge.Status = ChargeFailed
+		c.charge.FailureReason = "pembayaran ditolak oleh mock gateway"
+		return
+	}
+	c.charge.Status = ChargeAuthorized
+}
+
+func (g *MockGateway) find(reference string) (*mockCharge, error) {
+	c, ok := g.charges[reference]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrChargeNotFound, reference)
+	}
+	g.settle(c)
+	return c, nil
+}
+
+func (g *MockGateway) Status(reference string) (*Charge, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c, err := g.find(reference)
+	if err != nil {
+		return nil, err
+	}
+	out := c.charge
+	return &out, nil
+}
+
+func (g *MockGateway) Capture(reference string, amount float64) (*Charge, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c, err := g.find(reference)
+	if err != nil {
+		return nil, err
+	}
+	switch c.charge.Status {
+	case ChargeCaptured:
+		out := c.charge
+		return &out, nil
+	case ChargeAuthorized:
+	default:
+		return nil, fmt.Errorf("charge %s berstatus %s, tidak dapat di-capture", reference, c.charge.Status)
+	}
+	if amount <= 0 || amount > c.charge.Amount {
+		return nil, fmt.Errorf("nominal capture tidak valid")
+	}
+	c.charge.Status = ChargeCaptured
+	c.charge.CapturedAmount = amount
+	out := c.charge
+	return &out, nil
+}
+
+func (g *MockGateway) Refund(reference string, amount float64) (*Refund, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c, err := g.find(reference)
+	if err != nil {
+		return nil, err
+	}
+	if c.charge.Status != ChargeCaptured {
+		return nil, fmt.Errorf("charge %s belum di-capture", reference)
+	}
+	remaining := math.Round((c.charge.CapturedAmount-c.charge.RefundedAmount)*100) / 100
+	if amount <= 0 || amount > remaining {
+		return nil, fmt.Errorf("nominal refund melebihi sisa dana charge (%.2f)", remaining)
+	}
+	c.charge.RefundedAmount += amount
+	return &Refund{
+		Provider:        MockProvider,
+		Reference:       "mock_rf_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:16],
+		ChargeReference: reference,
+		Amount:          amount,
+		CreatedAt:       g.cfg.Now(),
+	}, nil
+}
+
+// MockSignatureHeader adalah header signature webhook mock gateway.
+const MockSignatureHeader = "X-Mock-Signature"
+
+type mockWebhookPayload struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      struct {
+		Reference string       `json:"reference"`
+		OrderID   string       `json:"order_id"`
+		Status    ChargeStatus `json:"status"`
+		Amount    float64      `json:"amount"`
+	} `json:"data"`
+}
+
+// Webhook membuat webhook bertanda tangan untuk status charge saat ini,
+// seperti yang akan dikirim gateway sungguhan ke
+// POST /webhooks/payments/mock.
+func (g *MockGateway) Webhook(reference string) ([]byte, string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c, err := g.find(reference)
+	if err != nil {
+		return nil, "", err
+	}
+	now := g.cfg.Now()
+	var event mockWebhookPayload
+	event.ID = "mock_evt_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:16]
+	event.Type = "charge." + string(c.charge.Status)
+	event.CreatedAt = now
+	event.Data.Reference = c.charge.Reference
+	event.Data.OrderID = c.charge.OrderID
+	event.Data.Status = c.charge.Status
+	event.Data.Amount = c.charge.Amount
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, NewSigner(g.cfg.WebhookSecret, 0).Sign(payload, now), nil
+}
+
+func (g *MockGateway) ParseWebhook(headers http.Header, payload []byte, now time.Time) (*WebhookEvent, error) {
+	g.mu.Lock()
+	secret := g.cfg.WebhookSecret
+	g.mu.Unlock()
+
+	if err := NewSigner(secret, 0).Verify(headers.Get(MockSignatureHeader), payload, now); err != nil {
+		return nil, err
+	}
+	var event mockWebhookPayload
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("payload webhook tidak valid: %v", err)
+	}
+	if event.ID == "" {
+		return nil, fmt.Errorf("payload webhook tidak memiliki id")
+	}
+	return &WebhookEvent{
+		ID:              event.ID,
+		Type:            event.Type,
+		ChargeReference: event.Data.Reference,
+		OrderID:         event.Data.OrderID,
+		Status:          event.Data.Status,
+		Amount:          event.Data.Amount,
+		CreatedAt:       event.CreatedAt,
+	}, nil
+}
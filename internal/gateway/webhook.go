@@ -0,0 +1,105 @@
+package gateway
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultWebhookTolerance adalah selisih maksimal timestamp signature dengan
+// jam server; event yang lebih tua ditolak walaupun signature-nya benar.
+const DefaultWebhookTolerance = 5 * time.Minute
+
+var ErrInvalidSignature = errors.New("signature webhook tidak valid")
+
+// WebhookEvent adalah notifikasi asinkron dari gateway yang sudah
+// diverifikasi. Status hanya informasi; status otoritatif tetap diambil
+// dari gateway lewat Status.
+type WebhookEvent struct {
+	ID              string       `json:"id"`
+	Type            string       `json:"type"`
+	ChargeReference string       `json:"charge_reference"`
+	OrderID         string       `json:"order_id"`
+	Status          ChargeStatus `json:"status"`
+	Amount          float64      `json:"amount"`
+	CreatedAt       time.Time    `json:"created_at"`
+}
+
+// WebhookReceiver diimplementasikan gateway yang mengirim webhook.
+type WebhookReceiver interface {
+	// ParseWebhook memverifikasi signature lalu mengurai payload.
+	ParseWebhook(headers http.Header, payload []byte, now time.Time) (*WebhookEvent, error)
+}
+
+// Signer membuat dan memeriksa signature HMAC-SHA256 berformat
+// "t=<unix>,v1=<hex>" atas "<unix>.<payload>". Dipakai mock gateway dan
+// bisa dipakai untuk mengirim webhook uji secara lokal.
+type Signer struct {
+	secret    []byte
+	tolerance time.Duration
+}
+
+func NewSigner(secret string, tolerance time.Duration) Signer {
+	if tolerance <= 0 {
+		tolerance = DefaultWebhookTolerance
+	}
+	return Signer{secret: []byte(secret), tolerance: tolerance}
+}
+
+func (s Signer) mac(timestamp string, payload []byte) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// Sign menghasilkan nilai header signature untuk payload pada waktu at.
+func (s Signer) Sign(payload []byte, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(s.mac(timestamp, payload))
+}
+
+// Verify memeriksa header signature terhadap payload mentah.
+func (s Signer) Verify(header string, payload []byte, now time.Time) error {
+	if len(s.secret) == 0 {
+		return fmt.Errorf("%w: secret webhook belum dikonfigurasi", ErrInvalidSignature)
+	}
+	var timestamp string
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			if sig, err := hex.DecodeString(value); err == nil {
+				signatures = append(signatures, sig)
+			}
+		}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return fmt.Errorf("%w: header signature tidak lengkap", ErrInvalidSignature)
+	}
+	signedAt := time.Unix(unix, 0)
+	if now.Sub(signedAt) > s.tolerance || signedAt.Sub(now) > s.tolerance {
+		return fmt.Errorf("%w: timestamp di luar toleransi", ErrInvalidSignature)
+	}
+	expected := s.mac(timestamp, payload)
+	for _, sig := range signatures {
+		if hmac.Equal(sig, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
@@ -0,0 +1,51 @@
+package gateway
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSignerVerify(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	signer := NewSigner("whsec_test", time.Minute)
+	payload := []byte(`{"id":"evt_1","type":"charge.captured"}`)
+
+	tests := []struct {
+		name    string
+		header  string
+		payload []byte
+		wantErr bool
+	}{
+		{name: "valid", header: signer.Sign(payload, now), payload: payload},
+		{name: "signed slightly in the future", header: signer.Sign(payload, now.Add(30*time.Second)), payload: payload},
+		{name: "tampered body", header: signer.Sign(payload, now), payload: []byte(`{"id":"evt_1","type":"charge.failed"}`), wantErr: true},
+		{name: "other secret", header: NewSigner("whsec_other", time.Minute).Sign(payload, now), payload: payload, wantErr: true},
+		{name: "malformed signature", header: "t=" + "1792314000" + ",v1=zz", payload: payload, wantErr: true},
+		{name: "missing header", header: "", payload: payload, wantErr: true},
+		{name: "stale timestamp", header: signer.Sign(payload, now.Add(-2*time.Minute)), payload: payload, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := signer.Verify(tt.header, tt.payload, now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSignature) {
+					t.Fatalf("Verify() error = %v, want ErrInvalidSignature", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestSignerWithoutSecretRejects(t *testing.T) {
+	now := time.Now()
+	payload := []byte(`{}`)
+	signer := NewSigner("", 0)
+	if err := signer.Verify(signer.Sign(payload, now), payload, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Verify() error = %v, want ErrInvalidSignature", err)
+	}
+}
@@ -2,6 +2,7 @@
 package handler
 
 import (
+	"errors"
 	"hotelbooking/internal/models"
 	"hotelbooking/internal/service"
 	"net/http"
@@ -286,10 +287,11 @@ func (h *AdminHandler) ListBookings(c echo.Context) error {
 	return c.JSON(http.StatusOK, bookings)
 }
 
+// UpdateBookingStatusRequest mengubah status booking. Refund di luar aturan
+// pembatalan dibuat lewat POST /admin/bookings/{id}/refunds.
 type UpdateBookingStatusRequest struct {
-	Status       models.BookingStatus `json:"status"`
-	Note         string               `json:"note"`
-	RefundAmount float64              `json:"refund_amount"`
+	Status models.BookingStatus `json:"status"`
+	Note   string               `json:"note"`
 }
 
 // @Summary Update booking status
@@ -303,7 +305,9 @@ type UpdateBookingStatusRequest struct {
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 422 {object} map[string]string
+// @Failure 502 {object} map[string]string
 // @Router /admin/bookings/{id}/status [put]
 func (h *AdminHandler) UpdateBookingStatus(c echo.Context) error {
 	admin, ok := middleware.GetAdminFromContext(c)
@@ -321,9 +325,157 @@ func (h *AdminHandler) UpdateBookingStatus(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request body"})
 	}
-	booking, err := h.BookingSvc.UpdateStatus(id, req.Status, req.Note, req.RefundAmount)
+	booking, err := h.BookingSvc.UpdateStatus(id, req.Status, req.Note, service.AdminActor(admin.ID.String()))
+	if errors.Is(err, service.ErrBookingStatusConflict) {
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	}
+	if errors.Is(err, service.ErrRefundIncomplete) {
+		return c.JSON(http.StatusBadGateway, echo.Map{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, booking)
 }
+
+// @Summary Booking status history
+// @Tags Bookings
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Success 200 {array} models.BookingStatusHistory
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/bookings/{id}/history [get]
+func (h *AdminHandler) BookingHistory(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	booking, err := h.BookingSvc.GetBookingByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	if admin.PropertyID != nil && (booking.PropertyID == nil || booking.PropertyID.String() != admin.PropertyID.String()) {
+		return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+	}
+	history, err := h.BookingSvc.GetStatusHistory(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, history)
+}
+
+type AssignRoomRequest struct {
+	RoomID string `json:"room_id"`
+}
+
+// @Summary Assign room to booking
+// @Description Kosongkan room_id untuk memilih kamar otomatis
+// @Tags Bookings
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Param payload body AssignRoomRequest false "Room to assign"
+// @Success 200 {object} models.Booking
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /admin/bookings/{id}/assign-room [post]
+func (h *AdminHandler) AssignRoom(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	if admin.PropertyID != nil {
+		booking, err := h.BookingSvc.GetBookingByID(id)
+		if err != nil || booking.PropertyID == nil || booking.PropertyID.String() != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+	var req AssignRoomRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request body"})
+	}
+	booking, err := h.BookingSvc.AssignRoom(id, req.RoomID)
+	if errors.Is(err, service.ErrRoomUnavailable) {
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, booking)
+}
+
+// @Summary Set named occupants
+// @Description Dipakai front desk saat check-in untuk melengkapi data registrasi tamu
+// @Tags Bookings
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Param payload body OccupantsRequest true "Occupants"
+// @Success 200 {array} models.BookingOccupant
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /admin/bookings/{id}/occupants [put]
+func (h *AdminHandler) SetOccupants(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	if admin.PropertyID != nil {
+		booking, err := h.BookingSvc.GetBookingByID(id)
+		if err != nil || booking.PropertyID == nil || booking.PropertyID.String() != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+	var req OccupantsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request body"})
+	}
+	occupants, err := h.BookingSvc.SetOccupants("", id, req.Occupants)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, occupants)
+}
+
+// @Summary Get named occupants
+// @Tags Bookings
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Success 200 {array} models.BookingOccupant
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/bookings/{id}/occupants [get]
+func (h *AdminHandler) GetOccupants(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	if admin.PropertyID != nil {
+		booking, err := h.BookingSvc.GetBookingByID(id)
+		if err != nil || booking.PropertyID == nil || booking.PropertyID.String() != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+	occupants, err := h.BookingSvc.GetOccupants("", id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, occupants)
+}
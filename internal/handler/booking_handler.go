@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"hotelbooking/internal/models"
 	"hotelbooking/internal/service"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -20,20 +22,21 @@ func NewBookingHandler(svc service.BookingService) *BookingHandler {
 
 type AvailabilityResponse struct {
 	Available    bool                  `json:"available"`
+	RatePlan     *models.RatePlan      `json:"rate_plan,omitempty"`
+	RoomsLeft    *int                  `json:"rooms_left,omitempty"`
 	Nights       int                   `json:"nights"`
+	Adults       int                   `json:"adults"`
+	Children     int                   `json:"children"`
 	TotalPrice   float64               `json:"total_price"`
 	NightlyRates []service.NightlyRate `json:"nightly_rates"`
 	Currency     string                `json:"currency,omitempty"`
-}
-
-type PaymentInvoiceResponse struct {
-	Payment *models.Payment `json:"payment"`
-	Invoice *models.Invoice `json:"invoice"`
+	Reasons      []service.QuoteReason `json:"reasons"`
+	Offers       []service.RateOffer   `json:"offers"`
 }
 
 type BookingCancelResponse struct {
-	Booking *models.Booking `json:"booking"`
-	Payment *models.Payment `json:"payment"`
+	Booking  *models.Booking  `json:"booking"`
+	Payments []models.Payment `json:"payments"`
 }
 
 // GET /api/v1/rooms/:room_id/availability?check_in=YYYY-MM-DD&check_out=YYYY-MM-DD
@@ -43,16 +46,104 @@ type BookingCancelResponse struct {
 // @Param room_id path string true "Room ID"
 // @Param check_in query string true "Check-in date (YYYY-MM-DD)"
 // @Param check_out query string true "Check-out date (YYYY-MM-DD)"
+// @Param adults query int false "Number of adults (default 1)"
+// @Param children query int false "Number of children"
+// @Param child_ages query string false "Comma-separated child ages, e.g. 4,9"
+// @Param rate_plan_id query string false "Rate plan ID (default: base room rate)"
 // @Success 200 {object} AvailabilityResponse
 // @Failure 400 {object} map[string]string
 // @Failure 422 {object} map[string]string
 // @Router /rooms/{room_id}/availability [get]
 func (h *BookingHandler) CheckAvailability(c echo.Context) error {
 	roomID := c.Param("room_id")
+	if roomID == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "room_id, check_in, and check_out are required"})
+	}
+	return h.availability(c, service.QuoteInput{RoomID: roomID})
+}
+
+// GET /api/v1/room-types/:room_type_id/availability?check_in=YYYY-MM-DD&check_out=YYYY-MM-DD
+// @Summary Check room type availability
+// @Description Sisa unit tipe kamar = kamar yang bisa dijual dikurangi booking yang beririsan
+// @Tags Rooms
+// @Produce json
+// @Param room_type_id path string true "Room type ID"
+// @Param check_in query string true "Check-in date (YYYY-MM-DD)"
+// @Param check_out query string true "Check-out date (YYYY-MM-DD)"
+// @Param adults query int false "Number of adults (default 1)"
+// @Param children query int false "Number of children"
+// @Param child_ages query string false "Comma-separated child ages, e.g. 4,9"
+// @Param rate_plan_id query string false "Rate plan ID (default: base room type rate)"
+// @Success 200 {object} AvailabilityResponse
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /room-types/{room_type_id}/availability [get]
+func (h *BookingHandler) CheckRoomTypeAvailability(c echo.Context) error {
+	roomTypeID := c.Param("room_type_id")
+	if roomTypeID == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "room_type_id, check_in, and check_out are required"})
+	}
+	return h.availability(c, service.QuoteInput{RoomTypeID: roomTypeID})
+}
+
+// GET /api/v1/hotels/:id/calendar?from=YYYY-MM-DD&to=YYYY-MM-DD
+// @Summary Availability calendar
+// @Description Per tanggal dan tipe kamar: sisa unit, harga termurah satu malam termasuk pajak dan restriction (CTA/CTD/min-stay). from dan to inklusif, maksimal 366 hari
+// @Tags Hotels
+// @Produce json
+// @Param id path string true "Property ID"
+// @Param from query string true "First date (YYYY-MM-DD)"
+// @Param to query string true "Last date, inclusive (YYYY-MM-DD)"
+// @Param room_type_id query string false "Only this room type"
+// @Param adults query int false "Number of adults (default 1)"
+// @Param children query int false "Number of children"
+// @Success 200 {object} service.AvailabilityCalendar
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /hotels/{id}/calendar [get]
+func (h *BookingHandler) GetCalendar(c echo.Context) error {
+	fromStr, toStr := c.QueryParam("from"), c.QueryParam("to")
+	if fromStr == "" || toStr == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "from and to are required"})
+	}
+	from, err := time.Parse("2006-01-02", fromStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid from"})
+	}
+	to, err := time.Parse("2006-01-02", toStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid to"})
+	}
+	adults, err := queryInt(c, "adults")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid adults"})
+	}
+	children, err := queryInt(c, "children")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid children"})
+	}
+
+	calendar, err := h.Svc.GetCalendar(service.CalendarInput{
+		PropertyID: c.Param("id"),
+		RoomTypeID: c.QueryParam("room_type_id"),
+		From:       from,
+		To:         to,
+		Adults:     adults,
+		Children:   children,
+	})
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, calendar)
+}
+
+// availability melengkapi input quote dari query string lalu menulis
+// AvailabilityResponse.
+func (h *BookingHandler) availability(c echo.Context, input service.QuoteInput) error {
 	checkInStr := c.QueryParam("check_in")
 	checkOutStr := c.QueryParam("check_out")
-	if roomID == "" || checkInStr == "" || checkOutStr == "" {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "room_id, check_in, and check_out are required"})
+	if checkInStr == "" || checkOutStr == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "check_in and check_out are required"})
 	}
 	checkIn, err := time.Parse("2006-01-02", checkInStr)
 	if err != nil {
@@ -63,25 +154,54 @@ func (h *BookingHandler) CheckAvailability(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid check_out"})
 	}
 
-	quote, err := h.Svc.QuoteBooking(roomID, checkIn, checkOut)
+	adults, err := queryInt(c, "adults")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid adults"})
+	}
+	children, err := queryInt(c, "children")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid children"})
+	}
+
+	childAges, err := queryInts(c, "child_ages")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid child_ages"})
+	}
+
+	input.RatePlanID = c.QueryParam("rate_plan_id")
+	input.CheckIn, input.CheckOut = checkIn, checkOut
+	input.Adults, input.Children, input.ChildAges = adults, children, childAges
+	quote, err := h.Svc.QuoteBooking(input)
 	if err != nil {
 		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
 	}
 
 	return c.JSON(http.StatusOK, AvailabilityResponse{
 		Available:    quote.Available,
+		RatePlan:     quote.RatePlan,
+		RoomsLeft:    quote.RoomsLeft,
 		Nights:       quote.Nights,
+		Adults:       quote.Adults,
+		Children:     quote.Children,
 		TotalPrice:   quote.TotalPrice,
 		NightlyRates: quote.NightlyRates,
 		Currency:     quote.Currency,
+		Reasons:      quote.Reasons,
+		Offers:       quote.Offers,
 	})
 }
 
 type CreateBookingRequest struct {
-	PropertyID string `json:"property_id"`
-	RoomID     string `json:"room_id"`
-	CheckIn    string `json:"check_in"`
-	CheckOut   string `json:"check_out"`
+	PropertyID string                  `json:"property_id"`
+	RoomID     string                  `json:"room_id"`
+	RoomTypeID string                  `json:"room_type_id"`
+	RatePlanID string                  `json:"rate_plan_id"`
+	CheckIn    string                  `json:"check_in"`
+	CheckOut   string                  `json:"check_out"`
+	Adults     int                     `json:"adults"`
+	Children   int                     `json:"children"`
+	ChildAges  []int                   `json:"child_ages"`
+	Occupants  []service.OccupantInput `json:"occupants"`
 }
 
 // POST /api/v1/guests/bookings
@@ -94,6 +214,7 @@ type CreateBookingRequest struct {
 // @Success 201 {object} service.BookingCreateResult
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 422 {object} map[string]string
 // @Router /guests/bookings [post]
 func (h *BookingHandler) CreateBooking(c echo.Context) error {
@@ -115,7 +236,22 @@ func (h *BookingHandler) CreateBooking(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid check_out"})
 	}
 
-	result, err := h.Svc.CreateBooking(user.ID.String(), req.PropertyID, req.RoomID, checkIn, checkOut)
+	result, err := h.Svc.CreateBooking(service.CreateBookingInput{
+		GuestID:    user.ID.String(),
+		PropertyID: req.PropertyID,
+		RoomID:     req.RoomID,
+		RoomTypeID: req.RoomTypeID,
+		RatePlanID: req.RatePlanID,
+		CheckIn:    checkIn,
+		CheckOut:   checkOut,
+		Adults:     req.Adults,
+		Children:   req.Children,
+		ChildAges:  req.ChildAges,
+		Occupants:  req.Occupants,
+	})
+	if errors.Is(err, service.ErrRoomUnavailable) {
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
 	}
@@ -123,20 +259,47 @@ func (h *BookingHandler) CreateBooking(c echo.Context) error {
 	return c.JSON(http.StatusCreated, result)
 }
 
+// queryInt membaca query param angka opsional; kosong berarti 0.
+func queryInt(c echo.Context, name string) (int, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// queryInts membaca daftar angka opsional, dipisah koma atau param berulang.
+func queryInts(c echo.Context, name string) ([]int, error) {
+	values := queryList(c, name)
+	out := make([]int, 0, len(values))
+	for _, value := range values {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// PayBookingRequest memilih payment gateway dan cicilan yang dibayar
+// (kosong berarti cicilan berikutnya yang jatuh tempo); status lunas hanya
+// datang dari gateway, bukan dari tamu.
 type PayBookingRequest struct {
-	Provider  string `json:"provider"`
-	Reference string `json:"reference"`
+	Provider  string `json:"provider" example:"mock"`
+	PaymentID string `json:"payment_id,omitempty"`
 }
 
 // POST /api/v1/guests/bookings/:id/pay
 // @Summary Pay booking
+// @Description Membuat charge di gateway untuk sisa tagihan satu cicilan dan mengembalikan redirect URL / nomor VA. Booking terkonfirmasi setelah gateway mengonfirmasi pembayaran deposit (atau pembayaran penuh)
 // @Tags Guests
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path string true "Booking ID"
 // @Param payload body PayBookingRequest true "Payment payload"
-// @Success 200 {object} PaymentInvoiceResponse
+// @Success 200 {object} service.PaymentSession
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Failure 422 {object} map[string]string
@@ -151,14 +314,34 @@ func (h *BookingHandler) PayBooking(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
 	}
-	payment, invoice, err := h.Svc.MarkPaymentPaid(user.ID.String(), bookingID, req.Provider, req.Reference)
+	session, err := h.Svc.StartPayment(user.ID.String(), bookingID, req.PaymentID, req.Provider, time.Now())
 	if err != nil {
 		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
 	}
-	return c.JSON(http.StatusOK, echo.Map{
-		"payment": payment,
-		"invoice": invoice,
-	})
+	return c.JSON(http.StatusOK, session)
+}
+
+// GET /api/v1/guests/bookings/:id/payment
+// @Summary Booking payment status
+// @Description Mengambil status charge terbaru dari gateway; charge yang sudah dibayar membuat cicilan lunas dan booking terkonfirmasi
+// @Tags Guests
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Success 200 {object} service.PaymentSession
+// @Failure 401 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /guests/bookings/{id}/payment [get]
+func (h *BookingHandler) GetPaymentStatus(c echo.Context) error {
+	user, ok := c.Get("user").(*types.User)
+	if !ok || user == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	session, err := h.Svc.SyncPayment(user.ID.String(), c.Param("id"), time.Now())
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, session)
 }
 
 // POST /api/v1/guests/bookings/:id/cancel
@@ -170,7 +353,9 @@ func (h *BookingHandler) PayBooking(c echo.Context) error {
 // @Param id path string true "Booking ID"
 // @Success 200 {object} BookingCancelResponse
 // @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 422 {object} map[string]string
+// @Failure 502 {object} map[string]string
 // @Router /guests/bookings/{id}/cancel [post]
 func (h *BookingHandler) CancelBooking(c echo.Context) error {
 	user, ok := c.Get("user").(*types.User)
@@ -179,13 +364,19 @@ func (h *BookingHandler) CancelBooking(c echo.Context) error {
 	}
 	bookingID := c.Param("id")
 
-	booking, payment, err := h.Svc.CancelBooking(user.ID.String(), bookingID, time.Now())
+	booking, payments, err := h.Svc.CancelBooking(user.ID.String(), bookingID, time.Now())
+	if errors.Is(err, service.ErrRefundIncomplete) {
+		return c.JSON(http.StatusBadGateway, echo.Map{"error": err.Error()})
+	}
+	if errors.Is(err, service.ErrBookingStatusConflict) {
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, echo.Map{
-		"booking": booking,
-		"payment": payment,
+		"booking":  booking,
+		"payments": payments,
 	})
 }
 
@@ -211,3 +402,59 @@ func (h *BookingHandler) GetInvoice(c echo.Context) error {
 	}
 	return c.JSON(http.StatusOK, invoice)
 }
+
+type OccupantsRequest struct {
+	Occupants []service.OccupantInput `json:"occupants"`
+}
+
+// PUT /api/v1/guests/bookings/:id/occupants
+// @Summary Set named occupants
+// @Description Mengganti daftar tamu bernama; isi guest_id untuk tamu terdaftar atau first_name untuk tamu lain
+// @Tags Guests
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Param payload body OccupantsRequest true "Occupants"
+// @Success 200 {array} models.BookingOccupant
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /guests/bookings/{id}/occupants [put]
+func (h *BookingHandler) SetOccupants(c echo.Context) error {
+	user, ok := c.Get("user").(*types.User)
+	if !ok || user == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	var req OccupantsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
+	}
+	occupants, err := h.Svc.SetOccupants(user.ID.String(), c.Param("id"), req.Occupants)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, occupants)
+}
+
+// GET /api/v1/guests/bookings/:id/occupants
+// @Summary Get named occupants
+// @Tags Guests
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Success 200 {array} models.BookingOccupant
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /guests/bookings/{id}/occupants [get]
+func (h *BookingHandler) GetOccupants(c echo.Context) error {
+	user, ok := c.Get("user").(*types.User)
+	if !ok || user == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	occupants, err := h.Svc.GetOccupants(user.ID.String(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, occupants)
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"errors"
+	"hotelbooking/internal/middleware"
+	"hotelbooking/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+// ModifyBookingRequest hanya berisi field yang ingin diubah; field yang
+// tidak dikirim tetap seperti booking semula.
+type ModifyBookingRequest struct {
+	CheckIn    string `json:"check_in"`
+	CheckOut   string `json:"check_out"`
+	RoomID     string `json:"room_id"`
+	RoomTypeID string `json:"room_type_id"`
+	RatePlanID string `json:"rate_plan_id"`
+	Adults     *int   `json:"adults"`
+	Children   *int   `json:"children"`
+	ChildAges  []int  `json:"child_ages"`
+}
+
+func (req ModifyBookingRequest) input() (service.ModifyBookingInput, error) {
+	input := service.ModifyBookingInput{
+		RoomID:     req.RoomID,
+		RoomTypeID: req.RoomTypeID,
+		RatePlanID: req.RatePlanID,
+		Adults:     req.Adults,
+		Children:   req.Children,
+		ChildAges:  req.ChildAges,
+	}
+	var err error
+	if req.CheckIn != "" {
+		if input.CheckIn, err = time.Parse("2006-01-02", req.CheckIn); err != nil {
+			return input, errors.New("invalid check_in")
+		}
+	}
+	if req.CheckOut != "" {
+		if input.CheckOut, err = time.Parse("2006-01-02", req.CheckOut); err != nil {
+			return input, errors.New("invalid check_out")
+		}
+	}
+	return input, nil
+}
+
+// PATCH /api/v1/guests/bookings/:id
+// @Summary Modify booking
+// @Description Mengubah tanggal, kamar, rate plan, atau jumlah tamu. Harga di-quote ulang; selisihnya menjadi tagihan tambahan atau refund sebagian. Rate plan non-refundable tidak bisa diubah, dan perubahan ditutup sesuai modification_cutoff_hours property
+// @Tags Guests
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Param payload body ModifyBookingRequest true "Fields to change"
+// @Success 200 {object} service.BookingModificationResult
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /guests/bookings/{id} [patch]
+func (h *BookingHandler) ModifyBooking(c echo.Context) error {
+	user, ok := c.Get("user").(*types.User)
+	if !ok || user == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	var req ModifyBookingRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
+	}
+	input, err := req.input()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	guestID := user.ID.String()
+	result, err := h.Svc.ModifyBooking(guestID, c.Param("id"), input, service.GuestActor(guestID), time.Now())
+	if errors.Is(err, service.ErrRefundIncomplete) {
+		return c.JSON(http.StatusBadGateway, echo.Map{"error": err.Error()})
+	}
+	if errors.Is(err, service.ErrRoomUnavailable) || errors.Is(err, service.ErrBookingStatusConflict) {
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Modify booking
+// @Description Sama dengan perubahan oleh tamu, tetapi tidak terikat batas waktu dan aturan non-refundable property
+// @Tags Bookings
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Param payload body ModifyBookingRequest true "Fields to change"
+// @Success 200 {object} service.BookingModificationResult
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /admin/bookings/{id} [patch]
+func (h *AdminHandler) ModifyBooking(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	if admin.PropertyID != nil {
+		booking, err := h.BookingSvc.GetBookingByID(id)
+		if err != nil || booking.PropertyID == nil || booking.PropertyID.String() != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+	var req ModifyBookingRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request body"})
+	}
+	input, err := req.input()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	result, err := h.BookingSvc.ModifyBooking("", id, input, service.AdminActor(admin.ID.String()), time.Now())
+	if errors.Is(err, service.ErrRefundIncomplete) {
+		return c.JSON(http.StatusBadGateway, echo.Map{"error": err.Error()})
+	}
+	if errors.Is(err, service.ErrRoomUnavailable) || errors.Is(err, service.ErrBookingStatusConflict) {
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Booking modification history
+// @Tags Bookings
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Success 200 {array} models.BookingModification
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/bookings/{id}/modifications [get]
+func (h *AdminHandler) BookingModifications(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	booking, err := h.BookingSvc.GetBookingByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	if admin.PropertyID != nil && (booking.PropertyID == nil || booking.PropertyID.String() != admin.PropertyID.String()) {
+		return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+	}
+	modifications, err := h.BookingSvc.GetModifications(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, modifications)
+}
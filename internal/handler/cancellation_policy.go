@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"hotelbooking/internal/middleware"
+	"hotelbooking/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+// CancellationPolicyRequest berisi aturan pembatalan baru; policy null
+// menghapus aturan sehingga kembali ke aturan di atasnya.
+type CancellationPolicyRequest struct {
+	Policy *models.CancellationPolicy `json:"policy"`
+}
+
+// @Summary Set hotel cancellation policy
+// @Description Tier diurutkan dari hours_before_check_in terbesar; pembatalan yang tidak masuk tier mana pun dikenakan denda penuh. penalty_type: percent, nights, fixed
+// @Tags Inventory
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Hotel ID"
+// @Param payload body CancellationPolicyRequest true "Cancellation policy"
+// @Success 200 {object} models.Properties
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /admin/hotels/{id}/cancellation-policy [put]
+func (h *InventoryHandler) SetHotelCancellationPolicy(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	if admin.PropertyID != nil && admin.PropertyID.String() != id {
+		return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+	}
+	var req CancellationPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
+	}
+	res, err := h.Svc.SetPropertyCancellationPolicy(id, req.Policy)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Summary Set rate plan cancellation policy
+// @Description Menggantikan aturan property untuk booking rate plan ini. Rate plan non-refundable selalu tanpa refund
+// @Tags Inventory
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Rate plan ID"
+// @Param payload body CancellationPolicyRequest true "Cancellation policy"
+// @Success 200 {object} models.RatePlan
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /admin/rate-plans/{id}/cancellation-policy [put]
+func (h *InventoryHandler) SetRatePlanCancellationPolicy(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	if admin.PropertyID != nil {
+		plan, err := h.Svc.GetRatePlanByID(id)
+		if err != nil || plan.PropertyID == nil || plan.PropertyID.String() != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+	var req CancellationPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
+	}
+	res, err := h.Svc.SetRatePlanCancellationPolicy(id, req.Policy)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// GET /api/v1/guests/bookings/:id/cancellation-preview
+// @Summary Preview cancellation refund
+// @Description Refund yang akan diterima jika booking dibatalkan sekarang, tanpa membatalkannya
+// @Tags Guests
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Success 200 {object} service.CancellationPreview
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /guests/bookings/{id}/cancellation-preview [get]
+func (h *BookingHandler) PreviewCancellation(c echo.Context) error {
+	user, ok := c.Get("user").(*types.User)
+	if !ok || user == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	preview, err := h.Svc.PreviewCancellation(user.ID.String(), c.Param("id"), time.Now())
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, preview)
+}
+
+// @Summary Preview cancellation refund
+// @Description Refund menurut aturan pembatalan jika booking dibatalkan sekarang
+// @Tags Bookings
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Success 200 {object} service.CancellationPreview
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/bookings/{id}/cancellation-preview [get]
+func (h *AdminHandler) PreviewCancellation(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	booking, err := h.BookingSvc.GetBookingByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	if admin.PropertyID != nil && (booking.PropertyID == nil || booking.PropertyID.String() != admin.PropertyID.String()) {
+		return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+	}
+	preview, err := h.BookingSvc.PreviewCancellation("", id, time.Now())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, preview)
+}
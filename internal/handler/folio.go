@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"errors"
+	"hotelbooking/internal/middleware"
+	"hotelbooking/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+// @Summary Booking folio
+// @Description Semua baris tagihan booking (kamar, tagihan tambahan, koreksi, dan baris yang di-void) beserta cicilan dan invoice. Nominal invoice diturunkan dari balance folio
+// @Tags Bookings
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Success 200 {object} service.Folio
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/bookings/{id}/folio [get]
+func (h *AdminHandler) GetFolio(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	if admin.PropertyID != nil {
+		booking, err := h.BookingSvc.GetBookingByID(id)
+		if err != nil || booking.PropertyID == nil || booking.PropertyID.String() != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+	folio, err := h.BookingSvc.GetFolio("", id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, folio)
+}
+
+// @Summary Post folio charge
+// @Description Memposting tagihan tambahan (minibar, restaurant, laundry, late_checkout, extra_bed, other) ke folio booking yang Confirmed atau CheckedIn. Tagihan ditambahkan ke cicilan yang belum dibayar, atau menjadi cicilan adjustment baru
+// @Tags Bookings
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Param payload body service.FolioChargeInput true "Charge"
+// @Success 201 {object} service.FolioPostingResult
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /admin/bookings/{id}/folio/charges [post]
+func (h *AdminHandler) PostFolioCharge(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	if admin.PropertyID != nil {
+		booking, err := h.BookingSvc.GetBookingByID(id)
+		if err != nil || booking.PropertyID == nil || booking.PropertyID.String() != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+	var req service.FolioChargeInput
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request body"})
+	}
+	result, err := h.BookingSvc.PostFolioCharge(id, req, service.AdminActor(admin.ID.String()), time.Now())
+	if errors.Is(err, service.ErrRefundIncomplete) {
+		return c.JSON(http.StatusBadGateway, echo.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, result)
+}
+
+// @Summary Adjust folio charge
+// @Description Memposting koreksi atas satu baris tagihan; amount negatif mengurangi tagihan. Pengurangan atas tagihan yang sudah dibayar di-refund lewat payment gateway
+// @Tags Bookings
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Param payload body service.FolioAdjustmentInput true "Adjustment"
+// @Success 201 {object} service.FolioPostingResult
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /admin/bookings/{id}/folio/adjustments [post]
+func (h *AdminHandler) AdjustFolioCharge(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	if admin.PropertyID != nil {
+		booking, err := h.BookingSvc.GetBookingByID(id)
+		if err != nil || booking.PropertyID == nil || booking.PropertyID.String() != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+	var req service.FolioAdjustmentInput
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request body"})
+	}
+	result, err := h.BookingSvc.AdjustFolioCharge(id, req, service.AdminActor(admin.ID.String()), time.Now())
+	if errors.Is(err, service.ErrRefundIncomplete) {
+		return c.JSON(http.StatusBadGateway, echo.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, result)
+}
+
+// VoidFolioChargeRequest berisi alasan void yang tercatat di baris folio.
+type VoidFolioChargeRequest struct {
+	Reason string `json:"reason"`
+}
+
+// @Summary Void folio charge
+// @Description Membatalkan satu baris folio beserta koreksinya. Tagihan kamar hanya berubah lewat perubahan atau pembatalan booking
+// @Tags Bookings
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Param charge_id path string true "Folio charge ID"
+// @Param payload body VoidFolioChargeRequest true "Void"
+// @Success 200 {object} service.FolioPostingResult
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /admin/bookings/{id}/folio/charges/{charge_id}/void [post]
+func (h *AdminHandler) VoidFolioCharge(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	if admin.PropertyID != nil {
+		booking, err := h.BookingSvc.GetBookingByID(id)
+		if err != nil || booking.PropertyID == nil || booking.PropertyID.String() != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+	var req VoidFolioChargeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request body"})
+	}
+	result, err := h.BookingSvc.VoidFolioCharge(id, c.Param("charge_id"), req.Reason, service.AdminActor(admin.ID.String()), time.Now())
+	if errors.Is(err, service.ErrRefundIncomplete) {
+		return c.JSON(http.StatusBadGateway, echo.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// GET /api/v1/guests/bookings/:id/folio
+// @Summary My booking folio
+// @Description Rincian tagihan booking milik tamu: kamar, tagihan tambahan selama menginap, dan koreksinya
+// @Tags Guests
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Success 200 {object} service.Folio
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /guests/bookings/{id}/folio [get]
+func (h *BookingHandler) GetFolio(c echo.Context) error {
+	user, ok := c.Get("user").(*types.User)
+	if !ok || user == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	folio, err := h.Svc.GetFolio(user.ID.String(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, folio)
+}
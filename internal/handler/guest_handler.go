@@ -2,9 +2,14 @@
 package handler
 
 import (
+	"fmt"
 	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
 	"hotelbooking/internal/service"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/supabase-community/gotrue-go/types"
@@ -93,29 +98,190 @@ func (h *GuestHandler) Login(c echo.Context) error {
 	return c.JSON(http.StatusOK, session)
 }
 
-// GET /api/v1/hotels?city=Jakarta
-// @Summary Search hotels by city
+// GET /api/v1/hotels?city=Jakarta&check_in=YYYY-MM-DD&check_out=YYYY-MM-DD&adults=2&rooms=1
+// @Summary Search hotels
+// @Description Tanpa tanggal hanya memfilter atribut hotel. Dengan check_in/check_out hanya hotel yang masih punya tipe kamar cukup yang dikembalikan, beserta harga total termurah. Facet dihitung dari semua hasil sebelum paging.
 // @Tags Hotels
 // @Produce json
-// @Param city query string true "City name"
-// @Success 200 {array} models.Properties
+// @Param q query string false "Free text: hotel name, city, address, facilities (typo tolerant)"
+// @Param city query string false "City name or alias (e.g. jogja)"
+// @Param check_in query string false "Check-in date (YYYY-MM-DD)"
+// @Param check_out query string false "Check-out date (YYYY-MM-DD)"
+// @Param adults query int false "Number of adults (default 1)"
+// @Param children query int false "Number of children"
+// @Param rooms query int false "Number of rooms (default 1)"
+// @Param min_price query number false "Minimum total price (needs dates)"
+// @Param max_price query number false "Maximum total price (needs dates)"
+// @Param facilities query string false "Property facilities, comma separated (all must match)"
+// @Param room_facilities query string false "Room type facilities, comma separated (all must match)"
+// @Param min_rating query number false "Minimum star rating"
+// @Param lat query number false "Reference latitude for distance"
+// @Param lng query number false "Reference longitude for distance"
+// @Param radius_km query number false "Only hotels within this radius of lat/lng"
+// @Param bbox query string false "Map bounding box: min_lng,min_lat,max_lng,max_lat"
+// @Param sort query string false "relevance (default with q), price_asc (default with dates), price_desc, rating, distance (needs lat/lng), popularity"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} service.HotelSearchPage
 // @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 422 {object} map[string]string
 // @Router /hotels [get]
 func (h *GuestHandler) SearchHotels(c echo.Context) error {
-	city := c.QueryParam("city")
-	if city == "" {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Parameter 'city' wajib diisi"})
+	input := service.HotelSearchInput{
+		Query:          c.QueryParam("q"),
+		City:           c.QueryParam("city"),
+		Facilities:     queryList(c, "facilities"),
+		RoomFacilities: queryList(c, "room_facilities"),
+		Sort:           c.QueryParam("sort"),
 	}
 
-	result, err := h.Svc.SearchHotels(city)
+	checkInStr := c.QueryParam("check_in")
+	checkOutStr := c.QueryParam("check_out")
+	if (checkInStr == "") != (checkOutStr == "") {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "check_in and check_out must be provided together"})
+	}
+	var err error
+	if checkInStr != "" {
+		if input.CheckIn, err = time.Parse("2006-01-02", checkInStr); err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid check_in"})
+		}
+		if input.CheckOut, err = time.Parse("2006-01-02", checkOutStr); err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid check_out"})
+		}
+	}
+	if input.Adults, err = queryInt(c, "adults"); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid adults"})
+	}
+	if input.Children, err = queryInt(c, "children"); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid children"})
+	}
+	if input.Rooms, err = queryInt(c, "rooms"); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid rooms"})
+	}
+	if input.Page, err = queryInt(c, "page"); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid page"})
+	}
+	if input.Limit, err = queryInt(c, "limit"); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid limit"})
+	}
+	if input.MinPrice, err = queryFloat(c, "min_price"); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid min_price"})
+	}
+	if input.MaxPrice, err = queryFloat(c, "max_price"); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid max_price"})
+	}
+	minRating, err := queryFloat(c, "min_rating")
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid min_rating"})
+	}
+	if minRating != nil {
+		input.MinRating = *minRating
 	}
 
+	lat, err := queryFloat(c, "lat")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid lat"})
+	}
+	lng, err := queryFloat(c, "lng")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid lng"})
+	}
+	if (lat == nil) != (lng == nil) {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "lat and lng must be provided together"})
+	}
+	if lat != nil {
+		input.Near = &service.GeoPoint{Lat: *lat, Lng: *lng}
+	}
+	radius, err := queryFloat(c, "radius_km")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid radius_km"})
+	}
+	if radius != nil {
+		input.RadiusKm = *radius
+	}
+	if raw := c.QueryParam("bbox"); raw != "" {
+		if input.Bounds, err = parseBBox(raw); err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid bbox, expected min_lng,min_lat,max_lng,max_lat"})
+		}
+	}
+
+	result, err := h.Svc.SearchHotels(input)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// GET /api/v1/hotels/autocomplete?q=jog
+// @Summary Autocomplete hotels and cities
+// @Description Saran kota (dengan jumlah hotel) dan nama hotel untuk kotak pencarian. Toleran typo dan alias kota.
+// @Tags Hotels
+// @Produce json
+// @Param q query string true "Partial text"
+// @Param limit query int false "Max suggestions per group (default 5, max 20)"
+// @Success 200 {object} service.AutocompleteResult
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /hotels/autocomplete [get]
+func (h *GuestHandler) Autocomplete(c echo.Context) error {
+	q := c.QueryParam("q")
+	if q == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Parameter 'q' wajib diisi"})
+	}
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid limit"})
+	}
+	result, err := h.Svc.Autocomplete(q, limit)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
 	return c.JSON(http.StatusOK, result)
 }
 
+// queryList menerima nilai berulang (?f=a&f=b) maupun dipisah koma (?f=a,b).
+func queryList(c echo.Context, name string) []string {
+	var out []string
+	for _, raw := range c.QueryParams()[name] {
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
+
+// parseBBox membaca bbox dengan urutan GeoJSON: min_lng,min_lat,max_lng,max_lat.
+func parseBBox(raw string) (*repository.GeoBounds, error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("bbox harus berisi 4 angka")
+	}
+	values := make([]float64, 4)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return &repository.GeoBounds{West: values[0], South: values[1], East: values[2], North: values[3]}, nil
+}
+
+func queryFloat(c echo.Context, name string) (*float64, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
 // GET /api/v1/hotels/:id
 // @Summary Get hotel detail
 // @Tags Hotels
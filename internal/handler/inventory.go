@@ -3,8 +3,8 @@ package handler
 import (
 	"encoding/json"
 	"fmt"
-	"hotelbooking/internal/models"
 	"hotelbooking/internal/middleware"
+	"hotelbooking/internal/models"
 	"hotelbooking/internal/service"
 	"net/http"
 	"time"
@@ -14,21 +14,27 @@ import (
 )
 
 type CreateHotelRequest struct {
-	Name      string   `json:"name"`
-	Address   string   `json:"address"`
-	City      string   `json:"city"`
-	HotelCode string   `json:"hotel_code"`
+	Name       string   `json:"name"`
+	Address    string   `json:"address"`
+	City       string   `json:"city"`
+	HotelCode  string   `json:"hotel_code"`
 	Facilities []string `json:"facilities"`
+	Latitude   *float64 `json:"latitude"`
+	Longitude  *float64 `json:"longitude"`
 }
 
 type UpdateHotelRequest struct {
-	Name               string   `json:"name"`
-	Address            string   `json:"address"`
-	City               string   `json:"city"`
-	Facilities         []string `json:"facilities"`
-	CheckInTime        string   `json:"checkin_time"`
-	CheckOutTime       string   `json:"checkout_time"`
-	CancellationPolicy string   `json:"cancellation_policy"`
+	Name                    string   `json:"name"`
+	Address                 string   `json:"address"`
+	City                    string   `json:"city"`
+	Facilities              []string `json:"facilities"`
+	CheckInTime             string   `json:"checkin_time"`
+	CheckOutTime            string   `json:"checkout_time"`
+	CancellationPolicy      string   `json:"cancellation_policy"`
+	ModificationCutoffHours *int     `json:"modification_cutoff_hours"`
+	Rating                  float64  `json:"rating"`
+	Latitude                *float64 `json:"latitude"`
+	Longitude               *float64 `json:"longitude"`
 }
 
 type InventoryHandler struct {
@@ -64,7 +70,7 @@ func (h *InventoryHandler) CreateHotel(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
 	}
 
-	result, err := h.Svc.CreateHotel(req.Name, req.Address, req.City, req.HotelCode)
+	result, err := h.Svc.CreateHotel(req.Name, req.Address, req.City, req.HotelCode, req.Latitude, req.Longitude)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
 	}
@@ -98,7 +104,7 @@ func (h *InventoryHandler) UpdateHotel(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
 	}
-	res, err := h.Svc.UpdateHotel(id, req.Name, req.Address, req.City, req.Facilities, req.CheckInTime, req.CheckOutTime, req.CancellationPolicy)
+	res, err := h.Svc.UpdateHotel(id, req.Name, req.Address, req.City, req.Facilities, req.CheckInTime, req.CheckOutTime, req.CancellationPolicy, req.ModificationCutoffHours, req.Rating, req.Latitude, req.Longitude)
 	if err != nil {
 		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
 	}
@@ -308,10 +314,10 @@ type CreateRoomRequest struct {
 }
 
 type UpdateRoomRequest struct {
-	PropertyID         string                   `json:"property_id"`
-	RoomTypeID         string                   `json:"room_type_id"`
-	RoomNumber         string                   `json:"room_number"`
-	Status             models.RoomStatus        `json:"status"`
+	PropertyID         string                    `json:"property_id"`
+	RoomTypeID         string                    `json:"room_type_id"`
+	RoomNumber         string                    `json:"room_number"`
+	Status             models.RoomStatus         `json:"status"`
 	HousekeepingStatus models.HousekeepingStatus `json:"housekeeping_status"`
 }
 
@@ -456,6 +462,7 @@ func (h *InventoryHandler) ListRooms(c echo.Context) error {
 
 type RoomRateRequest struct {
 	RoomID           string          `json:"room_id"`
+	RatePlanID       string          `json:"rate_plan_id"`
 	Dates            []string        `json:"dates"`
 	AvailableRooms   int             `json:"available_rooms"`
 	LinearRate       *float64        `json:"linear_rate"`
@@ -502,15 +509,37 @@ func (h *InventoryHandler) SetRoomRates(c echo.Context) error {
 			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
 		}
 	}
+	rates, err := req.toRates(&roomUUID, nil)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	if err := h.Svc.SetRoomRates(rates); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, echo.Map{"status": "ok"})
+}
+
+// toRates membentuk satu baris rate per tanggal untuk kamar atau tipe kamar.
+func (req RoomRateRequest) toRates(roomID, roomTypeID *uuid.UUID) ([]models.RoomRate, error) {
+	var ratePlanID *uuid.UUID
+	if req.RatePlanID != "" {
+		planUUID, err := uuid.Parse(req.RatePlanID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate_plan_id")
+		}
+		ratePlanID = &planUUID
+	}
 	var rates []models.RoomRate
 	for _, d := range req.Dates {
 		parsed, parseErr := time.Parse("2006-01-02", d)
 		if parseErr != nil {
-			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("invalid date: %s", d)})
+			return nil, fmt.Errorf("invalid date: %s", d)
 		}
 		rates = append(rates, models.RoomRate{
 			ID:               uuid.New(),
-			RoomID:           &roomUUID,
+			RoomID:           roomID,
+			RoomTypeID:       roomTypeID,
+			RatePlanID:       ratePlanID,
 			Date:             parsed,
 			AvailableRooms:   req.AvailableRooms,
 			LinearRate:       req.LinearRate,
@@ -523,10 +552,7 @@ func (h *InventoryHandler) SetRoomRates(c echo.Context) error {
 			CreatedAt:        time.Now(),
 		})
 	}
-	if err := h.Svc.SetRoomRates(rates); err != nil {
-		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
-	}
-	return c.JSON(http.StatusOK, echo.Map{"status": "ok"})
+	return rates, nil
 }
 
 // @Summary Get room rates
@@ -536,6 +562,7 @@ func (h *InventoryHandler) SetRoomRates(c echo.Context) error {
 // @Param room_id path string true "Room ID"
 // @Param start query string false "Start date (YYYY-MM-DD)"
 // @Param end query string false "End date (YYYY-MM-DD)"
+// @Param rate_plan_id query string false "Only rates of this rate plan"
 // @Success 200 {array} RoomRateDoc
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
@@ -555,7 +582,86 @@ func (h *InventoryHandler) GetRoomRates(c echo.Context) error {
 	}
 	start := c.QueryParam("start")
 	end := c.QueryParam("end")
-	rates, err := h.Svc.GetRoomRates(roomID, start, end)
+	rates, err := h.Svc.GetRoomRates(roomID, c.QueryParam("rate_plan_id"), start, end)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, rates)
+}
+
+// @Summary Set room type rates
+// @Description Rate level tipe kamar dipakai untuk booking yang memesan tipe kamar
+// @Tags Inventory
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param room_type_id path string true "Room type ID"
+// @Param payload body RoomRateRequestDoc true "Room rate payload (room_id diabaikan)"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /admin/room-types/{room_type_id}/rates [post]
+func (h *InventoryHandler) SetRoomTypeRates(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	roomTypeID := c.Param("room_type_id")
+	roomTypeUUID, err := uuid.Parse(roomTypeID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid room_type_id"})
+	}
+	var req RoomRateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
+	}
+	if len(req.Dates) == 0 {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "dates are required"})
+	}
+	if admin.PropertyID != nil {
+		roomType, err := h.Svc.GetRoomTypeByID(roomTypeID)
+		if err != nil || roomType.PropertyID == nil || roomType.PropertyID.String() != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+	rates, err := req.toRates(nil, &roomTypeUUID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	if err := h.Svc.SetRoomRates(rates); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, echo.Map{"status": "ok"})
+}
+
+// @Summary Get room type rates
+// @Tags Inventory
+// @Security BearerAuth
+// @Produce json
+// @Param room_type_id path string true "Room type ID"
+// @Param start query string false "Start date (YYYY-MM-DD)"
+// @Param end query string false "End date (YYYY-MM-DD)"
+// @Param rate_plan_id query string false "Only rates of this rate plan"
+// @Success 200 {array} RoomRateDoc
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/room-types/{room_type_id}/rates [get]
+func (h *InventoryHandler) GetRoomTypeRates(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	roomTypeID := c.Param("room_type_id")
+	if admin.PropertyID != nil {
+		roomType, err := h.Svc.GetRoomTypeByID(roomTypeID)
+		if err != nil || roomType.PropertyID == nil || roomType.PropertyID.String() != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+	rates, err := h.Svc.GetRoomTypeRates(roomTypeID, c.QueryParam("rate_plan_id"), c.QueryParam("start"), c.QueryParam("end"))
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
 	}
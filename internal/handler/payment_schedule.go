@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"hotelbooking/internal/middleware"
+	"hotelbooking/internal/models"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+// PaymentScheduleRequest berisi jadwal deposit/pelunasan baru; policy null
+// menghapus jadwal sehingga booking dibayar penuh sekaligus.
+type PaymentScheduleRequest struct {
+	Policy *models.PaymentSchedulePolicy `json:"policy"`
+}
+
+// @Summary Set hotel payment schedule
+// @Description Booking baru dibagi menjadi deposit (deposit_percent, jatuh tempo bersama hold) dan pelunasan balance_due_days hari sebelum check-in. Booking terkonfirmasi setelah deposit dibayar; pelunasan yang lewat auto_cancel_after_hours membatalkan booking dengan aturan pembatalan biasa
+// @Tags Inventory
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Hotel ID"
+// @Param payload body PaymentScheduleRequest true "Payment schedule"
+// @Success 200 {object} models.Properties
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /admin/hotels/{id}/payment-schedule [put]
+func (h *InventoryHandler) SetHotelPaymentSchedule(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	if admin.PropertyID != nil && admin.PropertyID.String() != id {
+		return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+	}
+	var req PaymentScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
+	}
+	res, err := h.Svc.SetPropertyPaymentSchedule(id, req.Policy)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Summary Booking payment schedule
+// @Description Semua cicilan booking (atau reservasinya) beserta total, dana masuk, refund, dan sisa tagihan
+// @Tags Bookings
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Success 200 {object} service.PaymentSchedule
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/bookings/{id}/payments [get]
+func (h *AdminHandler) GetPaymentSchedule(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	if admin.PropertyID != nil {
+		booking, err := h.BookingSvc.GetBookingByID(id)
+		if err != nil || booking.PropertyID == nil || booking.PropertyID.String() != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+	schedule, err := h.BookingSvc.GetPaymentSchedule("", id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, schedule)
+}
+
+// GET /api/v1/guests/bookings/:id/payments
+// @Summary Booking payment schedule
+// @Description Semua cicilan booking (atau reservasinya) beserta total, dana masuk, refund, dan sisa tagihan
+// @Tags Guests
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Success 200 {object} service.PaymentSchedule
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /guests/bookings/{id}/payments [get]
+func (h *BookingHandler) GetPaymentSchedule(c echo.Context) error {
+	user, ok := c.Get("user").(*types.User)
+	if !ok || user == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	schedule, err := h.Svc.GetPaymentSchedule(user.ID.String(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, schedule)
+}
+
+// SplitPaymentRequest berisi nominal setiap bagian; jumlahnya harus sama
+// dengan nominal cicilan.
+type SplitPaymentRequest struct {
+	Amounts []float64 `json:"amounts"`
+}
+
+// POST /api/v1/guests/payments/:id/split
+// @Summary Split payment
+// @Description Memecah cicilan yang belum dibayar menjadi beberapa payment, misalnya untuk dibayar dengan beberapa kartu
+// @Tags Guests
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Param payload body SplitPaymentRequest true "Split"
+// @Success 200 {object} service.PaymentSchedule
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /guests/payments/{id}/split [post]
+func (h *BookingHandler) SplitPayment(c echo.Context) error {
+	user, ok := c.Get("user").(*types.User)
+	if !ok || user == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	var req SplitPaymentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
+	}
+	schedule, err := h.Svc.SplitPayment(user.ID.String(), c.Param("id"), req.Amounts)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, schedule)
+}
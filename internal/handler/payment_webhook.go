@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"errors"
+	"hotelbooking/internal/gateway"
+	"hotelbooking/internal/service"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxWebhookBody membatasi ukuran payload webhook yang dibaca.
+const maxWebhookBody = 1 << 20
+
+type WebhookHandler struct {
+	Svc service.BookingService
+}
+
+func NewWebhookHandler(svc service.BookingService) *WebhookHandler {
+	return &WebhookHandler{Svc: svc}
+}
+
+// POST /api/v1/webhooks/payments/:provider
+// @Summary Payment gateway webhook
+// @Description Dipanggil payment gateway. Signature HMAC diverifikasi per provider (mock: header X-Mock-Signature). Event yang sama hanya diproses sekali; status payment selalu diambil ulang dari gateway
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider" example(mock)
+// @Success 200 {object} models.PaymentWebhookEvent
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/payments/{provider} [post]
+func (h *WebhookHandler) PaymentWebhook(c echo.Context) error {
+	payload, err := io.ReadAll(io.LimitReader(c.Request().Body, maxWebhookBody))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "gagal membaca payload"})
+	}
+	event, err := h.Svc.HandlePaymentWebhook(c.Param("provider"), c.Request().Header, payload, time.Now())
+	switch {
+	case errors.Is(err, gateway.ErrUnknownProvider):
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	case errors.Is(err, gateway.ErrInvalidSignature):
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidWebhookPayload):
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	case err != nil:
+		// 5xx membuat gateway mengirim ulang event nanti.
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, event)
+}
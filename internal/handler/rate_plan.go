@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"hotelbooking/internal/middleware"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/service"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type RatePlanRequest struct {
+	PropertyID         string                `json:"property_id"`
+	Code               string                `json:"code"`
+	Name               string                `json:"name"`
+	RateType           models.RateType       `json:"rate_type"`
+	CancellationPolicy string                `json:"cancellation_policy"`
+	IsActive           *bool                 `json:"is_active"`
+	ParentRatePlanID   string                `json:"parent_rate_plan_id"`
+	DerivationType     models.DerivationType `json:"derivation_type"`
+	DerivationValue    float64               `json:"derivation_value"`
+}
+
+func (req RatePlanRequest) input() service.RatePlanInput {
+	return service.RatePlanInput{
+		PropertyID:         req.PropertyID,
+		Code:               req.Code,
+		Name:               req.Name,
+		RateType:           req.RateType,
+		CancellationPolicy: req.CancellationPolicy,
+		IsActive:           req.IsActive,
+		ParentRatePlanID:   req.ParentRatePlanID,
+		DerivationType:     req.DerivationType,
+		DerivationValue:    req.DerivationValue,
+	}
+}
+
+// @Summary Create rate plan
+// @Tags Inventory
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param payload body RatePlanRequest true "Create rate plan"
+// @Success 201 {object} models.RatePlan
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /admin/rate-plans [post]
+func (h *InventoryHandler) CreateRatePlan(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	var req RatePlanRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
+	}
+	if admin.PropertyID != nil {
+		if req.PropertyID == "" {
+			req.PropertyID = admin.PropertyID.String()
+		} else if req.PropertyID != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+
+	result, err := h.Svc.CreateRatePlan(req.input())
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, result)
+}
+
+// @Summary Update rate plan
+// @Tags Inventory
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Rate plan ID"
+// @Param payload body RatePlanRequest true "Update rate plan"
+// @Success 200 {object} models.RatePlan
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /admin/rate-plans/{id} [put]
+func (h *InventoryHandler) UpdateRatePlan(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	if admin.PropertyID != nil {
+		plan, err := h.Svc.GetRatePlanByID(id)
+		if err != nil || plan.PropertyID == nil || plan.PropertyID.String() != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+	var req RatePlanRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
+	}
+	res, err := h.Svc.UpdateRatePlan(id, req.input())
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Summary Delete rate plan
+// @Tags Inventory
+// @Security BearerAuth
+// @Param id path string true "Rate plan ID"
+// @Success 204 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /admin/rate-plans/{id} [delete]
+func (h *InventoryHandler) DeleteRatePlan(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	if admin.PropertyID != nil {
+		plan, err := h.Svc.GetRatePlanByID(c.Param("id"))
+		if err != nil || plan.PropertyID == nil || plan.PropertyID.String() != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+	if err := h.Svc.DeleteRatePlan(c.Param("id")); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary List rate plans
+// @Tags Inventory
+// @Security BearerAuth
+// @Produce json
+// @Param property_id query string true "Property ID"
+// @Success 200 {array} models.RatePlan
+// @Failure 401 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /admin/rate-plans [get]
+func (h *InventoryHandler) ListRatePlans(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	propertyID := c.QueryParam("property_id")
+	if admin.PropertyID != nil {
+		propertyID = admin.PropertyID.String()
+	}
+	res, err := h.Svc.ListRatePlans(propertyID)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
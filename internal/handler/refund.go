@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"hotelbooking/internal/middleware"
+	"hotelbooking/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+// @Summary Issue refund
+// @Description Refund diskresioner di luar aturan pembatalan, dieksekusi lewat payment gateway. Nominal maksimal dana yang sudah dibayar dikurangi refund sebelumnya; tanpa payment_id refund dibagi ke cicilan yang sudah dibayar, mulai dari yang terakhir. Booking reservasi di-refund dari payment reservasi
+// @Tags Bookings
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Param payload body service.RefundInput true "Refund"
+// @Success 201 {object} service.RefundResult
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /admin/bookings/{id}/refunds [post]
+func (h *AdminHandler) IssueRefund(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	if admin.PropertyID != nil {
+		booking, err := h.BookingSvc.GetBookingByID(id)
+		if err != nil || booking.PropertyID == nil || booking.PropertyID.String() != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+	var req service.RefundInput
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid request body"})
+	}
+
+	result, err := h.BookingSvc.IssueRefund(id, req, service.AdminActor(admin.ID.String()), time.Now())
+	if err != nil && result != nil {
+		// Gateway menolak; refund tetap tercatat dengan status Failed
+		return c.JSON(http.StatusBadGateway, echo.Map{"error": err.Error(), "refunds": result.Refunds})
+	}
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, result)
+}
+
+// @Summary List refunds
+// @Description Riwayat refund payment booking (atau reservasinya), terlama dulu
+// @Tags Bookings
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Success 200 {array} models.Refund
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/bookings/{id}/refunds [get]
+func (h *AdminHandler) ListRefunds(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	if admin.PropertyID != nil {
+		booking, err := h.BookingSvc.GetBookingByID(id)
+		if err != nil || booking.PropertyID == nil || booking.PropertyID.String() != admin.PropertyID.String() {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+		}
+	}
+	refunds, err := h.BookingSvc.ListRefunds("", id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, refunds)
+}
+
+// GET /api/v1/guests/bookings/:id/refunds
+// @Summary List my booking refunds
+// @Description Riwayat refund booking milik tamu beserta statusnya di gateway
+// @Tags Guests
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Success 200 {array} models.Refund
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /guests/bookings/{id}/refunds [get]
+func (h *BookingHandler) ListRefunds(c echo.Context) error {
+	user, ok := c.Get("user").(*types.User)
+	if !ok || user == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	refunds, err := h.Svc.ListRefunds(user.ID.String(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, refunds)
+}
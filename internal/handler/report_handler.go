@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"encoding/csv"
+	"fmt"
 	"hotelbooking/internal/middleware"
 	"hotelbooking/internal/service"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -65,3 +68,58 @@ func (h *ReportHandler) Summary(c echo.Context) error {
 	}
 	return c.JSON(http.StatusOK, summary)
 }
+
+// @Summary Police registration export
+// @Description Daftar tamu yang sudah check-in dan menginap pada malam date. format=csv untuk file unduhan
+// @Tags Reports
+// @Security BearerAuth
+// @Produce json
+// @Produce text/csv
+// @Param property_id query string false "Property ID (wajib untuk super admin)"
+// @Param date query string true "Night (YYYY-MM-DD)"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {array} service.PoliceRegistrationRow
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /admin/reports/police-registration [get]
+func (h *ReportHandler) PoliceRegistration(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	propertyID := c.QueryParam("property_id")
+	if admin.PropertyID != nil {
+		propertyID = admin.PropertyID.String()
+	}
+	date, err := time.Parse("2006-01-02", c.QueryParam("date"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid date"})
+	}
+
+	rows, err := h.Svc.PoliceRegistration(propertyID, date)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	if c.QueryParam("format") != "csv" {
+		return c.JSON(http.StatusOK, rows)
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=police-registration-%s.csv", date.Format("2006-01-02")))
+	res.WriteHeader(http.StatusOK)
+	w := csv.NewWriter(res)
+	_ = w.Write([]string{"room_number", "first_name", "last_name", "nationality", "document_type", "document_number", "age", "check_in", "check_out", "adults", "children", "booking_id"})
+	for _, row := range rows {
+		age := ""
+		if row.Age != nil {
+			age = strconv.Itoa(*row.Age)
+		}
+		_ = w.Write([]string{
+			row.RoomNumber, row.FirstName, row.LastName, row.Nationality, row.DocumentType, row.DocumentNumber, age,
+			row.CheckIn, row.CheckOut, strconv.Itoa(row.Adults), strconv.Itoa(row.Children), row.BookingID,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
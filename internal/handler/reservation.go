@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"hotelbooking/internal/middleware"
+	"hotelbooking/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+type ReservationRoomRequest struct {
+	RoomID     string                  `json:"room_id"`
+	RoomTypeID string                  `json:"room_type_id"`
+	RatePlanID string                  `json:"rate_plan_id"`
+	CheckIn    string                  `json:"check_in"`
+	CheckOut   string                  `json:"check_out"`
+	Adults     int                     `json:"adults"`
+	Children   int                     `json:"children"`
+	ChildAges  []int                   `json:"child_ages"`
+	Occupants  []service.OccupantInput `json:"occupants"`
+}
+
+type CreateReservationRequest struct {
+	PropertyID string                   `json:"property_id"`
+	Rooms      []ReservationRoomRequest `json:"rooms"`
+}
+
+// POST /api/v1/guests/reservations
+// @Summary Create multi-room reservation
+// @Description Beberapa kamar (tipe dan tanggal boleh berbeda) dalam satu kode konfirmasi dengan satu invoice dan jadwal cicilan gabungan. Semua kamar dialokasikan sekaligus atau tidak sama sekali
+// @Tags Guests
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param payload body CreateReservationRequest true "Reservation rooms"
+// @Success 201 {object} service.ReservationDetail
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /guests/reservations [post]
+func (h *BookingHandler) CreateReservation(c echo.Context) error {
+	user, ok := c.Get("user").(*types.User)
+	if !ok || user == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+
+	var req CreateReservationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
+	}
+	rooms := make([]service.ReservationRoomInput, 0, len(req.Rooms))
+	for i, room := range req.Rooms {
+		checkIn, err := time.Parse("2006-01-02", room.CheckIn)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("rooms[%d]: invalid check_in", i)})
+		}
+		checkOut, err := time.Parse("2006-01-02", room.CheckOut)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("rooms[%d]: invalid check_out", i)})
+		}
+		rooms = append(rooms, service.ReservationRoomInput{
+			RoomID:     room.RoomID,
+			RoomTypeID: room.RoomTypeID,
+			RatePlanID: room.RatePlanID,
+			CheckIn:    checkIn,
+			CheckOut:   checkOut,
+			Adults:     room.Adults,
+			Children:   room.Children,
+			ChildAges:  room.ChildAges,
+			Occupants:  room.Occupants,
+		})
+	}
+
+	result, err := h.Svc.CreateReservation(service.CreateReservationInput{
+		GuestID:    user.ID.String(),
+		PropertyID: req.PropertyID,
+		Rooms:      rooms,
+	})
+	if errors.Is(err, service.ErrRoomUnavailable) {
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, result)
+}
+
+// GET /api/v1/guests/reservations/:id
+// @Summary Get reservation
+// @Tags Guests
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Reservation ID"
+// @Success 200 {object} service.ReservationDetail
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /guests/reservations/{id} [get]
+func (h *BookingHandler) GetReservation(c echo.Context) error {
+	user, ok := c.Get("user").(*types.User)
+	if !ok || user == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	detail, err := h.Svc.GetReservation(user.ID.String(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, detail)
+}
+
+// POST /api/v1/guests/reservations/:id/pay
+// @Summary Pay reservation
+// @Description Membuat charge di gateway untuk satu cicilan payment gabungan. Semua kamar yang masih New terkonfirmasi setelah gateway mengonfirmasi pembayaran deposit (atau pembayaran penuh)
+// @Tags Guests
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Reservation ID"
+// @Param payload body PayBookingRequest true "Payment payload"
+// @Success 200 {object} service.PaymentSession
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /guests/reservations/{id}/pay [post]
+func (h *BookingHandler) PayReservation(c echo.Context) error {
+	user, ok := c.Get("user").(*types.User)
+	if !ok || user == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	var req PayBookingRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
+	}
+	session, err := h.Svc.StartReservationPayment(user.ID.String(), c.Param("id"), req.PaymentID, req.Provider, time.Now())
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, session)
+}
+
+// GET /api/v1/guests/reservations/:id/payment
+// @Summary Reservation payment status
+// @Description Mengambil status charge reservasi terbaru dari gateway
+// @Tags Guests
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Reservation ID"
+// @Success 200 {object} service.PaymentSession
+// @Failure 401 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /guests/reservations/{id}/payment [get]
+func (h *BookingHandler) GetReservationPaymentStatus(c echo.Context) error {
+	user, ok := c.Get("user").(*types.User)
+	if !ok || user == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	session, err := h.Svc.SyncReservationPayment(user.ID.String(), c.Param("id"), time.Now())
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, session)
+}
+
+// POST /api/v1/guests/reservations/:id/rooms/:booking_id/cancel
+// @Summary Cancel one room of a reservation
+// @Description Kamar lain tetap aktif; total reservasi dan tagihan yang belum dibayar ikut disesuaikan
+// @Tags Guests
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Reservation ID"
+// @Param booking_id path string true "Booking ID of the room"
+// @Success 200 {object} service.ReservationDetail
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /guests/reservations/{id}/rooms/{booking_id}/cancel [post]
+func (h *BookingHandler) CancelReservationRoom(c echo.Context) error {
+	user, ok := c.Get("user").(*types.User)
+	if !ok || user == nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	detail, err := h.Svc.CancelReservationRoom(user.ID.String(), c.Param("id"), c.Param("booking_id"), time.Now())
+	if errors.Is(err, service.ErrRefundIncomplete) {
+		return c.JSON(http.StatusBadGateway, echo.Map{"error": err.Error()})
+	}
+	if errors.Is(err, service.ErrBookingStatusConflict) {
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, detail)
+}
+
+// @Summary Find reservation by confirmation code
+// @Tags Bookings
+// @Security BearerAuth
+// @Produce json
+// @Param code path string true "Confirmation code"
+// @Success 200 {object} service.ReservationDetail
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/reservations/{code} [get]
+func (h *AdminHandler) GetReservation(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	detail, err := h.BookingSvc.GetReservationByCode(c.Param("code"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": err.Error()})
+	}
+	reservation := detail.Reservation
+	if admin.PropertyID != nil && (reservation.PropertyID == nil || reservation.PropertyID.String() != admin.PropertyID.String()) {
+		return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+	}
+	return c.JSON(http.StatusOK, detail)
+}
@@ -17,29 +17,33 @@ type AdminLoginResponseDoc struct {
 }
 
 type RoomRateDoc struct {
-	ID               string          `json:"id"`
-	RoomID           string          `json:"room_id"`
-	Date             string          `json:"date"`
-	AvailableRooms   int             `json:"available_rooms"`
-	LinearRate       *float64        `json:"linear_rate"`
-	NonLinearRate    interface{}     `json:"non_linear_rate"`
-	MinNights        int             `json:"min_nights"`
-	MaxNights        int             `json:"max_nights"`
-	StopSell         bool            `json:"stop_sell"`
-	CloseOnArrival   bool            `json:"close_on_arrival"`
-	CloseOnDeparture bool            `json:"close_on_departure"`
-	CreatedAt        string          `json:"created_at"`
+	ID               string                `json:"id"`
+	RoomID           string                `json:"room_id,omitempty"`
+	RoomTypeID       string                `json:"room_type_id,omitempty"`
+	RatePlanID       string                `json:"rate_plan_id,omitempty"`
+	Date             string                `json:"date"`
+	AvailableRooms   int                   `json:"available_rooms"`
+	LinearRate       *float64              `json:"linear_rate"`
+	NonLinearRate    *models.NonLinearRate `json:"non_linear_rate"`
+	MinNights        int                   `json:"min_nights"`
+	MaxNights        int                   `json:"max_nights"`
+	StopSell         bool                  `json:"stop_sell"`
+	CloseOnArrival   bool                  `json:"close_on_arrival"`
+	CloseOnDeparture bool                  `json:"close_on_departure"`
+	Derived          bool                  `json:"derived"`
+	CreatedAt        string                `json:"created_at"`
 }
 
 type RoomRateRequestDoc struct {
-	RoomID           string      `json:"room_id"`
-	Dates            []string    `json:"dates"`
-	AvailableRooms   int         `json:"available_rooms"`
-	LinearRate       *float64    `json:"linear_rate"`
-	NonLinearRate    interface{} `json:"non_linear_rate"`
-	MinNights        int         `json:"min_nights"`
-	MaxNights        int         `json:"max_nights"`
-	StopSell         bool        `json:"stop_sell"`
-	CloseOnArrival   bool        `json:"close_on_arrival"`
-	CloseOnDeparture bool        `json:"close_on_departure"`
+	RoomID           string                `json:"room_id"`
+	RatePlanID       string                `json:"rate_plan_id,omitempty"`
+	Dates            []string              `json:"dates"`
+	AvailableRooms   int                   `json:"available_rooms"`
+	LinearRate       *float64              `json:"linear_rate"`
+	NonLinearRate    *models.NonLinearRate `json:"non_linear_rate"`
+	MinNights        int                   `json:"min_nights"`
+	MaxNights        int                   `json:"max_nights"`
+	StopSell         bool                  `json:"stop_sell"`
+	CloseOnArrival   bool                  `json:"close_on_arrival"`
+	CloseOnDeparture bool                  `json:"close_on_departure"`
 }
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"hotelbooking/internal/middleware"
+	"hotelbooking/internal/models"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TaxRulesRequest berisi seluruh aturan pajak property yang baru; daftar
+// kosong menghapus semua pajak.
+type TaxRulesRequest struct {
+	Rules []models.TaxRule `json:"rules"`
+}
+
+// @Summary Set hotel tax rules
+// @Description Mengganti aturan pajak dan service charge hotel (mis. service charge 11% lalu PB1 10% compound). Type percent atau fixed (per malam/unit), inclusive berarti sudah termasuk di harga, order menentukan urutan perhitungan, dan applies_to membatasi charge code. Pajak diterapkan pada quote, folio, dan invoice booking baru
+// @Tags Inventory
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Hotel ID"
+// @Param payload body TaxRulesRequest true "Tax rules"
+// @Success 200 {object} models.Properties
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /admin/hotels/{id}/tax-rules [put]
+func (h *InventoryHandler) SetHotelTaxRules(c echo.Context) error {
+	admin, ok := middleware.GetAdminFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Unauthorized"})
+	}
+	id := c.Param("id")
+	if admin.PropertyID != nil && admin.PropertyID.String() != id {
+		return c.JSON(http.StatusForbidden, echo.Map{"error": "forbidden property access"})
+	}
+	var req TaxRulesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
+	}
+	res, err := h.Svc.SetPropertyTaxRules(id, req.Rules)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, res)
+}
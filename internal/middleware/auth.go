@@ -25,6 +25,9 @@ func AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		token := parts[1]
 
 		// 3. Validasi token ke Supabase (PROSES YANG BENAR)
+		if config.SupabaseClient == nil {
+			return c.JSON(http.StatusServiceUnavailable, echo.Map{"error": "Authentication is not configured"})
+		}
 		//    a. Buat klien baru dengan token yang diberikan
 		authedClient := config.SupabaseClient.Auth.WithToken(token)
 		//    b. Panggil GetUser() pada klien baru tersebut
@@ -7,16 +7,24 @@ import (
 )
 
 type Booking struct {
-	ID         uuid.UUID     `json:"id" db:"id"`
-	GuestID    *uuid.UUID    `json:"guest_id,omitempty" db:"guest_id"`
-	PropertyID *uuid.UUID    `json:"property_id,omitempty" db:"property_id"`
-	RoomID     *uuid.UUID    `json:"room_id,omitempty" db:"room_id"`
-	CheckIn    time.Time     `json:"check_in" db:"check_in"`
-	CheckOut   time.Time     `json:"check_out" db:"check_out"`
-	Nights     int           `json:"nights" db:"nights"`
-	TotalPrice float64       `json:"total_price" db:"total_price"`
-	Status     BookingStatus `json:"booking_status" db:"booking_status"`
-	RefundAmount float64     `json:"refund_amount,omitempty" db:"refund_amount"`
-	Note         string      `json:"note,omitempty" db:"note"`
-	CreatedAt  time.Time     `json:"created_at" db:"created_at"`
+	ID            uuid.UUID     `json:"id" db:"id"`
+	GuestID       *uuid.UUID    `json:"guest_id,omitempty" db:"guest_id"`
+	PropertyID    *uuid.UUID    `json:"property_id,omitempty" db:"property_id"`
+	ReservationID *uuid.UUID    `json:"reservation_id,omitempty" db:"reservation_id"`
+	RoomID        *uuid.UUID    `json:"room_id,omitempty" db:"room_id"`
+	RoomTypeID    *uuid.UUID    `json:"room_type_id,omitempty" db:"room_type_id"`
+	RatePlanID    *uuid.UUID    `json:"rate_plan_id,omitempty" db:"rate_plan_id"`
+	CheckIn       time.Time     `json:"check_in" db:"check_in"`
+	CheckOut      time.Time     `json:"check_out" db:"check_out"`
+	Nights        int           `json:"nights" db:"nights"`
+	Adults        int           `json:"adults" db:"adults"`
+	Children      int           `json:"children" db:"children"`
+	ChildAges     []int         `json:"child_ages,omitempty" db:"child_ages"`
+	TotalPrice    float64       `json:"total_price" db:"total_price"`
+	TaxAmount     float64       `json:"tax_amount" db:"tax_amount"`
+	Status        BookingStatus `json:"booking_status" db:"booking_status"`
+	RefundAmount  float64       `json:"refund_amount,omitempty" db:"refund_amount"`
+	Note          string        `json:"note,omitempty" db:"note"`
+	HoldExpiresAt *time.Time    `json:"hold_expires_at,omitempty" db:"hold_expires_at"`
+	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
 }
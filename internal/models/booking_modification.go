@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookingModification mencatat satu perubahan tanggal, kamar, atau tamu
+// pada booking. AmountDue adalah tambahan yang harus dibayar tamu dan
+// RefundAmount adalah kelebihan bayar yang dikembalikan; keduanya 0 jika
+// booking belum dibayar saat diubah.
+type BookingModification struct {
+	ID                 uuid.UUID  `json:"id" db:"id"`
+	BookingID          *uuid.UUID `json:"booking_id,omitempty" db:"booking_id"`
+	Actor              string     `json:"actor" db:"actor"`
+	PreviousCheckIn    time.Time  `json:"previous_check_in" db:"previous_check_in"`
+	PreviousCheckOut   time.Time  `json:"previous_check_out" db:"previous_check_out"`
+	PreviousRoomTypeID *uuid.UUID `json:"previous_room_type_id,omitempty" db:"previous_room_type_id"`
+	PreviousRoomID     *uuid.UUID `json:"previous_room_id,omitempty" db:"previous_room_id"`
+	PreviousRatePlanID *uuid.UUID `json:"previous_rate_plan_id,omitempty" db:"previous_rate_plan_id"`
+	PreviousAdults     int        `json:"previous_adults" db:"previous_adults"`
+	PreviousChildren   int        `json:"previous_children" db:"previous_children"`
+	PreviousTotal      float64    `json:"previous_total" db:"previous_total"`
+	NewTotal           float64    `json:"new_total" db:"new_total"`
+	AmountDue          float64    `json:"amount_due" db:"amount_due"`
+	RefundAmount       float64    `json:"refund_amount" db:"refund_amount"`
+	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookingOccupant adalah tamu bernama yang menginap di satu booking. Jika
+// GuestID diisi, nama dan kewarganegaraan disalin dari profil tamu saat
+// disimpan; selain itu data diisi bebas (misalnya anggota keluarga).
+// Dokumen identitas dipakai untuk laporan registrasi tamu ke kepolisian.
+type BookingOccupant struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	BookingID      *uuid.UUID `json:"booking_id,omitempty" db:"booking_id"`
+	GuestID        *uuid.UUID `json:"guest_id,omitempty" db:"guest_id"`
+	FirstName      string     `json:"first_name" db:"first_name"`
+	LastName       string     `json:"last_name,omitempty" db:"last_name"`
+	Nationality    string     `json:"nationality,omitempty" db:"nationality"`
+	DocumentType   string     `json:"document_type,omitempty" db:"document_type"`
+	DocumentNumber string     `json:"document_number,omitempty" db:"document_number"`
+	Age            *int       `json:"age,omitempty" db:"age"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type BookingStatusHistory struct {
+	ID         uuid.UUID     `json:"id" db:"id"`
+	BookingID  *uuid.UUID    `json:"booking_id,omitempty" db:"booking_id"`
+	FromStatus BookingStatus `json:"from_status,omitempty" db:"from_status"`
+	ToStatus   BookingStatus `json:"to_status" db:"to_status"`
+	Actor      string        `json:"actor" db:"actor"`
+	Note       string        `json:"note,omitempty" db:"note"`
+	CreatedAt  time.Time     `json:"created_at" db:"created_at"`
+}
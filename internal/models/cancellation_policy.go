@@ -0,0 +1,29 @@
+package models
+
+type CancellationPenaltyType string
+
+const (
+	// CancellationPenaltyPercent: denda sebesar persen dari total booking.
+	CancellationPenaltyPercent CancellationPenaltyType = "percent"
+	// CancellationPenaltyNights: denda sebesar harga N malam pertama.
+	CancellationPenaltyNights CancellationPenaltyType = "nights"
+	// CancellationPenaltyFixed: denda nominal tetap.
+	CancellationPenaltyFixed CancellationPenaltyType = "fixed"
+)
+
+// CancellationTier berlaku untuk pembatalan yang dilakukan paling lambat
+// HoursBeforeCheckIn jam sebelum check-in. Contoh {168, percent, 0} berarti
+// gratis jika dibatalkan 7 hari atau lebih sebelum kedatangan.
+type CancellationTier struct {
+	HoursBeforeCheckIn int                     `json:"hours_before_check_in"`
+	PenaltyType        CancellationPenaltyType `json:"penalty_type"`
+	PenaltyValue       float64                 `json:"penalty_value"`
+}
+
+// CancellationPolicy adalah aturan refund terstruktur yang dipasang di
+// property atau rate plan. Pembatalan yang tidak masuk tier mana pun
+// (misalnya setelah check-in) dikenakan denda penuh.
+type CancellationPolicy struct {
+	NonRefundable bool               `json:"non_refundable"`
+	Tiers         []CancellationTier `json:"tiers,omitempty"`
+}
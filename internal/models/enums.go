@@ -32,6 +32,7 @@ const (
 	BookingStatusCheckedIn  BookingStatus = "CheckedIn"
 	BookingStatusCheckedOut BookingStatus = "CheckedOut"
 	BookingStatusNoShow     BookingStatus = "NoShow"
+	BookingStatusExpired    BookingStatus = "Expired"
 )
 
 type RateType string
@@ -43,6 +44,14 @@ const (
 	RateTypeRefundableWithBreakfast    RateType = "Refundable with Breakfast"
 )
 
+type DerivationType string
+
+const (
+	DerivationPercent   DerivationType = "percent"
+	DerivationFixed     DerivationType = "fixed"
+	DerivationPerPerson DerivationType = "per_person"
+)
+
 type RoomStatus string
 
 const (
@@ -65,7 +74,84 @@ const (
 type PaymentStatus string
 
 const (
-	PaymentStatusPending  PaymentStatus = "Pending"
-	PaymentStatusPaid     PaymentStatus = "Paid"
-	PaymentStatusRefunded PaymentStatus = "Refunded"
+	PaymentStatusPending           PaymentStatus = "Pending"
+	PaymentStatusPaid              PaymentStatus = "Paid"
+	PaymentStatusPartiallyRefunded PaymentStatus = "PartiallyRefunded"
+	PaymentStatusRefunded          PaymentStatus = "Refunded"
+	PaymentStatusVoid              PaymentStatus = "Void"
+)
+
+// PaymentKind adalah peran payment dalam jadwal pembayaran booking.
+type PaymentKind string
+
+const (
+	// PaymentKindFull: seluruh tagihan dibayar saat booking.
+	PaymentKindFull PaymentKind = "full"
+	// PaymentKindDeposit: uang muka yang mengonfirmasi booking.
+	PaymentKindDeposit PaymentKind = "deposit"
+	// PaymentKindBalance: pelunasan yang jatuh tempo menjelang check-in.
+	PaymentKindBalance PaymentKind = "balance"
+	// PaymentKindAdjustment: tambahan tagihan setelah booking diubah.
+	PaymentKindAdjustment PaymentKind = "adjustment"
+)
+
+// RefundStatus adalah status eksekusi refund di payment gateway.
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "Pending"
+	RefundStatusSucceeded RefundStatus = "Succeeded"
+	RefundStatusFailed    RefundStatus = "Failed"
+)
+
+// WebhookEventStatus adalah hasil pemrosesan webhook payment.
+type WebhookEventStatus string
+
+const (
+	WebhookEventReceived  WebhookEventStatus = "received"
+	WebhookEventProcessed WebhookEventStatus = "processed"
+	WebhookEventIgnored   WebhookEventStatus = "ignored"
+	WebhookEventFailed    WebhookEventStatus = "failed"
+)
+
+// FolioChargeKind membedakan tagihan asli dengan koreksi atas tagihan lain.
+type FolioChargeKind string
+
+const (
+	FolioChargeKindCharge     FolioChargeKind = "charge"
+	FolioChargeKindAdjustment FolioChargeKind = "adjustment"
+)
+
+// FolioChargeStatus adalah status baris folio. Baris tidak pernah dihapus;
+// koreksi dilakukan dengan adjustment atau void.
+type FolioChargeStatus string
+
+const (
+	FolioChargeStatusPosted FolioChargeStatus = "Posted"
+	FolioChargeStatusVoid   FolioChargeStatus = "Void"
+)
+
+// ChargeCode mengelompokkan baris folio untuk laporan pendapatan.
+type ChargeCode string
+
+const (
+	// ChargeCodeRoom hanya diposting sistem dari harga kamar booking.
+	ChargeCodeRoom         ChargeCode = "room"
+	ChargeCodeMinibar      ChargeCode = "minibar"
+	ChargeCodeRestaurant   ChargeCode = "restaurant"
+	ChargeCodeLaundry      ChargeCode = "laundry"
+	ChargeCodeLateCheckout ChargeCode = "late_checkout"
+	ChargeCodeExtraBed     ChargeCode = "extra_bed"
+	ChargeCodeOther        ChargeCode = "other"
+)
+
+// TaxType menentukan cara aturan pajak/service charge dihitung.
+type TaxType string
+
+const (
+	// TaxTypePercent: persentase dari dasar pengenaan.
+	TaxTypePercent TaxType = "percent"
+	// TaxTypeFixed: nominal tetap per unit (per malam untuk kamar, per
+	// quantity untuk tagihan folio).
+	TaxTypeFixed TaxType = "fixed"
 )
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FolioCharge adalah satu baris folio booking: tagihan kamar, tagihan
+// tambahan selama menginap (minibar, restoran, laundry, ...) atau koreksi
+// atas baris lain. Amount adalah nominal yang ditagih, yaitu Quantity x
+// UnitPrice ditambah pajak eksklusif, dan boleh negatif untuk adjustment.
+// TaxAmount adalah seluruh pajak di dalam Amount, termasuk pajak inklusif,
+// dengan rincian di TaxLines. PostedBy/VoidedBy memakai format actor
+// riwayat status.
+type FolioCharge struct {
+	ID          uuid.UUID         `json:"id" db:"id"`
+	BookingID   *uuid.UUID        `json:"booking_id" db:"booking_id"`
+	Kind        FolioChargeKind   `json:"kind" db:"kind"`
+	ChargeCode  ChargeCode        `json:"charge_code" db:"charge_code"`
+	Description string            `json:"description" db:"description"`
+	Quantity    int               `json:"quantity" db:"quantity"`
+	UnitPrice   float64           `json:"unit_price" db:"unit_price"`
+	TaxAmount   float64           `json:"tax_amount" db:"tax_amount"`
+	TaxLines    []TaxLine         `json:"tax_lines,omitempty" db:"tax_lines"`
+	Amount      float64           `json:"amount" db:"amount"`
+	AdjustsID   *uuid.UUID        `json:"adjusts_id,omitempty" db:"adjusts_id"`
+	Status      FolioChargeStatus `json:"status" db:"status"`
+	PostedBy    string            `json:"posted_by" db:"posted_by"`
+	PostedAt    time.Time         `json:"posted_at" db:"posted_at"`
+	VoidedBy    string            `json:"voided_by,omitempty" db:"voided_by"`
+	VoidReason  string            `json:"void_reason,omitempty" db:"void_reason"`
+	VoidedAt    *time.Time        `json:"voided_at,omitempty" db:"voided_at"`
+}
@@ -6,11 +6,26 @@ import (
 	"github.com/google/uuid"
 )
 
+// Invoice adalah tagihan gabungan booking atau reservasi. Amount diturunkan
+// dari folio dan sudah termasuk pajak; NetAmount adalah pendapatan sebelum
+// pajak dan TaxLines rincian pajaknya.
 type Invoice struct {
 	ID            uuid.UUID     `json:"id" db:"id"`
 	BookingID     *uuid.UUID    `json:"booking_id,omitempty" db:"booking_id"`
+	ReservationID *uuid.UUID    `json:"reservation_id,omitempty" db:"reservation_id"`
 	InvoiceNumber string        `json:"invoice_number" db:"invoice_number"`
 	Amount        float64       `json:"amount" db:"amount"`
+	NetAmount     float64       `json:"net_amount" db:"net_amount"`
+	TaxAmount     float64       `json:"tax_amount" db:"tax_amount"`
+	TaxLines      []TaxLine     `json:"tax_lines,omitempty" db:"tax_lines"`
 	Status        PaymentStatus `json:"status" db:"status"`
 	IssuedAt      time.Time     `json:"issued_at" db:"issued_at"`
 }
+
+// InvoiceTotals adalah nominal invoice yang dihitung ulang dari folio.
+type InvoiceTotals struct {
+	Amount    float64
+	NetAmount float64
+	TaxAmount float64
+	TaxLines  []TaxLine
+}
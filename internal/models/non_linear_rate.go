@@ -0,0 +1,25 @@
+package models
+
+// NonLinearRate adalah skema kolom room_rates.non_linear_rate. Jika diisi,
+// harga per malam dihitung dari jumlah tamu dan lama menginap, bukan hanya
+// dari LinearRate.
+type NonLinearRate struct {
+	Occupancy    *OccupancyRate     `json:"occupancy,omitempty"`
+	LengthOfStay []LengthOfStayTier `json:"length_of_stay,omitempty"`
+}
+
+// OccupancyRate memberi harga per malam berdasarkan jumlah tamu.
+// Tamu dewasa ketiga dst. dikenakan ExtraAdult, setiap anak ExtraChild.
+type OccupancyRate struct {
+	OneAdult   float64 `json:"one_adult"`
+	TwoAdults  float64 `json:"two_adults"`
+	ExtraAdult float64 `json:"extra_adult,omitempty"`
+	ExtraChild float64 `json:"extra_child,omitempty"`
+}
+
+// LengthOfStayTier memberi potongan persen per malam untuk menginap minimal
+// MinNights malam. Tier dengan MinNights terbesar yang terpenuhi yang dipakai.
+type LengthOfStayTier struct {
+	MinNights       int     `json:"min_nights"`
+	DiscountPercent float64 `json:"discount_percent"`
+}
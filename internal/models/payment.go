@@ -6,13 +6,24 @@ import (
 	"github.com/google/uuid"
 )
 
+// Payment adalah satu cicilan tagihan booking atau reservasi; satu booking
+// bisa punya beberapa payment (deposit dan pelunasan, atau tagihan yang
+// dibagi ke beberapa kartu). Provider dan Reference menunjuk charge terbuka
+// di payment gateway; AmountPaid adalah total dana yang sudah di-capture
+// gateway dan AmountRefunded total yang sudah dikembalikan lewat refund.
 type Payment struct {
-	ID        uuid.UUID     `json:"id" db:"id"`
-	BookingID *uuid.UUID    `json:"booking_id,omitempty" db:"booking_id"`
-	Amount    float64       `json:"amount" db:"amount"`
-	Status    PaymentStatus `json:"status" db:"status"`
-	Provider  string        `json:"provider,omitempty" db:"provider"`
-	Reference string        `json:"reference,omitempty" db:"reference"`
-	PaidAt    *time.Time    `json:"paid_at,omitempty" db:"paid_at"`
-	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+	ID             uuid.UUID     `json:"id" db:"id"`
+	BookingID      *uuid.UUID    `json:"booking_id,omitempty" db:"booking_id"`
+	ReservationID  *uuid.UUID    `json:"reservation_id,omitempty" db:"reservation_id"`
+	Kind           PaymentKind   `json:"kind" db:"kind"`
+	Amount         float64       `json:"amount" db:"amount"`
+	AmountPaid     float64       `json:"amount_paid" db:"amount_paid"`
+	AmountRefunded float64       `json:"amount_refunded" db:"amount_refunded"`
+	Status         PaymentStatus `json:"status" db:"status"`
+	Provider       string        `json:"provider,omitempty" db:"provider"`
+	Reference      string        `json:"reference,omitempty" db:"reference"`
+	DueAt          *time.Time    `json:"due_at,omitempty" db:"due_at"`
+	ReminderSentAt *time.Time    `json:"reminder_sent_at,omitempty" db:"reminder_sent_at"`
+	PaidAt         *time.Time    `json:"paid_at,omitempty" db:"paid_at"`
+	CreatedAt      time.Time     `json:"created_at" db:"created_at"`
 }
@@ -0,0 +1,17 @@
+package models
+
+// PaymentSchedulePolicy membagi tagihan booking menjadi deposit saat booking
+// dan pelunasan BalanceDueDays hari sebelum tanggal check-in (0 berarti
+// pada tanggal check-in). Booking terkonfirmasi setelah deposit dibayar.
+//
+// Tamu diingatkan ReminderHoursBeforeDue jam sebelum pelunasan jatuh tempo
+// (nil berarti default 24 jam, 0 berarti tepat saat jatuh tempo).
+// Jika AutoCancelAfterHours diisi, booking yang pelunasannya belum dibayar
+// sekian jam setelah jatuh tempo dibatalkan otomatis dengan aturan
+// pembatalan biasa.
+type PaymentSchedulePolicy struct {
+	DepositPercent         float64 `json:"deposit_percent"`
+	BalanceDueDays         int     `json:"balance_due_days"`
+	ReminderHoursBeforeDue *int    `json:"reminder_hours_before_due,omitempty"`
+	AutoCancelAfterHours   *int    `json:"auto_cancel_after_hours,omitempty"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentWebhookEvent menyimpan webhook payment apa adanya (Payload mentah)
+// beserta hasil pemrosesannya. Provider dan EventID unik sehingga event yang
+// dikirim ulang tidak diproses dua kali.
+type PaymentWebhookEvent struct {
+	ID              uuid.UUID          `json:"id" db:"id"`
+	Provider        string             `json:"provider" db:"provider"`
+	EventID         string             `json:"event_id" db:"event_id"`
+	EventType       string             `json:"event_type" db:"event_type"`
+	ChargeReference string             `json:"charge_reference" db:"charge_reference"`
+	PaymentID       *uuid.UUID         `json:"payment_id,omitempty" db:"payment_id"`
+	Payload         string             `json:"payload" db:"payload"`
+	Status          WebhookEventStatus `json:"status" db:"status"`
+	Note            string             `json:"note,omitempty" db:"note"`
+	ReceivedAt      time.Time          `json:"received_at" db:"received_at"`
+	ProcessedAt     *time.Time         `json:"processed_at,omitempty" db:"processed_at"`
+}
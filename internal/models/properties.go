@@ -7,16 +7,31 @@ import (
 )
 
 type Properties struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	HotelCode string    `json:"hotel_code" db:"hotel_code"`
-	AuthCode  string    `json:"auth_code" db:"auth_code"`
-	Name      string    `json:"name" db:"name"`
-	City      string    `json:"city,omitempty" db:"city"`
-	Address   string    `json:"address,omitempty" db:"address"`
-	Facilities []string `json:"facilities,omitempty" db:"facilities"`
-	CheckInTime string  `json:"checkin_time,omitempty" db:"checkin_time"`
-	CheckOutTime string `json:"checkout_time,omitempty" db:"checkout_time"`
-	CancellationPolicy string `json:"cancellation_policy,omitempty" db:"cancellation_policy"`
+	ID                 uuid.UUID `json:"id" db:"id"`
+	HotelCode          string    `json:"hotel_code" db:"hotel_code"`
+	AuthCode           string    `json:"auth_code" db:"auth_code"`
+	Name               string    `json:"name" db:"name"`
+	City               string    `json:"city,omitempty" db:"city"`
+	Address            string    `json:"address,omitempty" db:"address"`
+	Facilities         []string  `json:"facilities,omitempty" db:"facilities"`
+	CheckInTime        string    `json:"checkin_time,omitempty" db:"checkin_time"`
+	CheckOutTime       string    `json:"checkout_time,omitempty" db:"checkout_time"`
+	CancellationPolicy string    `json:"cancellation_policy,omitempty" db:"cancellation_policy"`
+	// Aturan refund pembatalan; CancellationPolicy hanya teks untuk tamu.
+	// Kosong berarti aturan default (gratis sampai 24 jam sebelum check-in)
+	CancellationRules *CancellationPolicy `json:"cancellation_rules,omitempty" db:"cancellation_rules"`
+	// Jadwal deposit dan pelunasan. Kosong berarti dibayar penuh saat booking
+	PaymentSchedule *PaymentSchedulePolicy `json:"payment_schedule,omitempty" db:"payment_schedule"`
+	// Pajak dan service charge yang dikenakan pada kamar dan tagihan folio
+	TaxRules []TaxRule `json:"tax_rules,omitempty" db:"tax_rules"`
+	// Batas perubahan booking oleh tamu, dalam jam sebelum tanggal check-in.
+	// Kosong berarti memakai default 24 jam
+	ModificationCutoffHours *int `json:"modification_cutoff_hours,omitempty" db:"modification_cutoff_hours"`
+	// Rating bintang hotel, 0 (belum dinilai) sampai 5
+	Rating float64 `json:"rating" db:"rating"`
+	// Koordinat WGS84, kosong jika lokasi hotel belum diisi
+	Latitude  *float64  `json:"latitude,omitempty" db:"latitude"`
+	Longitude *float64  `json:"longitude,omitempty" db:"longitude"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
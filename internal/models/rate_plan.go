@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RatePlan turunan (ParentRatePlanID terisi) tidak punya harga sendiri:
+// harganya dihitung dari parent dengan DerivationType dan DerivationValue,
+// misalnya percent -10 atau per_person 150000. CancellationRules, jika diisi,
+// menggantikan aturan pembatalan property untuk booking rate plan ini.
+type RatePlan struct {
+	ID                 uuid.UUID           `json:"id" db:"id"`
+	PropertyID         *uuid.UUID          `json:"property_id,omitempty" db:"property_id"`
+	Code               string              `json:"code" db:"code"`
+	Name               string              `json:"name" db:"name"`
+	RateType           RateType            `json:"rate_type" db:"rate_type"`
+	BreakfastIncluded  bool                `json:"breakfast_included" db:"breakfast_included"`
+	Refundable         bool                `json:"refundable" db:"refundable"`
+	CancellationPolicy string              `json:"cancellation_policy,omitempty" db:"cancellation_policy"`
+	CancellationRules  *CancellationPolicy `json:"cancellation_rules,omitempty" db:"cancellation_rules"`
+	IsActive           bool                `json:"is_active" db:"is_active"`
+	ParentRatePlanID   *uuid.UUID          `json:"parent_rate_plan_id,omitempty" db:"parent_rate_plan_id"`
+	DerivationType     DerivationType      `json:"derivation_type,omitempty" db:"derivation_type"`
+	DerivationValue    float64             `json:"derivation_value,omitempty" db:"derivation_value"`
+	CreatedAt          time.Time           `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Refund adalah satu pengembalian dana atas payment. Satu payment bisa
+// punya beberapa refund parsial; GatewayReference menunjuk refund di
+// payment gateway dan InitiatedBy memakai format actor riwayat status.
+type Refund struct {
+	ID               uuid.UUID    `json:"id" db:"id"`
+	PaymentID        *uuid.UUID   `json:"payment_id" db:"payment_id"`
+	BookingID        *uuid.UUID   `json:"booking_id,omitempty" db:"booking_id"`
+	Amount           float64      `json:"amount" db:"amount"`
+	Reason           string       `json:"reason" db:"reason"`
+	Status           RefundStatus `json:"status" db:"status"`
+	Provider         string       `json:"provider" db:"provider"`
+	ChargeReference  string       `json:"charge_reference" db:"charge_reference"`
+	GatewayReference string       `json:"gateway_reference,omitempty" db:"gateway_reference"`
+	FailureReason    string       `json:"failure_reason,omitempty" db:"failure_reason"`
+	InitiatedBy      string       `json:"initiated_by" db:"initiated_by"`
+	CreatedAt        time.Time    `json:"created_at" db:"created_at"`
+	CompletedAt      *time.Time   `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// PaymentCharge adalah charge gateway yang dananya sudah di-capture untuk
+// sebuah payment. Payment.Reference hanya menunjuk charge yang sedang
+// terbuka; jika tagihan naik setelah charge pertama dibayar, payment punya
+// beberapa charge dan refund dieksekusi per charge.
+type PaymentCharge struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	PaymentID  uuid.UUID `json:"payment_id" db:"payment_id"`
+	Provider   string    `json:"provider" db:"provider"`
+	Reference  string    `json:"reference" db:"reference"`
+	Amount     float64   `json:"amount" db:"amount"`
+	CapturedAt time.Time `json:"captured_at" db:"captured_at"`
+}
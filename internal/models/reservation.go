@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reservation mengelompokkan beberapa booking kamar (tipe dan tanggal boleh
+// berbeda) di bawah satu kode konfirmasi, dengan satu Payment dan Invoice
+// gabungan. TotalPrice adalah jumlah harga kamar yang belum dibatalkan.
+type Reservation struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	ConfirmationCode string     `json:"confirmation_code" db:"confirmation_code"`
+	GuestID          *uuid.UUID `json:"guest_id,omitempty" db:"guest_id"`
+	PropertyID       *uuid.UUID `json:"property_id,omitempty" db:"property_id"`
+	TotalPrice       float64    `json:"total_price" db:"total_price"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+}
@@ -10,6 +10,8 @@ import (
 type RoomRate struct {
 	ID               uuid.UUID       `json:"id" db:"id"`
 	RoomID           *uuid.UUID      `json:"room_id,omitempty" db:"room_id"`
+	RoomTypeID       *uuid.UUID      `json:"room_type_id,omitempty" db:"room_type_id"`
+	RatePlanID       *uuid.UUID      `json:"rate_plan_id,omitempty" db:"rate_plan_id"`
 	Date             time.Time       `json:"date" db:"date"`
 	AvailableRooms   int             `json:"available_rooms" db:"available_rooms"`
 	LinearRate       *float64        `json:"linear_rate,omitempty" db:"linear_rate"`
@@ -19,5 +21,6 @@ type RoomRate struct {
 	StopSell         bool            `json:"stop_sell" db:"stop_sell"`
 	CloseOnArrival   bool            `json:"close_on_arrival" db:"close_on_arrival"`
 	CloseOnDeparture bool            `json:"close_on_departure" db:"close_on_departure"`
+	Derived          bool            `json:"derived" db:"derived"`
 	CreatedAt        time.Time       `json:"created_at" db:"created_at"`
 }
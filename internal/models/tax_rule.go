@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// TaxRule adalah satu pajak atau service charge property, misalnya service
+// charge 11% lalu PB1 10% yang dihitung dari harga plus service charge.
+//
+// Aturan dihitung urut Order dari kecil ke besar; aturan Compound memakai
+// harga ditambah pajak dari Order yang lebih kecil sebagai dasar pengenaan.
+// Inclusive berarti pajak sudah termasuk di harga rate/tagihan, selain itu
+// ditambahkan di atasnya. AppliesTo kosong berarti berlaku untuk semua
+// charge code. EffectiveFrom/EffectiveTo (inklusif, per tanggal) membatasi
+// masa berlaku; untuk kamar yang dipakai adalah tanggal menginap, untuk
+// tagihan folio tanggal posting.
+type TaxRule struct {
+	Code          string       `json:"code"`
+	Name          string       `json:"name"`
+	Type          TaxType      `json:"type"`
+	Value         float64      `json:"value"`
+	Inclusive     bool         `json:"inclusive"`
+	Order         int          `json:"order"`
+	Compound      bool         `json:"compound"`
+	AppliesTo     []ChargeCode `json:"applies_to,omitempty"`
+	EffectiveFrom *time.Time   `json:"effective_from,omitempty"`
+	EffectiveTo   *time.Time   `json:"effective_to,omitempty"`
+}
+
+// TaxLine adalah hasil perhitungan satu aturan pajak. Rate adalah
+// persentase (type percent) atau nominal per unit (type fixed).
+type TaxLine struct {
+	Code      string  `json:"code"`
+	Name      string  `json:"name"`
+	Type      TaxType `json:"type"`
+	Rate      float64 `json:"rate"`
+	Inclusive bool    `json:"inclusive"`
+	Amount    float64 `json:"amount"`
+}
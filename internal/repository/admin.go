@@ -4,8 +4,9 @@ package repository
 import (
 	"encoding/json"
 	"fmt"
-	"hotelbooking/internal/config"
 	"hotelbooking/internal/models"
+
+	"github.com/supabase-community/supabase-go"
 )
 
 const adminTable = "admin"
@@ -22,18 +23,20 @@ type AdminRepo interface {
 	ListAdmins(propertyID string) ([]models.Admin, error)
 }
 
-type adminRepo struct{}
+type adminRepo struct {
+	client *supabase.Client
+}
 
-func NewAdminRepo() AdminRepo {
-	return &adminRepo{}
+func NewAdminRepo(client *supabase.Client) AdminRepo {
+	return &adminRepo{client: client}
 }
 
 func (r *adminRepo) CreateAdmin(admin models.Admin) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
 
-	_, _, err := config.SupabaseClient.
+	_, _, err := r.client.
 		From(adminTable).
 		Insert(admin, false, "", "", "").
 		Execute()
@@ -44,11 +47,11 @@ func (r *adminRepo) CreateAdmin(admin models.Admin) error {
 }
 
 func (r *adminRepo) GetAdminByEmail(email string) (*models.Admin, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
 
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From(adminTable).
 		Select("*", "", false).
 		Eq("email", email).
@@ -67,11 +70,11 @@ func (r *adminRepo) GetAdminByEmail(email string) (*models.Admin, error) {
 }
 
 func (r *adminRepo) GetAdminByID(id string) (*models.Admin, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
 
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From(adminTable).
 		Select("*", "", false).
 		Eq("id", id).
@@ -90,11 +93,11 @@ func (r *adminRepo) GetAdminByID(id string) (*models.Admin, error) {
 }
 
 func (r *adminRepo) GetAdminByProperty(propertyID string) (*models.Admin, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
 
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From(adminTable).
 		Select("*", "", false).
 		Eq("property_id", propertyID).
@@ -113,11 +116,11 @@ func (r *adminRepo) GetAdminByProperty(propertyID string) (*models.Admin, error)
 }
 
 func (r *adminRepo) GetAdminByEmailAndProperty(email, propertyID string) (*models.Admin, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
 
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From(adminTable).
 		Select("*", "", false).
 		Eq("email", email).
@@ -137,7 +140,7 @@ func (r *adminRepo) GetAdminByEmailAndProperty(email, propertyID string) (*model
 }
 
 func (r *adminRepo) UpdateActiveStatus(adminID string, isActive bool) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
 
@@ -145,7 +148,7 @@ func (r *adminRepo) UpdateActiveStatus(adminID string, isActive bool) error {
 		"is_active": isActive,
 	}
 
-	_, _, err := config.SupabaseClient.
+	_, _, err := r.client.
 		From(adminTable).
 		Update(updateData, "", "").
 		Eq("id", adminID).
@@ -157,11 +160,11 @@ func (r *adminRepo) UpdateActiveStatus(adminID string, isActive bool) error {
 }
 
 func (r *adminRepo) UpdateRole(adminID, role string) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
 	data := map[string]any{"role": role}
-	_, _, err := config.SupabaseClient.
+	_, _, err := r.client.
 		From(adminTable).
 		Update(data, "", "").
 		Eq("id", adminID).
@@ -173,11 +176,11 @@ func (r *adminRepo) UpdateRole(adminID, role string) error {
 }
 
 func (r *adminRepo) UpdateProperty(adminID, propertyID string) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
 	data := map[string]any{"property_id": propertyID}
-	_, _, err := config.SupabaseClient.
+	_, _, err := r.client.
 		From(adminTable).
 		Update(data, "", "").
 		Eq("id", adminID).
@@ -189,10 +192,10 @@ func (r *adminRepo) UpdateProperty(adminID, propertyID string) error {
 }
 
 func (r *adminRepo) ListAdmins(propertyID string) ([]models.Admin, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	q := config.SupabaseClient.
+	q := r.client.
 		From(adminTable).
 		Select("*", "", false)
 	if propertyID != "" {
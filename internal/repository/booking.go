@@ -2,59 +2,139 @@ package repository
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"hotelbooking/internal/config"
 	"hotelbooking/internal/models"
+	"strconv"
+	"strings"
+	"time"
 
 	// PENTING: Import library postgrest untuk opsi sorting
 	"github.com/supabase-community/postgrest-go"
+	"github.com/supabase-community/supabase-go"
 )
 
+// ErrRoomUnavailable dikembalikan CreateBooking ketika kamar sudah terpakai
+// oleh booking lain yang tanggalnya beririsan.
+var ErrRoomUnavailable = errors.New("kamar tidak tersedia pada tanggal tersebut")
+
+// ErrBookingStatusConflict dikembalikan UpdateBookingStatus ketika status
+// booking sudah diubah proses lain sejak dibaca, misalnya dua pembatalan
+// bersamaan atau konfirmasi payment yang kalah dengan sweeper hold.
+var ErrBookingStatusConflict = errors.New("status booking sudah berubah, muat ulang lalu coba lagi")
+
+// bookingOverlapConstraint adalah exclusion constraint pada tabel bookings
+// (lihat postgres/schema.sql) yang menolak dua booking aktif pada kamar dan
+// malam yang sama. Constraint ini yang membuat alokasi kamar atomik.
+const bookingOverlapConstraint = "bookings_room_no_overlap"
+
+// roomUnavailableException adalah pesan raise exception create_booking.
+const roomUnavailableException = "room_unavailable"
+
+// RemainingRooms menghitung sisa unit tipe kamar untuk stay checkIn..checkOut:
+// jumlah kamar yang bisa dijual dikurangi booking terbanyak pada satu malam.
+// bookings harus sudah difilter ke booking yang masih memegang inventori.
+func RemainingRooms(sellable int, bookings []models.Booking, checkIn, checkOut time.Time) int {
+	busiest := 0
+	for night := checkIn; night.Before(checkOut); night = night.AddDate(0, 0, 1) {
+		taken := 0
+		for _, booking := range bookings {
+			if !booking.CheckIn.After(night) && booking.CheckOut.After(night) {
+				taken++
+			}
+		}
+		if taken > busiest {
+			busiest = taken
+		}
+	}
+	return sellable - busiest
+}
+
 type BookingRepo interface {
 	CreateBooking(booking models.Booking) error
 	CheckAvailability(roomID string, checkIn, checkOut string) (bool, error)
+	CheckRoomTypeAvailability(roomTypeID string, checkIn, checkOut string) (int, error)
+	AssignRoom(bookingID, roomID string) (*models.Booking, error)
+	// UpdateBookingStay mengubah stay hanya jika booking masih New atau
+	// Confirmed dan total_price masih previousTotal; selain itu
+	// mengembalikan ErrBookingStatusConflict.
+	UpdateBookingStay(booking models.Booking, previousTotal float64) (*models.Booking, error)
 	GetBookingsByGuestID(guestID string) ([]models.Booking, error)
 	GetBookingByID(bookingID string) (*models.Booking, error)
 	ListBookings(propertyID, status, startDate, endDate string) ([]models.Booking, error)
-	UpdateBookingStatus(bookingID string, status models.BookingStatus, note string, refundAmount float64) (*models.Booking, error)
+	ListActiveBookings(propertyID, startDate, endDate string) ([]models.Booking, error)
+	CountBookingsByProperty(since time.Time) (map[string]int, error)
+	// UpdateBookingStatus mengubah status hanya jika status saat ini masih
+	// from; selain itu mengembalikan ErrBookingStatusConflict.
+	UpdateBookingStatus(bookingID string, from, status models.BookingStatus, note string, refundAmount float64) (*models.Booking, error)
+	ExpireHolds(now time.Time) ([]models.Booking, error)
+	CreateStatusHistory(entry models.BookingStatusHistory) error
+	ListStatusHistory(bookingID string) ([]models.BookingStatusHistory, error)
+	CreateReservation(reservation models.Reservation) error
+	GetReservationByID(reservationID string) (*models.Reservation, error)
+	GetReservationByCode(code string) (*models.Reservation, error)
+	ListReservationBookings(reservationID string) ([]models.Booking, error)
+	UpdateReservationTotal(reservationID string, totalPrice float64) (*models.Reservation, error)
+	ReplaceOccupants(bookingID string, occupants []models.BookingOccupant) error
+	ListOccupants(bookingIDs []string) ([]models.BookingOccupant, error)
+	CreateModification(modification models.BookingModification) error
+	ListModifications(bookingID string) ([]models.BookingModification, error)
 }
 
-type bookingRepo struct{}
+type bookingRepo struct {
+	client *supabase.Client
+}
 
-func NewBookingRepo() BookingRepo {
-	return &bookingRepo{}
+func NewBookingRepo(client *supabase.Client) BookingRepo {
+	return &bookingRepo{client: client}
 }
 
+// CreateBooking membuat booking lewat function create_booking, yang mengunci
+// tipe kamar dan kamarnya sebelum menghitung sisa inventori sehingga booking
+// paralel antre (lihat supabase/migrations).
 func (r *bookingRepo) CreateBooking(booking models.Booking) error {
-	// Double-check ketersediaan di layer repo untuk mengurangi race condition sederhana
-	available, err := r.CheckAvailability(booking.RoomID.String(), booking.CheckIn.Format("2006-01-02"), booking.CheckOut.Format("2006-01-02"))
-	if err != nil {
-		return err
+	if booking.RoomID == nil && booking.RoomTypeID == nil {
+		return fmt.Errorf("gagal membuat booking: room_id atau room_type_id wajib diisi")
 	}
-	if !available {
-		return fmt.Errorf("kamar tidak tersedia pada tanggal tersebut")
-	}
-
-	_, _, err = config.SupabaseClient.
-		From("bookings").
-		Insert(booking, false, "", "", "").
-		Execute()
-
+	var created []models.Booking
+	err := callRPC(r.client, "create_booking", map[string]any{"p_booking": booking}, &created)
 	if err != nil {
+		if isRPCException(err, roomUnavailableException) || isOverlapViolation(err) {
+			return ErrRoomUnavailable
+		}
 		return fmt.Errorf("gagal membuat booking: %v", err)
 	}
 	return nil
 }
 
+// isOverlapViolation mengenali error PostgREST untuk pelanggaran exclusion
+// constraint (SQLSTATE 23P01) pada tabel bookings.
+func isOverlapViolation(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "23P01") || strings.Contains(msg, bookingOverlapConstraint)
+}
+
 func (r *bookingRepo) CheckAvailability(roomID string, checkIn, checkOut string) (bool, error) {
-	resp, _, err := config.SupabaseClient.
+	return r.roomAvailable(roomID, checkIn, checkOut, "")
+}
+
+// roomAvailable mengecek kamar tanpa menghitung booking excludeID, yaitu
+// booking yang sedang dipindah tanggal/kamarnya.
+func (r *bookingRepo) roomAvailable(roomID, checkIn, checkOut, excludeID string) (bool, error) {
+	// Booking batal/kedaluwarsa tidak memakai kamar, begitu juga booking New
+	// yang hold-nya sudah lewat walau belum disapu sweeper
+	q := r.client.
 		From("bookings").
 		Select("id, check_in, check_out, booking_status", "", false).
 		Eq("room_id", roomID).
-		Filter("booking_status", "neq", string(models.BookingStatusCancel)).
+		Not("booking_status", "in", fmt.Sprintf("(%s,%s)", models.BookingStatusCancel, models.BookingStatusExpired)).
+		Or(fmt.Sprintf("booking_status.neq.%s,hold_expires_at.is.null,hold_expires_at.gt.%s", models.BookingStatusNew, time.Now().UTC().Format(time.RFC3339)), "").
 		Filter("check_in", "lt", checkOut).
-		Filter("check_out", "gt", checkIn).
-		Execute()
+		Filter("check_out", "gt", checkIn)
+	if excludeID != "" {
+		q = q.Neq("id", excludeID)
+	}
+	resp, _, err := q.Execute()
 
 	if err != nil {
 		return false, fmt.Errorf("gagal mengecek ketersediaan kamar: %v", err)
@@ -68,9 +148,152 @@ func (r *bookingRepo) CheckAvailability(roomID string, checkIn, checkOut string)
 	return len(bookings) == 0, nil
 }
 
+// CheckRoomTypeAvailability mengembalikan sisa unit tipe kamar yang bisa
+// dijual untuk seluruh malam stay.
+func (r *bookingRepo) CheckRoomTypeAvailability(roomTypeID string, checkIn, checkOut string) (int, error) {
+	return r.roomTypeRemaining(roomTypeID, checkIn, checkOut, "")
+}
+
+func (r *bookingRepo) roomTypeRemaining(roomTypeID, checkIn, checkOut, excludeID string) (int, error) {
+	start, err := time.Parse("2006-01-02", checkIn)
+	if err != nil {
+		return 0, fmt.Errorf("invalid check_in")
+	}
+	end, err := time.Parse("2006-01-02", checkOut)
+	if err != nil {
+		return 0, fmt.Errorf("invalid check_out")
+	}
+
+	resp, _, err := r.client.
+		From("rooms").
+		Select("id", "", false).
+		Eq("room_type_id", roomTypeID).
+		Neq("status", string(models.RoomStatusOutOfOrder)).
+		Execute()
+	if err != nil {
+		return 0, fmt.Errorf("gagal menghitung kamar tipe ini: %v", err)
+	}
+	var rooms []models.Room
+	if err := json.Unmarshal(resp, &rooms); err != nil {
+		return 0, err
+	}
+
+	q := r.client.
+		From("bookings").
+		Select("id, check_in, check_out, booking_status", "", false).
+		Eq("room_type_id", roomTypeID).
+		Not("booking_status", "in", fmt.Sprintf("(%s,%s)", models.BookingStatusCancel, models.BookingStatusExpired)).
+		Or(fmt.Sprintf("booking_status.neq.%s,hold_expires_at.is.null,hold_expires_at.gt.%s", models.BookingStatusNew, time.Now().UTC().Format(time.RFC3339)), "").
+		Filter("check_in", "lt", checkOut).
+		Filter("check_out", "gt", checkIn)
+	if excludeID != "" {
+		q = q.Neq("id", excludeID)
+	}
+	resp, _, err = q.Execute()
+	if err != nil {
+		return 0, fmt.Errorf("gagal mengecek ketersediaan tipe kamar: %v", err)
+	}
+	var bookings []models.Booking
+	if err := json.Unmarshal(resp, &bookings); err != nil {
+		return 0, err
+	}
+	return RemainingRooms(len(rooms), bookings, start, end), nil
+}
+
+// AssignRoom mengisi room_id booking; bentrok dengan booking lain pada kamar
+// yang sama ditolak exclusion constraint.
+func (r *bookingRepo) AssignRoom(bookingID, roomID string) (*models.Booking, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("bookings").
+		Update(map[string]any{"room_id": roomID}, "", "").
+		Eq("id", bookingID).
+		Single().
+		Execute()
+	if err != nil {
+		if isOverlapViolation(err) {
+			return nil, ErrRoomUnavailable
+		}
+		return nil, fmt.Errorf("gagal meng-assign kamar: %v", err)
+	}
+	var booking models.Booking
+	if err := json.Unmarshal(resp, &booking); err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// UpdateBookingStay memindahkan tanggal, kamar, rate plan, tamu, dan harga
+// (beserta pajaknya) booking. Booking itu sendiri tidak dihitung saat mengecek ketersediaan;
+// bentrok nomor kamar tetap ditolak exclusion constraint.
+func (r *bookingRepo) UpdateBookingStay(booking models.Booking, previousTotal float64) (*models.Booking, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	bookingID := booking.ID.String()
+	checkIn, checkOut := booking.CheckIn.Format("2006-01-02"), booking.CheckOut.Format("2006-01-02")
+	if booking.RoomID != nil {
+		available, err := r.roomAvailable(booking.RoomID.String(), checkIn, checkOut, bookingID)
+		if err != nil {
+			return nil, err
+		}
+		if !available {
+			return nil, ErrRoomUnavailable
+		}
+	}
+	if booking.RoomTypeID != nil {
+		remaining, err := r.roomTypeRemaining(booking.RoomTypeID.String(), checkIn, checkOut, bookingID)
+		if err != nil {
+			return nil, err
+		}
+		if remaining <= 0 {
+			return nil, ErrRoomUnavailable
+		}
+	}
+
+	updates := map[string]any{
+		"room_id":      booking.RoomID,
+		"room_type_id": booking.RoomTypeID,
+		"rate_plan_id": booking.RatePlanID,
+		"check_in":     booking.CheckIn,
+		"check_out":    booking.CheckOut,
+		"nights":       booking.Nights,
+		"adults":       booking.Adults,
+		"children":     booking.Children,
+		"child_ages":   booking.ChildAges,
+		"total_price":  booking.TotalPrice,
+		"tax_amount":   booking.TaxAmount,
+	}
+	// Filter status dan total lama membuat update menjadi compare-and-set:
+	// booking yang dibatalkan atau diubah proses lain tidak ikut ter-update
+	resp, _, err := r.client.
+		From("bookings").
+		Update(updates, "", "").
+		Eq("id", bookingID).
+		In("booking_status", []string{string(models.BookingStatusNew), string(models.BookingStatusConfirmed)}).
+		Eq("total_price", strconv.FormatFloat(previousTotal, 'f', 2, 64)).
+		Execute()
+	if err != nil {
+		if isOverlapViolation(err) {
+			return nil, ErrRoomUnavailable
+		}
+		return nil, fmt.Errorf("gagal mengubah booking: %v", err)
+	}
+	var updated []models.Booking
+	if err := json.Unmarshal(resp, &updated); err != nil {
+		return nil, err
+	}
+	if len(updated) == 0 {
+		return nil, ErrBookingStatusConflict
+	}
+	return &updated[0], nil
+}
+
 func (r *bookingRepo) GetBookingsByGuestID(guestID string) ([]models.Booking, error) {
 	// Menggunakan postgrest.OrderOpts dari library yang sudah di-import
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From("bookings").
 		Select("*, properties(name, city)", "", false).
 		Eq("guest_id", guestID).
@@ -90,10 +313,10 @@ func (r *bookingRepo) GetBookingsByGuestID(guestID string) ([]models.Booking, er
 }
 
 func (r *bookingRepo) GetBookingByID(bookingID string) (*models.Booking, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From("bookings").
 		Select("*", "", false).
 		Eq("id", bookingID).
@@ -110,10 +333,10 @@ func (r *bookingRepo) GetBookingByID(bookingID string) (*models.Booking, error)
 }
 
 func (r *bookingRepo) ListBookings(propertyID, status, startDate, endDate string) ([]models.Booking, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	q := config.SupabaseClient.
+	q := r.client.
 		From("bookings").
 		Select("*", "", false)
 	if propertyID != "" {
@@ -139,8 +362,65 @@ func (r *bookingRepo) ListBookings(propertyID, status, startDate, endDate string
 	return bookings, nil
 }
 
-func (r *bookingRepo) UpdateBookingStatus(bookingID string, status models.BookingStatus, note string, refundAmount float64) (*models.Booking, error) {
-	if config.SupabaseClient == nil {
+// ListActiveBookings mengembalikan booking property yang masih memegang
+// inventori dan beririsan dengan rentang startDate..endDate (endDate eksklusif).
+// Dipakai kalender ketersediaan supaya cukup satu query untuk seluruh rentang,
+// dan daftar tamu wajib lapor sehingga tamu dan jumlah penghuni ikut diambil.
+func (r *bookingRepo) ListActiveBookings(propertyID, startDate, endDate string) ([]models.Booking, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("bookings").
+		Select("id, guest_id, room_id, room_type_id, check_in, check_out, adults, children, booking_status, hold_expires_at", "", false).
+		Eq("property_id", propertyID).
+		Not("booking_status", "in", fmt.Sprintf("(%s,%s)", models.BookingStatusCancel, models.BookingStatusExpired)).
+		Or(fmt.Sprintf("booking_status.neq.%s,hold_expires_at.is.null,hold_expires_at.gt.%s", models.BookingStatusNew, time.Now().UTC().Format(time.RFC3339)), "").
+		Filter("check_in", "lt", endDate).
+		Filter("check_out", "gt", startDate).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil booking aktif: %v", err)
+	}
+	var bookings []models.Booking
+	if err := json.Unmarshal(resp, &bookings); err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// CountBookingsByProperty menghitung booking yang tidak batal/kedaluwarsa per
+// property sejak waktu tertentu. Dipakai untuk urutan popularitas pencarian.
+func (r *bookingRepo) CountBookingsByProperty(since time.Time) (map[string]int, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("bookings").
+		Select("property_id", "", false).
+		Gte("created_at", since.UTC().Format(time.RFC3339)).
+		Not("booking_status", "in", fmt.Sprintf("(%s,%s)", models.BookingStatusCancel, models.BookingStatusExpired)).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal menghitung booking: %v", err)
+	}
+	var rows []struct {
+		PropertyID *string `json:"property_id"`
+	}
+	if err := json.Unmarshal(resp, &rows); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, row := range rows {
+		if row.PropertyID != nil {
+			counts[*row.PropertyID]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *bookingRepo) UpdateBookingStatus(bookingID string, from, status models.BookingStatus, note string, refundAmount float64) (*models.Booking, error) {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
 	updateData := map[string]any{
@@ -153,19 +433,241 @@ func (r *bookingRepo) UpdateBookingStatus(bookingID string, status models.Bookin
 		updateData["refund_amount"] = refundAmount
 	}
 
-	resp, _, err := config.SupabaseClient.
+	// Filter status lama membuat update menjadi compare-and-set: baris yang
+	// sudah diubah proses lain tidak ikut ter-update
+	resp, _, err := r.client.
 		From("bookings").
 		Update(updateData, "", "").
 		Eq("id", bookingID).
-		Single().
+		Eq("booking_status", string(from)).
 		Execute()
 	if err != nil {
 		return nil, fmt.Errorf("gagal memperbarui status booking: %v", err)
 	}
 
-	var booking models.Booking
-	if err := json.Unmarshal(resp, &booking); err != nil {
+	var bookings []models.Booking
+	if err := json.Unmarshal(resp, &bookings); err != nil {
 		return nil, err
 	}
-	return &booking, nil
+	if len(bookings) == 0 {
+		return nil, ErrBookingStatusConflict
+	}
+	return &bookings[0], nil
+}
+
+// ExpireHolds mengubah semua booking New yang hold-nya sudah lewat menjadi
+// Expired dan mengembalikan booking yang terdampak.
+func (r *bookingRepo) ExpireHolds(now time.Time) ([]models.Booking, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("bookings").
+		Update(map[string]any{"booking_status": models.BookingStatusExpired}, "", "").
+		Eq("booking_status", string(models.BookingStatusNew)).
+		Lte("hold_expires_at", now.UTC().Format(time.RFC3339)).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengakhiri hold booking: %v", err)
+	}
+	var bookings []models.Booking
+	if err := json.Unmarshal(resp, &bookings); err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+func (r *bookingRepo) CreateStatusHistory(entry models.BookingStatusHistory) error {
+	if r.client == nil {
+		return fmt.Errorf("supabase client is not initialized")
+	}
+	_, _, err := r.client.
+		From("booking_status_history").
+		Insert(entry, false, "", "", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("gagal mencatat riwayat status booking: %v", err)
+	}
+	return nil
+}
+
+func (r *bookingRepo) ListStatusHistory(bookingID string) ([]models.BookingStatusHistory, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("booking_status_history").
+		Select("*", "", false).
+		Eq("booking_id", bookingID).
+		Order("created_at", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil riwayat status booking: %v", err)
+	}
+	var history []models.BookingStatusHistory
+	if err := json.Unmarshal(resp, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (r *bookingRepo) CreateReservation(reservation models.Reservation) error {
+	if r.client == nil {
+		return fmt.Errorf("supabase client is not initialized")
+	}
+	_, _, err := r.client.
+		From("reservations").
+		Insert(reservation, false, "", "", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("gagal membuat reservasi: %v", err)
+	}
+	return nil
+}
+
+func (r *bookingRepo) GetReservationByID(reservationID string) (*models.Reservation, error) {
+	return r.getReservation("id", reservationID)
+}
+
+// GetReservationByCode mencari reservasi dari kode konfirmasi yang
+// diberikan ke tamu.
+func (r *bookingRepo) GetReservationByCode(code string) (*models.Reservation, error) {
+	return r.getReservation("confirmation_code", code)
+}
+
+func (r *bookingRepo) getReservation(column, value string) (*models.Reservation, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("reservations").
+		Select("*", "", false).
+		Eq(column, value).
+		Single().
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil reservasi: %v", err)
+	}
+	var reservation models.Reservation
+	if err := json.Unmarshal(resp, &reservation); err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+func (r *bookingRepo) ListReservationBookings(reservationID string) ([]models.Booking, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("bookings").
+		Select("*", "", false).
+		Eq("reservation_id", reservationID).
+		Order("created_at", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil kamar reservasi: %v", err)
+	}
+	var bookings []models.Booking
+	if err := json.Unmarshal(resp, &bookings); err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+func (r *bookingRepo) UpdateReservationTotal(reservationID string, totalPrice float64) (*models.Reservation, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("reservations").
+		Update(map[string]any{"total_price": totalPrice}, "", "").
+		Eq("id", reservationID).
+		Single().
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui reservasi: %v", err)
+	}
+	var reservation models.Reservation
+	if err := json.Unmarshal(resp, &reservation); err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// ReplaceOccupants mengganti seluruh daftar tamu bernama milik booking.
+func (r *bookingRepo) ReplaceOccupants(bookingID string, occupants []models.BookingOccupant) error {
+	if r.client == nil {
+		return fmt.Errorf("supabase client is not initialized")
+	}
+	_, _, err := r.client.
+		From("booking_occupants").
+		Delete("", "").
+		Eq("booking_id", bookingID).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("gagal menyimpan tamu booking: %v", err)
+	}
+	if len(occupants) == 0 {
+		return nil
+	}
+	_, _, err = r.client.
+		From("booking_occupants").
+		Insert(occupants, false, "", "", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("gagal menyimpan tamu booking: %v", err)
+	}
+	return nil
+}
+
+func (r *bookingRepo) ListOccupants(bookingIDs []string) ([]models.BookingOccupant, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	if len(bookingIDs) == 0 {
+		return []models.BookingOccupant{}, nil
+	}
+	resp, _, err := r.client.
+		From("booking_occupants").
+		Select("*", "", false).
+		In("booking_id", bookingIDs).
+		Order("created_at", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil tamu booking: %v", err)
+	}
+	var occupants []models.BookingOccupant
+	if err := json.Unmarshal(resp, &occupants); err != nil {
+		return nil, err
+	}
+	return occupants, nil
+}
+
+func (r *bookingRepo) CreateModification(modification models.BookingModification) error {
+	_, _, err := r.client.
+		From("booking_modifications").
+		Insert(modification, false, "", "", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("gagal mencatat perubahan booking: %v", err)
+	}
+	return nil
+}
+
+func (r *bookingRepo) ListModifications(bookingID string) ([]models.BookingModification, error) {
+	resp, _, err := r.client.
+		From("booking_modifications").
+		Select("*", "", false).
+		Eq("booking_id", bookingID).
+		Order("created_at", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil riwayat perubahan booking: %v", err)
+	}
+	var modifications []models.BookingModification
+	if err := json.Unmarshal(resp, &modifications); err != nil {
+		return nil, err
+	}
+	return modifications, nil
 }
@@ -3,8 +3,9 @@ package repository
 import (
 	"encoding/json"
 	"fmt"
-	"hotelbooking/internal/config"
 	"hotelbooking/internal/models"
+
+	"github.com/supabase-community/supabase-go"
 )
 
 type GuestRepo interface {
@@ -12,17 +13,19 @@ type GuestRepo interface {
 	GetGuestByID(id string) (*models.Guest, error)
 }
 
-type guestRepo struct{}
+type guestRepo struct {
+	client *supabase.Client
+}
 
-func NewGuestRepo() GuestRepo {
-	return &guestRepo{}
+func NewGuestRepo(client *supabase.Client) GuestRepo {
+	return &guestRepo{client: client}
 }
 
 func (r *guestRepo) CreateProfile(profile models.Guest) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
-	_, _, err := config.SupabaseClient.From("guests").Insert(profile, false, "", "", "").Execute()
+	_, _, err := r.client.From("guests").Insert(profile, false, "", "", "").Execute()
 	if err != nil {
 		return fmt.Errorf("gagal menyisipkan profil tamu ke db: %v", err)
 	}
@@ -30,11 +33,11 @@ func (r *guestRepo) CreateProfile(profile models.Guest) error {
 }
 
 func (r *guestRepo) GetGuestByID(id string) (*models.Guest, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
 
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From("guests").
 		Select("*", "", false).
 		Eq("id", id).
@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type adminRepo struct {
+	conn
+}
+
+func NewAdminRepo(store *Store) repository.AdminRepo {
+	return &adminRepo{conn{store: store}}
+}
+
+func (r *adminRepo) CreateAdmin(admin models.Admin) error {
+	defer r.lock()()
+
+	if _, exists := r.store.admins[admin.ID]; exists {
+		return fmt.Errorf("gagal menambahkan admin ke database: duplicate id %s", admin.ID)
+	}
+	for _, existing := range r.store.admins {
+		if strings.EqualFold(existing.Email, admin.Email) {
+			return fmt.Errorf("gagal menambahkan admin ke database: duplicate email %s", admin.Email)
+		}
+	}
+	r.store.admins[admin.ID] = clone(admin)
+	return nil
+}
+
+// findOne meniru Single(): error jika hasil kosong atau lebih dari satu baris.
+func (r *adminRepo) findOne(match func(models.Admin) bool) (*models.Admin, bool) {
+	var found []models.Admin
+	for _, admin := range r.store.admins {
+		if match(admin) {
+			found = append(found, admin)
+		}
+	}
+	if len(found) != 1 {
+		return nil, false
+	}
+	out := clone(found[0])
+	return &out, true
+}
+
+func (r *adminRepo) GetAdminByEmail(email string) (*models.Admin, error) {
+	defer r.lock()()
+
+	admin, ok := r.findOne(func(a models.Admin) bool { return a.Email == email })
+	if !ok {
+		return nil, fmt.Errorf("admin tidak ditemukan: not found")
+	}
+	return admin, nil
+}
+
+func (r *adminRepo) GetAdminByID(id string) (*models.Admin, error) {
+	defer r.lock()()
+
+	admin, ok := r.findOne(func(a models.Admin) bool { return a.ID.String() == id })
+	if !ok {
+		return nil, fmt.Errorf("admin tidak ditemukan: not found")
+	}
+	return admin, nil
+}
+
+func (r *adminRepo) GetAdminByProperty(propertyID string) (*models.Admin, error) {
+	defer r.lock()()
+
+	admin, ok := r.findOne(func(a models.Admin) bool { return sameID(a.PropertyID, propertyID) })
+	if !ok {
+		return nil, fmt.Errorf("admin berdasarkan property_id tidak ditemukan: not found")
+	}
+	return admin, nil
+}
+
+func (r *adminRepo) GetAdminByEmailAndProperty(email, propertyID string) (*models.Admin, error) {
+	defer r.lock()()
+
+	admin, ok := r.findOne(func(a models.Admin) bool {
+		return a.Email == email && sameID(a.PropertyID, propertyID)
+	})
+	if !ok {
+		return nil, fmt.Errorf("admin tidak ditemukan untuk email dan property_id tersebut: not found")
+	}
+	return admin, nil
+}
+
+func (r *adminRepo) update(adminID string, apply func(*models.Admin)) {
+	id, ok := parseID(adminID)
+	if !ok {
+		return
+	}
+	admin, exists := r.store.admins[id]
+	if !exists {
+		return
+	}
+	apply(&admin)
+	r.store.admins[id] = admin
+}
+
+func (r *adminRepo) UpdateActiveStatus(adminID string, isActive bool) error {
+	defer r.lock()()
+
+	r.update(adminID, func(a *models.Admin) { a.IsActive = isActive })
+	return nil
+}
+
+func (r *adminRepo) UpdateRole(adminID, role string) error {
+	defer r.lock()()
+
+	r.update(adminID, func(a *models.Admin) { a.Role = role })
+	return nil
+}
+
+func (r *adminRepo) UpdateProperty(adminID, propertyID string) error {
+	defer r.lock()()
+
+	pid, ok := parseID(propertyID)
+	if !ok {
+		return fmt.Errorf("gagal memperbarui property admin: invalid property id")
+	}
+	r.update(adminID, func(a *models.Admin) { a.PropertyID = &pid })
+	return nil
+}
+
+func (r *adminRepo) ListAdmins(propertyID string) ([]models.Admin, error) {
+	defer r.lock()()
+
+	admins := make([]models.Admin, 0)
+	for _, admin := range r.store.admins {
+		if propertyID != "" && !sameID(admin.PropertyID, propertyID) {
+			continue
+		}
+		admins = append(admins, clone(admin))
+	}
+	sortByCreated(admins, func(a models.Admin) time.Time { return a.CreatedAt }, func(a models.Admin) uuid.UUID { return a.ID })
+	return admins, nil
+}
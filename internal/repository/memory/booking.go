@@ -0,0 +1,440 @@
+package memory
+
+import (
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type bookingRepo struct {
+	conn
+}
+
+func NewBookingRepo(store *Store) repository.BookingRepo {
+	return &bookingRepo{conn{store: store}}
+}
+
+func (r *bookingRepo) CreateBooking(booking models.Booking) error {
+	defer r.lock()()
+
+	if booking.RoomID == nil && booking.RoomTypeID == nil {
+		return fmt.Errorf("gagal membuat booking: room_id atau room_type_id wajib diisi")
+	}
+	if _, exists := r.store.bookings[booking.ID]; exists {
+		return fmt.Errorf("gagal membuat booking: duplicate id %s", booking.ID)
+	}
+	// Cek dan insert terjadi di bawah lock yang sama, sehingga alokasi kamar atomik
+	now := time.Now()
+	if booking.RoomID != nil && !r.isAvailable(booking.RoomID.String(), dateKey(booking.CheckIn), dateKey(booking.CheckOut), now) {
+		return repository.ErrRoomUnavailable
+	}
+	if booking.RoomTypeID != nil && r.remainingRooms(*booking.RoomTypeID, booking.CheckIn, booking.CheckOut, now) <= 0 {
+		return repository.ErrRoomUnavailable
+	}
+	r.store.bookings[booking.ID] = clone(booking)
+	return nil
+}
+
+func (r *bookingRepo) CheckAvailability(roomID string, checkIn, checkOut string) (bool, error) {
+	defer r.lock()()
+
+	return r.isAvailable(roomID, checkIn, checkOut, time.Now()), nil
+}
+
+// isAvailable memakai aturan overlap yang sama dengan query Supabase:
+// check_in < checkOut AND check_out > checkIn, booking yang tidak lagi
+// memegang kamar diabaikan.
+func (r *bookingRepo) isAvailable(roomID, checkIn, checkOut string, now time.Time) bool {
+	for _, booking := range r.store.bookings {
+		if !sameID(booking.RoomID, roomID) || !holdsRoom(booking, now) {
+			continue
+		}
+		if dateKey(booking.CheckIn) < checkOut && dateKey(booking.CheckOut) > checkIn {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *bookingRepo) CheckRoomTypeAvailability(roomTypeID string, checkIn, checkOut string) (int, error) {
+	defer r.lock()()
+
+	id, _ := parseID(roomTypeID)
+	start, err := time.Parse("2006-01-02", checkIn)
+	if err != nil {
+		return 0, fmt.Errorf("invalid check_in")
+	}
+	end, err := time.Parse("2006-01-02", checkOut)
+	if err != nil {
+		return 0, fmt.Errorf("invalid check_out")
+	}
+	return r.remainingRooms(id, start, end, time.Now()), nil
+}
+
+// remainingRooms menghitung kamar tipe ini yang tidak OutOfOrder dikurangi
+// booking aktif terbanyak pada satu malam.
+func (r *bookingRepo) remainingRooms(roomTypeID uuid.UUID, checkIn, checkOut time.Time, now time.Time) int {
+	sellable := 0
+	for _, room := range r.store.rooms {
+		if room.RoomTypeID != nil && *room.RoomTypeID == roomTypeID && room.Status != models.RoomStatusOutOfOrder {
+			sellable++
+		}
+	}
+	overlapping := make([]models.Booking, 0)
+	for _, booking := range r.store.bookings {
+		if booking.RoomTypeID == nil || *booking.RoomTypeID != roomTypeID || !holdsRoom(booking, now) {
+			continue
+		}
+		if booking.CheckIn.Before(checkOut) && booking.CheckOut.After(checkIn) {
+			overlapping = append(overlapping, booking)
+		}
+	}
+	return repository.RemainingRooms(sellable, overlapping, checkIn, checkOut)
+}
+
+func (r *bookingRepo) AssignRoom(bookingID, roomID string) (*models.Booking, error) {
+	defer r.lock()()
+
+	id, _ := parseID(bookingID)
+	booking, exists := r.store.bookings[id]
+	if !exists {
+		return nil, fmt.Errorf("gagal meng-assign kamar: not found")
+	}
+	roomUUID, ok := parseID(roomID)
+	if !ok {
+		return nil, fmt.Errorf("gagal meng-assign kamar: invalid room id")
+	}
+	// Booking ini sendiri belum memegang kamar tersebut, jadi tidak ikut terhitung
+	if !sameID(booking.RoomID, roomID) && !r.isAvailable(roomID, dateKey(booking.CheckIn), dateKey(booking.CheckOut), time.Now()) {
+		return nil, repository.ErrRoomUnavailable
+	}
+	booking.RoomID = &roomUUID
+	r.store.bookings[id] = booking
+
+	out := clone(booking)
+	return &out, nil
+}
+
+func (r *bookingRepo) UpdateBookingStay(booking models.Booking, previousTotal float64) (*models.Booking, error) {
+	defer r.lock()()
+
+	existing, exists := r.store.bookings[booking.ID]
+	if !exists {
+		return nil, fmt.Errorf("gagal mengubah booking: not found")
+	}
+	if (existing.Status != models.BookingStatusNew && existing.Status != models.BookingStatusConfirmed) ||
+		existing.TotalPrice != previousTotal {
+		return nil, repository.ErrBookingStatusConflict
+	}
+	// Booking dikeluarkan sementara supaya stay lamanya tidak menghalangi
+	// stay baru, lalu dikembalikan jika inventori tidak cukup
+	delete(r.store.bookings, booking.ID)
+	now := time.Now()
+	if (booking.RoomID != nil && !r.isAvailable(booking.RoomID.String(), dateKey(booking.CheckIn), dateKey(booking.CheckOut), now)) ||
+		(booking.RoomTypeID != nil && r.remainingRooms(*booking.RoomTypeID, booking.CheckIn, booking.CheckOut, now) <= 0) {
+		r.store.bookings[booking.ID] = existing
+		return nil, repository.ErrRoomUnavailable
+	}
+	existing.RoomID = booking.RoomID
+	existing.RoomTypeID = booking.RoomTypeID
+	existing.RatePlanID = booking.RatePlanID
+	existing.CheckIn = booking.CheckIn
+	existing.CheckOut = booking.CheckOut
+	existing.Nights = booking.Nights
+	existing.Adults = booking.Adults
+	existing.Children = booking.Children
+	existing.ChildAges = booking.ChildAges
+	existing.TotalPrice = booking.TotalPrice
+	existing.TaxAmount = booking.TaxAmount
+	r.store.bookings[booking.ID] = clone(existing)
+
+	out := clone(existing)
+	return &out, nil
+}
+
+// holdsRoom bernilai false untuk booking batal/kedaluwarsa dan booking New
+// yang hold-nya sudah lewat.
+func holdsRoom(booking models.Booking, now time.Time) bool {
+	switch booking.Status {
+	case models.BookingStatusCancel, models.BookingStatusExpired:
+		return false
+	case models.BookingStatusNew:
+		return booking.HoldExpiresAt == nil || booking.HoldExpiresAt.After(now)
+	}
+	return true
+}
+
+func (r *bookingRepo) GetBookingsByGuestID(guestID string) ([]models.Booking, error) {
+	defer r.lock()()
+
+	bookings := make([]models.Booking, 0)
+	for _, booking := range r.store.bookings {
+		if sameID(booking.GuestID, guestID) {
+			bookings = append(bookings, clone(booking))
+		}
+	}
+	sort.SliceStable(bookings, func(i, j int) bool {
+		return bookings[i].CreatedAt.After(bookings[j].CreatedAt)
+	})
+	return bookings, nil
+}
+
+func (r *bookingRepo) GetBookingByID(bookingID string) (*models.Booking, error) {
+	defer r.lock()()
+
+	id, _ := parseID(bookingID)
+	booking, exists := r.store.bookings[id]
+	if !exists {
+		return nil, fmt.Errorf("gagal mengambil booking: not found")
+	}
+	out := clone(booking)
+	return &out, nil
+}
+
+func (r *bookingRepo) ListBookings(propertyID, status, startDate, endDate string) ([]models.Booking, error) {
+	defer r.lock()()
+
+	bookings := make([]models.Booking, 0)
+	for _, booking := range r.store.bookings {
+		if propertyID != "" && !sameID(booking.PropertyID, propertyID) {
+			continue
+		}
+		if status != "" && string(booking.Status) != status {
+			continue
+		}
+		if startDate != "" && dateKey(booking.CheckIn) < startDate {
+			continue
+		}
+		if endDate != "" && dateKey(booking.CheckOut) > endDate {
+			continue
+		}
+		bookings = append(bookings, clone(booking))
+	}
+	sortByCreated(bookings, func(b models.Booking) time.Time { return b.CreatedAt }, func(b models.Booking) uuid.UUID { return b.ID })
+	return bookings, nil
+}
+
+func (r *bookingRepo) ListActiveBookings(propertyID, startDate, endDate string) ([]models.Booking, error) {
+	defer r.lock()()
+
+	now := time.Now()
+	bookings := make([]models.Booking, 0)
+	for _, booking := range r.store.bookings {
+		if !sameID(booking.PropertyID, propertyID) || !holdsRoom(booking, now) {
+			continue
+		}
+		if dateKey(booking.CheckIn) < endDate && dateKey(booking.CheckOut) > startDate {
+			bookings = append(bookings, clone(booking))
+		}
+	}
+	sortByCreated(bookings, func(b models.Booking) time.Time { return b.CreatedAt }, func(b models.Booking) uuid.UUID { return b.ID })
+	return bookings, nil
+}
+
+func (r *bookingRepo) CountBookingsByProperty(since time.Time) (map[string]int, error) {
+	defer r.lock()()
+
+	counts := make(map[string]int)
+	for _, booking := range r.store.bookings {
+		if booking.PropertyID == nil || booking.CreatedAt.Before(since) {
+			continue
+		}
+		if booking.Status == models.BookingStatusCancel || booking.Status == models.BookingStatusExpired {
+			continue
+		}
+		counts[booking.PropertyID.String()]++
+	}
+	return counts, nil
+}
+
+func (r *bookingRepo) UpdateBookingStatus(bookingID string, from, status models.BookingStatus, note string, refundAmount float64) (*models.Booking, error) {
+	defer r.lock()()
+
+	id, _ := parseID(bookingID)
+	booking, exists := r.store.bookings[id]
+	if !exists {
+		return nil, fmt.Errorf("gagal memperbarui status booking: not found")
+	}
+	if booking.Status != from {
+		return nil, repository.ErrBookingStatusConflict
+	}
+	booking.Status = status
+	if note != "" {
+		booking.Note = note
+	}
+	if refundAmount != 0 || status == models.BookingStatusCancel {
+		booking.RefundAmount = refundAmount
+	}
+	r.store.bookings[id] = booking
+
+	out := clone(booking)
+	return &out, nil
+}
+
+func (r *bookingRepo) ExpireHolds(now time.Time) ([]models.Booking, error) {
+	defer r.lock()()
+
+	expired := make([]models.Booking, 0)
+	for id, booking := range r.store.bookings {
+		if booking.Status != models.BookingStatusNew || booking.HoldExpiresAt == nil || booking.HoldExpiresAt.After(now) {
+			continue
+		}
+		booking.Status = models.BookingStatusExpired
+		r.store.bookings[id] = booking
+		expired = append(expired, clone(booking))
+	}
+	return expired, nil
+}
+
+func (r *bookingRepo) CreateStatusHistory(entry models.BookingStatusHistory) error {
+	defer r.lock()()
+
+	if _, exists := r.store.statusHistory[entry.ID]; exists {
+		return fmt.Errorf("gagal mencatat riwayat status booking: duplicate id %s", entry.ID)
+	}
+	r.store.statusHistory[entry.ID] = clone(entry)
+	return nil
+}
+
+func (r *bookingRepo) ListStatusHistory(bookingID string) ([]models.BookingStatusHistory, error) {
+	defer r.lock()()
+
+	history := make([]models.BookingStatusHistory, 0)
+	for _, entry := range r.store.statusHistory {
+		if sameID(entry.BookingID, bookingID) {
+			history = append(history, clone(entry))
+		}
+	}
+	sortByCreated(history, func(h models.BookingStatusHistory) time.Time { return h.CreatedAt }, func(h models.BookingStatusHistory) uuid.UUID { return h.ID })
+	return history, nil
+}
+
+func (r *bookingRepo) CreateReservation(reservation models.Reservation) error {
+	defer r.lock()()
+
+	if _, exists := r.store.reservations[reservation.ID]; exists {
+		return fmt.Errorf("gagal membuat reservasi: duplicate id %s", reservation.ID)
+	}
+	for _, existing := range r.store.reservations {
+		if existing.ConfirmationCode == reservation.ConfirmationCode {
+			return fmt.Errorf("gagal membuat reservasi: duplicate confirmation_code %s", reservation.ConfirmationCode)
+		}
+	}
+	r.store.reservations[reservation.ID] = clone(reservation)
+	return nil
+}
+
+func (r *bookingRepo) GetReservationByID(reservationID string) (*models.Reservation, error) {
+	defer r.lock()()
+
+	id, _ := parseID(reservationID)
+	reservation, exists := r.store.reservations[id]
+	if !exists {
+		return nil, fmt.Errorf("gagal mengambil reservasi: not found")
+	}
+	out := clone(reservation)
+	return &out, nil
+}
+
+func (r *bookingRepo) GetReservationByCode(code string) (*models.Reservation, error) {
+	defer r.lock()()
+
+	for _, reservation := range r.store.reservations {
+		if reservation.ConfirmationCode == code {
+			out := clone(reservation)
+			return &out, nil
+		}
+	}
+	return nil, fmt.Errorf("gagal mengambil reservasi: not found")
+}
+
+func (r *bookingRepo) ListReservationBookings(reservationID string) ([]models.Booking, error) {
+	defer r.lock()()
+
+	bookings := make([]models.Booking, 0)
+	for _, booking := range r.store.bookings {
+		if sameID(booking.ReservationID, reservationID) {
+			bookings = append(bookings, clone(booking))
+		}
+	}
+	sortByCreated(bookings, func(b models.Booking) time.Time { return b.CreatedAt }, func(b models.Booking) uuid.UUID { return b.ID })
+	return bookings, nil
+}
+
+func (r *bookingRepo) UpdateReservationTotal(reservationID string, totalPrice float64) (*models.Reservation, error) {
+	defer r.lock()()
+
+	id, _ := parseID(reservationID)
+	reservation, exists := r.store.reservations[id]
+	if !exists {
+		return nil, fmt.Errorf("gagal memperbarui reservasi: not found")
+	}
+	reservation.TotalPrice = totalPrice
+	r.store.reservations[id] = reservation
+
+	out := clone(reservation)
+	return &out, nil
+}
+
+func (r *bookingRepo) ReplaceOccupants(bookingID string, occupants []models.BookingOccupant) error {
+	defer r.lock()()
+
+	for id, occupant := range r.store.occupants {
+		if sameID(occupant.BookingID, bookingID) {
+			delete(r.store.occupants, id)
+		}
+	}
+	bookingUUID, ok := parseID(bookingID)
+	if !ok {
+		return fmt.Errorf("gagal menyimpan tamu booking: invalid booking id")
+	}
+	for _, occupant := range occupants {
+		occupant.BookingID = &bookingUUID
+		r.store.occupants[occupant.ID] = clone(occupant)
+	}
+	return nil
+}
+
+func (r *bookingRepo) ListOccupants(bookingIDs []string) ([]models.BookingOccupant, error) {
+	defer r.lock()()
+
+	wanted := make(map[string]bool, len(bookingIDs))
+	for _, id := range bookingIDs {
+		wanted[id] = true
+	}
+	occupants := make([]models.BookingOccupant, 0)
+	for _, occupant := range r.store.occupants {
+		if occupant.BookingID != nil && wanted[occupant.BookingID.String()] {
+			occupants = append(occupants, clone(occupant))
+		}
+	}
+	sortByCreated(occupants, func(o models.BookingOccupant) time.Time { return o.CreatedAt }, func(o models.BookingOccupant) uuid.UUID { return o.ID })
+	return occupants, nil
+}
+
+func (r *bookingRepo) CreateModification(modification models.BookingModification) error {
+	defer r.lock()()
+
+	if _, exists := r.store.modifications[modification.ID]; exists {
+		return fmt.Errorf("gagal mencatat perubahan booking: duplicate id %s", modification.ID)
+	}
+	r.store.modifications[modification.ID] = clone(modification)
+	return nil
+}
+
+func (r *bookingRepo) ListModifications(bookingID string) ([]models.BookingModification, error) {
+	defer r.lock()()
+
+	modifications := make([]models.BookingModification, 0)
+	for _, modification := range r.store.modifications {
+		if sameID(modification.BookingID, bookingID) {
+			modifications = append(modifications, clone(modification))
+		}
+	}
+	sortByCreated(modifications, func(m models.BookingModification) time.Time { return m.CreatedAt }, func(m models.BookingModification) uuid.UUID { return m.ID })
+	return modifications, nil
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+)
+
+type guestRepo struct {
+	conn
+}
+
+func NewGuestRepo(store *Store) repository.GuestRepo {
+	return &guestRepo{conn{store: store}}
+}
+
+func (r *guestRepo) CreateProfile(profile models.Guest) error {
+	defer r.lock()()
+
+	if _, exists := r.store.guests[profile.ID]; exists {
+		return fmt.Errorf("gagal menyisipkan profil tamu ke db: duplicate id %s", profile.ID)
+	}
+	r.store.guests[profile.ID] = clone(profile)
+	return nil
+}
+
+func (r *guestRepo) GetGuestByID(id string) (*models.Guest, error) {
+	defer r.lock()()
+
+	guestID, ok := parseID(id)
+	if !ok {
+		return nil, fmt.Errorf("gagal mengambil profil tamu: invalid id")
+	}
+	guest, exists := r.store.guests[guestID]
+	if !exists {
+		return nil, fmt.Errorf("gagal mengambil profil tamu: not found")
+	}
+	out := clone(guest)
+	return &out, nil
+}
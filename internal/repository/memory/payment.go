@@ -0,0 +1,438 @@
+package memory
+
+import (
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type paymentRepo struct {
+	conn
+}
+
+func NewPaymentRepo(store *Store) repository.PaymentRepo {
+	return &paymentRepo{conn{store: store}}
+}
+
+func (r *paymentRepo) CreatePayment(payment models.Payment) error {
+	defer r.lock()()
+
+	if _, exists := r.store.payments[payment.ID]; exists {
+		return fmt.Errorf("gagal membuat payment: duplicate id %s", payment.ID)
+	}
+	r.store.payments[payment.ID] = clone(payment)
+	return nil
+}
+
+func (r *paymentRepo) GetPaymentByID(paymentID string) (*models.Payment, error) {
+	defer r.lock()()
+
+	id, _ := parseID(paymentID)
+	payment, ok := r.store.payments[id]
+	if !ok {
+		return nil, fmt.Errorf("gagal mengambil payment: not found")
+	}
+	out := clone(payment)
+	return &out, nil
+}
+
+func (r *paymentRepo) ListPaymentsByBookingID(bookingID string) ([]models.Payment, error) {
+	defer r.lock()()
+
+	return r.listPayments(func(payment models.Payment) bool { return sameID(payment.BookingID, bookingID) }), nil
+}
+
+func (r *paymentRepo) ListPaymentsByReservationID(reservationID string) ([]models.Payment, error) {
+	defer r.lock()()
+
+	return r.listPayments(func(payment models.Payment) bool { return sameID(payment.ReservationID, reservationID) }), nil
+}
+
+func (r *paymentRepo) ListDuePayments(before time.Time) ([]models.Payment, error) {
+	defer r.lock()()
+
+	payments := r.listPayments(func(payment models.Payment) bool {
+		return payment.Status == models.PaymentStatusPending && payment.DueAt != nil && !payment.DueAt.After(before)
+	})
+	sort.SliceStable(payments, func(i, j int) bool { return payments[i].DueAt.Before(*payments[j].DueAt) })
+	return payments, nil
+}
+
+func (r *paymentRepo) listPayments(match func(models.Payment) bool) []models.Payment {
+	var out []models.Payment
+	for _, payment := range r.store.payments {
+		if match(payment) {
+			out = append(out, clone(payment))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+func (r *paymentRepo) SetPaymentStatus(paymentID string, status models.PaymentStatus) (*models.Payment, error) {
+	return r.updatePayment(paymentID, func(payment *models.Payment) {
+		payment.Status = status
+		if status == models.PaymentStatusPaid {
+			now := time.Now()
+			payment.PaidAt = &now
+		}
+	})
+}
+
+func (r *paymentRepo) UpdatePaymentAmount(paymentID string, amount float64) (*models.Payment, error) {
+	return r.updatePayment(paymentID, func(payment *models.Payment) { payment.Amount = amount })
+}
+
+func (r *paymentRepo) MarkPaymentReminded(paymentID string, remindedAt time.Time) (*models.Payment, error) {
+	return r.updatePayment(paymentID, func(payment *models.Payment) { payment.ReminderSentAt = &remindedAt })
+}
+
+func (r *paymentRepo) updatePayment(paymentID string, update func(*models.Payment)) (*models.Payment, error) {
+	defer r.lock()()
+
+	id, _ := parseID(paymentID)
+	payment, ok := r.store.payments[id]
+	if !ok {
+		return nil, fmt.Errorf("gagal memperbarui payment: not found")
+	}
+	update(&payment)
+	r.store.payments[id] = payment
+
+	out := clone(payment)
+	return &out, nil
+}
+
+func (r *paymentRepo) CreateInvoice(invoice models.Invoice) error {
+	defer r.lock()()
+
+	if _, exists := r.store.invoices[invoice.ID]; exists {
+		return fmt.Errorf("gagal membuat invoice: duplicate id %s", invoice.ID)
+	}
+	r.store.invoices[invoice.ID] = clone(invoice)
+	return nil
+}
+
+func (r *paymentRepo) invoiceByBooking(bookingID string) (uuid.UUID, bool) {
+	return r.findInvoice(func(invoice models.Invoice) bool { return sameID(invoice.BookingID, bookingID) })
+}
+
+func (r *paymentRepo) invoiceByReservation(reservationID string) (uuid.UUID, bool) {
+	return r.findInvoice(func(invoice models.Invoice) bool { return sameID(invoice.ReservationID, reservationID) })
+}
+
+func (r *paymentRepo) findInvoice(match func(models.Invoice) bool) (uuid.UUID, bool) {
+	var found []uuid.UUID
+	for id, invoice := range r.store.invoices {
+		if match(invoice) {
+			found = append(found, id)
+		}
+	}
+	if len(found) != 1 {
+		return uuid.Nil, false
+	}
+	return found[0], true
+}
+
+func (r *paymentRepo) GetInvoiceByBookingID(bookingID string) (*models.Invoice, error) {
+	defer r.lock()()
+
+	id, ok := r.invoiceByBooking(bookingID)
+	return r.getInvoice(id, ok)
+}
+
+func (r *paymentRepo) GetInvoiceByReservationID(reservationID string) (*models.Invoice, error) {
+	defer r.lock()()
+
+	id, ok := r.invoiceByReservation(reservationID)
+	return r.getInvoice(id, ok)
+}
+
+func (r *paymentRepo) getInvoice(id uuid.UUID, ok bool) (*models.Invoice, error) {
+	if !ok {
+		return nil, fmt.Errorf("gagal mengambil invoice: not found")
+	}
+	out := clone(r.store.invoices[id])
+	return &out, nil
+}
+
+func (r *paymentRepo) UpdateInvoiceStatus(bookingID string, status models.PaymentStatus) (*models.Invoice, error) {
+	defer r.lock()()
+
+	id, ok := r.invoiceByBooking(bookingID)
+	return r.updateInvoiceStatus(id, ok, status)
+}
+
+func (r *paymentRepo) UpdateReservationInvoiceStatus(reservationID string, status models.PaymentStatus) (*models.Invoice, error) {
+	defer r.lock()()
+
+	id, ok := r.invoiceByReservation(reservationID)
+	return r.updateInvoiceStatus(id, ok, status)
+}
+
+func (r *paymentRepo) updateInvoiceStatus(id uuid.UUID, ok bool, status models.PaymentStatus) (*models.Invoice, error) {
+	if !ok {
+		return nil, fmt.Errorf("gagal memperbarui invoice: not found")
+	}
+	invoice := r.store.invoices[id]
+	invoice.Status = status
+	r.store.invoices[id] = invoice
+
+	out := clone(invoice)
+	return &out, nil
+}
+
+func (r *paymentRepo) UpdateInvoiceTotals(bookingID string, totals models.InvoiceTotals) error {
+	defer r.lock()()
+
+	id, ok := r.invoiceByBooking(bookingID)
+	return r.updateInvoiceTotals(id, ok, totals)
+}
+
+func (r *paymentRepo) UpdateReservationInvoiceTotals(reservationID string, totals models.InvoiceTotals) error {
+	defer r.lock()()
+
+	id, ok := r.invoiceByReservation(reservationID)
+	return r.updateInvoiceTotals(id, ok, totals)
+}
+
+func (r *paymentRepo) updateInvoiceTotals(id uuid.UUID, ok bool, totals models.InvoiceTotals) error {
+	if !ok {
+		return fmt.Errorf("gagal memperbarui nominal tagihan: not found")
+	}
+	invoice := r.store.invoices[id]
+	invoice.Amount = totals.Amount
+	invoice.NetAmount = totals.NetAmount
+	invoice.TaxAmount = totals.TaxAmount
+	invoice.TaxLines = clone(totals.TaxLines)
+	r.store.invoices[id] = invoice
+	return nil
+}
+
+func (r *paymentRepo) SetPaymentCharge(paymentID, provider, reference string) (*models.Payment, error) {
+	defer r.lock()()
+
+	id, _ := parseID(paymentID)
+	payment, ok := r.store.payments[id]
+	if !ok {
+		return nil, fmt.Errorf("gagal memperbarui payment: not found")
+	}
+	payment.Provider = provider
+	payment.Reference = reference
+	r.store.payments[id] = payment
+
+	out := clone(payment)
+	return &out, nil
+}
+
+func (r *paymentRepo) RecordPaymentCapture(paymentID string, charge models.PaymentCharge) (*models.Payment, error) {
+	defer r.lock()()
+
+	id, _ := parseID(paymentID)
+	payment, ok := r.store.payments[id]
+	if !ok {
+		return nil, fmt.Errorf("gagal memperbarui payment: not found")
+	}
+	for _, existing := range r.store.paymentCharges {
+		if existing.PaymentID == id && existing.Reference == charge.Reference {
+			return nil, fmt.Errorf("gagal mencatat charge payment: duplicate reference %s", charge.Reference)
+		}
+	}
+	r.store.paymentCharges[charge.ID] = clone(charge)
+	payment.AmountPaid = math.Round((payment.AmountPaid+charge.Amount)*100) / 100
+	if payment.AmountPaid-payment.AmountRefunded >= payment.Amount {
+		payment.Status = models.PaymentStatusPaid
+		paidAt := charge.CapturedAt
+		payment.PaidAt = &paidAt
+	}
+	r.store.payments[id] = payment
+
+	out := clone(payment)
+	return &out, nil
+}
+
+func (r *paymentRepo) ListPaymentCharges(paymentID string) ([]models.PaymentCharge, error) {
+	defer r.lock()()
+
+	return r.paymentCharges(paymentID), nil
+}
+
+func (r *paymentRepo) paymentCharges(paymentID string) []models.PaymentCharge {
+	var out []models.PaymentCharge
+	for _, charge := range r.store.paymentCharges {
+		if charge.PaymentID.String() == paymentID {
+			out = append(out, clone(charge))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CapturedAt.Before(out[j].CapturedAt) })
+	return out
+}
+
+func (r *paymentRepo) CreateWebhookEvent(event models.PaymentWebhookEvent) (*models.PaymentWebhookEvent, bool, error) {
+	defer r.lock()()
+
+	for _, existing := range r.store.webhookEvents {
+		if existing.Provider == event.Provider && existing.EventID == event.EventID {
+			out := clone(existing)
+			return &out, false, nil
+		}
+	}
+	if _, exists := r.store.webhookEvents[event.ID]; exists {
+		return nil, false, fmt.Errorf("gagal menyimpan webhook event: duplicate id %s", event.ID)
+	}
+	r.store.webhookEvents[event.ID] = clone(event)
+	out := clone(event)
+	return &out, true, nil
+}
+
+func (r *paymentRepo) UpdateWebhookEventStatus(eventID string, status models.WebhookEventStatus, note, paymentID string, processedAt time.Time) (*models.PaymentWebhookEvent, error) {
+	defer r.lock()()
+
+	id, _ := parseID(eventID)
+	event, ok := r.store.webhookEvents[id]
+	if !ok {
+		return nil, fmt.Errorf("gagal memperbarui webhook event: not found")
+	}
+	event.Status = status
+	event.Note = note
+	event.ProcessedAt = &processedAt
+	if pid, ok := parseID(paymentID); ok {
+		event.PaymentID = &pid
+	}
+	r.store.webhookEvents[id] = event
+
+	out := clone(event)
+	return &out, nil
+}
+
+func (r *paymentRepo) RecordPaymentRefund(paymentID string, amount float64) (*models.Payment, error) {
+	defer r.lock()()
+
+	id, _ := parseID(paymentID)
+	payment, ok := r.store.payments[id]
+	if !ok {
+		return nil, fmt.Errorf("gagal memperbarui payment: not found")
+	}
+	// Sama dengan check constraint payments_refund_within_paid
+	if amount > math.Round((payment.AmountPaid-payment.AmountRefunded)*100)/100 {
+		return nil, repository.ErrRefundExceedsCharge
+	}
+	payment.AmountRefunded = math.Round((payment.AmountRefunded+amount)*100) / 100
+	payment.Status = models.PaymentStatusPartiallyRefunded
+	if payment.AmountRefunded >= payment.AmountPaid {
+		payment.Status = models.PaymentStatusRefunded
+	}
+	r.store.payments[id] = payment
+
+	out := clone(payment)
+	return &out, nil
+}
+
+func (r *paymentRepo) CreateRefund(refund models.Refund) error {
+	defer r.lock()()
+
+	if _, exists := r.store.refunds[refund.ID]; exists {
+		return fmt.Errorf("gagal membuat refund: duplicate id %s", refund.ID)
+	}
+	if refund.PaymentID == nil {
+		return fmt.Errorf("gagal membuat refund: payment_id wajib diisi")
+	}
+	paymentID := refund.PaymentID.String()
+	if refund.Amount > repository.ChargeRefundable(r.paymentCharges(paymentID), r.refunds(paymentID), refund.ChargeReference) {
+		return repository.ErrRefundExceedsCharge
+	}
+	r.store.refunds[refund.ID] = clone(refund)
+	return nil
+}
+
+func (r *paymentRepo) CompleteRefund(refundID string, status models.RefundStatus, gatewayReference, failureReason string, completedAt time.Time) (*models.Refund, error) {
+	defer r.lock()()
+
+	id, _ := parseID(refundID)
+	refund, ok := r.store.refunds[id]
+	if !ok {
+		return nil, fmt.Errorf("gagal memperbarui refund: not found")
+	}
+	refund.Status = status
+	refund.GatewayReference = gatewayReference
+	refund.FailureReason = failureReason
+	refund.CompletedAt = &completedAt
+	r.store.refunds[id] = refund
+
+	out := clone(refund)
+	return &out, nil
+}
+
+func (r *paymentRepo) ListRefunds(paymentID string) ([]models.Refund, error) {
+	defer r.lock()()
+
+	return r.refunds(paymentID), nil
+}
+
+func (r *paymentRepo) refunds(paymentID string) []models.Refund {
+	var out []models.Refund
+	for _, refund := range r.store.refunds {
+		if sameID(refund.PaymentID, paymentID) {
+			out = append(out, clone(refund))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+func (r *paymentRepo) CreateFolioCharge(charge models.FolioCharge) error {
+	defer r.lock()()
+
+	if _, exists := r.store.folioCharges[charge.ID]; exists {
+		return fmt.Errorf("gagal memposting tagihan folio: duplicate id %s", charge.ID)
+	}
+	r.store.folioCharges[charge.ID] = clone(charge)
+	return nil
+}
+
+func (r *paymentRepo) GetFolioCharge(chargeID string) (*models.FolioCharge, error) {
+	defer r.lock()()
+
+	id, _ := parseID(chargeID)
+	charge, ok := r.store.folioCharges[id]
+	if !ok {
+		return nil, fmt.Errorf("gagal mengambil tagihan folio: not found")
+	}
+	out := clone(charge)
+	return &out, nil
+}
+
+func (r *paymentRepo) ListFolioCharges(bookingID string) ([]models.FolioCharge, error) {
+	defer r.lock()()
+
+	var out []models.FolioCharge
+	for _, charge := range r.store.folioCharges {
+		if sameID(charge.BookingID, bookingID) {
+			out = append(out, clone(charge))
+		}
+	}
+	sortByCreated(out, func(c models.FolioCharge) time.Time { return c.PostedAt }, func(c models.FolioCharge) uuid.UUID { return c.ID })
+	return out, nil
+}
+
+func (r *paymentRepo) VoidFolioCharge(chargeID, voidedBy, reason string, voidedAt time.Time) (*models.FolioCharge, error) {
+	defer r.lock()()
+
+	id, _ := parseID(chargeID)
+	charge, ok := r.store.folioCharges[id]
+	if !ok || charge.Status != models.FolioChargeStatusPosted {
+		return nil, fmt.Errorf("gagal void tagihan folio: not found")
+	}
+	charge.Status = models.FolioChargeStatusVoid
+	charge.VoidedBy = voidedBy
+	charge.VoidReason = reason
+	charge.VoidedAt = &voidedAt
+	r.store.folioCharges[id] = charge
+
+	out := clone(charge)
+	return &out, nil
+}
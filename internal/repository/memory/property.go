@@ -0,0 +1,456 @@
+package memory
+
+import (
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type propertyRepo struct {
+	conn
+}
+
+func NewPropertyRepo(store *Store) repository.PropertyRepo {
+	return &propertyRepo{conn{store: store}}
+}
+
+func (r *propertyRepo) GetPropertyByAuth(hotelCode, authCode string) (*models.Properties, error) {
+	defer r.lock()()
+
+	for _, property := range r.store.properties {
+		if property.HotelCode == hotelCode && property.AuthCode == authCode {
+			out := clone(property)
+			return &out, nil
+		}
+	}
+	return nil, fmt.Errorf("gagal mengambil property (periksa hotel/auth code): not found")
+}
+
+func (r *propertyRepo) CreateProperty(property models.Properties) error {
+	defer r.lock()()
+
+	if _, exists := r.store.properties[property.ID]; exists {
+		return fmt.Errorf("gagal memebuat property hotel: duplicate id %s", property.ID)
+	}
+	r.store.properties[property.ID] = clone(property)
+	return nil
+}
+
+func (r *propertyRepo) UpdateProperty(property models.Properties) (*models.Properties, error) {
+	defer r.lock()()
+
+	existing, exists := r.store.properties[property.ID]
+	if !exists {
+		return nil, fmt.Errorf("gagal mengubah property: not found")
+	}
+	existing.Name = property.Name
+	existing.Address = property.Address
+	existing.City = property.City
+	existing.Facilities = property.Facilities
+	existing.CheckInTime = property.CheckInTime
+	existing.CheckOutTime = property.CheckOutTime
+	existing.CancellationPolicy = property.CancellationPolicy
+	existing.Rating = property.Rating
+	existing.Latitude = property.Latitude
+	existing.Longitude = property.Longitude
+	existing.ModificationCutoffHours = property.ModificationCutoffHours
+	r.store.properties[property.ID] = clone(existing)
+
+	out := clone(existing)
+	return &out, nil
+}
+
+func (r *propertyRepo) DeleteProperty(id string) error {
+	defer r.lock()()
+
+	if propertyID, ok := parseID(id); ok {
+		delete(r.store.properties, propertyID)
+	}
+	return nil
+}
+
+func (r *propertyRepo) ListProperties(city string) ([]models.Properties, error) {
+	defer r.lock()()
+
+	return r.filterProperties(strings.TrimSpace(city)), nil
+}
+
+func (r *propertyRepo) filterProperties(city string) []models.Properties {
+	props := make([]models.Properties, 0)
+	for _, property := range r.store.properties {
+		if city != "" && !containsFold(property.City, city) {
+			continue
+		}
+		props = append(props, clone(property))
+	}
+	sortByCreated(props, func(p models.Properties) time.Time { return p.CreatedAt }, func(p models.Properties) uuid.UUID { return p.ID })
+	return props
+}
+
+func (r *propertyRepo) CreateRoomType(roomType models.RoomType) error {
+	defer r.lock()()
+
+	if _, exists := r.store.roomTypes[roomType.ID]; exists {
+		return fmt.Errorf("gagal membuat tipe kamar: duplicate id %s", roomType.ID)
+	}
+	r.store.roomTypes[roomType.ID] = clone(roomType)
+	return nil
+}
+
+func (r *propertyRepo) UpdateRoomType(roomType models.RoomType) (*models.RoomType, error) {
+	defer r.lock()()
+
+	existing, exists := r.store.roomTypes[roomType.ID]
+	if !exists {
+		return nil, fmt.Errorf("gagal memperbarui tipe kamar: not found")
+	}
+	existing.Name = roomType.Name
+	existing.Description = roomType.Description
+	existing.BasePrice = roomType.BasePrice
+	existing.Capacity = roomType.Capacity
+	existing.Facilities = roomType.Facilities
+	r.store.roomTypes[roomType.ID] = clone(existing)
+
+	out := clone(existing)
+	return &out, nil
+}
+
+func (r *propertyRepo) DeleteRoomType(id string) error {
+	defer r.lock()()
+
+	if roomTypeID, ok := parseID(id); ok {
+		delete(r.store.roomTypes, roomTypeID)
+	}
+	return nil
+}
+
+func (r *propertyRepo) ListRoomTypes(propertyID string) ([]models.RoomType, error) {
+	defer r.lock()()
+
+	return r.filterRoomTypes(strings.TrimSpace(propertyID)), nil
+}
+
+func (r *propertyRepo) filterRoomTypes(propertyID string) []models.RoomType {
+	roomTypes := make([]models.RoomType, 0)
+	for _, roomType := range r.store.roomTypes {
+		if propertyID != "" && !sameID(roomType.PropertyID, propertyID) {
+			continue
+		}
+		roomTypes = append(roomTypes, clone(roomType))
+	}
+	sortByCreated(roomTypes, func(rt models.RoomType) time.Time { return rt.CreatedAt }, func(rt models.RoomType) uuid.UUID { return rt.ID })
+	return roomTypes
+}
+
+func (r *propertyRepo) CreateRoom(room models.Room) error {
+	defer r.lock()()
+
+	if _, exists := r.store.rooms[room.ID]; exists {
+		return fmt.Errorf("gagal membuat unit kamar: duplicate id %s", room.ID)
+	}
+	room.RoomTypeDetail = nil
+	r.store.rooms[room.ID] = clone(room)
+	return nil
+}
+
+func (r *propertyRepo) UpdateRoom(room models.Room) (*models.Room, error) {
+	defer r.lock()()
+
+	existing, exists := r.store.rooms[room.ID]
+	if !exists {
+		return nil, fmt.Errorf("gagal memperbarui unit kamar: not found")
+	}
+	existing.RoomNumber = room.RoomNumber
+	existing.RoomTypeID = room.RoomTypeID
+	existing.Status = room.Status
+	existing.HousekeepingStatus = room.HousekeepingStatus
+	r.store.rooms[room.ID] = clone(existing)
+
+	out := clone(existing)
+	return &out, nil
+}
+
+func (r *propertyRepo) DeleteRoom(id string) error {
+	defer r.lock()()
+
+	if roomID, ok := parseID(id); ok {
+		delete(r.store.rooms, roomID)
+	}
+	return nil
+}
+
+func (r *propertyRepo) ListRooms(propertyID, roomTypeID string) ([]models.Room, error) {
+	defer r.lock()()
+
+	propertyID = strings.TrimSpace(propertyID)
+	roomTypeID = strings.TrimSpace(roomTypeID)
+	rooms := make([]models.Room, 0)
+	for _, room := range r.store.rooms {
+		if propertyID != "" && !sameID(room.PropertyID, propertyID) {
+			continue
+		}
+		if roomTypeID != "" && !sameID(room.RoomTypeID, roomTypeID) {
+			continue
+		}
+		rooms = append(rooms, clone(room))
+	}
+	sortByCreated(rooms, func(rm models.Room) time.Time { return rm.CreatedAt }, func(rm models.Room) uuid.UUID { return rm.ID })
+	return rooms, nil
+}
+
+// UpsertRoomRates meniru upsert on_conflict room_id,room_type_id,rate_plan_id,date.
+func (r *propertyRepo) UpsertRoomRates(rates []models.RoomRate) error {
+	defer r.lock()()
+
+	if len(rates) == 0 {
+		return fmt.Errorf("rates list cannot be empty")
+	}
+	for _, rate := range rates {
+		if rate.RoomID == nil && rate.RoomTypeID == nil {
+			return fmt.Errorf("gagal menyimpan rate kamar: room_id atau room_type_id wajib diisi")
+		}
+	}
+	for _, rate := range rates {
+		r.store.roomRates[newRateKey(rate)] = clone(rate)
+	}
+	return nil
+}
+
+func (r *propertyRepo) ListRoomRates(roomID string, startDate, endDate string) ([]models.RoomRate, error) {
+	defer r.lock()()
+
+	id, _ := parseID(roomID)
+	return r.listRates(func(key rateKey) bool { return key.roomID == id }, startDate, endDate), nil
+}
+
+func (r *propertyRepo) ListRoomTypeRates(roomTypeID string, startDate, endDate string) ([]models.RoomRate, error) {
+	defer r.lock()()
+
+	id, _ := parseID(roomTypeID)
+	return r.listRates(func(key rateKey) bool { return key.roomID == uuid.Nil && key.roomTypeID == id }, startDate, endDate), nil
+}
+
+func (r *propertyRepo) ListRoomTypesRates(roomTypeIDs []string, startDate, endDate string) ([]models.RoomRate, error) {
+	defer r.lock()()
+
+	ids := make(map[uuid.UUID]bool, len(roomTypeIDs))
+	for _, roomTypeID := range roomTypeIDs {
+		if id, ok := parseID(roomTypeID); ok {
+			ids[id] = true
+		}
+	}
+	return r.listRates(func(key rateKey) bool { return key.roomID == uuid.Nil && ids[key.roomTypeID] }, startDate, endDate), nil
+}
+
+func (r *propertyRepo) ListRoomsRates(roomIDs []string, startDate, endDate string) ([]models.RoomRate, error) {
+	defer r.lock()()
+
+	ids := make(map[uuid.UUID]bool, len(roomIDs))
+	for _, roomID := range roomIDs {
+		if id, ok := parseID(roomID); ok {
+			ids[id] = true
+		}
+	}
+	return r.listRates(func(key rateKey) bool { return key.roomID != uuid.Nil && ids[key.roomID] }, startDate, endDate), nil
+}
+
+func (r *propertyRepo) listRates(match func(rateKey) bool, startDate, endDate string) []models.RoomRate {
+	rates := make([]models.RoomRate, 0)
+	for key, rate := range r.store.roomRates {
+		if !match(key) {
+			continue
+		}
+		if startDate != "" && key.date < startDate {
+			continue
+		}
+		if endDate != "" && key.date > endDate {
+			continue
+		}
+		rates = append(rates, clone(rate))
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if !rates[i].Date.Equal(rates[j].Date) {
+			return rates[i].Date.Before(rates[j].Date)
+		}
+		return rates[i].ID.String() < rates[j].ID.String()
+	})
+	return rates
+}
+
+func (r *propertyRepo) GetRoomByID(id string) (*models.Room, error) {
+	defer r.lock()()
+
+	roomID, _ := parseID(id)
+	room, exists := r.store.rooms[roomID]
+	if !exists {
+		return nil, fmt.Errorf("gagal mengambil room: not found")
+	}
+	out := clone(room)
+	return &out, nil
+}
+
+func (r *propertyRepo) GetRoomTypeByID(id string) (*models.RoomType, error) {
+	defer r.lock()()
+
+	roomTypeID, _ := parseID(id)
+	roomType, exists := r.store.roomTypes[roomTypeID]
+	if !exists {
+		return nil, fmt.Errorf("gagal mengambil room type: not found")
+	}
+	out := clone(roomType)
+	return &out, nil
+}
+
+func (r *propertyRepo) GetPropertyPhotoByID(id string) (*models.PropertyPhoto, error) {
+	defer r.lock()()
+
+	photoID, _ := parseID(id)
+	photo, exists := r.store.propertyPhotos[photoID]
+	if !exists {
+		return nil, fmt.Errorf("gagal mengambil foto property: not found")
+	}
+	out := clone(photo)
+	return &out, nil
+}
+
+func (r *propertyRepo) GetRoomPhotoByID(id string) (*models.RoomPhoto, error) {
+	defer r.lock()()
+
+	photoID, _ := parseID(id)
+	photo, exists := r.store.roomPhotos[photoID]
+	if !exists {
+		return nil, fmt.Errorf("gagal mengambil foto kamar: not found")
+	}
+	out := clone(photo)
+	return &out, nil
+}
+
+func (r *propertyRepo) AddPropertyPhoto(photo models.PropertyPhoto) error {
+	defer r.lock()()
+
+	if _, exists := r.store.propertyPhotos[photo.ID]; exists {
+		return fmt.Errorf("gagal menambahkan foto property: duplicate id %s", photo.ID)
+	}
+	r.store.propertyPhotos[photo.ID] = clone(photo)
+	return nil
+}
+
+func (r *propertyRepo) ListPropertyPhotos(propertyID string) ([]models.PropertyPhoto, error) {
+	defer r.lock()()
+
+	photos := make([]models.PropertyPhoto, 0)
+	for _, photo := range r.store.propertyPhotos {
+		if sameID(photo.PropertyID, propertyID) {
+			photos = append(photos, clone(photo))
+		}
+	}
+	sortByCreated(photos, func(p models.PropertyPhoto) time.Time { return p.CreatedAt }, func(p models.PropertyPhoto) uuid.UUID { return p.ID })
+	return photos, nil
+}
+
+func (r *propertyRepo) DeletePropertyPhoto(id string) error {
+	defer r.lock()()
+
+	if photoID, ok := parseID(id); ok {
+		delete(r.store.propertyPhotos, photoID)
+	}
+	return nil
+}
+
+func (r *propertyRepo) AddRoomPhoto(photo models.RoomPhoto) error {
+	defer r.lock()()
+
+	if _, exists := r.store.roomPhotos[photo.ID]; exists {
+		return fmt.Errorf("gagal menambahkan foto kamar: duplicate id %s", photo.ID)
+	}
+	r.store.roomPhotos[photo.ID] = clone(photo)
+	return nil
+}
+
+func (r *propertyRepo) ListRoomPhotos(roomTypeID, roomID string) ([]models.RoomPhoto, error) {
+	defer r.lock()()
+
+	roomTypeID = strings.TrimSpace(roomTypeID)
+	roomID = strings.TrimSpace(roomID)
+	photos := make([]models.RoomPhoto, 0)
+	for _, photo := range r.store.roomPhotos {
+		if roomTypeID != "" && !sameID(photo.RoomTypeID, roomTypeID) {
+			continue
+		}
+		if roomID != "" && !sameID(photo.RoomID, roomID) {
+			continue
+		}
+		photos = append(photos, clone(photo))
+	}
+	sortByCreated(photos, func(p models.RoomPhoto) time.Time { return p.CreatedAt }, func(p models.RoomPhoto) uuid.UUID { return p.ID })
+	return photos, nil
+}
+
+func (r *propertyRepo) DeleteRoomPhoto(id string) error {
+	defer r.lock()()
+
+	if photoID, ok := parseID(id); ok {
+		delete(r.store.roomPhotos, photoID)
+	}
+	return nil
+}
+
+func (r *propertyRepo) SearchProperties(filter repository.PropertySearchFilter) ([]models.Properties, error) {
+	defer r.lock()()
+
+	props := make([]models.Properties, 0)
+	for _, property := range r.filterProperties(strings.TrimSpace(filter.City)) {
+		if property.Rating < filter.MinRating || !containsAll(property.Facilities, filter.Facilities) {
+			continue
+		}
+		if filter.Bounds != nil && (property.Latitude == nil || property.Longitude == nil ||
+			!filter.Bounds.Contains(*property.Latitude, *property.Longitude)) {
+			continue
+		}
+		props = append(props, property)
+	}
+	return props, nil
+}
+
+// containsAll sama dengan operator array @> di postgres.
+func containsAll(values, wanted []string) bool {
+	for _, w := range wanted {
+		found := false
+		for _, v := range values {
+			if v == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *propertyRepo) GetPropertyByID(id string) (*models.Properties, error) {
+	defer r.lock()()
+
+	propertyID, _ := parseID(id)
+	property, exists := r.store.properties[propertyID]
+	if !exists {
+		return nil, repository.ErrPropertyNotFound
+	}
+	out := clone(property)
+	return &out, nil
+}
+
+func (r *propertyRepo) GetRoomTypesByPropertyID(propertyID string) ([]models.RoomType, error) {
+	defer r.lock()()
+
+	if strings.TrimSpace(propertyID) == "" {
+		return []models.RoomType{}, nil
+	}
+	return r.filterRoomTypes(strings.TrimSpace(propertyID)), nil
+}
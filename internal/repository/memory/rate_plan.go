@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"fmt"
+	"hotelbooking/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (r *propertyRepo) CreateRatePlan(plan models.RatePlan) error {
+	defer r.lock()()
+
+	if _, exists := r.store.ratePlans[plan.ID]; exists {
+		return fmt.Errorf("gagal membuat rate plan: duplicate id %s", plan.ID)
+	}
+	r.store.ratePlans[plan.ID] = clone(plan)
+	return nil
+}
+
+func (r *propertyRepo) UpdateRatePlan(plan models.RatePlan) (*models.RatePlan, error) {
+	defer r.lock()()
+
+	existing, exists := r.store.ratePlans[plan.ID]
+	if !exists {
+		return nil, fmt.Errorf("gagal memperbarui rate plan: not found")
+	}
+	existing.Code = plan.Code
+	existing.Name = plan.Name
+	existing.RateType = plan.RateType
+	existing.BreakfastIncluded = plan.BreakfastIncluded
+	existing.Refundable = plan.Refundable
+	existing.CancellationPolicy = plan.CancellationPolicy
+	existing.IsActive = plan.IsActive
+	existing.ParentRatePlanID = plan.ParentRatePlanID
+	existing.DerivationType = plan.DerivationType
+	existing.DerivationValue = plan.DerivationValue
+	r.store.ratePlans[plan.ID] = clone(existing)
+
+	out := clone(existing)
+	return &out, nil
+}
+
+// DeleteRatePlan ikut menghapus rate kamar milik plan tersebut, sama seperti
+// on delete cascade di database.
+func (r *propertyRepo) DeleteRatePlan(id string) error {
+	defer r.lock()()
+
+	planID, ok := parseID(id)
+	if !ok {
+		return nil
+	}
+	delete(r.store.ratePlans, planID)
+	for key := range r.store.roomRates {
+		if key.ratePlanID == planID {
+			delete(r.store.roomRates, key)
+		}
+	}
+	return nil
+}
+
+func (r *propertyRepo) ListRatePlans(propertyID string) ([]models.RatePlan, error) {
+	defer r.lock()()
+
+	propertyID = strings.TrimSpace(propertyID)
+	plans := make([]models.RatePlan, 0)
+	for _, plan := range r.store.ratePlans {
+		if propertyID != "" && !sameID(plan.PropertyID, propertyID) {
+			continue
+		}
+		plans = append(plans, clone(plan))
+	}
+	sortByCreated(plans, func(p models.RatePlan) time.Time { return p.CreatedAt }, func(p models.RatePlan) uuid.UUID { return p.ID })
+	return plans, nil
+}
+
+func (r *propertyRepo) GetRatePlanByID(id string) (*models.RatePlan, error) {
+	defer r.lock()()
+
+	planID, _ := parseID(id)
+	plan, exists := r.store.ratePlans[planID]
+	if !exists {
+		return nil, fmt.Errorf("gagal mengambil rate plan: not found")
+	}
+	out := clone(plan)
+	return &out, nil
+}
+
+func (r *propertyRepo) SetPropertyCancellationRules(propertyID string, rules *models.CancellationPolicy) (*models.Properties, error) {
+	defer r.lock()()
+
+	id, _ := parseID(propertyID)
+	property, exists := r.store.properties[id]
+	if !exists {
+		return nil, fmt.Errorf("gagal menyimpan aturan pembatalan: not found")
+	}
+	property.CancellationRules = clone(rules)
+	r.store.properties[id] = property
+
+	out := clone(property)
+	return &out, nil
+}
+
+func (r *propertyRepo) SetPropertyPaymentSchedule(propertyID string, schedule *models.PaymentSchedulePolicy) (*models.Properties, error) {
+	defer r.lock()()
+
+	id, _ := parseID(propertyID)
+	property, exists := r.store.properties[id]
+	if !exists {
+		return nil, fmt.Errorf("gagal menyimpan jadwal pembayaran: not found")
+	}
+	property.PaymentSchedule = clone(schedule)
+	r.store.properties[id] = property
+
+	out := clone(property)
+	return &out, nil
+}
+
+func (r *propertyRepo) SetPropertyTaxRules(propertyID string, rules []models.TaxRule) (*models.Properties, error) {
+	defer r.lock()()
+
+	id, _ := parseID(propertyID)
+	property, exists := r.store.properties[id]
+	if !exists {
+		return nil, fmt.Errorf("gagal menyimpan aturan pajak: not found")
+	}
+	property.TaxRules = clone(rules)
+	r.store.properties[id] = property
+
+	out := clone(property)
+	return &out, nil
+}
+
+func (r *propertyRepo) SetRatePlanCancellationRules(planID string, rules *models.CancellationPolicy) (*models.RatePlan, error) {
+	defer r.lock()()
+
+	id, _ := parseID(planID)
+	plan, exists := r.store.ratePlans[id]
+	if !exists {
+		return nil, fmt.Errorf("gagal menyimpan aturan pembatalan: not found")
+	}
+	plan.CancellationRules = clone(rules)
+	r.store.ratePlans[id] = plan
+
+	out := clone(plan)
+	return &out, nil
+}
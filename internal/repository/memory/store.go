@@ -0,0 +1,229 @@
+// Package memory menyediakan implementasi in-memory dari semua repository.
+// Cocok untuk unit test, demo lokal, dan mode server --storage=memory.
+package memory
+
+import (
+	"encoding/json"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const dateLayout = "2006-01-02"
+
+// rateKey meniru unique (room_id, room_type_id, rate_plan_id, date); uuid.Nil
+// mewakili kolom yang kosong.
+type rateKey struct {
+	roomID     uuid.UUID
+	roomTypeID uuid.UUID
+	ratePlanID uuid.UUID
+	date       string
+}
+
+func newRateKey(rate models.RoomRate) rateKey {
+	key := rateKey{date: dateKey(rate.Date)}
+	if rate.RoomID != nil {
+		key.roomID = *rate.RoomID
+	}
+	if rate.RoomTypeID != nil {
+		key.roomTypeID = *rate.RoomTypeID
+	}
+	if rate.RatePlanID != nil {
+		key.ratePlanID = *rate.RatePlanID
+	}
+	return key
+}
+
+// tables berisi semua "tabel" in-memory. Dipisah dari Store supaya bisa
+// di-snapshot dan dikembalikan saat transaksi gagal.
+type tables struct {
+	guests         map[uuid.UUID]models.Guest
+	admins         map[uuid.UUID]models.Admin
+	properties     map[uuid.UUID]models.Properties
+	roomTypes      map[uuid.UUID]models.RoomType
+	rooms          map[uuid.UUID]models.Room
+	roomRates      map[rateKey]models.RoomRate
+	ratePlans      map[uuid.UUID]models.RatePlan
+	propertyPhotos map[uuid.UUID]models.PropertyPhoto
+	roomPhotos     map[uuid.UUID]models.RoomPhoto
+	bookings       map[uuid.UUID]models.Booking
+	payments       map[uuid.UUID]models.Payment
+	invoices       map[uuid.UUID]models.Invoice
+	statusHistory  map[uuid.UUID]models.BookingStatusHistory
+	reservations   map[uuid.UUID]models.Reservation
+	occupants      map[uuid.UUID]models.BookingOccupant
+	modifications  map[uuid.UUID]models.BookingModification
+	webhookEvents  map[uuid.UUID]models.PaymentWebhookEvent
+	refunds        map[uuid.UUID]models.Refund
+	folioCharges   map[uuid.UUID]models.FolioCharge
+	paymentCharges map[uuid.UUID]models.PaymentCharge
+}
+
+func newTables() *tables {
+	return &tables{
+		guests:         make(map[uuid.UUID]models.Guest),
+		admins:         make(map[uuid.UUID]models.Admin),
+		properties:     make(map[uuid.UUID]models.Properties),
+		roomTypes:      make(map[uuid.UUID]models.RoomType),
+		rooms:          make(map[uuid.UUID]models.Room),
+		roomRates:      make(map[rateKey]models.RoomRate),
+		ratePlans:      make(map[uuid.UUID]models.RatePlan),
+		propertyPhotos: make(map[uuid.UUID]models.PropertyPhoto),
+		roomPhotos:     make(map[uuid.UUID]models.RoomPhoto),
+		bookings:       make(map[uuid.UUID]models.Booking),
+		payments:       make(map[uuid.UUID]models.Payment),
+		invoices:       make(map[uuid.UUID]models.Invoice),
+		statusHistory:  make(map[uuid.UUID]models.BookingStatusHistory),
+		reservations:   make(map[uuid.UUID]models.Reservation),
+		occupants:      make(map[uuid.UUID]models.BookingOccupant),
+		modifications:  make(map[uuid.UUID]models.BookingModification),
+		webhookEvents:  make(map[uuid.UUID]models.PaymentWebhookEvent),
+		refunds:        make(map[uuid.UUID]models.Refund),
+		folioCharges:   make(map[uuid.UUID]models.FolioCharge),
+		paymentCharges: make(map[uuid.UUID]models.PaymentCharge),
+	}
+}
+
+// snapshot menyalin isi map. Nilai di dalam map tidak pernah diubah in-place
+// (selalu diganti), jadi salinan dangkal sudah cukup.
+func (t *tables) snapshot() *tables {
+	return &tables{
+		guests:         maps.Clone(t.guests),
+		admins:         maps.Clone(t.admins),
+		properties:     maps.Clone(t.properties),
+		roomTypes:      maps.Clone(t.roomTypes),
+		rooms:          maps.Clone(t.rooms),
+		roomRates:      maps.Clone(t.roomRates),
+		ratePlans:      maps.Clone(t.ratePlans),
+		propertyPhotos: maps.Clone(t.propertyPhotos),
+		roomPhotos:     maps.Clone(t.roomPhotos),
+		bookings:       maps.Clone(t.bookings),
+		payments:       maps.Clone(t.payments),
+		invoices:       maps.Clone(t.invoices),
+		statusHistory:  maps.Clone(t.statusHistory),
+		reservations:   maps.Clone(t.reservations),
+		occupants:      maps.Clone(t.occupants),
+		modifications:  maps.Clone(t.modifications),
+		webhookEvents:  maps.Clone(t.webhookEvents),
+		refunds:        maps.Clone(t.refunds),
+		folioCharges:   maps.Clone(t.folioCharges),
+		paymentCharges: maps.Clone(t.paymentCharges),
+	}
+}
+
+// Store menyimpan seluruh tabel di memori. Satu Store bisa dipakai bersama
+// oleh beberapa repository sehingga data antar repository tetap konsisten.
+type Store struct {
+	mu sync.Mutex
+	*tables
+}
+
+func NewStore() *Store {
+	return &Store{tables: newTables()}
+}
+
+// conn adalah akses repository ke Store. Di dalam transaksi lock sudah
+// dipegang oleh UnitOfWork, jadi repository tidak mengunci ulang.
+type conn struct {
+	store *Store
+	inTx  bool
+}
+
+func (c conn) lock() func() {
+	if c.inTx {
+		return func() {}
+	}
+	c.store.mu.Lock()
+	return c.store.mu.Unlock
+}
+
+// NewRepositories membangun semua repository di atas satu Store.
+func NewRepositories(store *Store) *repository.Repositories {
+	return newRepositories(conn{store: store})
+}
+
+func newRepositories(c conn) *repository.Repositories {
+	return &repository.Repositories{
+		Guest:    &guestRepo{c},
+		Admin:    &adminRepo{c},
+		Property: &propertyRepo{c},
+		Booking:  &bookingRepo{c},
+		Payment:  &paymentRepo{c},
+	}
+}
+
+type unitOfWork struct {
+	store *Store
+}
+
+// NewUnitOfWork menjalankan fn dengan lock Store dipegang penuh; jika fn
+// mengembalikan error, semua perubahan dikembalikan ke kondisi awal.
+func NewUnitOfWork(store *Store) repository.UnitOfWork {
+	return &unitOfWork{store: store}
+}
+
+func (u *unitOfWork) Do(fn func(tx *repository.Repositories) error) (err error) {
+	u.store.mu.Lock()
+	defer u.store.mu.Unlock()
+
+	backup := u.store.tables.snapshot()
+	defer func() {
+		if p := recover(); p != nil {
+			u.store.tables = backup
+			panic(p)
+		}
+		if err != nil {
+			u.store.tables = backup
+		}
+	}()
+
+	return fn(newRepositories(conn{store: u.store, inTx: true}))
+}
+
+// clone menyalin nilai lewat JSON, sama seperti data yang bolak-balik ke
+// PostgREST, supaya caller tidak bisa mengubah isi store lewat slice/pointer.
+func clone[T any](v T) T {
+	var out T
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+func parseID(id string) (uuid.UUID, bool) {
+	parsed, err := uuid.Parse(strings.TrimSpace(id))
+	return parsed, err == nil
+}
+
+func sameID(ptr *uuid.UUID, id string) bool {
+	return ptr != nil && ptr.String() == strings.TrimSpace(id)
+}
+
+func dateKey(t time.Time) string {
+	return t.Format(dateLayout)
+}
+
+// containsFold meniru operator ilike '%needle%'.
+func containsFold(haystack, needle string) bool {
+	return strings.Contains(strings.ToLower(haystack), strings.ToLower(needle))
+}
+
+func sortByCreated[T any](items []T, created func(T) time.Time, id func(T) uuid.UUID) {
+	sort.SliceStable(items, func(i, j int) bool {
+		ci, cj := created(items[i]), created(items[j])
+		if !ci.Equal(cj) {
+			return ci.Before(cj)
+		}
+		return id(items[i]).String() < id(items[j]).String()
+	})
+}
@@ -2,32 +2,91 @@ package repository
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"hotelbooking/internal/config"
 	"hotelbooking/internal/models"
+	"math"
 	"time"
+
+	"github.com/supabase-community/postgrest-go"
+	"github.com/supabase-community/supabase-go"
 )
 
+// ErrRefundExceedsCharge dikembalikan CreateRefund ketika nominal refund
+// melebihi dana charge yang belum dikembalikan atau sedang dikembalikan.
+var ErrRefundExceedsCharge = errors.New("nominal refund melebihi dana charge yang dapat dikembalikan")
+
+// refundExceedsChargeException adalah pesan raise exception create_refund.
+const refundExceedsChargeException = "refund_exceeds_charge"
+
+// ChargeRefundable adalah dana charge reference yang masih bisa
+// dikembalikan: nominal capture dikurangi refund yang Pending atau
+// Succeeded.
+func ChargeRefundable(charges []models.PaymentCharge, refunds []models.Refund, reference string) float64 {
+	available := 0.0
+	for _, charge := range charges {
+		if charge.Reference == reference {
+			available += charge.Amount
+		}
+	}
+	for _, refund := range refunds {
+		if refund.ChargeReference == reference && refund.Status != models.RefundStatusFailed {
+			available -= refund.Amount
+		}
+	}
+	return math.Round(available*100) / 100
+}
+
 type PaymentRepo interface {
 	CreatePayment(payment models.Payment) error
-	GetPaymentByBookingID(bookingID string) (*models.Payment, error)
-	UpdatePaymentStatus(bookingID string, status models.PaymentStatus, provider, reference string) (*models.Payment, error)
+	GetPaymentByID(paymentID string) (*models.Payment, error)
+	ListPaymentsByBookingID(bookingID string) ([]models.Payment, error)
+	ListPaymentsByReservationID(reservationID string) ([]models.Payment, error)
+	ListDuePayments(before time.Time) ([]models.Payment, error)
+	SetPaymentStatus(paymentID string, status models.PaymentStatus) (*models.Payment, error)
+	UpdatePaymentAmount(paymentID string, amount float64) (*models.Payment, error)
+	MarkPaymentReminded(paymentID string, remindedAt time.Time) (*models.Payment, error)
 	CreateInvoice(invoice models.Invoice) error
 	GetInvoiceByBookingID(bookingID string) (*models.Invoice, error)
 	UpdateInvoiceStatus(bookingID string, status models.PaymentStatus) (*models.Invoice, error)
+	GetInvoiceByReservationID(reservationID string) (*models.Invoice, error)
+	UpdateReservationInvoiceStatus(reservationID string, status models.PaymentStatus) (*models.Invoice, error)
+	UpdateInvoiceTotals(bookingID string, totals models.InvoiceTotals) error
+	UpdateReservationInvoiceTotals(reservationID string, totals models.InvoiceTotals) error
+	SetPaymentCharge(paymentID, provider, reference string) (*models.Payment, error)
+	// RecordPaymentCapture menambah dana payment sebesar charge.Amount dan
+	// menyimpan charge-nya supaya tetap bisa di-refund setelah payment
+	// menunjuk charge lain.
+	RecordPaymentCapture(paymentID string, charge models.PaymentCharge) (*models.Payment, error)
+	ListPaymentCharges(paymentID string) ([]models.PaymentCharge, error)
+	CreateWebhookEvent(event models.PaymentWebhookEvent) (*models.PaymentWebhookEvent, bool, error)
+	UpdateWebhookEventStatus(eventID string, status models.WebhookEventStatus, note, paymentID string, processedAt time.Time) (*models.PaymentWebhookEvent, error)
+	RecordPaymentRefund(paymentID string, amount float64) (*models.Payment, error)
+	// CreateRefund mencatat refund atas satu charge payment. Refund yang
+	// melebihi dana charge dikurangi refund Pending dan Succeeded sebelumnya
+	// ditolak dengan ErrRefundExceedsCharge.
+	CreateRefund(refund models.Refund) error
+	CompleteRefund(refundID string, status models.RefundStatus, gatewayReference, failureReason string, completedAt time.Time) (*models.Refund, error)
+	ListRefunds(paymentID string) ([]models.Refund, error)
+	CreateFolioCharge(charge models.FolioCharge) error
+	GetFolioCharge(chargeID string) (*models.FolioCharge, error)
+	ListFolioCharges(bookingID string) ([]models.FolioCharge, error)
+	VoidFolioCharge(chargeID, voidedBy, reason string, voidedAt time.Time) (*models.FolioCharge, error)
 }
 
-type paymentRepo struct{}
+type paymentRepo struct {
+	client *supabase.Client
+}
 
-func NewPaymentRepo() PaymentRepo {
-	return &paymentRepo{}
+func NewPaymentRepo(client *supabase.Client) PaymentRepo {
+	return &paymentRepo{client: client}
 }
 
 func (r *paymentRepo) CreatePayment(payment models.Payment) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
-	_, _, err := config.SupabaseClient.
+	_, _, err := r.client.
 		From("payments").
 		Insert(payment, false, "", "", "").
 		Execute()
@@ -37,14 +96,14 @@ func (r *paymentRepo) CreatePayment(payment models.Payment) error {
 	return nil
 }
 
-func (r *paymentRepo) GetPaymentByBookingID(bookingID string) (*models.Payment, error) {
-	if config.SupabaseClient == nil {
+func (r *paymentRepo) GetPaymentByID(paymentID string) (*models.Payment, error) {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From("payments").
 		Select("*", "", false).
-		Eq("booking_id", bookingID).
+		Eq("id", paymentID).
 		Single().
 		Execute()
 	if err != nil {
@@ -57,26 +116,87 @@ func (r *paymentRepo) GetPaymentByBookingID(bookingID string) (*models.Payment,
 	return &payment, nil
 }
 
-func (r *paymentRepo) UpdatePaymentStatus(bookingID string, status models.PaymentStatus, provider, reference string) (*models.Payment, error) {
-	if config.SupabaseClient == nil {
+// ListPaymentsByBookingID mengambil semua cicilan payment booking, urut
+// waktu dibuat.
+func (r *paymentRepo) ListPaymentsByBookingID(bookingID string) ([]models.Payment, error) {
+	return r.listPayments("booking_id", bookingID)
+}
+
+// ListPaymentsByReservationID mengambil semua cicilan payment gabungan
+// milik reservasi.
+func (r *paymentRepo) ListPaymentsByReservationID(reservationID string) ([]models.Payment, error) {
+	return r.listPayments("reservation_id", reservationID)
+}
+
+func (r *paymentRepo) listPayments(column, id string) ([]models.Payment, error) {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	updateData := map[string]any{
-		"status": status,
+	resp, _, err := r.client.
+		From("payments").
+		Select("*", "", false).
+		Eq(column, id).
+		Order("created_at", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil payment: %v", err)
+	}
+	var payments []models.Payment
+	if err := json.Unmarshal(resp, &payments); err != nil {
+		return nil, fmt.Errorf("gagal decode payment: %v", err)
+	}
+	return payments, nil
+}
+
+// ListDuePayments mengambil payment Pending yang jatuh tempo paling lambat
+// before, untuk pengingat dan pembatalan otomatis.
+func (r *paymentRepo) ListDuePayments(before time.Time) ([]models.Payment, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
 	}
+	resp, _, err := r.client.
+		From("payments").
+		Select("*", "", false).
+		Eq("status", string(models.PaymentStatusPending)).
+		Lte("due_at", before.Format(time.RFC3339)).
+		Order("due_at", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil payment jatuh tempo: %v", err)
+	}
+	var payments []models.Payment
+	if err := json.Unmarshal(resp, &payments); err != nil {
+		return nil, fmt.Errorf("gagal decode payment: %v", err)
+	}
+	return payments, nil
+}
+
+func (r *paymentRepo) SetPaymentStatus(paymentID string, status models.PaymentStatus) (*models.Payment, error) {
+	updateData := map[string]any{"status": status}
 	if status == models.PaymentStatusPaid {
 		updateData["paid_at"] = time.Now()
 	}
-	if provider != "" {
-		updateData["provider"] = provider
-	}
-	if reference != "" {
-		updateData["reference"] = reference
+	return r.updatePayment(paymentID, updateData)
+}
+
+// UpdatePaymentAmount mengubah nominal satu cicilan, misalnya setelah
+// booking diubah atau tagihan dibagi ke beberapa kartu.
+func (r *paymentRepo) UpdatePaymentAmount(paymentID string, amount float64) (*models.Payment, error) {
+	return r.updatePayment(paymentID, map[string]any{"amount": amount})
+}
+
+func (r *paymentRepo) MarkPaymentReminded(paymentID string, remindedAt time.Time) (*models.Payment, error) {
+	return r.updatePayment(paymentID, map[string]any{"reminder_sent_at": remindedAt})
+}
+
+func (r *paymentRepo) updatePayment(paymentID string, updateData map[string]any) (*models.Payment, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From("payments").
 		Update(updateData, "", "").
-		Eq("booking_id", bookingID).
+		Eq("id", paymentID).
 		Single().
 		Execute()
 	if err != nil {
@@ -90,10 +210,10 @@ func (r *paymentRepo) UpdatePaymentStatus(bookingID string, status models.Paymen
 }
 
 func (r *paymentRepo) CreateInvoice(invoice models.Invoice) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
-	_, _, err := config.SupabaseClient.
+	_, _, err := r.client.
 		From("invoices").
 		Insert(invoice, false, "", "", "").
 		Execute()
@@ -104,13 +224,22 @@ func (r *paymentRepo) CreateInvoice(invoice models.Invoice) error {
 }
 
 func (r *paymentRepo) GetInvoiceByBookingID(bookingID string) (*models.Invoice, error) {
-	if config.SupabaseClient == nil {
+	return r.getInvoice("booking_id", bookingID)
+}
+
+// GetInvoiceByReservationID mengambil invoice gabungan milik reservasi.
+func (r *paymentRepo) GetInvoiceByReservationID(reservationID string) (*models.Invoice, error) {
+	return r.getInvoice("reservation_id", reservationID)
+}
+
+func (r *paymentRepo) getInvoice(column, id string) (*models.Invoice, error) {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From("invoices").
 		Select("*", "", false).
-		Eq("booking_id", bookingID).
+		Eq(column, id).
 		Single().
 		Execute()
 	if err != nil {
@@ -124,16 +253,24 @@ func (r *paymentRepo) GetInvoiceByBookingID(bookingID string) (*models.Invoice,
 }
 
 func (r *paymentRepo) UpdateInvoiceStatus(bookingID string, status models.PaymentStatus) (*models.Invoice, error) {
-	if config.SupabaseClient == nil {
+	return r.updateInvoiceStatus("booking_id", bookingID, status)
+}
+
+func (r *paymentRepo) UpdateReservationInvoiceStatus(reservationID string, status models.PaymentStatus) (*models.Invoice, error) {
+	return r.updateInvoiceStatus("reservation_id", reservationID, status)
+}
+
+func (r *paymentRepo) updateInvoiceStatus(column, id string, status models.PaymentStatus) (*models.Invoice, error) {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
 	updateData := map[string]any{
 		"status": status,
 	}
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From("invoices").
 		Update(updateData, "", "").
-		Eq("booking_id", bookingID).
+		Eq(column, id).
 		Single().
 		Execute()
 	if err != nil {
@@ -145,3 +282,327 @@ func (r *paymentRepo) UpdateInvoiceStatus(bookingID string, status models.Paymen
 	}
 	return &invoice, nil
 }
+
+// UpdateInvoiceTotals menyamakan nominal dan rincian pajak invoice dengan
+// folio booking.
+func (r *paymentRepo) UpdateInvoiceTotals(bookingID string, totals models.InvoiceTotals) error {
+	return r.updateInvoiceTotals("booking_id", bookingID, totals)
+}
+
+// UpdateReservationInvoiceTotals menyamakan invoice gabungan dengan folio
+// semua kamar reservasi, misalnya setelah satu kamar dibatalkan.
+func (r *paymentRepo) UpdateReservationInvoiceTotals(reservationID string, totals models.InvoiceTotals) error {
+	return r.updateInvoiceTotals("reservation_id", reservationID, totals)
+}
+
+func (r *paymentRepo) updateInvoiceTotals(column, id string, totals models.InvoiceTotals) error {
+	if r.client == nil {
+		return fmt.Errorf("supabase client is not initialized")
+	}
+	updateData := map[string]any{
+		"amount":     totals.Amount,
+		"net_amount": totals.NetAmount,
+		"tax_amount": totals.TaxAmount,
+		"tax_lines":  totals.TaxLines,
+	}
+	_, _, err := r.client.
+		From("invoices").
+		Update(updateData, "", "").
+		Eq(column, id).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("gagal memperbarui nominal tagihan: %v", err)
+	}
+	return nil
+}
+
+// SetPaymentCharge mencatat charge gateway yang sedang terbuka untuk
+// payment. reference kosong menandakan tidak ada charge terbuka.
+func (r *paymentRepo) SetPaymentCharge(paymentID, provider, reference string) (*models.Payment, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("payments").
+		Update(map[string]any{"provider": provider, "reference": reference}, "", "").
+		Eq("id", paymentID).
+		Single().
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui payment: %v", err)
+	}
+	var payment models.Payment
+	if err := json.Unmarshal(resp, &payment); err != nil {
+		return nil, fmt.Errorf("gagal decode payment: %v", err)
+	}
+	return &payment, nil
+}
+
+// RecordPaymentCapture menambah dana yang di-capture gateway. Payment
+// menjadi Paid begitu amount_paid dikurangi amount_refunded menutup amount.
+// Charge dan penambahan dananya dijalankan function record_payment_capture
+// supaya capture paralel tidak saling menimpa amount_paid.
+func (r *paymentRepo) RecordPaymentCapture(paymentID string, charge models.PaymentCharge) (*models.Payment, error) {
+	var payments []models.Payment
+	err := callRPC(r.client, "record_payment_capture", map[string]any{
+		"p_payment_id":  paymentID,
+		"p_charge_id":   charge.ID,
+		"p_provider":    charge.Provider,
+		"p_reference":   charge.Reference,
+		"p_amount":      charge.Amount,
+		"p_captured_at": charge.CapturedAt,
+	}, &payments)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mencatat capture payment: %v", err)
+	}
+	if len(payments) == 0 {
+		return nil, fmt.Errorf("gagal mencatat capture payment: payment tidak ditemukan")
+	}
+	return &payments[0], nil
+}
+
+func (r *paymentRepo) ListPaymentCharges(paymentID string) ([]models.PaymentCharge, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("payment_charges").
+		Select("*", "", false).
+		Eq("payment_id", paymentID).
+		Order("captured_at", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil charge payment: %v", err)
+	}
+	var charges []models.PaymentCharge
+	if err := json.Unmarshal(resp, &charges); err != nil {
+		return nil, fmt.Errorf("gagal decode charge payment: %v", err)
+	}
+	return charges, nil
+}
+
+// CreateWebhookEvent menyimpan webhook baru. Jika provider dan event_id yang
+// sama sudah tersimpan, event lama dikembalikan dengan created = false.
+func (r *paymentRepo) CreateWebhookEvent(event models.PaymentWebhookEvent) (*models.PaymentWebhookEvent, bool, error) {
+	if r.client == nil {
+		return nil, false, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("payment_webhook_events").
+		Select("*", "", false).
+		Eq("provider", event.Provider).
+		Eq("event_id", event.EventID).
+		Execute()
+	if err != nil {
+		return nil, false, fmt.Errorf("gagal mengambil webhook event: %v", err)
+	}
+	var existing []models.PaymentWebhookEvent
+	if err := json.Unmarshal(resp, &existing); err != nil {
+		return nil, false, fmt.Errorf("gagal decode webhook event: %v", err)
+	}
+	if len(existing) > 0 {
+		return &existing[0], false, nil
+	}
+
+	_, _, err = r.client.
+		From("payment_webhook_events").
+		Insert(event, false, "", "", "").
+		Execute()
+	if err != nil {
+		return nil, false, fmt.Errorf("gagal menyimpan webhook event: %v", err)
+	}
+	return &event, true, nil
+}
+
+func (r *paymentRepo) UpdateWebhookEventStatus(eventID string, status models.WebhookEventStatus, note, paymentID string, processedAt time.Time) (*models.PaymentWebhookEvent, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	updateData := map[string]any{
+		"status":       status,
+		"note":         note,
+		"processed_at": processedAt,
+	}
+	if paymentID != "" {
+		updateData["payment_id"] = paymentID
+	}
+	resp, _, err := r.client.
+		From("payment_webhook_events").
+		Update(updateData, "", "").
+		Eq("id", eventID).
+		Single().
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui webhook event: %v", err)
+	}
+	var event models.PaymentWebhookEvent
+	if err := json.Unmarshal(resp, &event); err != nil {
+		return nil, fmt.Errorf("gagal decode webhook event: %v", err)
+	}
+	return &event, nil
+}
+
+// RecordPaymentRefund menambah dana yang sudah dikembalikan. Payment menjadi
+// Refunded jika seluruh dana yang di-capture sudah kembali, selain itu
+// PartiallyRefunded. Penambahan dilakukan atomik oleh record_payment_refund
+// dan ditolak dengan ErrRefundExceedsCharge jika melebihi dana yang tersisa.
+func (r *paymentRepo) RecordPaymentRefund(paymentID string, amount float64) (*models.Payment, error) {
+	var payments []models.Payment
+	err := callRPC(r.client, "record_payment_refund", map[string]any{
+		"p_payment_id": paymentID,
+		"p_amount":     amount,
+	}, &payments)
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui payment: %v", err)
+	}
+	if len(payments) == 0 {
+		return nil, ErrRefundExceedsCharge
+	}
+	return &payments[0], nil
+}
+
+// CreateRefund mencatat refund lewat function create_refund, yang mengunci
+// payment sebelum menghitung sisa dana charge sehingga refund paralel antre.
+func (r *paymentRepo) CreateRefund(refund models.Refund) error {
+	if refund.PaymentID == nil {
+		return fmt.Errorf("gagal membuat refund: payment_id wajib diisi")
+	}
+	var created []models.Refund
+	err := callRPC(r.client, "create_refund", map[string]any{"p_refund": refund}, &created)
+	if isRPCException(err, refundExceedsChargeException) {
+		return ErrRefundExceedsCharge
+	}
+	if err != nil {
+		return fmt.Errorf("gagal membuat refund: %v", err)
+	}
+	return nil
+}
+
+// CompleteRefund mencatat hasil eksekusi refund di gateway.
+func (r *paymentRepo) CompleteRefund(refundID string, status models.RefundStatus, gatewayReference, failureReason string, completedAt time.Time) (*models.Refund, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	updateData := map[string]any{
+		"status":            status,
+		"gateway_reference": gatewayReference,
+		"failure_reason":    failureReason,
+		"completed_at":      completedAt,
+	}
+	resp, _, err := r.client.
+		From("refunds").
+		Update(updateData, "", "").
+		Eq("id", refundID).
+		Single().
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui refund: %v", err)
+	}
+	var refund models.Refund
+	if err := json.Unmarshal(resp, &refund); err != nil {
+		return nil, fmt.Errorf("gagal decode refund: %v", err)
+	}
+	return &refund, nil
+}
+
+func (r *paymentRepo) ListRefunds(paymentID string) ([]models.Refund, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("refunds").
+		Select("*", "", false).
+		Eq("payment_id", paymentID).
+		Order("created_at", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil refund: %v", err)
+	}
+	var refunds []models.Refund
+	if err := json.Unmarshal(resp, &refunds); err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+func (r *paymentRepo) CreateFolioCharge(charge models.FolioCharge) error {
+	if r.client == nil {
+		return fmt.Errorf("supabase client is not initialized")
+	}
+	_, _, err := r.client.
+		From("folio_charges").
+		Insert(charge, false, "", "", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("gagal memposting tagihan folio: %v", err)
+	}
+	return nil
+}
+
+func (r *paymentRepo) GetFolioCharge(chargeID string) (*models.FolioCharge, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("folio_charges").
+		Select("*", "", false).
+		Eq("id", chargeID).
+		Single().
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil tagihan folio: %v", err)
+	}
+	var charge models.FolioCharge
+	if err := json.Unmarshal(resp, &charge); err != nil {
+		return nil, fmt.Errorf("gagal decode tagihan folio: %v", err)
+	}
+	return &charge, nil
+}
+
+func (r *paymentRepo) ListFolioCharges(bookingID string) ([]models.FolioCharge, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("folio_charges").
+		Select("*", "", false).
+		Eq("booking_id", bookingID).
+		Order("posted_at", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil folio: %v", err)
+	}
+	var charges []models.FolioCharge
+	if err := json.Unmarshal(resp, &charges); err != nil {
+		return nil, err
+	}
+	return charges, nil
+}
+
+// VoidFolioCharge membatalkan baris folio yang masih Posted.
+func (r *paymentRepo) VoidFolioCharge(chargeID, voidedBy, reason string, voidedAt time.Time) (*models.FolioCharge, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	updateData := map[string]any{
+		"status":      models.FolioChargeStatusVoid,
+		"voided_by":   voidedBy,
+		"void_reason": reason,
+		"voided_at":   voidedAt,
+	}
+	resp, _, err := r.client.
+		From("folio_charges").
+		Update(updateData, "", "").
+		Eq("id", chargeID).
+		Eq("status", string(models.FolioChargeStatusPosted)).
+		Single().
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal void tagihan folio: %v", err)
+	}
+	var charge models.FolioCharge
+	if err := json.Unmarshal(resp, &charge); err != nil {
+		return nil, fmt.Errorf("gagal decode tagihan folio: %v", err)
+	}
+	return &charge, nil
+}
@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"hotelbooking/internal/models"
+)
+
+type adminRepo struct {
+	db querier
+}
+
+func (r *adminRepo) CreateAdmin(admin models.Admin) error {
+	_, err := r.db.Exec(context.Background(), `
+		insert into admin (id, property_id, email, role, is_active, created_at)
+		values ($1, $2, $3, $4, $5, $6)`,
+		admin.ID, admin.PropertyID, admin.Email, admin.Role, admin.IsActive, admin.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("gagal menambahkan admin ke database: %v", err)
+	}
+	return nil
+}
+
+func (r *adminRepo) GetAdminByEmail(email string) (*models.Admin, error) {
+	admin, err := collectOne[models.Admin](r.db.Query(context.Background(),
+		`select * from admin where email = $1`, email))
+	if err != nil {
+		return nil, fmt.Errorf("admin tidak ditemukan: %v", err)
+	}
+	return admin, nil
+}
+
+func (r *adminRepo) GetAdminByID(id string) (*models.Admin, error) {
+	admin, err := collectOne[models.Admin](r.db.Query(context.Background(),
+		`select * from admin where id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("admin tidak ditemukan: %v", err)
+	}
+	return admin, nil
+}
+
+func (r *adminRepo) GetAdminByProperty(propertyID string) (*models.Admin, error) {
+	admin, err := collectOne[models.Admin](r.db.Query(context.Background(),
+		`select * from admin where property_id = $1`, propertyID))
+	if err != nil {
+		return nil, fmt.Errorf("admin berdasarkan property_id tidak ditemukan: %v", err)
+	}
+	return admin, nil
+}
+
+func (r *adminRepo) GetAdminByEmailAndProperty(email, propertyID string) (*models.Admin, error) {
+	admin, err := collectOne[models.Admin](r.db.Query(context.Background(),
+		`select * from admin where email = $1 and property_id = $2`, email, propertyID))
+	if err != nil {
+		return nil, fmt.Errorf("admin tidak ditemukan untuk email dan property_id tersebut: %v", err)
+	}
+	return admin, nil
+}
+
+func (r *adminRepo) UpdateActiveStatus(adminID string, isActive bool) error {
+	_, err := r.db.Exec(context.Background(),
+		`update admin set is_active = $2 where id = $1`, adminID, isActive)
+	if err != nil {
+		return fmt.Errorf("gagal memperbarui status aktif admin: %v", err)
+	}
+	return nil
+}
+
+func (r *adminRepo) UpdateRole(adminID, role string) error {
+	_, err := r.db.Exec(context.Background(),
+		`update admin set role = $2 where id = $1`, adminID, role)
+	if err != nil {
+		return fmt.Errorf("gagal memperbarui role admin: %v", err)
+	}
+	return nil
+}
+
+func (r *adminRepo) UpdateProperty(adminID, propertyID string) error {
+	_, err := r.db.Exec(context.Background(),
+		`update admin set property_id = $2 where id = $1`, adminID, propertyID)
+	if err != nil {
+		return fmt.Errorf("gagal memperbarui property admin: %v", err)
+	}
+	return nil
+}
+
+func (r *adminRepo) ListAdmins(propertyID string) ([]models.Admin, error) {
+	admins, err := collectAll[models.Admin](r.db.Query(context.Background(), `
+		select * from admin
+		where ($1::uuid is null or property_id = $1::uuid)
+		order by created_at, id`, nullableText(propertyID)))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil daftar admin: %v", err)
+	}
+	return admins, nil
+}
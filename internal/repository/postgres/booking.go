@@ -0,0 +1,480 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// exclusionViolation adalah SQLSTATE untuk pelanggaran exclusion constraint,
+// di sini bookings_room_no_overlap.
+const exclusionViolation = "23P01"
+
+type bookingRepo struct {
+	db querier
+}
+
+func (r *bookingRepo) CreateBooking(booking models.Booking) error {
+	if booking.RoomID == nil && booking.RoomTypeID == nil {
+		return fmt.Errorf("gagal membuat booking: room_id atau room_type_id wajib diisi")
+	}
+	checkIn, checkOut := booking.CheckIn.Format(dateLayout), booking.CheckOut.Format(dateLayout)
+
+	return inTx(r.db, func(q querier) error {
+		ctx := context.Background()
+		repo := &bookingRepo{db: q}
+		// Kunci tipe kamar lalu kamar supaya booking paralel untuk inventori
+		// yang sama antre; exclusion constraint tetap menjadi pengaman terakhir
+		if booking.RoomTypeID != nil {
+			if _, err := q.Exec(ctx, `select 1 from room_types where id = $1 for update`, booking.RoomTypeID); err != nil {
+				return fmt.Errorf("gagal mengunci tipe kamar: %v", err)
+			}
+		}
+		if booking.RoomID != nil {
+			if _, err := q.Exec(ctx, `select 1 from rooms where id = $1 for update`, booking.RoomID); err != nil {
+				return fmt.Errorf("gagal mengunci kamar: %v", err)
+			}
+			available, err := repo.CheckAvailability(booking.RoomID.String(), checkIn, checkOut)
+			if err != nil {
+				return err
+			}
+			if !available {
+				return repository.ErrRoomUnavailable
+			}
+		}
+		if booking.RoomTypeID != nil {
+			remaining, err := repo.CheckRoomTypeAvailability(booking.RoomTypeID.String(), checkIn, checkOut)
+			if err != nil {
+				return err
+			}
+			if remaining <= 0 {
+				return repository.ErrRoomUnavailable
+			}
+		}
+
+		_, err := q.Exec(ctx, `
+			insert into bookings (id, guest_id, property_id, reservation_id, room_id, room_type_id, rate_plan_id, check_in, check_out,
+				nights, adults, children, child_ages, total_price, tax_amount, booking_status, refund_amount, note, hold_expires_at, created_at)
+			values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)`,
+			booking.ID, booking.GuestID, booking.PropertyID, booking.ReservationID, booking.RoomID, booking.RoomTypeID, booking.RatePlanID,
+			booking.CheckIn, booking.CheckOut, booking.Nights, booking.Adults, booking.Children, booking.ChildAges, booking.TotalPrice,
+			booking.TaxAmount, booking.Status, booking.RefundAmount, booking.Note, booking.HoldExpiresAt, booking.CreatedAt)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == exclusionViolation {
+				return repository.ErrRoomUnavailable
+			}
+			return fmt.Errorf("gagal membuat booking: %v", err)
+		}
+		return nil
+	})
+}
+
+func (r *bookingRepo) CheckAvailability(roomID string, checkIn, checkOut string) (bool, error) {
+	return r.roomAvailable(roomID, checkIn, checkOut, "")
+}
+
+// roomAvailable tidak menghitung booking excludeID, yaitu booking yang
+// sedang dipindah tanggal/kamarnya.
+func (r *bookingRepo) roomAvailable(roomID, checkIn, checkOut, excludeID string) (bool, error) {
+	start, err := parseDate(checkIn)
+	if err != nil {
+		return false, err
+	}
+	end, err := parseDate(checkOut)
+	if err != nil {
+		return false, err
+	}
+	var overlapping bool
+	err = r.db.QueryRow(context.Background(), `
+		select exists (
+			select 1 from bookings
+			where room_id = $1
+			  and booking_status not in ($2, $3)
+			  and (booking_status <> $4 or hold_expires_at is null or hold_expires_at > now())
+			  and check_in < $6
+			  and check_out > $5
+			  and ($7::uuid is null or id <> $7::uuid)
+		)`, roomID, models.BookingStatusCancel, models.BookingStatusExpired, models.BookingStatusNew, start, end,
+		nullableText(excludeID)).Scan(&overlapping)
+	if err != nil {
+		return false, fmt.Errorf("gagal mengecek ketersediaan kamar: %v", err)
+	}
+	return !overlapping, nil
+}
+
+// CheckRoomTypeAvailability menghitung kamar tipe ini yang tidak OutOfOrder
+// dikurangi booking aktif terbanyak pada satu malam stay.
+func (r *bookingRepo) CheckRoomTypeAvailability(roomTypeID string, checkIn, checkOut string) (int, error) {
+	return r.roomTypeRemaining(roomTypeID, checkIn, checkOut, "")
+}
+
+func (r *bookingRepo) roomTypeRemaining(roomTypeID, checkIn, checkOut, excludeID string) (int, error) {
+	start, err := parseDate(checkIn)
+	if err != nil {
+		return 0, err
+	}
+	end, err := parseDate(checkOut)
+	if err != nil {
+		return 0, err
+	}
+	var remaining int64
+	err = r.db.QueryRow(context.Background(), `
+		select (select count(*) from rooms where room_type_id = $1 and status <> $4)
+			- coalesce(max(taken.n), 0)
+		from generate_series($2::date, $3::date - 1, interval '1 day') as night(d)
+		cross join lateral (
+			select count(*) as n from bookings
+			where room_type_id = $1
+			  and booking_status not in ($5, $6)
+			  and (booking_status <> $7 or hold_expires_at is null or hold_expires_at > now())
+			  and check_in <= night.d::date
+			  and check_out > night.d::date
+			  and ($8::uuid is null or id <> $8::uuid)
+		) taken`,
+		roomTypeID, start, end, models.RoomStatusOutOfOrder,
+		models.BookingStatusCancel, models.BookingStatusExpired, models.BookingStatusNew, nullableText(excludeID)).Scan(&remaining)
+	if err != nil {
+		return 0, fmt.Errorf("gagal mengecek ketersediaan tipe kamar: %v", err)
+	}
+	return int(remaining), nil
+}
+
+func (r *bookingRepo) AssignRoom(bookingID, roomID string) (*models.Booking, error) {
+	var booking *models.Booking
+	err := inTx(r.db, func(q querier) error {
+		ctx := context.Background()
+		if _, err := q.Exec(ctx, `select 1 from rooms where id = $1 for update`, roomID); err != nil {
+			return fmt.Errorf("gagal mengunci kamar: %v", err)
+		}
+		var err error
+		booking, err = collectOne[models.Booking](q.Query(ctx,
+			`update bookings set room_id = $2 where id = $1 returning *`, bookingID, roomID))
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == exclusionViolation {
+				return repository.ErrRoomUnavailable
+			}
+			return fmt.Errorf("gagal meng-assign kamar: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+func (r *bookingRepo) UpdateBookingStay(booking models.Booking, previousTotal float64) (*models.Booking, error) {
+	bookingID := booking.ID.String()
+	checkIn, checkOut := booking.CheckIn.Format(dateLayout), booking.CheckOut.Format(dateLayout)
+
+	var updated *models.Booking
+	err := inTx(r.db, func(q querier) error {
+		ctx := context.Background()
+		repo := &bookingRepo{db: q}
+		// Urutan kunci sama dengan CreateBooking supaya tidak deadlock
+		if booking.RoomTypeID != nil {
+			if _, err := q.Exec(ctx, `select 1 from room_types where id = $1 for update`, booking.RoomTypeID); err != nil {
+				return fmt.Errorf("gagal mengunci tipe kamar: %v", err)
+			}
+		}
+		if booking.RoomID != nil {
+			if _, err := q.Exec(ctx, `select 1 from rooms where id = $1 for update`, booking.RoomID); err != nil {
+				return fmt.Errorf("gagal mengunci kamar: %v", err)
+			}
+			available, err := repo.roomAvailable(booking.RoomID.String(), checkIn, checkOut, bookingID)
+			if err != nil {
+				return err
+			}
+			if !available {
+				return repository.ErrRoomUnavailable
+			}
+		}
+		if booking.RoomTypeID != nil {
+			remaining, err := repo.roomTypeRemaining(booking.RoomTypeID.String(), checkIn, checkOut, bookingID)
+			if err != nil {
+				return err
+			}
+			if remaining <= 0 {
+				return repository.ErrRoomUnavailable
+			}
+		}
+
+		var err error
+		updated, err = collectOne[models.Booking](q.Query(ctx, `
+			update bookings
+			set room_id = $2, room_type_id = $3, rate_plan_id = $4, check_in = $5, check_out = $6,
+				nights = $7, adults = $8, children = $9, child_ages = $10, total_price = $11, tax_amount = $12
+			where id = $1 and booking_status in ($13, $14) and total_price = $15::numeric
+			returning *`,
+			bookingID, booking.RoomID, booking.RoomTypeID, booking.RatePlanID, booking.CheckIn, booking.CheckOut,
+			booking.Nights, booking.Adults, booking.Children, booking.ChildAges, booking.TotalPrice, booking.TaxAmount,
+			models.BookingStatusNew, models.BookingStatusConfirmed, previousTotal))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.ErrBookingStatusConflict
+		}
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == exclusionViolation {
+				return repository.ErrRoomUnavailable
+			}
+			return fmt.Errorf("gagal mengubah booking: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (r *bookingRepo) GetBookingsByGuestID(guestID string) ([]models.Booking, error) {
+	bookings, err := collectAll[models.Booking](r.db.Query(context.Background(),
+		`select * from bookings where guest_id = $1 order by created_at desc`, guestID))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil history booking: %v", err)
+	}
+	return bookings, nil
+}
+
+func (r *bookingRepo) GetBookingByID(bookingID string) (*models.Booking, error) {
+	booking, err := collectOne[models.Booking](r.db.Query(context.Background(),
+		`select * from bookings where id = $1`, bookingID))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil booking: %v", err)
+	}
+	return booking, nil
+}
+
+func (r *bookingRepo) ListBookings(propertyID, status, startDate, endDate string) ([]models.Booking, error) {
+	start, err := nullableDate(startDate)
+	if err != nil {
+		return nil, err
+	}
+	end, err := nullableDate(endDate)
+	if err != nil {
+		return nil, err
+	}
+	bookings, err := collectAll[models.Booking](r.db.Query(context.Background(), `
+		select * from bookings
+		where ($1::uuid is null or property_id = $1::uuid)
+		  and ($2::text is null or booking_status = $2::text)
+		  and ($3::date is null or check_in >= $3::date)
+		  and ($4::date is null or check_out <= $4::date)
+		order by created_at, id`,
+		nullableText(propertyID), nullableText(status), start, end))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil daftar booking: %v", err)
+	}
+	return bookings, nil
+}
+
+func (r *bookingRepo) ListActiveBookings(propertyID, startDate, endDate string) ([]models.Booking, error) {
+	start, err := parseDate(startDate)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseDate(endDate)
+	if err != nil {
+		return nil, err
+	}
+	bookings, err := collectAll[models.Booking](r.db.Query(context.Background(), `
+		select * from bookings
+		where property_id = $1
+		  and booking_status not in ($2, $3)
+		  and (booking_status <> $4 or hold_expires_at is null or hold_expires_at > now())
+		  and check_in < $6
+		  and check_out > $5
+		order by created_at, id`,
+		propertyID, models.BookingStatusCancel, models.BookingStatusExpired, models.BookingStatusNew, start, end))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil booking aktif: %v", err)
+	}
+	return bookings, nil
+}
+
+func (r *bookingRepo) CountBookingsByProperty(since time.Time) (map[string]int, error) {
+	rows, err := r.db.Query(context.Background(), `
+		select property_id::text, count(*) from bookings
+		where property_id is not null and created_at >= $1
+		  and booking_status not in ($2, $3)
+		group by property_id`,
+		since, models.BookingStatusCancel, models.BookingStatusExpired)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menghitung booking: %v", err)
+	}
+	counts := make(map[string]int)
+	var (
+		propertyID string
+		count      int
+	)
+	_, err = pgx.ForEachRow(rows, []any{&propertyID, &count}, func() error {
+		counts[propertyID] = count
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gagal menghitung booking: %v", err)
+	}
+	return counts, nil
+}
+
+func (r *bookingRepo) UpdateBookingStatus(bookingID string, from, status models.BookingStatus, note string, refundAmount float64) (*models.Booking, error) {
+	setRefund := refundAmount != 0 || status == models.BookingStatusCancel
+	booking, err := collectOne[models.Booking](r.db.Query(context.Background(), `
+		update bookings
+		set booking_status = $2,
+			note = coalesce($3::text, note),
+			refund_amount = case when $5 then $4 else refund_amount end
+		where id = $1 and booking_status = $6
+		returning *`,
+		bookingID, status, nullableText(note), refundAmount, setRefund, from))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrBookingStatusConflict
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui status booking: %v", err)
+	}
+	return booking, nil
+}
+
+func (r *bookingRepo) ExpireHolds(now time.Time) ([]models.Booking, error) {
+	bookings, err := collectAll[models.Booking](r.db.Query(context.Background(), `
+		update bookings set booking_status = $1
+		where booking_status = $2 and hold_expires_at <= $3
+		returning *`,
+		models.BookingStatusExpired, models.BookingStatusNew, now))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengakhiri hold booking: %v", err)
+	}
+	return bookings, nil
+}
+
+func (r *bookingRepo) CreateStatusHistory(entry models.BookingStatusHistory) error {
+	_, err := r.db.Exec(context.Background(), `
+		insert into booking_status_history (id, booking_id, from_status, to_status, actor, note, created_at)
+		values ($1, $2, $3, $4, $5, $6, $7)`,
+		entry.ID, entry.BookingID, entry.FromStatus, entry.ToStatus, entry.Actor, entry.Note, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("gagal mencatat riwayat status booking: %v", err)
+	}
+	return nil
+}
+
+func (r *bookingRepo) ListStatusHistory(bookingID string) ([]models.BookingStatusHistory, error) {
+	history, err := collectAll[models.BookingStatusHistory](r.db.Query(context.Background(),
+		`select * from booking_status_history where booking_id = $1 order by created_at, id`, bookingID))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil riwayat status booking: %v", err)
+	}
+	return history, nil
+}
+
+func (r *bookingRepo) CreateReservation(reservation models.Reservation) error {
+	_, err := r.db.Exec(context.Background(), `
+		insert into reservations (id, confirmation_code, guest_id, property_id, total_price, created_at)
+		values ($1, $2, $3, $4, $5, $6)`,
+		reservation.ID, reservation.ConfirmationCode, reservation.GuestID, reservation.PropertyID,
+		reservation.TotalPrice, reservation.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("gagal membuat reservasi: %v", err)
+	}
+	return nil
+}
+
+func (r *bookingRepo) GetReservationByID(reservationID string) (*models.Reservation, error) {
+	reservation, err := collectOne[models.Reservation](r.db.Query(context.Background(),
+		`select * from reservations where id = $1`, reservationID))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil reservasi: %v", err)
+	}
+	return reservation, nil
+}
+
+func (r *bookingRepo) GetReservationByCode(code string) (*models.Reservation, error) {
+	reservation, err := collectOne[models.Reservation](r.db.Query(context.Background(),
+		`select * from reservations where confirmation_code = $1`, code))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil reservasi: %v", err)
+	}
+	return reservation, nil
+}
+
+func (r *bookingRepo) ListReservationBookings(reservationID string) ([]models.Booking, error) {
+	bookings, err := collectAll[models.Booking](r.db.Query(context.Background(),
+		`select * from bookings where reservation_id = $1 order by created_at, id`, reservationID))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil kamar reservasi: %v", err)
+	}
+	return bookings, nil
+}
+
+func (r *bookingRepo) UpdateReservationTotal(reservationID string, totalPrice float64) (*models.Reservation, error) {
+	reservation, err := collectOne[models.Reservation](r.db.Query(context.Background(),
+		`update reservations set total_price = $2 where id = $1 returning *`, reservationID, totalPrice))
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui reservasi: %v", err)
+	}
+	return reservation, nil
+}
+
+func (r *bookingRepo) ReplaceOccupants(bookingID string, occupants []models.BookingOccupant) error {
+	return inTx(r.db, func(q querier) error {
+		ctx := context.Background()
+		if _, err := q.Exec(ctx, `delete from booking_occupants where booking_id = $1`, bookingID); err != nil {
+			return fmt.Errorf("gagal menyimpan tamu booking: %v", err)
+		}
+		for _, occupant := range occupants {
+			_, err := q.Exec(ctx, `
+				insert into booking_occupants (id, booking_id, guest_id, first_name, last_name, nationality,
+					document_type, document_number, age, created_at)
+				values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+				occupant.ID, bookingID, occupant.GuestID, occupant.FirstName, occupant.LastName, occupant.Nationality,
+				occupant.DocumentType, occupant.DocumentNumber, occupant.Age, occupant.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("gagal menyimpan tamu booking: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *bookingRepo) ListOccupants(bookingIDs []string) ([]models.BookingOccupant, error) {
+	occupants, err := collectAll[models.BookingOccupant](r.db.Query(context.Background(),
+		`select * from booking_occupants where booking_id = any($1::uuid[]) order by booking_id, created_at, id`, bookingIDs))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil tamu booking: %v", err)
+	}
+	return occupants, nil
+}
+
+func (r *bookingRepo) CreateModification(modification models.BookingModification) error {
+	_, err := r.db.Exec(context.Background(), `
+		insert into booking_modifications (id, booking_id, actor, previous_check_in, previous_check_out,
+			previous_room_type_id, previous_room_id, previous_rate_plan_id, previous_adults, previous_children,
+			previous_total, new_total, amount_due, refund_amount, created_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+		modification.ID, modification.BookingID, modification.Actor, modification.PreviousCheckIn, modification.PreviousCheckOut,
+		modification.PreviousRoomTypeID, modification.PreviousRoomID, modification.PreviousRatePlanID, modification.PreviousAdults,
+		modification.PreviousChildren, modification.PreviousTotal, modification.NewTotal, modification.AmountDue,
+		modification.RefundAmount, modification.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("gagal mencatat perubahan booking: %v", err)
+	}
+	return nil
+}
+
+func (r *bookingRepo) ListModifications(bookingID string) ([]models.BookingModification, error) {
+	modifications, err := collectAll[models.BookingModification](r.db.Query(context.Background(),
+		`select * from booking_modifications where booking_id = $1 order by created_at, id`, bookingID))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil riwayat perubahan booking: %v", err)
+	}
+	return modifications, nil
+}
@@ -0,0 +1,161 @@
+// Package postgres mengimplementasikan semua repository langsung ke
+// PostgreSQL memakai pgx, termasuk transaksi lewat UnitOfWork.
+package postgres
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"hotelbooking/internal/repository"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const dateLayout = "2006-01-02"
+
+//go:embed schema.sql
+var schemaSQL string
+
+// querier dipenuhi oleh *pgxpool.Pool maupun pgx.Tx, sehingga repository
+// yang sama bisa berjalan di luar maupun di dalam transaksi.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Migrate membuat tabel yang belum ada. Aman dijalankan berulang kali.
+func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	if _, err := pool.Exec(ctx, schemaSQL); err != nil {
+		return fmt.Errorf("gagal menjalankan migrasi skema: %v", err)
+	}
+	return nil
+}
+
+// NewRepositories membangun semua repository di atas connection pool.
+func NewRepositories(pool *pgxpool.Pool) *repository.Repositories {
+	return newRepositories(pool)
+}
+
+func newRepositories(db querier) *repository.Repositories {
+	return &repository.Repositories{
+		Guest:    &guestRepo{db: db},
+		Admin:    &adminRepo{db: db},
+		Property: &propertyRepo{db: db},
+		Booking:  &bookingRepo{db: db},
+		Payment:  &paymentRepo{db: db},
+	}
+}
+
+type unitOfWork struct {
+	pool *pgxpool.Pool
+}
+
+// NewUnitOfWork menjalankan fn di dalam satu transaksi database.
+func NewUnitOfWork(pool *pgxpool.Pool) repository.UnitOfWork {
+	return &unitOfWork{pool: pool}
+}
+
+func (u *unitOfWork) Do(fn func(tx *repository.Repositories) error) (err error) {
+	ctx := context.Background()
+	tx, err := u.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaksi: %v", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(ctx)
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if err = fn(newRepositories(tx)); err != nil {
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("gagal commit transaksi: %v", err)
+	}
+	return nil
+}
+
+// inTx menjalankan fn dalam transaksi baru, atau savepoint jika db sudah
+// merupakan transaksi milik UnitOfWork.
+func inTx(db querier, fn func(q querier) error) (err error) {
+	ctx := context.Background()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaksi: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func collectAll[T any](rows pgx.Rows, err error) ([]T, error) {
+	if err != nil {
+		return nil, err
+	}
+	items, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[T])
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []T{}
+	}
+	return items, nil
+}
+
+func collectOne[T any](rows pgx.Rows, err error) (*T, error) {
+	if err != nil {
+		return nil, err
+	}
+	item, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByNameLax[T])
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// parseDate mengubah parameter "YYYY-MM-DD" menjadi time.Time agar bisa
+// dikirim sebagai tipe date.
+func parseDate(value string) (time.Time, error) {
+	parsed, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("format tanggal tidak valid: %s", value)
+	}
+	return parsed, nil
+}
+
+// nullableDate mengembalikan nil untuk string kosong, sehingga filter
+// opsional bisa ditulis sebagai ($1::date is null or kolom >= $1).
+func nullableDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := parseDate(value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func nullableText(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
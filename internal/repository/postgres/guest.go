@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"hotelbooking/internal/models"
+)
+
+type guestRepo struct {
+	db querier
+}
+
+func (r *guestRepo) CreateProfile(profile models.Guest) error {
+	_, err := r.db.Exec(context.Background(), `
+		insert into guests (id, first_name, last_name, email, phone, guest_type, gender,
+			vip_status, address, city, postal_code, state, country, nationality)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+		profile.ID, profile.FirstName, profile.LastName, profile.Email, profile.Phone,
+		profile.GuestType, profile.Gender, profile.VIPStatus, profile.Address, profile.City,
+		profile.PostalCode, profile.State, profile.Country, profile.Nationality)
+	if err != nil {
+		return fmt.Errorf("gagal menyisipkan profil tamu ke db: %v", err)
+	}
+	return nil
+}
+
+func (r *guestRepo) GetGuestByID(id string) (*models.Guest, error) {
+	guest, err := collectOne[models.Guest](r.db.Query(context.Background(),
+		`select * from guests where id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil profil tamu: %v", err)
+	}
+	return guest, nil
+}
@@ -0,0 +1,393 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type paymentRepo struct {
+	db querier
+}
+
+func (r *paymentRepo) CreatePayment(payment models.Payment) error {
+	_, err := r.db.Exec(context.Background(), `
+		insert into payments
+			(id, booking_id, reservation_id, kind, amount, amount_paid, amount_refunded, status,
+			 provider, reference, due_at, reminder_sent_at, paid_at, created_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+		payment.ID, payment.BookingID, payment.ReservationID, payment.Kind, payment.Amount, payment.AmountPaid,
+		payment.AmountRefunded, payment.Status, payment.Provider, payment.Reference, payment.DueAt,
+		payment.ReminderSentAt, payment.PaidAt, payment.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("gagal membuat payment: %v", err)
+	}
+	return nil
+}
+
+func (r *paymentRepo) GetPaymentByID(paymentID string) (*models.Payment, error) {
+	payment, err := collectOne[models.Payment](r.db.Query(context.Background(),
+		`select * from payments where id = $1`, paymentID))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil payment: %v", err)
+	}
+	return payment, nil
+}
+
+func (r *paymentRepo) ListPaymentsByBookingID(bookingID string) ([]models.Payment, error) {
+	return r.listPayments("booking_id", bookingID)
+}
+
+func (r *paymentRepo) ListPaymentsByReservationID(reservationID string) ([]models.Payment, error) {
+	return r.listPayments("reservation_id", reservationID)
+}
+
+// listPayments dan helper sejenis menerima nama kolom pemilik (booking_id
+// atau reservation_id); nilainya selalu konstanta dari kode, bukan input user.
+func (r *paymentRepo) listPayments(column, id string) ([]models.Payment, error) {
+	payments, err := collectAll[models.Payment](r.db.Query(context.Background(),
+		`select * from payments where `+column+` = $1 order by created_at`, id))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil payment: %v", err)
+	}
+	return payments, nil
+}
+
+func (r *paymentRepo) ListDuePayments(before time.Time) ([]models.Payment, error) {
+	payments, err := collectAll[models.Payment](r.db.Query(context.Background(), `
+		select * from payments
+		where status = $1 and due_at <= $2
+		order by due_at`,
+		models.PaymentStatusPending, before))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil payment jatuh tempo: %v", err)
+	}
+	return payments, nil
+}
+
+func (r *paymentRepo) SetPaymentStatus(paymentID string, status models.PaymentStatus) (*models.Payment, error) {
+	var paidAt *time.Time
+	if status == models.PaymentStatusPaid {
+		now := time.Now()
+		paidAt = &now
+	}
+	payment, err := collectOne[models.Payment](r.db.Query(context.Background(), `
+		update payments set status = $2, paid_at = coalesce($3, paid_at)
+		where id = $1
+		returning *`,
+		paymentID, status, paidAt))
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui payment: %v", err)
+	}
+	return payment, nil
+}
+
+func (r *paymentRepo) UpdatePaymentAmount(paymentID string, amount float64) (*models.Payment, error) {
+	payment, err := collectOne[models.Payment](r.db.Query(context.Background(),
+		`update payments set amount = $2 where id = $1 returning *`, paymentID, amount))
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui payment: %v", err)
+	}
+	return payment, nil
+}
+
+func (r *paymentRepo) MarkPaymentReminded(paymentID string, remindedAt time.Time) (*models.Payment, error) {
+	payment, err := collectOne[models.Payment](r.db.Query(context.Background(),
+		`update payments set reminder_sent_at = $2 where id = $1 returning *`, paymentID, remindedAt))
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui payment: %v", err)
+	}
+	return payment, nil
+}
+
+func (r *paymentRepo) CreateInvoice(invoice models.Invoice) error {
+	_, err := r.db.Exec(context.Background(), `
+		insert into invoices (id, booking_id, reservation_id, invoice_number, amount, net_amount, tax_amount, tax_lines, status, issued_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		invoice.ID, invoice.BookingID, invoice.ReservationID, invoice.InvoiceNumber, invoice.Amount, invoice.NetAmount,
+		invoice.TaxAmount, invoice.TaxLines, invoice.Status, invoice.IssuedAt)
+	if err != nil {
+		return fmt.Errorf("gagal membuat invoice: %v", err)
+	}
+	return nil
+}
+
+func (r *paymentRepo) GetInvoiceByBookingID(bookingID string) (*models.Invoice, error) {
+	return r.getInvoice("booking_id", bookingID)
+}
+
+func (r *paymentRepo) GetInvoiceByReservationID(reservationID string) (*models.Invoice, error) {
+	return r.getInvoice("reservation_id", reservationID)
+}
+
+func (r *paymentRepo) getInvoice(column, id string) (*models.Invoice, error) {
+	invoice, err := collectOne[models.Invoice](r.db.Query(context.Background(),
+		`select * from invoices where `+column+` = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil invoice: %v", err)
+	}
+	return invoice, nil
+}
+
+func (r *paymentRepo) UpdateInvoiceStatus(bookingID string, status models.PaymentStatus) (*models.Invoice, error) {
+	return r.updateInvoiceStatus("booking_id", bookingID, status)
+}
+
+func (r *paymentRepo) UpdateReservationInvoiceStatus(reservationID string, status models.PaymentStatus) (*models.Invoice, error) {
+	return r.updateInvoiceStatus("reservation_id", reservationID, status)
+}
+
+func (r *paymentRepo) updateInvoiceStatus(column, id string, status models.PaymentStatus) (*models.Invoice, error) {
+	invoice, err := collectOne[models.Invoice](r.db.Query(context.Background(),
+		`update invoices set status = $2 where `+column+` = $1 returning *`, id, status))
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui invoice: %v", err)
+	}
+	return invoice, nil
+}
+
+func (r *paymentRepo) UpdateInvoiceTotals(bookingID string, totals models.InvoiceTotals) error {
+	return r.updateInvoiceTotals("booking_id", bookingID, totals)
+}
+
+func (r *paymentRepo) UpdateReservationInvoiceTotals(reservationID string, totals models.InvoiceTotals) error {
+	return r.updateInvoiceTotals("reservation_id", reservationID, totals)
+}
+
+func (r *paymentRepo) updateInvoiceTotals(column, id string, totals models.InvoiceTotals) error {
+	_, err := r.db.Exec(context.Background(),
+		`update invoices set amount = $2, net_amount = $3, tax_amount = $4, tax_lines = $5 where `+column+` = $1`,
+		id, totals.Amount, totals.NetAmount, totals.TaxAmount, totals.TaxLines)
+	if err != nil {
+		return fmt.Errorf("gagal memperbarui nominal tagihan: %v", err)
+	}
+	return nil
+}
+
+func (r *paymentRepo) SetPaymentCharge(paymentID, provider, reference string) (*models.Payment, error) {
+	payment, err := collectOne[models.Payment](r.db.Query(context.Background(), `
+		update payments set provider = $2, reference = $3
+		where id = $1
+		returning *`,
+		paymentID, provider, reference))
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui payment: %v", err)
+	}
+	return payment, nil
+}
+
+func (r *paymentRepo) RecordPaymentCapture(paymentID string, charge models.PaymentCharge) (*models.Payment, error) {
+	var payment *models.Payment
+	err := inTx(r.db, func(q querier) error {
+		ctx := context.Background()
+		// unique (payment_id, reference) menolak charge yang sama dicatat dua kali
+		if _, err := q.Exec(ctx, `
+			insert into payment_charges (id, payment_id, provider, reference, amount, captured_at)
+			values ($1, $2, $3, $4, $5, $6)`,
+			charge.ID, paymentID, charge.Provider, charge.Reference, charge.Amount, charge.CapturedAt); err != nil {
+			return fmt.Errorf("gagal mencatat charge payment: %v", err)
+		}
+		var err error
+		payment, err = collectOne[models.Payment](q.Query(ctx, `
+			update payments
+			set amount_paid = amount_paid + $2,
+				status = case when amount_paid + $2 - amount_refunded >= amount then $3 else status end,
+				paid_at = case when amount_paid + $2 - amount_refunded >= amount then $4 else paid_at end
+			where id = $1
+			returning *`,
+			paymentID, charge.Amount, models.PaymentStatusPaid, charge.CapturedAt))
+		if err != nil {
+			return fmt.Errorf("gagal memperbarui payment: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (r *paymentRepo) ListPaymentCharges(paymentID string) ([]models.PaymentCharge, error) {
+	charges, err := collectAll[models.PaymentCharge](r.db.Query(context.Background(),
+		`select * from payment_charges where payment_id = $1 order by captured_at`, paymentID))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil charge payment: %v", err)
+	}
+	return charges, nil
+}
+
+func (r *paymentRepo) CreateWebhookEvent(event models.PaymentWebhookEvent) (*models.PaymentWebhookEvent, bool, error) {
+	ctx := context.Background()
+	created, err := collectAll[models.PaymentWebhookEvent](r.db.Query(ctx, `
+		insert into payment_webhook_events
+			(id, provider, event_id, event_type, charge_reference, payment_id, payload, status, note, received_at, processed_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		on conflict (provider, event_id) do nothing
+		returning *`,
+		event.ID, event.Provider, event.EventID, event.EventType, event.ChargeReference, event.PaymentID,
+		event.Payload, event.Status, event.Note, event.ReceivedAt, event.ProcessedAt))
+	if err != nil {
+		return nil, false, fmt.Errorf("gagal menyimpan webhook event: %v", err)
+	}
+	if len(created) == 1 {
+		return &created[0], true, nil
+	}
+	existing, err := collectOne[models.PaymentWebhookEvent](r.db.Query(ctx,
+		`select * from payment_webhook_events where provider = $1 and event_id = $2`, event.Provider, event.EventID))
+	if err != nil {
+		return nil, false, fmt.Errorf("gagal mengambil webhook event: %v", err)
+	}
+	return existing, false, nil
+}
+
+func (r *paymentRepo) UpdateWebhookEventStatus(eventID string, status models.WebhookEventStatus, note, paymentID string, processedAt time.Time) (*models.PaymentWebhookEvent, error) {
+	event, err := collectOne[models.PaymentWebhookEvent](r.db.Query(context.Background(), `
+		update payment_webhook_events
+		set status = $2,
+			note = $3,
+			payment_id = coalesce($4::uuid, payment_id),
+			processed_at = $5
+		where id = $1
+		returning *`,
+		eventID, status, note, nullableText(paymentID), processedAt))
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui webhook event: %v", err)
+	}
+	return event, nil
+}
+
+// RecordPaymentRefund menambah amount_refunded secara atomik; refund yang
+// melebihi dana tersisa tidak mengubah baris dan ditolak dengan
+// ErrRefundExceedsCharge.
+func (r *paymentRepo) RecordPaymentRefund(paymentID string, amount float64) (*models.Payment, error) {
+	payment, err := collectOne[models.Payment](r.db.Query(context.Background(), `
+		update payments
+		set amount_refunded = amount_refunded + $2::numeric,
+			status = case when amount_refunded + $2::numeric >= amount_paid then $3 else $4 end
+		where id = $1 and amount_paid - amount_refunded >= $2::numeric
+		returning *`,
+		paymentID, amount, models.PaymentStatusRefunded, models.PaymentStatusPartiallyRefunded))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrRefundExceedsCharge
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui payment: %v", err)
+	}
+	return payment, nil
+}
+
+func (r *paymentRepo) CreateRefund(refund models.Refund) error {
+	if refund.PaymentID == nil {
+		return fmt.Errorf("gagal membuat refund: payment_id wajib diisi")
+	}
+	return inTx(r.db, func(q querier) error {
+		ctx := context.Background()
+		// Kunci payment supaya refund paralel atas charge yang sama antre dan
+		// masing-masing melihat refund Pending yang lain
+		if _, err := q.Exec(ctx, `select 1 from payments where id = $1 for update`, refund.PaymentID); err != nil {
+			return fmt.Errorf("gagal mengunci payment: %v", err)
+		}
+		var available float64
+		err := q.QueryRow(ctx, `
+			select coalesce((select sum(amount) from payment_charges where payment_id = $1 and reference = $2), 0)
+				- coalesce((select sum(amount) from refunds where payment_id = $1 and charge_reference = $2 and status <> $3), 0)`,
+			refund.PaymentID, refund.ChargeReference, models.RefundStatusFailed).Scan(&available)
+		if err != nil {
+			return fmt.Errorf("gagal menghitung dana refund: %v", err)
+		}
+		if refund.Amount > math.Round(available*100)/100 {
+			return repository.ErrRefundExceedsCharge
+		}
+		_, err = q.Exec(ctx, `
+			insert into refunds
+				(id, payment_id, booking_id, amount, reason, status, provider, charge_reference,
+				 gateway_reference, failure_reason, initiated_by, created_at, completed_at)
+			values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+			refund.ID, refund.PaymentID, refund.BookingID, refund.Amount, refund.Reason, refund.Status, refund.Provider,
+			refund.ChargeReference, refund.GatewayReference, refund.FailureReason, refund.InitiatedBy, refund.CreatedAt, refund.CompletedAt)
+		if err != nil {
+			return fmt.Errorf("gagal membuat refund: %v", err)
+		}
+		return nil
+	})
+}
+
+func (r *paymentRepo) CompleteRefund(refundID string, status models.RefundStatus, gatewayReference, failureReason string, completedAt time.Time) (*models.Refund, error) {
+	refund, err := collectOne[models.Refund](r.db.Query(context.Background(), `
+		update refunds
+		set status = $2,
+			gateway_reference = $3,
+			failure_reason = $4,
+			completed_at = $5
+		where id = $1
+		returning *`,
+		refundID, status, gatewayReference, failureReason, completedAt))
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui refund: %v", err)
+	}
+	return refund, nil
+}
+
+func (r *paymentRepo) ListRefunds(paymentID string) ([]models.Refund, error) {
+	refunds, err := collectAll[models.Refund](r.db.Query(context.Background(),
+		`select * from refunds where payment_id = $1 order by created_at`, paymentID))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil refund: %v", err)
+	}
+	return refunds, nil
+}
+
+func (r *paymentRepo) CreateFolioCharge(charge models.FolioCharge) error {
+	_, err := r.db.Exec(context.Background(), `
+		insert into folio_charges
+			(id, booking_id, kind, charge_code, description, quantity, unit_price, tax_amount, tax_lines, amount,
+			 adjusts_id, status, posted_by, posted_at, voided_by, void_reason, voided_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`,
+		charge.ID, charge.BookingID, charge.Kind, charge.ChargeCode, charge.Description, charge.Quantity,
+		charge.UnitPrice, charge.TaxAmount, charge.TaxLines, charge.Amount, charge.AdjustsID, charge.Status, charge.PostedBy,
+		charge.PostedAt, charge.VoidedBy, charge.VoidReason, charge.VoidedAt)
+	if err != nil {
+		return fmt.Errorf("gagal memposting tagihan folio: %v", err)
+	}
+	return nil
+}
+
+func (r *paymentRepo) GetFolioCharge(chargeID string) (*models.FolioCharge, error) {
+	charge, err := collectOne[models.FolioCharge](r.db.Query(context.Background(),
+		`select * from folio_charges where id = $1`, chargeID))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil tagihan folio: %v", err)
+	}
+	return charge, nil
+}
+
+func (r *paymentRepo) ListFolioCharges(bookingID string) ([]models.FolioCharge, error) {
+	charges, err := collectAll[models.FolioCharge](r.db.Query(context.Background(),
+		`select * from folio_charges where booking_id = $1 order by posted_at, id`, bookingID))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil folio: %v", err)
+	}
+	return charges, nil
+}
+
+func (r *paymentRepo) VoidFolioCharge(chargeID, voidedBy, reason string, voidedAt time.Time) (*models.FolioCharge, error) {
+	charge, err := collectOne[models.FolioCharge](r.db.Query(context.Background(), `
+		update folio_charges
+		set status = $2,
+			voided_by = $3,
+			void_reason = $4,
+			voided_at = $5
+		where id = $1 and status = $6
+		returning *`,
+		chargeID, models.FolioChargeStatusVoid, voidedBy, reason, voidedAt, models.FolioChargeStatusPosted))
+	if err != nil {
+		return nil, fmt.Errorf("gagal void tagihan folio: %v", err)
+	}
+	return charge, nil
+}
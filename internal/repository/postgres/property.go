@@ -0,0 +1,432 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type propertyRepo struct {
+	db querier
+}
+
+func (r *propertyRepo) GetPropertyByAuth(hotelCode, authCode string) (*models.Properties, error) {
+	property, err := collectOne[models.Properties](r.db.Query(context.Background(),
+		`select * from properties where hotel_code = $1 and auth_code = $2`, hotelCode, authCode))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil property (periksa hotel/auth code): %w", err)
+	}
+	return property, nil
+}
+
+func (r *propertyRepo) CreateProperty(property models.Properties) error {
+	_, err := r.db.Exec(context.Background(), `
+		insert into properties (id, hotel_code, auth_code, name, city, address, facilities,
+			checkin_time, checkout_time, cancellation_policy, created_at, rating, latitude, longitude)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+		property.ID, property.HotelCode, property.AuthCode, property.Name, property.City,
+		property.Address, property.Facilities, property.CheckInTime, property.CheckOutTime,
+		property.CancellationPolicy, property.CreatedAt, property.Rating, property.Latitude, property.Longitude)
+	if err != nil {
+		return fmt.Errorf("gagal memebuat property hotel: %v", err)
+	}
+	return nil
+}
+
+func (r *propertyRepo) UpdateProperty(property models.Properties) (*models.Properties, error) {
+	updated, err := collectOne[models.Properties](r.db.Query(context.Background(), `
+		update properties
+		set name = $2, address = $3, city = $4, facilities = $5,
+			checkin_time = $6, checkout_time = $7, cancellation_policy = $8, rating = $9,
+			latitude = $10, longitude = $11, modification_cutoff_hours = $12
+		where id = $1
+		returning *`,
+		property.ID, property.Name, property.Address, property.City, property.Facilities,
+		property.CheckInTime, property.CheckOutTime, property.CancellationPolicy, property.Rating,
+		property.Latitude, property.Longitude, property.ModificationCutoffHours))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengubah property: %v", err)
+	}
+	return updated, nil
+}
+
+func (r *propertyRepo) DeleteProperty(id string) error {
+	if _, err := r.db.Exec(context.Background(), `delete from properties where id = $1`, id); err != nil {
+		return fmt.Errorf("gagal menghapus property: %v", err)
+	}
+	return nil
+}
+
+func (r *propertyRepo) ListProperties(city string) ([]models.Properties, error) {
+	props, err := r.queryProperties(strings.TrimSpace(city))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil daftar property: %v", err)
+	}
+	return props, nil
+}
+
+func (r *propertyRepo) queryProperties(city string) ([]models.Properties, error) {
+	return collectAll[models.Properties](r.db.Query(context.Background(), `
+		select * from properties
+		where ($1::text is null or city ilike '%' || $1::text || '%')
+		order by created_at, id`, nullableText(city)))
+}
+
+func (r *propertyRepo) CreateRoomType(roomType models.RoomType) error {
+	_, err := r.db.Exec(context.Background(), `
+		insert into room_types (id, property_id, name, description, base_price, capacity, facilities, created_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		roomType.ID, roomType.PropertyID, roomType.Name, roomType.Description,
+		roomType.BasePrice, roomType.Capacity, roomType.Facilities, roomType.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("gagal membuat tipe kamar: %v", err)
+	}
+	return nil
+}
+
+func (r *propertyRepo) UpdateRoomType(roomType models.RoomType) (*models.RoomType, error) {
+	updated, err := collectOne[models.RoomType](r.db.Query(context.Background(), `
+		update room_types
+		set name = $2, description = $3, base_price = $4, capacity = $5, facilities = $6
+		where id = $1
+		returning *`,
+		roomType.ID, roomType.Name, roomType.Description, roomType.BasePrice,
+		roomType.Capacity, roomType.Facilities))
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui tipe kamar: %v", err)
+	}
+	return updated, nil
+}
+
+func (r *propertyRepo) DeleteRoomType(id string) error {
+	if _, err := r.db.Exec(context.Background(), `delete from room_types where id = $1`, id); err != nil {
+		return fmt.Errorf("gagal menghapus tipe kamar: %v", err)
+	}
+	return nil
+}
+
+func (r *propertyRepo) ListRoomTypes(propertyID string) ([]models.RoomType, error) {
+	roomTypes, err := collectAll[models.RoomType](r.db.Query(context.Background(), `
+		select * from room_types
+		where ($1::uuid is null or property_id = $1::uuid)
+		order by created_at, id`, nullableText(strings.TrimSpace(propertyID))))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil daftar tipe kamar: %v", err)
+	}
+	return roomTypes, nil
+}
+
+func (r *propertyRepo) CreateRoom(room models.Room) error {
+	_, err := r.db.Exec(context.Background(), `
+		insert into rooms (id, property_id, room_number, room_type_id, status, housekeeping_status, created_at)
+		values ($1, $2, $3, $4, $5, $6, $7)`,
+		room.ID, room.PropertyID, room.RoomNumber, room.RoomTypeID, room.Status,
+		room.HousekeepingStatus, room.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("gagal membuat unit kamar: %v", err)
+	}
+	return nil
+}
+
+func (r *propertyRepo) UpdateRoom(room models.Room) (*models.Room, error) {
+	updated, err := collectOne[models.Room](r.db.Query(context.Background(), `
+		update rooms
+		set room_number = $2, room_type_id = $3, status = $4, housekeeping_status = $5
+		where id = $1
+		returning *`,
+		room.ID, room.RoomNumber, room.RoomTypeID, room.Status, room.HousekeepingStatus))
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui unit kamar: %v", err)
+	}
+	return updated, nil
+}
+
+func (r *propertyRepo) DeleteRoom(id string) error {
+	if _, err := r.db.Exec(context.Background(), `delete from rooms where id = $1`, id); err != nil {
+		return fmt.Errorf("gagal menghapus unit kamar: %v", err)
+	}
+	return nil
+}
+
+func (r *propertyRepo) ListRooms(propertyID, roomTypeID string) ([]models.Room, error) {
+	rooms, err := collectAll[models.Room](r.db.Query(context.Background(), `
+		select * from rooms
+		where ($1::uuid is null or property_id = $1::uuid)
+		  and ($2::uuid is null or room_type_id = $2::uuid)
+		order by created_at, id`,
+		nullableText(strings.TrimSpace(propertyID)), nullableText(strings.TrimSpace(roomTypeID))))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil daftar unit kamar: %v", err)
+	}
+	return rooms, nil
+}
+
+func (r *propertyRepo) UpsertRoomRates(rates []models.RoomRate) error {
+	if len(rates) == 0 {
+		return fmt.Errorf("rates list cannot be empty")
+	}
+	batch := &pgx.Batch{}
+	for _, rate := range rates {
+		batch.Queue(`
+			insert into room_rates (id, room_id, room_type_id, rate_plan_id, date, available_rooms, linear_rate,
+				non_linear_rate, min_nights, max_nights, stop_sell, close_on_arrival, close_on_departure, derived,
+				created_at)
+			values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+			on conflict (room_id, room_type_id, rate_plan_id, date) do update set
+				available_rooms = excluded.available_rooms,
+				linear_rate = excluded.linear_rate,
+				non_linear_rate = excluded.non_linear_rate,
+				min_nights = excluded.min_nights,
+				max_nights = excluded.max_nights,
+				stop_sell = excluded.stop_sell,
+				close_on_arrival = excluded.close_on_arrival,
+				close_on_departure = excluded.close_on_departure,
+				derived = excluded.derived`,
+			rate.ID, rate.RoomID, rate.RoomTypeID, rate.RatePlanID, rate.Date, rate.AvailableRooms, rate.LinearRate,
+			rate.NonLinearRate, rate.MinNights, rate.MaxNights, rate.StopSell, rate.CloseOnArrival, rate.CloseOnDeparture,
+			rate.Derived, rate.CreatedAt)
+	}
+	if err := r.sendBatch(batch); err != nil {
+		return fmt.Errorf("gagal menyimpan rate kamar: %v", err)
+	}
+	return nil
+}
+
+// sendBatch menjalankan batch di dalam transaksi (atau savepoint bila sudah
+// berada di dalam UnitOfWork) supaya upsert sebagian tidak tertinggal.
+func (r *propertyRepo) sendBatch(batch *pgx.Batch) error {
+	return inTx(r.db, func(q querier) error {
+		results := q.SendBatch(context.Background(), batch)
+		for i := 0; i < batch.Len(); i++ {
+			if _, err := results.Exec(); err != nil {
+				_ = results.Close()
+				return err
+			}
+		}
+		return results.Close()
+	})
+}
+
+func (r *propertyRepo) ListRoomRates(roomID string, startDate, endDate string) ([]models.RoomRate, error) {
+	start, err := nullableDate(startDate)
+	if err != nil {
+		return nil, err
+	}
+	end, err := nullableDate(endDate)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := collectAll[models.RoomRate](r.db.Query(context.Background(), `
+		select * from room_rates
+		where room_id = $1
+		  and ($2::date is null or date >= $2::date)
+		  and ($3::date is null or date <= $3::date)
+		order by date, id`, roomID, start, end))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil rate kamar: %v", err)
+	}
+	return rates, nil
+}
+
+func (r *propertyRepo) ListRoomTypeRates(roomTypeID string, startDate, endDate string) ([]models.RoomRate, error) {
+	start, err := nullableDate(startDate)
+	if err != nil {
+		return nil, err
+	}
+	end, err := nullableDate(endDate)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := collectAll[models.RoomRate](r.db.Query(context.Background(), `
+		select * from room_rates
+		where room_type_id = $1 and room_id is null
+		  and ($2::date is null or date >= $2::date)
+		  and ($3::date is null or date <= $3::date)
+		order by date, id`, roomTypeID, start, end))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil rate kamar: %v", err)
+	}
+	return rates, nil
+}
+
+func (r *propertyRepo) ListRoomTypesRates(roomTypeIDs []string, startDate, endDate string) ([]models.RoomRate, error) {
+	start, err := nullableDate(startDate)
+	if err != nil {
+		return nil, err
+	}
+	end, err := nullableDate(endDate)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := collectAll[models.RoomRate](r.db.Query(context.Background(), `
+		select * from room_rates
+		where room_type_id = any($1::uuid[]) and room_id is null
+		  and ($2::date is null or date >= $2::date)
+		  and ($3::date is null or date <= $3::date)
+		order by date, id`, roomTypeIDs, start, end))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil rate kamar: %v", err)
+	}
+	return rates, nil
+}
+
+func (r *propertyRepo) ListRoomsRates(roomIDs []string, startDate, endDate string) ([]models.RoomRate, error) {
+	start, err := nullableDate(startDate)
+	if err != nil {
+		return nil, err
+	}
+	end, err := nullableDate(endDate)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := collectAll[models.RoomRate](r.db.Query(context.Background(), `
+		select * from room_rates
+		where room_id = any($1::uuid[])
+		  and ($2::date is null or date >= $2::date)
+		  and ($3::date is null or date <= $3::date)
+		order by date, id`, roomIDs, start, end))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil rate kamar: %v", err)
+	}
+	return rates, nil
+}
+
+func (r *propertyRepo) GetRoomByID(id string) (*models.Room, error) {
+	room, err := collectOne[models.Room](r.db.Query(context.Background(),
+		`select * from rooms where id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil room: %v", err)
+	}
+	return room, nil
+}
+
+func (r *propertyRepo) GetRoomTypeByID(id string) (*models.RoomType, error) {
+	roomType, err := collectOne[models.RoomType](r.db.Query(context.Background(),
+		`select * from room_types where id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil room type: %v", err)
+	}
+	return roomType, nil
+}
+
+func (r *propertyRepo) GetPropertyPhotoByID(id string) (*models.PropertyPhoto, error) {
+	photo, err := collectOne[models.PropertyPhoto](r.db.Query(context.Background(),
+		`select * from property_photos where id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil foto property: %v", err)
+	}
+	return photo, nil
+}
+
+func (r *propertyRepo) GetRoomPhotoByID(id string) (*models.RoomPhoto, error) {
+	photo, err := collectOne[models.RoomPhoto](r.db.Query(context.Background(),
+		`select * from room_photos where id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil foto kamar: %v", err)
+	}
+	return photo, nil
+}
+
+func (r *propertyRepo) AddPropertyPhoto(photo models.PropertyPhoto) error {
+	_, err := r.db.Exec(context.Background(), `
+		insert into property_photos (id, property_id, url, caption, created_at)
+		values ($1, $2, $3, $4, $5)`,
+		photo.ID, photo.PropertyID, photo.URL, photo.Caption, photo.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("gagal menambahkan foto property: %v", err)
+	}
+	return nil
+}
+
+func (r *propertyRepo) ListPropertyPhotos(propertyID string) ([]models.PropertyPhoto, error) {
+	photos, err := collectAll[models.PropertyPhoto](r.db.Query(context.Background(),
+		`select * from property_photos where property_id = $1 order by created_at, id`, propertyID))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil foto property: %v", err)
+	}
+	return photos, nil
+}
+
+func (r *propertyRepo) DeletePropertyPhoto(id string) error {
+	if _, err := r.db.Exec(context.Background(), `delete from property_photos where id = $1`, id); err != nil {
+		return fmt.Errorf("gagal menghapus foto property: %v", err)
+	}
+	return nil
+}
+
+func (r *propertyRepo) AddRoomPhoto(photo models.RoomPhoto) error {
+	_, err := r.db.Exec(context.Background(), `
+		insert into room_photos (id, property_id, room_type_id, room_id, url, caption, created_at)
+		values ($1, $2, $3, $4, $5, $6, $7)`,
+		photo.ID, photo.PropertyID, photo.RoomTypeID, photo.RoomID, photo.URL, photo.Caption, photo.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("gagal menambahkan foto kamar: %v", err)
+	}
+	return nil
+}
+
+func (r *propertyRepo) ListRoomPhotos(roomTypeID, roomID string) ([]models.RoomPhoto, error) {
+	photos, err := collectAll[models.RoomPhoto](r.db.Query(context.Background(), `
+		select * from room_photos
+		where ($1::uuid is null or room_type_id = $1::uuid)
+		  and ($2::uuid is null or room_id = $2::uuid)
+		order by created_at, id`,
+		nullableText(strings.TrimSpace(roomTypeID)), nullableText(strings.TrimSpace(roomID))))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil foto kamar: %v", err)
+	}
+	return photos, nil
+}
+
+func (r *propertyRepo) DeleteRoomPhoto(id string) error {
+	if _, err := r.db.Exec(context.Background(), `delete from room_photos where id = $1`, id); err != nil {
+		return fmt.Errorf("gagal menghapus foto kamar: %v", err)
+	}
+	return nil
+}
+
+func (r *propertyRepo) SearchProperties(filter repository.PropertySearchFilter) ([]models.Properties, error) {
+	facilities := filter.Facilities
+	if facilities == nil {
+		facilities = []string{}
+	}
+	var south, west, north, east *float64
+	if b := filter.Bounds; b != nil {
+		south, west, north, east = &b.South, &b.West, &b.North, &b.East
+	}
+	props, err := collectAll[models.Properties](r.db.Query(context.Background(), `
+		select * from properties
+		where ($1::text is null or city ilike '%' || $1::text || '%')
+		  and coalesce(facilities, '{}') @> $2::text[]
+		  and rating >= $3
+		  and ($4::float8 is null or (
+			latitude between $4::float8 and $6::float8
+			and case when $5::float8 <= $7::float8 then longitude between $5::float8 and $7::float8
+			         else longitude >= $5::float8 or longitude <= $7::float8 end))
+		order by created_at, id`,
+		nullableText(strings.TrimSpace(filter.City)), facilities, filter.MinRating,
+		south, west, north, east))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mencari properti: %v", err)
+	}
+	return props, nil
+}
+
+func (r *propertyRepo) GetPropertyByID(id string) (*models.Properties, error) {
+	property, err := collectOne[models.Properties](r.db.Query(context.Background(),
+		`select * from properties where id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrPropertyNotFound
+	}
+	return property, err
+}
+
+func (r *propertyRepo) GetRoomTypesByPropertyID(propertyID string) ([]models.RoomType, error) {
+	return collectAll[models.RoomType](r.db.Query(context.Background(),
+		`select * from room_types where property_id = $1 order by created_at, id`, propertyID))
+}
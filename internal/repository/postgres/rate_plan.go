@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"hotelbooking/internal/models"
+	"strings"
+)
+
+func (r *propertyRepo) CreateRatePlan(plan models.RatePlan) error {
+	_, err := r.db.Exec(context.Background(), `
+		insert into rate_plans (id, property_id, code, name, rate_type, breakfast_included, refundable,
+			cancellation_policy, is_active, parent_rate_plan_id, derivation_type, derivation_value, created_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		plan.ID, plan.PropertyID, plan.Code, plan.Name, plan.RateType, plan.BreakfastIncluded,
+		plan.Refundable, plan.CancellationPolicy, plan.IsActive, plan.ParentRatePlanID, plan.DerivationType,
+		plan.DerivationValue, plan.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("gagal membuat rate plan: %v", err)
+	}
+	return nil
+}
+
+func (r *propertyRepo) UpdateRatePlan(plan models.RatePlan) (*models.RatePlan, error) {
+	updated, err := collectOne[models.RatePlan](r.db.Query(context.Background(), `
+		update rate_plans
+		set code = $2, name = $3, rate_type = $4, breakfast_included = $5, refundable = $6,
+			cancellation_policy = $7, is_active = $8, parent_rate_plan_id = $9, derivation_type = $10,
+			derivation_value = $11
+		where id = $1
+		returning *`,
+		plan.ID, plan.Code, plan.Name, plan.RateType, plan.BreakfastIncluded, plan.Refundable,
+		plan.CancellationPolicy, plan.IsActive, plan.ParentRatePlanID, plan.DerivationType, plan.DerivationValue))
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui rate plan: %v", err)
+	}
+	return updated, nil
+}
+
+func (r *propertyRepo) DeleteRatePlan(id string) error {
+	if _, err := r.db.Exec(context.Background(), `delete from rate_plans where id = $1`, id); err != nil {
+		return fmt.Errorf("gagal menghapus rate plan: %v", err)
+	}
+	return nil
+}
+
+func (r *propertyRepo) ListRatePlans(propertyID string) ([]models.RatePlan, error) {
+	plans, err := collectAll[models.RatePlan](r.db.Query(context.Background(), `
+		select * from rate_plans
+		where ($1::uuid is null or property_id = $1::uuid)
+		order by created_at, id`, nullableText(strings.TrimSpace(propertyID))))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil daftar rate plan: %v", err)
+	}
+	return plans, nil
+}
+
+func (r *propertyRepo) GetRatePlanByID(id string) (*models.RatePlan, error) {
+	plan, err := collectOne[models.RatePlan](r.db.Query(context.Background(),
+		`select * from rate_plans where id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil rate plan: %v", err)
+	}
+	return plan, nil
+}
+
+func (r *propertyRepo) SetPropertyCancellationRules(propertyID string, rules *models.CancellationPolicy) (*models.Properties, error) {
+	property, err := collectOne[models.Properties](r.db.Query(context.Background(),
+		`update properties set cancellation_rules = $2 where id = $1 returning *`, propertyID, rules))
+	if err != nil {
+		return nil, fmt.Errorf("gagal menyimpan aturan pembatalan: %v", err)
+	}
+	return property, nil
+}
+
+func (r *propertyRepo) SetPropertyPaymentSchedule(propertyID string, schedule *models.PaymentSchedulePolicy) (*models.Properties, error) {
+	property, err := collectOne[models.Properties](r.db.Query(context.Background(),
+		`update properties set payment_schedule = $2 where id = $1 returning *`, propertyID, schedule))
+	if err != nil {
+		return nil, fmt.Errorf("gagal menyimpan jadwal pembayaran: %v", err)
+	}
+	return property, nil
+}
+
+func (r *propertyRepo) SetPropertyTaxRules(propertyID string, rules []models.TaxRule) (*models.Properties, error) {
+	property, err := collectOne[models.Properties](r.db.Query(context.Background(),
+		`update properties set tax_rules = $2 where id = $1 returning *`, propertyID, rules))
+	if err != nil {
+		return nil, fmt.Errorf("gagal menyimpan aturan pajak: %v", err)
+	}
+	return property, nil
+}
+
+func (r *propertyRepo) SetRatePlanCancellationRules(planID string, rules *models.CancellationPolicy) (*models.RatePlan, error) {
+	plan, err := collectOne[models.RatePlan](r.db.Query(context.Background(),
+		`update rate_plans set cancellation_rules = $2 where id = $1 returning *`, planID, rules))
+	if err != nil {
+		return nil, fmt.Errorf("gagal menyimpan aturan pembatalan: %v", err)
+	}
+	return plan, nil
+}
@@ -0,0 +1,474 @@
+-- Skema database untuk backend postgres (--storage=postgres).
+-- Semua statement idempotent sehingga aman dijalankan setiap startup.
+
+-- Migrasi data satu kali. Skema ini dijalankan setiap server start, jadi
+-- backfill yang tidak boleh diulang dicatat namanya di sini.
+create table if not exists schema_migrations (
+    name       text primary key,
+    applied_at timestamptz not null default now()
+);
+
+create table if not exists properties (
+    id                  uuid primary key,
+    hotel_code          text not null unique,
+    auth_code           text not null default '',
+    name                text not null,
+    city                text not null default '',
+    address             text not null default '',
+    facilities          text[],
+    checkin_time        text not null default '',
+    checkout_time       text not null default '',
+    cancellation_policy text not null default '',
+    created_at          timestamptz not null default now()
+);
+
+alter table properties add column if not exists rating numeric(2, 1) not null default 0;
+alter table properties add column if not exists latitude double precision;
+alter table properties add column if not exists longitude double precision;
+create index if not exists properties_location_idx on properties (latitude, longitude);
+alter table properties add column if not exists cancellation_rules jsonb;
+alter table properties add column if not exists modification_cutoff_hours integer check (modification_cutoff_hours >= 0);
+
+create table if not exists admin (
+    id          uuid primary key,
+    property_id uuid references properties (id) on delete set null,
+    email       text not null unique,
+    role        text not null default '',
+    is_active   boolean not null default true,
+    created_at  timestamptz not null default now()
+);
+
+create table if not exists guests (
+    id          uuid primary key,
+    first_name  text not null default '',
+    last_name   text not null default '',
+    email       text not null default '',
+    phone       text not null default '',
+    guest_type  text not null default '',
+    gender      text not null default '',
+    vip_status  text not null default '',
+    address     text not null default '',
+    city        text not null default '',
+    postal_code text not null default '',
+    state       text not null default '',
+    country     text not null default '',
+    nationality text not null default ''
+);
+
+create table if not exists room_types (
+    id          uuid primary key,
+    property_id uuid references properties (id) on delete cascade,
+    name        text not null,
+    description text not null default '',
+    base_price  numeric(14, 2) not null default 0,
+    capacity    integer not null default 1,
+    facilities  text[],
+    created_at  timestamptz not null default now()
+);
+
+create table if not exists rooms (
+    id                  uuid primary key,
+    property_id         uuid references properties (id) on delete cascade,
+    room_number         text not null,
+    room_type_id        uuid references room_types (id) on delete set null,
+    status              text not null default 'Available',
+    housekeeping_status text not null default 'Clean',
+    created_at          timestamptz not null default now()
+);
+
+create table if not exists room_rates (
+    id                 uuid primary key,
+    room_id            uuid references rooms (id) on delete cascade,
+    date               date not null,
+    available_rooms    integer not null default 0,
+    linear_rate        numeric(14, 2),
+    non_linear_rate    jsonb,
+    min_nights         integer not null default 0,
+    max_nights         integer not null default 0,
+    stop_sell          boolean not null default false,
+    close_on_arrival   boolean not null default false,
+    close_on_departure boolean not null default false,
+    created_at         timestamptz not null default now()
+);
+
+create table if not exists rate_plans (
+    id                  uuid primary key,
+    property_id         uuid references properties (id) on delete cascade,
+    code                text not null,
+    name                text not null,
+    rate_type           text not null default '',
+    breakfast_included  boolean not null default false,
+    refundable          boolean not null default true,
+    cancellation_policy text not null default '',
+    is_active           boolean not null default true,
+    created_at          timestamptz not null default now(),
+    unique (property_id, code)
+);
+
+-- Rate disimpan per kamar + rate plan + tanggal. Baris tanpa rate plan
+-- (rate_plan_id null) adalah harga dasar kamar.
+alter table room_rates add column if not exists rate_plan_id uuid references rate_plans (id) on delete cascade;
+alter table room_rates drop constraint if exists room_rates_room_id_date_key;
+-- Baris derived dihitung ulang dari rate plan parent, bukan diinput admin
+alter table room_rates add column if not exists derived boolean not null default false;
+alter table rate_plans add column if not exists parent_rate_plan_id uuid references rate_plans (id) on delete restrict;
+alter table rate_plans add column if not exists derivation_type text not null default '';
+alter table rate_plans add column if not exists derivation_value numeric(14, 2) not null default 0;
+alter table rate_plans add column if not exists cancellation_rules jsonb;
+
+-- Rate juga bisa diset per tipe kamar (room_id null, room_type_id terisi)
+-- untuk booking yang memesan tipe kamar, bukan nomor kamar.
+alter table room_rates add column if not exists room_type_id uuid references room_types (id) on delete cascade;
+drop index if exists room_rates_room_plan_date_key;
+create unique index if not exists room_rates_scope_plan_date_key
+    on room_rates (room_id, room_type_id, rate_plan_id, date) nulls not distinct;
+
+create table if not exists property_photos (
+    id          uuid primary key,
+    property_id uuid references properties (id) on delete cascade,
+    url         text not null,
+    caption     text not null default '',
+    created_at  timestamptz not null default now()
+);
+
+create table if not exists room_photos (
+    id           uuid primary key,
+    property_id  uuid references properties (id) on delete cascade,
+    room_type_id uuid references room_types (id) on delete cascade,
+    room_id      uuid references rooms (id) on delete cascade,
+    url          text not null,
+    caption      text not null default '',
+    created_at   timestamptz not null default now()
+);
+
+create table if not exists bookings (
+    id             uuid primary key,
+    guest_id       uuid,
+    property_id    uuid references properties (id) on delete set null,
+    room_id        uuid references rooms (id) on delete set null,
+    check_in       date not null,
+    check_out      date not null,
+    nights         integer not null,
+    total_price    numeric(14, 2) not null default 0,
+    booking_status text not null,
+    refund_amount  numeric(14, 2) not null default 0,
+    note           text not null default '',
+    created_at     timestamptz not null default now(),
+    check (check_out > check_in)
+);
+
+alter table bookings add column if not exists hold_expires_at timestamptz;
+alter table bookings add column if not exists rate_plan_id uuid references rate_plans (id) on delete set null;
+alter table bookings add column if not exists adults integer not null default 1;
+alter table bookings add column if not exists children integer not null default 0;
+
+-- Booking memegang satu unit tipe kamar; room_id baru terisi saat kamar
+-- di-assign. Booking lama mewarisi tipe dari kamarnya; hanya dijalankan
+-- sekali supaya booking yang sengaja dilepas dari tipenya tidak terisi lagi.
+alter table bookings add column if not exists room_type_id uuid references room_types (id) on delete set null;
+do $$
+begin
+    if not exists (select 1 from schema_migrations where name = 'booking_room_type_backfill') then
+        update bookings b set room_type_id = r.room_type_id
+        from rooms r
+        where b.room_id = r.id and b.room_type_id is null and r.room_type_id is not null;
+        insert into schema_migrations (name) values ('booking_room_type_backfill')
+        on conflict (name) do nothing;
+    end if;
+end
+$$;
+create index if not exists bookings_room_type_dates_idx on bookings (room_type_id, check_in, check_out);
+
+create index if not exists bookings_room_dates_idx on bookings (room_id, check_in, check_out);
+
+-- Satu kamar tidak boleh dipakai dua booking aktif pada malam yang sama.
+-- Constraint ini yang menjamin alokasi atomik walau ada request paralel;
+-- booking yang dibatalkan atau kedaluwarsa otomatis melepas kamarnya.
+create extension if not exists btree_gist;
+
+do $$
+begin
+    -- Skema lama hanya mengecualikan Cancelled; buat ulang agar Expired ikut dilepas
+    if exists (select 1 from pg_constraint
+               where conname = 'bookings_room_no_overlap'
+                 and pg_get_constraintdef(oid) not like '%Expired%') then
+        alter table bookings drop constraint bookings_room_no_overlap;
+    end if;
+    if not exists (select 1 from pg_constraint where conname = 'bookings_room_no_overlap') then
+        alter table bookings
+            add constraint bookings_room_no_overlap
+            exclude using gist (room_id with =, daterange(check_in, check_out) with &&)
+            where (booking_status not in ('Cancelled', 'Expired'));
+    end if;
+end
+$$;
+create index if not exists bookings_guest_idx on bookings (guest_id);
+create index if not exists bookings_hold_idx on bookings (hold_expires_at) where booking_status = 'New';
+
+create table if not exists booking_status_history (
+    id          uuid primary key,
+    booking_id  uuid references bookings (id) on delete cascade,
+    from_status text not null default '',
+    to_status   text not null,
+    actor       text not null default '',
+    note        text not null default '',
+    created_at  timestamptz not null default now()
+);
+
+create index if not exists booking_status_history_booking_idx on booking_status_history (booking_id, created_at);
+
+create table if not exists payments (
+    id         uuid primary key,
+    booking_id uuid references bookings (id) on delete cascade,
+    amount     numeric(14, 2) not null default 0,
+    status     text not null,
+    provider   text not null default '',
+    reference  text not null default '',
+    paid_at    timestamptz,
+    created_at timestamptz not null default now()
+);
+
+create table if not exists invoices (
+    id             uuid primary key,
+    booking_id     uuid references bookings (id) on delete cascade,
+    invoice_number text not null unique,
+    amount         numeric(14, 2) not null default 0,
+    status         text not null,
+    issued_at      timestamptz not null default now()
+);
+
+-- Reservasi mengelompokkan beberapa booking kamar di bawah satu kode
+-- konfirmasi; payment dan invoice gabungan menunjuk ke reservasi, bukan booking.
+create table if not exists reservations (
+    id                uuid primary key,
+    confirmation_code text not null unique,
+    guest_id          uuid,
+    property_id       uuid references properties (id) on delete set null,
+    total_price       numeric(14, 2) not null default 0,
+    created_at        timestamptz not null default now()
+);
+
+create index if not exists reservations_guest_idx on reservations (guest_id);
+
+alter table bookings add column if not exists reservation_id uuid references reservations (id) on delete set null;
+alter table payments add column if not exists reservation_id uuid references reservations (id) on delete cascade;
+alter table invoices add column if not exists reservation_id uuid references reservations (id) on delete cascade;
+create index if not exists bookings_reservation_idx on bookings (reservation_id);
+create index if not exists payments_reservation_idx on payments (reservation_id);
+create index if not exists invoices_reservation_idx on invoices (reservation_id);
+
+-- Umur anak per booking dipakai untuk harga dan kapasitas; tamu bernama
+-- disimpan terpisah untuk laporan registrasi tamu.
+alter table bookings add column if not exists child_ages integer[];
+
+create table if not exists booking_occupants (
+    id              uuid primary key,
+    booking_id      uuid references bookings (id) on delete cascade,
+    guest_id        uuid,
+    first_name      text not null,
+    last_name       text not null default '',
+    nationality     text not null default '',
+    document_type   text not null default '',
+    document_number text not null default '',
+    age             integer check (age >= 0),
+    created_at      timestamptz not null default now()
+);
+
+create index if not exists booking_occupants_booking_idx on booking_occupants (booking_id);
+
+create table if not exists booking_modifications (
+    id                    uuid primary key,
+    booking_id            uuid references bookings (id) on delete cascade,
+    actor                 text not null default '',
+    previous_check_in     date not null,
+    previous_check_out    date not null,
+    previous_room_type_id uuid,
+    previous_room_id      uuid,
+    previous_rate_plan_id uuid,
+    previous_adults       integer not null default 1,
+    previous_children     integer not null default 0,
+    previous_total        numeric(14, 2) not null default 0,
+    new_total             numeric(14, 2) not null default 0,
+    amount_due            numeric(14, 2) not null default 0,
+    refund_amount         numeric(14, 2) not null default 0,
+    created_at            timestamptz not null default now()
+);
+
+create index if not exists booking_modifications_booking_idx on booking_modifications (booking_id, created_at);
+
+-- Dana yang sudah di-capture payment gateway. Payment lama yang sudah
+-- lunas dianggap terbayar penuh; hanya dijalankan sekali karena setelahnya
+-- amount_paid dicatat dari charge yang benar-benar di-capture.
+alter table payments add column if not exists amount_paid numeric(14, 2) not null default 0;
+do $$
+begin
+    if not exists (select 1 from schema_migrations where name = 'payment_amount_paid_backfill') then
+        update payments set amount_paid = amount where status in ('Paid', 'Refunded') and amount_paid = 0;
+        insert into schema_migrations (name) values ('payment_amount_paid_backfill')
+        on conflict (name) do nothing;
+    end if;
+end
+$$;
+
+-- Webhook payment disimpan mentah; (provider, event_id) unik untuk dedup.
+create table if not exists payment_webhook_events (
+    id               uuid primary key,
+    provider         text not null,
+    event_id         text not null,
+    event_type       text not null default '',
+    charge_reference text not null default '',
+    payment_id       uuid references payments (id) on delete set null,
+    payload          text not null,
+    status           text not null,
+    note             text not null default '',
+    received_at      timestamptz not null default now(),
+    processed_at     timestamptz,
+    unique (provider, event_id)
+);
+
+create index if not exists payment_webhook_events_payment_idx on payment_webhook_events (payment_id);
+
+-- Refund parsial/berulang per payment, dieksekusi lewat payment gateway.
+-- Payment lama yang berstatus Refunded dianggap dikembalikan penuh; hanya
+-- dijalankan sekali karena setelahnya amount_refunded dicatat per refund.
+alter table payments add column if not exists amount_refunded numeric(14, 2) not null default 0;
+do $$
+begin
+    if not exists (select 1 from schema_migrations where name = 'payment_amount_refunded_backfill') then
+        update payments set amount_refunded = amount_paid where status = 'Refunded' and amount_refunded = 0;
+        insert into schema_migrations (name) values ('payment_amount_refunded_backfill')
+        on conflict (name) do nothing;
+    end if;
+end
+$$;
+
+create table if not exists refunds (
+    id                uuid primary key,
+    payment_id        uuid not null references payments (id) on delete cascade,
+    booking_id        uuid references bookings (id) on delete set null,
+    amount            numeric(14, 2) not null,
+    reason            text not null default '',
+    status            text not null,
+    provider          text not null default '',
+    charge_reference  text not null default '',
+    gateway_reference text not null default '',
+    failure_reason    text not null default '',
+    initiated_by      text not null,
+    created_at        timestamptz not null default now(),
+    completed_at      timestamptz
+);
+
+create index if not exists refunds_payment_idx on refunds (payment_id, created_at);
+
+-- Satu booking/reservasi bisa punya beberapa payment: deposit, pelunasan
+-- yang jatuh tempo menjelang check-in, atau tagihan yang dibagi ke beberapa
+-- kartu.
+alter table payments add column if not exists kind text not null default 'full';
+alter table payments add column if not exists due_at timestamptz;
+alter table payments add column if not exists reminder_sent_at timestamptz;
+alter table properties add column if not exists payment_schedule jsonb;
+
+create index if not exists payments_booking_idx on payments (booking_id);
+create index if not exists payments_due_idx on payments (status, due_at);
+
+-- Folio per booking: tagihan kamar, tagihan tambahan selama menginap, dan
+-- koreksinya. Nominal invoice diturunkan dari baris yang masih Posted.
+create table if not exists folio_charges (
+    id          uuid primary key,
+    booking_id  uuid not null references bookings (id) on delete cascade,
+    kind        text not null default 'charge',
+    charge_code text not null,
+    description text not null default '',
+    quantity    integer not null default 1 check (quantity > 0),
+    unit_price  numeric(14, 2) not null,
+    tax_amount  numeric(14, 2) not null default 0,
+    amount      numeric(14, 2) not null,
+    adjusts_id  uuid references folio_charges (id) on delete set null,
+    status      text not null default 'Posted',
+    posted_by   text not null,
+    posted_at   timestamptz not null default now(),
+    voided_by   text not null default '',
+    void_reason text not null default '',
+    voided_at   timestamptz
+);
+
+create index if not exists folio_charges_booking_idx on folio_charges (booking_id, posted_at);
+
+-- Booking lama belum punya folio: tagihan kamarnya diposting dari total harga.
+-- Booking yang sudah batal atau kedaluwarsa tidak lagi ditagih. Hanya
+-- dijalankan sekali supaya baris folio yang dihapus tidak muncul lagi.
+do $$
+begin
+    if not exists (select 1 from schema_migrations where name = 'folio_room_charges_backfill') then
+        insert into folio_charges (id, booking_id, kind, charge_code, description, quantity, unit_price, amount, posted_by, posted_at)
+        select gen_random_uuid(), b.id, 'charge', 'room', 'Kamar', 1, b.total_price, b.total_price, 'system', b.created_at
+        from bookings b
+        where not exists (select 1 from folio_charges f where f.booking_id = b.id)
+          and b.booking_status not in ('Cancelled', 'Expired');
+        insert into schema_migrations (name) values ('folio_room_charges_backfill')
+        on conflict (name) do nothing;
+    end if;
+end
+$$;
+
+-- Pajak dan service charge per property; hasilnya dirinci di folio dan
+-- invoice, dan pajak kamar disimpan di booking untuk laporan pendapatan bersih.
+alter table properties add column if not exists tax_rules jsonb;
+alter table bookings add column if not exists tax_amount numeric(14, 2) not null default 0;
+alter table folio_charges add column if not exists tax_lines jsonb;
+alter table invoices add column if not exists net_amount numeric(14, 2) not null default 0;
+alter table invoices add column if not exists tax_amount numeric(14, 2) not null default 0;
+alter table invoices add column if not exists tax_lines jsonb;
+
+-- Invoice sebelum ada pajak: seluruh nominalnya adalah pendapatan bersih.
+do $$
+begin
+    if not exists (select 1 from schema_migrations where name = 'invoice_net_amount_backfill') then
+        update invoices set net_amount = amount where net_amount = 0 and tax_amount = 0;
+        insert into schema_migrations (name) values ('invoice_net_amount_backfill')
+        on conflict (name) do nothing;
+    end if;
+end
+$$;
+
+-- Charge yang sudah di-capture per payment. payments.reference hanya
+-- menunjuk charge yang sedang terbuka, jadi refund memakai tabel ini supaya
+-- dana charge sebelumnya tetap bisa dikembalikan.
+create table if not exists payment_charges (
+    id          uuid primary key,
+    payment_id  uuid not null references payments (id) on delete cascade,
+    provider    text not null,
+    reference   text not null,
+    amount      numeric(14, 2) not null,
+    captured_at timestamptz not null,
+    unique (payment_id, reference)
+);
+
+-- Payment lunas yang dibuat sebelum tabel ini ada dianggap dibayar lewat
+-- charge yang masih tercatat di payments.reference. Hanya dijalankan sekali:
+-- setelahnya payments.reference bisa menunjuk charge yang belum di-capture.
+do $$
+begin
+    if not exists (select 1 from schema_migrations where name = 'payment_charges_backfill') then
+        insert into payment_charges (id, payment_id, provider, reference, amount, captured_at)
+        select gen_random_uuid(), p.id, p.provider, p.reference, p.amount_paid, coalesce(p.paid_at, p.created_at)
+        from payments p
+        where p.reference <> '' and p.amount_paid > 0
+          and p.status in ('Paid', 'PartiallyRefunded', 'Refunded')
+        on conflict (payment_id, reference) do nothing;
+        insert into schema_migrations (name) values ('payment_charges_backfill')
+        on conflict (name) do nothing;
+    end if;
+end
+$$;
+
+-- Dana yang dikembalikan tidak boleh melebihi dana yang di-capture; refund
+-- paralel yang lolos dari pengecekan aplikasi tetap ditolak database.
+do $$
+begin
+    if not exists (select 1 from pg_constraint where conname = 'payments_refund_within_paid') then
+        alter table payments
+            add constraint payments_refund_within_paid check (amount_refunded <= amount_paid);
+    end if;
+end
+$$;
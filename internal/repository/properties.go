@@ -2,12 +2,19 @@ package repository
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"hotelbooking/internal/config"
 	"hotelbooking/internal/models"
 	"strings"
+
+	"github.com/supabase-community/postgrest-go"
+	"github.com/supabase-community/supabase-go"
 )
 
+// ErrPropertyNotFound dikembalikan GetPropertyByID ketika property tidak
+// ada, supaya caller bisa membedakannya dari gangguan database.
+var ErrPropertyNotFound = errors.New("property tidak ditemukan")
+
 type PropertyRepo interface {
 	// admin
 	GetPropertyByAuth(hotelCode, authCode string) (*models.Properties, error)
@@ -23,8 +30,24 @@ type PropertyRepo interface {
 	UpdateRoom(room models.Room) (*models.Room, error)
 	DeleteRoom(id string) error
 	ListRooms(propertyID, roomTypeID string) ([]models.Room, error)
+	CreateRatePlan(plan models.RatePlan) error
+	UpdateRatePlan(plan models.RatePlan) (*models.RatePlan, error)
+	DeleteRatePlan(id string) error
+	ListRatePlans(propertyID string) ([]models.RatePlan, error)
+	GetRatePlanByID(id string) (*models.RatePlan, error)
+	SetPropertyCancellationRules(propertyID string, rules *models.CancellationPolicy) (*models.Properties, error)
+	SetPropertyPaymentSchedule(propertyID string, schedule *models.PaymentSchedulePolicy) (*models.Properties, error)
+	SetPropertyTaxRules(propertyID string, rules []models.TaxRule) (*models.Properties, error)
+	SetRatePlanCancellationRules(planID string, rules *models.CancellationPolicy) (*models.RatePlan, error)
 	UpsertRoomRates(rates []models.RoomRate) error
 	ListRoomRates(roomID string, startDate, endDate string) ([]models.RoomRate, error)
+	ListRoomTypeRates(roomTypeID string, startDate, endDate string) ([]models.RoomRate, error)
+	// ListRoomTypesRates mengambil rate level tipe kamar untuk beberapa tipe
+	// kamar sekaligus.
+	ListRoomTypesRates(roomTypeIDs []string, startDate, endDate string) ([]models.RoomRate, error)
+	// ListRoomsRates mengambil rate level kamar untuk beberapa kamar
+	// sekaligus.
+	ListRoomsRates(roomIDs []string, startDate, endDate string) ([]models.RoomRate, error)
 	GetRoomByID(id string) (*models.Room, error)
 	GetRoomTypeByID(id string) (*models.RoomType, error)
 	GetPropertyPhotoByID(id string) (*models.PropertyPhoto, error)
@@ -37,22 +60,54 @@ type PropertyRepo interface {
 	DeleteRoomPhoto(id string) error
 
 	// guest
-	SearchProperties(city string) ([]models.Properties, error)
+	SearchProperties(filter PropertySearchFilter) ([]models.Properties, error)
 	GetPropertyByID(id string) (*models.Properties, error)
 	GetRoomTypesByPropertyID(propertyID string) ([]models.RoomType, error)
 }
 
-type propertyRepo struct{}
+// PropertySearchFilter adalah filter level property untuk pencarian hotel.
+// Facilities harus dimiliki semua (bukan salah satu). Bounds membatasi hasil
+// ke property yang punya koordinat di dalam kotak tersebut.
+type PropertySearchFilter struct {
+	City       string
+	Facilities []string
+	MinRating  float64
+	Bounds     *GeoBounds
+}
+
+// GeoBounds adalah kotak peta dalam derajat. West > East berarti kotak
+// melewati garis bujur 180.
+type GeoBounds struct {
+	South float64
+	West  float64
+	North float64
+	East  float64
+}
+
+// Contains bernilai true jika titik berada di dalam kotak (termasuk tepinya).
+func (b GeoBounds) Contains(lat, lng float64) bool {
+	if lat < b.South || lat > b.North {
+		return false
+	}
+	if b.West <= b.East {
+		return lng >= b.West && lng <= b.East
+	}
+	return lng >= b.West || lng <= b.East
+}
+
+type propertyRepo struct {
+	client *supabase.Client
+}
 
-func NewPropertyRepo() PropertyRepo {
-	return &propertyRepo{}
+func NewPropertyRepo(client *supabase.Client) PropertyRepo {
+	return &propertyRepo{client: client}
 }
 
 func (r *propertyRepo) GetPropertyByAuth(hotelcode, authCode string) (*models.Properties, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	respon, _, err := config.SupabaseClient.
+	respon, _, err := r.client.
 		From("properties").
 		Select("*", "", false).
 		Eq("hotel_code", hotelcode).
@@ -75,10 +130,10 @@ func (r *propertyRepo) GetPropertyByAuth(hotelcode, authCode string) (*models.Pr
 
 // CreateProperty
 func (r *propertyRepo) CreateProperty(property models.Properties) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
-	_, _, err := config.SupabaseClient.
+	_, _, err := r.client.
 		From("properties").
 		Insert(property, false, "", "", "").
 		Execute()
@@ -91,10 +146,10 @@ func (r *propertyRepo) CreateProperty(property models.Properties) error {
 }
 
 func (r *propertyRepo) CreateRoomType(roomType models.RoomType) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
-	_, _, err := config.SupabaseClient.
+	_, _, err := r.client.
 		From("room_types").
 		Insert(roomType, false, "", "", "").
 		Execute()
@@ -106,7 +161,7 @@ func (r *propertyRepo) CreateRoomType(roomType models.RoomType) error {
 }
 
 func (r *propertyRepo) UpdateRoomType(roomType models.RoomType) (*models.RoomType, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
 	updates := map[string]any{
@@ -116,7 +171,7 @@ func (r *propertyRepo) UpdateRoomType(roomType models.RoomType) (*models.RoomTyp
 		"capacity":    roomType.Capacity,
 		"facilities":  roomType.Facilities,
 	}
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From("room_types").
 		Update(updates, "", "").
 		Eq("id", roomType.ID.String()).
@@ -133,10 +188,10 @@ func (r *propertyRepo) UpdateRoomType(roomType models.RoomType) (*models.RoomTyp
 }
 
 func (r *propertyRepo) DeleteRoomType(id string) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
-	_, _, err := config.SupabaseClient.
+	_, _, err := r.client.
 		From("room_types").
 		Delete("", "").
 		Eq("id", id).
@@ -148,10 +203,10 @@ func (r *propertyRepo) DeleteRoomType(id string) error {
 }
 
 func (r *propertyRepo) ListRoomTypes(propertyID string) ([]models.RoomType, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	q := config.SupabaseClient.
+	q := r.client.
 		From("room_types").
 		Select("*", "", false)
 	if strings.TrimSpace(propertyID) != "" {
@@ -169,10 +224,10 @@ func (r *propertyRepo) ListRoomTypes(propertyID string) ([]models.RoomType, erro
 }
 
 func (r *propertyRepo) CreateRoom(room models.Room) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
-	_, _, err := config.SupabaseClient.
+	_, _, err := r.client.
 		From("rooms").
 		Insert(room, false, "", "", "").
 		Execute()
@@ -185,7 +240,7 @@ func (r *propertyRepo) CreateRoom(room models.Room) error {
 }
 
 func (r *propertyRepo) UpdateRoom(room models.Room) (*models.Room, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
 	updates := map[string]any{
@@ -195,7 +250,7 @@ func (r *propertyRepo) UpdateRoom(room models.Room) (*models.Room, error) {
 		"housekeeping_status": room.HousekeepingStatus,
 	}
 
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From("rooms").
 		Update(updates, "", "").
 		Eq("id", room.ID.String()).
@@ -215,10 +270,10 @@ func (r *propertyRepo) UpdateRoom(room models.Room) (*models.Room, error) {
 }
 
 func (r *propertyRepo) DeleteRoom(id string) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
-	_, _, err := config.SupabaseClient.
+	_, _, err := r.client.
 		From("rooms").
 		Delete("", "").
 		Eq("id", id).
@@ -232,10 +287,10 @@ func (r *propertyRepo) DeleteRoom(id string) error {
 }
 
 func (r *propertyRepo) ListRooms(propertyID, roomTypeID string) ([]models.Room, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	q := config.SupabaseClient.
+	q := r.client.
 		From("rooms").
 		Select("*", "", false)
 
@@ -260,16 +315,16 @@ func (r *propertyRepo) ListRooms(propertyID, roomTypeID string) ([]models.Room,
 }
 
 func (r *propertyRepo) UpsertRoomRates(rates []models.RoomRate) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
 	if len(rates) == 0 {
 		return fmt.Errorf("rates list cannot be empty")
 	}
 
-	_, _, err := config.SupabaseClient.
+	_, _, err := r.client.
 		From("room_rates").
-		Insert(rates, true, "room_id,date", "", ""). // upsert by room_id+date
+		Insert(rates, true, "room_id,room_type_id,rate_plan_id,date", "", ""). // upsert by kamar/tipe kamar+rate_plan_id+date
 		Execute()
 	if err != nil {
 		return fmt.Errorf("gagal menyimpan rate kamar: %v", err)
@@ -278,13 +333,86 @@ func (r *propertyRepo) UpsertRoomRates(rates []models.RoomRate) error {
 }
 
 func (r *propertyRepo) ListRoomRates(roomID string, startDate, endDate string) ([]models.RoomRate, error) {
-	if config.SupabaseClient == nil {
+	return r.listRates("room_id", roomID, startDate, endDate)
+}
+
+// ListRoomTypeRates mengambil rate level tipe kamar (room_id kosong).
+func (r *propertyRepo) ListRoomTypeRates(roomTypeID string, startDate, endDate string) ([]models.RoomRate, error) {
+	return r.listRates("room_type_id", roomTypeID, startDate, endDate)
+}
+
+// ListRoomTypesRates mengambil rate level tipe kamar untuk beberapa tipe
+// kamar dalam satu query.
+func (r *propertyRepo) ListRoomTypesRates(roomTypeIDs []string, startDate, endDate string) ([]models.RoomRate, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	if len(roomTypeIDs) == 0 {
+		return []models.RoomRate{}, nil
+	}
+	q := r.client.
+		From("room_rates").
+		Select("*", "", false).
+		In("room_type_id", roomTypeIDs).
+		Is("room_id", "null")
+	if startDate != "" {
+		q = q.Filter("date", "gte", startDate)
+	}
+	if endDate != "" {
+		q = q.Filter("date", "lte", endDate)
+	}
+	resp, _, err := q.Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil rate kamar: %v", err)
+	}
+	var rates []models.RoomRate
+	if err := json.Unmarshal(resp, &rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (r *propertyRepo) listRates(column, id string, startDate, endDate string) ([]models.RoomRate, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	q := r.client.
+		From("room_rates").
+		Select("*", "", false).
+		Eq(column, id)
+	if column == "room_type_id" {
+		q = q.Is("room_id", "null")
+	}
+	if startDate != "" {
+		q = q.Filter("date", "gte", startDate)
+	}
+	if endDate != "" {
+		q = q.Filter("date", "lte", endDate)
+	}
+	resp, _, err := q.Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil rate kamar: %v", err)
+	}
+	var rates []models.RoomRate
+	if err := json.Unmarshal(resp, &rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// ListRoomsRates mengambil rate level kamar untuk beberapa kamar dalam satu
+// query.
+func (r *propertyRepo) ListRoomsRates(roomIDs []string, startDate, endDate string) ([]models.RoomRate, error) {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	q := config.SupabaseClient.
+	if len(roomIDs) == 0 {
+		return []models.RoomRate{}, nil
+	}
+	q := r.client.
 		From("room_rates").
 		Select("*", "", false).
-		Eq("room_id", roomID)
+		In("room_id", roomIDs)
 	if startDate != "" {
 		q = q.Filter("date", "gte", startDate)
 	}
@@ -303,10 +431,10 @@ func (r *propertyRepo) ListRoomRates(roomID string, startDate, endDate string) (
 }
 
 func (r *propertyRepo) GetRoomByID(id string) (*models.Room, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From("rooms").
 		Select("*", "", false).
 		Eq("id", id).
@@ -323,10 +451,10 @@ func (r *propertyRepo) GetRoomByID(id string) (*models.Room, error) {
 }
 
 func (r *propertyRepo) GetRoomTypeByID(id string) (*models.RoomType, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From("room_types").
 		Select("*", "", false).
 		Eq("id", id).
@@ -343,10 +471,10 @@ func (r *propertyRepo) GetRoomTypeByID(id string) (*models.RoomType, error) {
 }
 
 func (r *propertyRepo) AddPropertyPhoto(photo models.PropertyPhoto) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
-	_, _, err := config.SupabaseClient.
+	_, _, err := r.client.
 		From("property_photos").
 		Insert(photo, false, "", "", "").
 		Execute()
@@ -357,10 +485,10 @@ func (r *propertyRepo) AddPropertyPhoto(photo models.PropertyPhoto) error {
 }
 
 func (r *propertyRepo) GetPropertyPhotoByID(id string) (*models.PropertyPhoto, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From("property_photos").
 		Select("*", "", false).
 		Eq("id", id).
@@ -377,10 +505,10 @@ func (r *propertyRepo) GetPropertyPhotoByID(id string) (*models.PropertyPhoto, e
 }
 
 func (r *propertyRepo) ListPropertyPhotos(propertyID string) ([]models.PropertyPhoto, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From("property_photos").
 		Select("*", "", false).
 		Eq("property_id", propertyID).
@@ -396,10 +524,10 @@ func (r *propertyRepo) ListPropertyPhotos(propertyID string) ([]models.PropertyP
 }
 
 func (r *propertyRepo) DeletePropertyPhoto(id string) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
-	_, _, err := config.SupabaseClient.
+	_, _, err := r.client.
 		From("property_photos").
 		Delete("", "").
 		Eq("id", id).
@@ -411,10 +539,10 @@ func (r *propertyRepo) DeletePropertyPhoto(id string) error {
 }
 
 func (r *propertyRepo) AddRoomPhoto(photo models.RoomPhoto) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
-	_, _, err := config.SupabaseClient.
+	_, _, err := r.client.
 		From("room_photos").
 		Insert(photo, false, "", "", "").
 		Execute()
@@ -425,10 +553,10 @@ func (r *propertyRepo) AddRoomPhoto(photo models.RoomPhoto) error {
 }
 
 func (r *propertyRepo) GetRoomPhotoByID(id string) (*models.RoomPhoto, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From("room_photos").
 		Select("*", "", false).
 		Eq("id", id).
@@ -445,10 +573,10 @@ func (r *propertyRepo) GetRoomPhotoByID(id string) (*models.RoomPhoto, error) {
 }
 
 func (r *propertyRepo) ListRoomPhotos(roomTypeID, roomID string) ([]models.RoomPhoto, error) {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return nil, fmt.Errorf("supabase client is not initialized")
 	}
-	q := config.SupabaseClient.
+	q := r.client.
 		From("room_photos").
 		Select("*", "", false)
 	if strings.TrimSpace(roomTypeID) != "" {
@@ -469,10 +597,10 @@ func (r *propertyRepo) ListRoomPhotos(roomTypeID, roomID string) ([]models.RoomP
 }
 
 func (r *propertyRepo) DeleteRoomPhoto(id string) error {
-	if config.SupabaseClient == nil {
+	if r.client == nil {
 		return fmt.Errorf("supabase client is not initialized")
 	}
-	_, _, err := config.SupabaseClient.
+	_, _, err := r.client.
 		From("room_photos").
 		Delete("", "").
 		Eq("id", id).
@@ -484,21 +612,31 @@ func (r *propertyRepo) DeleteRoomPhoto(id string) error {
 }
 
 // Implementasi Baru: Mencari properti berdasarkan kota (Case insensitive search logic di Supabase agak tricky, kita pakai Eq dulu atau TextSearch jika dikonfigurasi)
-func (r *propertyRepo) SearchProperties(city string) ([]models.Properties, error) {
-	trimmedCity := strings.TrimSpace(city)
-	if trimmedCity == "" {
-		return nil, fmt.Errorf("parameter city tidak boleh kosong")
-	}
-
-	// Note: Supabase/Postgrest filter 'ilike' formatnya "ilike.%query%"
-	// query := fmt.Sprintf("ilike.%%%s%%", city)
-
-	resp, _, err := config.SupabaseClient.
+func (r *propertyRepo) SearchProperties(filter PropertySearchFilter) ([]models.Properties, error) {
+	query := r.client.
 		From("properties").
-		Select("*", "", false).
-		Filter("city", "ilike", fmt.Sprintf("%%%s%%", trimmedCity)). // Menggunakan filter ilike untuk pencarian
-		Execute()
+		Select("*", "", false)
+	if city := strings.TrimSpace(filter.City); city != "" {
+		query = query.Filter("city", "ilike", fmt.Sprintf("%%%s%%", city)) // Menggunakan filter ilike untuk pencarian
+	}
+	if len(filter.Facilities) > 0 {
+		query = query.Contains("facilities", filter.Facilities)
+	}
+	if filter.MinRating > 0 {
+		query = query.Gte("rating", fmt.Sprintf("%g", filter.MinRating))
+	}
+	if b := filter.Bounds; b != nil {
+		query = query.
+			Gte("latitude", fmt.Sprintf("%g", b.South)).
+			Lte("latitude", fmt.Sprintf("%g", b.North))
+		if b.West <= b.East {
+			query = query.Gte("longitude", fmt.Sprintf("%g", b.West)).Lte("longitude", fmt.Sprintf("%g", b.East))
+		} else {
+			query = query.Or(fmt.Sprintf("longitude.gte.%g,longitude.lte.%g", b.West, b.East), "")
+		}
+	}
 
+	resp, _, err := query.Order("created_at", &postgrest.OrderOpts{Ascending: true}).Execute()
 	if err != nil {
 		return nil, fmt.Errorf("gagal mencari properti: %v", err)
 	}
@@ -512,7 +650,7 @@ func (r *propertyRepo) SearchProperties(city string) ([]models.Properties, error
 }
 
 func (r *propertyRepo) GetPropertyByID(id string) (*models.Properties, error) {
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From("properties").
 		Select("*", "", false).
 		Eq("id", id).
@@ -520,6 +658,9 @@ func (r *propertyRepo) GetPropertyByID(id string) (*models.Properties, error) {
 		Execute()
 
 	if err != nil {
+		if isNoRows(err) {
+			return nil, ErrPropertyNotFound
+		}
 		return nil, err
 	}
 
@@ -530,8 +671,14 @@ func (r *propertyRepo) GetPropertyByID(id string) (*models.Properties, error) {
 	return &property, nil
 }
 
+// isNoRows mengenali error PostgREST untuk Single() yang tidak menemukan
+// baris (PGRST116).
+func isNoRows(err error) bool {
+	return strings.Contains(err.Error(), "PGRST116")
+}
+
 func (r *propertyRepo) GetRoomTypesByPropertyID(propertyID string) ([]models.RoomType, error) {
-	resp, _, err := config.SupabaseClient.
+	resp, _, err := r.client.
 		From("room_types").
 		Select("*", "", false).
 		Eq("property_id", propertyID).
@@ -550,15 +697,19 @@ func (r *propertyRepo) GetRoomTypesByPropertyID(propertyID string) ([]models.Roo
 
 func (r *propertyRepo) UpdateProperty(property models.Properties) (*models.Properties, error) {
 	updates := map[string]any{
-		"name":                 property.Name,
-		"address":              property.Address,
-		"city":                 property.City,
-		"facilities":           property.Facilities,
-		"checkin_time":         property.CheckInTime,
-		"checkout_time":        property.CheckOutTime,
-		"cancellation_policy":  property.CancellationPolicy,
-	}
-	resp, _, err := config.SupabaseClient.
+		"name":                      property.Name,
+		"address":                   property.Address,
+		"city":                      property.City,
+		"facilities":                property.Facilities,
+		"checkin_time":              property.CheckInTime,
+		"checkout_time":             property.CheckOutTime,
+		"cancellation_policy":       property.CancellationPolicy,
+		"rating":                    property.Rating,
+		"latitude":                  property.Latitude,
+		"longitude":                 property.Longitude,
+		"modification_cutoff_hours": property.ModificationCutoffHours,
+	}
+	resp, _, err := r.client.
 		From("properties").
 		Update(updates, "", "").
 		Eq("id", property.ID.String()).
@@ -575,7 +726,7 @@ func (r *propertyRepo) UpdateProperty(property models.Properties) (*models.Prope
 }
 
 func (r *propertyRepo) DeleteProperty(id string) error {
-	_, _, err := config.SupabaseClient.From("properties").Delete("", "").Eq("id", id).Execute()
+	_, _, err := r.client.From("properties").Delete("", "").Eq("id", id).Execute()
 	if err != nil {
 		return fmt.Errorf("gagal menghapus property: %v", err)
 	}
@@ -583,7 +734,7 @@ func (r *propertyRepo) DeleteProperty(id string) error {
 }
 
 func (r *propertyRepo) ListProperties(city string) ([]models.Properties, error) {
-	q := config.SupabaseClient.From("properties").Select("*", "", false)
+	q := r.client.From("properties").Select("*", "", false)
 	if strings.TrimSpace(city) != "" {
 		q = q.Filter("city", "ilike", fmt.Sprintf("%%%s%%", strings.TrimSpace(city)))
 	}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"hotelbooking/internal/models"
+	"strings"
+)
+
+func (r *propertyRepo) CreateRatePlan(plan models.RatePlan) error {
+	if r.client == nil {
+		return fmt.Errorf("supabase client is not initialized")
+	}
+	_, _, err := r.client.
+		From("rate_plans").
+		Insert(plan, false, "", "", "").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("gagal membuat rate plan: %v", err)
+	}
+	return nil
+}
+
+func (r *propertyRepo) UpdateRatePlan(plan models.RatePlan) (*models.RatePlan, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	updates := map[string]any{
+		"code":                plan.Code,
+		"name":                plan.Name,
+		"rate_type":           plan.RateType,
+		"breakfast_included":  plan.BreakfastIncluded,
+		"refundable":          plan.Refundable,
+		"cancellation_policy": plan.CancellationPolicy,
+		"is_active":           plan.IsActive,
+		"parent_rate_plan_id": plan.ParentRatePlanID,
+		"derivation_type":     plan.DerivationType,
+		"derivation_value":    plan.DerivationValue,
+	}
+	resp, _, err := r.client.
+		From("rate_plans").
+		Update(updates, "", "").
+		Eq("id", plan.ID.String()).
+		Single().
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal memperbarui rate plan: %v", err)
+	}
+	var updated models.RatePlan
+	if err := json.Unmarshal(resp, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (r *propertyRepo) DeleteRatePlan(id string) error {
+	if r.client == nil {
+		return fmt.Errorf("supabase client is not initialized")
+	}
+	_, _, err := r.client.
+		From("rate_plans").
+		Delete("", "").
+		Eq("id", id).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("gagal menghapus rate plan: %v", err)
+	}
+	return nil
+}
+
+func (r *propertyRepo) ListRatePlans(propertyID string) ([]models.RatePlan, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	q := r.client.
+		From("rate_plans").
+		Select("*", "", false)
+	if strings.TrimSpace(propertyID) != "" {
+		q = q.Eq("property_id", propertyID)
+	}
+	resp, _, err := q.Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil daftar rate plan: %v", err)
+	}
+	var plans []models.RatePlan
+	if err := json.Unmarshal(resp, &plans); err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+func (r *propertyRepo) GetRatePlanByID(id string) (*models.RatePlan, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("rate_plans").
+		Select("*", "", false).
+		Eq("id", id).
+		Single().
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil rate plan: %v", err)
+	}
+	var plan models.RatePlan
+	if err := json.Unmarshal(resp, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// SetPropertyCancellationRules mengganti aturan pembatalan property; nil
+// kembali ke aturan default.
+func (r *propertyRepo) SetPropertyCancellationRules(propertyID string, rules *models.CancellationPolicy) (*models.Properties, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("properties").
+		Update(map[string]any{"cancellation_rules": rules}, "", "").
+		Eq("id", propertyID).
+		Single().
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal menyimpan aturan pembatalan: %v", err)
+	}
+	var updated models.Properties
+	if err := json.Unmarshal(resp, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// SetPropertyPaymentSchedule mengganti jadwal deposit/pelunasan property;
+// nil kembali ke pembayaran penuh saat booking.
+func (r *propertyRepo) SetPropertyPaymentSchedule(propertyID string, schedule *models.PaymentSchedulePolicy) (*models.Properties, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("properties").
+		Update(map[string]any{"payment_schedule": schedule}, "", "").
+		Eq("id", propertyID).
+		Single().
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal menyimpan jadwal pembayaran: %v", err)
+	}
+	var updated models.Properties
+	if err := json.Unmarshal(resp, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// SetPropertyTaxRules mengganti seluruh aturan pajak property; list kosong
+// berarti harga tanpa pajak.
+func (r *propertyRepo) SetPropertyTaxRules(propertyID string, rules []models.TaxRule) (*models.Properties, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("properties").
+		Update(map[string]any{"tax_rules": rules}, "", "").
+		Eq("id", propertyID).
+		Single().
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal menyimpan aturan pajak: %v", err)
+	}
+	var updated models.Properties
+	if err := json.Unmarshal(resp, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// SetRatePlanCancellationRules mengganti aturan pembatalan rate plan; nil
+// berarti mengikuti aturan property.
+func (r *propertyRepo) SetRatePlanCancellationRules(planID string, rules *models.CancellationPolicy) (*models.RatePlan, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("supabase client is not initialized")
+	}
+	resp, _, err := r.client.
+		From("rate_plans").
+		Update(map[string]any{"cancellation_rules": rules}, "", "").
+		Eq("id", planID).
+		Single().
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("gagal menyimpan aturan pembatalan: %v", err)
+	}
+	var updated models.RatePlan
+	if err := json.Unmarshal(resp, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import "github.com/supabase-community/supabase-go"
+
+// Repositories mengelompokkan semua repository yang dipakai oleh layer service,
+// sehingga backend storage (Supabase, in-memory, dll) bisa ditukar di satu tempat.
+type Repositories struct {
+	Guest    GuestRepo
+	Admin    AdminRepo
+	Property PropertyRepo
+	Booking  BookingRepo
+	Payment  PaymentRepo
+}
+
+// NewSupabaseRepositories membangun semua repository di atas satu client Supabase.
+func NewSupabaseRepositories(client *supabase.Client) *Repositories {
+	return &Repositories{
+		Guest:    NewGuestRepo(client),
+		Admin:    NewAdminRepo(client),
+		Property: NewPropertyRepo(client),
+		Booking:  NewBookingRepo(client),
+		Payment:  NewPaymentRepo(client),
+	}
+}
+
+// UnitOfWork menjalankan beberapa operasi repository sebagai satu kesatuan.
+// Repository yang diberikan ke fn terikat pada transaksi yang sama; jika fn
+// mengembalikan error, seluruh perubahan dibatalkan (bila backend mendukung).
+type UnitOfWork interface {
+	Do(fn func(tx *Repositories) error) error
+}
+
+type supabaseUnitOfWork struct {
+	repos *Repositories
+}
+
+// NewSupabaseUnitOfWork membungkus repository Supabase. PostgREST tidak
+// mendukung transaksi lintas request, jadi fn dijalankan tanpa rollback;
+// gunakan backend postgres jika butuh atomicity penuh.
+func NewSupabaseUnitOfWork(repos *Repositories) UnitOfWork {
+	return &supabaseUnitOfWork{repos: repos}
+}
+
+func (u *supabaseUnitOfWork) Do(fn func(tx *Repositories) error) error {
+	return fn(u.repos)
+}
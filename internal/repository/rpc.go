@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/supabase-community/supabase-go"
+)
+
+// rpcError adalah body error PostgREST dari function database, misalnya
+// raise exception di dalam function atau pelanggaran constraint.
+type rpcError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// callRPC memanggil function database (lihat supabase/migrations) yang
+// mengembalikan setof baris dan men-decode hasilnya ke out. supabase-go tidak
+// mengembalikan status HTTP, jadi body yang bukan array dianggap error.
+func callRPC(client *supabase.Client, name string, params any, out any) error {
+	if client == nil {
+		return fmt.Errorf("supabase client is not initialized")
+	}
+	body := strings.TrimSpace(client.Rpc(name, "", params))
+	if strings.HasPrefix(body, "[") {
+		return json.Unmarshal([]byte(body), out)
+	}
+	var rpcErr rpcError
+	if err := json.Unmarshal([]byte(body), &rpcErr); err != nil || rpcErr.Message == "" {
+		return fmt.Errorf("gagal memanggil %s", name)
+	}
+	return &rpcErr
+}
+
+// isRPCException mengecek apakah err berasal dari raise exception dengan
+// pesan message di function database.
+func isRPCException(err error, message string) bool {
+	var rpcErr *rpcError
+	return errors.As(err, &rpcErr) && rpcErr.Message == message
+}
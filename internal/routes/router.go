@@ -1,17 +1,40 @@
 package routes
 
 import (
+	"context"
+	"fmt"
+	"hotelbooking/internal/config"
+	"hotelbooking/internal/gateway"
 	"hotelbooking/internal/handler"
 	"hotelbooking/internal/middleware"
 	"hotelbooking/internal/repository"
 	"hotelbooking/internal/service"
+	"log"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 	echoSwagger "github.com/swaggo/echo-swagger"
 )
 
-func SetupRoutes(e *echo.Echo) {
+// NewPaymentGateways mendaftarkan payment gateway yang dikonfigurasi. Mock
+// gateway hanya didaftarkan jika allowMock; tanpa provider sama sekali server
+// tidak boleh jalan karena status lunas hanya datang dari gateway.
+func NewPaymentGateways(allowMock bool) (*gateway.Registry, error) {
+	gateways := gateway.NewRegistry()
+	if allowMock {
+		gateways.Register(gateway.NewMockGateway(gateway.MockConfig{
+			Outcome:       gateway.MockOutcome(config.PaymentMockOutcome()),
+			Delay:         config.PaymentMockDelay(),
+			WebhookSecret: config.PaymentWebhookSecret(gateway.MockProvider),
+		}))
+	}
+	if len(gateways.Providers()) == 0 {
+		return nil, fmt.Errorf("no payment gateway configured (set PAYMENT_MOCK_ENABLED=true for development)")
+	}
+	return gateways, nil
+}
+
+func SetupRoutes(e *echo.Echo, repos *repository.Repositories, uow repository.UnitOfWork, gateways *gateway.Registry) {
 	// Health check
 	e.GET("/", func(c echo.Context) error {
 		return c.String(http.StatusOK, "Hotel Booking API is running!")
@@ -23,25 +46,35 @@ func SetupRoutes(e *echo.Echo) {
 	// ======================
 	// REPOSITORIES
 	// ======================
-	guestRepo := repository.NewGuestRepo()
-	adminRepo := repository.NewAdminRepo()
-	propertyRepo := repository.NewPropertyRepo()
-	bookingRepo := repository.NewBookingRepo()
-	paymentRepo := repository.NewPaymentRepo()
+	guestRepo := repos.Guest
+	adminRepo := repos.Admin
+	propertyRepo := repos.Property
+	bookingRepo := repos.Booking
+	paymentRepo := repos.Payment
 
 	// ======================
 	// SERVICES (DOMAIN BASED)
 	// ======================
-	// Guest domain: auth + experience (search hotel, bookings, profile)
-	guestSvc := service.NewGuestService(guestRepo, propertyRepo, bookingRepo)
 
 	// Admin domain: login + (nanti) manajemen admin
 	adminSvc := service.NewAdminService(adminRepo)
 
+	// Index pencarian teks hotel, dibangun sekali lalu diperbarui oleh inventory
+	searchIndex := service.NewSearchIndex(propertyRepo)
+	if err := searchIndex.Rebuild(); err != nil {
+		log.Printf("search index: gagal membangun index: %v", err)
+	}
+
 	// Inventory domain (admin kelola hotel/room/room-type)
-	inventorySvc := service.NewInventoryService(propertyRepo)
-	bookingSvc := service.NewBookingService(bookingRepo, propertyRepo, paymentRepo)
-	reportSvc := service.NewReportService(bookingRepo, propertyRepo)
+	inventorySvc := service.NewInventoryService(propertyRepo, searchIndex)
+	bookingSvc := service.NewBookingService(bookingRepo, propertyRepo, paymentRepo, uow, gateways, service.LogPaymentNotifier{}, config.BookingHoldTTL())
+	reportSvc := service.NewReportService(bookingRepo, propertyRepo, guestRepo)
+
+	// Guest domain: auth + experience (search hotel, bookings, profile)
+	guestSvc := service.NewGuestService(guestRepo, propertyRepo, bookingRepo, searchIndex)
+
+	// Background job: lepas hold booking yang tidak dibayar
+	service.StartHoldSweeper(context.Background(), bookingSvc, config.BookingSweepInterval())
 
 	// ======================
 	// HANDLERS
@@ -51,6 +84,7 @@ func SetupRoutes(e *echo.Echo) {
 	inventoryHandler := handler.NewInventoryHandler(inventorySvc)
 	reportHandler := handler.NewReportHandler(reportSvc)
 	bookingHandler := handler.NewBookingHandler(bookingSvc)
+	webhookHandler := handler.NewWebhookHandler(bookingSvc)
 
 	// ======================
 	// PUBLIC ROUTES
@@ -64,9 +98,15 @@ func SetupRoutes(e *echo.Echo) {
 	api.POST("/auth/admin/login", adminHandler.Login)
 
 	// Guest Experience (tanpa login: explore hotel)
-	api.GET("/hotels", guestHandler.SearchHotels)       // ?city=Jakarta
-	api.GET("/hotels/:id", guestHandler.GetHotelDetail) // detail 1 hotel
+	api.GET("/hotels", guestHandler.SearchHotels)               // ?city=Jakarta
+	api.GET("/hotels/autocomplete", guestHandler.Autocomplete)  // ?q=jog
+	api.GET("/hotels/:id", guestHandler.GetHotelDetail)         // detail 1 hotel
+	api.GET("/hotels/:id/calendar", bookingHandler.GetCalendar) // ?from=&to=
 	api.GET("/rooms/:room_id/availability", bookingHandler.CheckAvailability)
+	api.GET("/room-types/:room_type_id/availability", bookingHandler.CheckRoomTypeAvailability)
+
+	// Webhook payment gateway (tanpa token, diverifikasi lewat signature)
+	api.POST("/webhooks/payments/:provider", webhookHandler.PaymentWebhook)
 
 	// ======================
 	// PROTECTED ROUTES (BUTUH TOKEN)
@@ -79,8 +119,22 @@ func SetupRoutes(e *echo.Echo) {
 	guestGroup.GET("/me", guestHandler.GetMyProfile)
 	guestGroup.POST("/bookings", bookingHandler.CreateBooking)
 	guestGroup.POST("/bookings/:id/pay", bookingHandler.PayBooking)
+	guestGroup.GET("/bookings/:id/payment", bookingHandler.GetPaymentStatus)
+	guestGroup.GET("/bookings/:id/payments", bookingHandler.GetPaymentSchedule)
+	guestGroup.POST("/payments/:id/split", bookingHandler.SplitPayment)
+	guestGroup.PATCH("/bookings/:id", bookingHandler.ModifyBooking)
+	guestGroup.GET("/bookings/:id/cancellation-preview", bookingHandler.PreviewCancellation)
 	guestGroup.POST("/bookings/:id/cancel", bookingHandler.CancelBooking)
+	guestGroup.GET("/bookings/:id/refunds", bookingHandler.ListRefunds)
 	guestGroup.GET("/bookings/:id/invoice", bookingHandler.GetInvoice)
+	guestGroup.GET("/bookings/:id/folio", bookingHandler.GetFolio)
+	guestGroup.GET("/bookings/:id/occupants", bookingHandler.GetOccupants)
+	guestGroup.PUT("/bookings/:id/occupants", bookingHandler.SetOccupants)
+	guestGroup.POST("/reservations", bookingHandler.CreateReservation)
+	guestGroup.GET("/reservations/:id", bookingHandler.GetReservation)
+	guestGroup.POST("/reservations/:id/pay", bookingHandler.PayReservation)
+	guestGroup.GET("/reservations/:id/payment", bookingHandler.GetReservationPaymentStatus)
+	guestGroup.POST("/reservations/:id/rooms/:booking_id/cancel", bookingHandler.CancelReservationRoom)
 
 	// Group khusus Admin (butuh AuthMiddleware)
 	adminGroup := api.Group("/admin")
@@ -92,11 +146,21 @@ func SetupRoutes(e *echo.Echo) {
 	adminGroup.GET("/hotels", inventoryHandler.ListHotels)
 	adminGroup.PUT("/hotels/:id", inventoryHandler.UpdateHotel)
 	adminGroup.DELETE("/hotels/:id", inventoryHandler.DeleteHotel)
+	adminGroup.PUT("/hotels/:id/cancellation-policy", inventoryHandler.SetHotelCancellationPolicy)
+	adminGroup.PUT("/hotels/:id/payment-schedule", inventoryHandler.SetHotelPaymentSchedule)
+	adminGroup.PUT("/hotels/:id/tax-rules", inventoryHandler.SetHotelTaxRules)
 
 	adminGroup.POST("/room-types", inventoryHandler.CreateRoomType)
 	adminGroup.PUT("/room-types/:id", inventoryHandler.UpdateRoomType)
 	adminGroup.DELETE("/room-types/:id", inventoryHandler.DeleteRoomType)
 	adminGroup.GET("/room-types", inventoryHandler.ListRoomTypes)
+	adminGroup.POST("/room-types/:room_type_id/rates", inventoryHandler.SetRoomTypeRates)
+	adminGroup.GET("/room-types/:room_type_id/rates", inventoryHandler.GetRoomTypeRates)
+	adminGroup.POST("/rate-plans", inventoryHandler.CreateRatePlan)
+	adminGroup.PUT("/rate-plans/:id", inventoryHandler.UpdateRatePlan)
+	adminGroup.DELETE("/rate-plans/:id", inventoryHandler.DeleteRatePlan)
+	adminGroup.PUT("/rate-plans/:id/cancellation-policy", inventoryHandler.SetRatePlanCancellationPolicy)
+	adminGroup.GET("/rate-plans", inventoryHandler.ListRatePlans)
 
 	adminGroup.POST("/rooms", inventoryHandler.CreateRoom)
 	adminGroup.PUT("/rooms/:id", inventoryHandler.UpdateRoom)
@@ -123,8 +187,24 @@ func SetupRoutes(e *echo.Echo) {
 
 	// Booking oversight
 	adminGroup.GET("/bookings", adminHandler.ListBookings)
+	adminGroup.PATCH("/bookings/:id", adminHandler.ModifyBooking)
 	adminGroup.PUT("/bookings/:id/status", adminHandler.UpdateBookingStatus)
+	adminGroup.GET("/bookings/:id/history", adminHandler.BookingHistory)
+	adminGroup.GET("/bookings/:id/modifications", adminHandler.BookingModifications)
+	adminGroup.GET("/bookings/:id/cancellation-preview", adminHandler.PreviewCancellation)
+	adminGroup.POST("/bookings/:id/refunds", adminHandler.IssueRefund)
+	adminGroup.GET("/bookings/:id/refunds", adminHandler.ListRefunds)
+	adminGroup.GET("/bookings/:id/payments", adminHandler.GetPaymentSchedule)
+	adminGroup.GET("/bookings/:id/folio", adminHandler.GetFolio)
+	adminGroup.POST("/bookings/:id/folio/charges", adminHandler.PostFolioCharge)
+	adminGroup.POST("/bookings/:id/folio/adjustments", adminHandler.AdjustFolioCharge)
+	adminGroup.POST("/bookings/:id/folio/charges/:charge_id/void", adminHandler.VoidFolioCharge)
+	adminGroup.POST("/bookings/:id/assign-room", adminHandler.AssignRoom)
+	adminGroup.GET("/bookings/:id/occupants", adminHandler.GetOccupants)
+	adminGroup.PUT("/bookings/:id/occupants", adminHandler.SetOccupants)
+	adminGroup.GET("/reservations/:code", adminHandler.GetReservation)
 
 	// Reports
 	adminGroup.GET("/reports/summary", reportHandler.Summary)
+	adminGroup.GET("/reports/police-registration", reportHandler.PoliceRegistration) // ?date=&format=csv
 }
@@ -0,0 +1,215 @@
+package service_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"hotelbooking/internal/gateway"
+	"hotelbooking/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// TestCreateBookingConcurrent menembakkan N CreateBooking paralel untuk kamar
+// dan malam yang sama; tepat satu boleh berhasil.
+func TestCreateBookingConcurrent(t *testing.T) {
+	const n = 25
+	for _, backend := range testBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			hotel := newTestHotel(t, backend, 500000)
+			bookings := newTestBookingService(backend)
+			checkIn := stayDate(3)
+
+			var (
+				wg          sync.WaitGroup
+				mu          sync.Mutex
+				successes   int
+				unavailable int
+				others      []error
+			)
+			start := make(chan struct{})
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					_, err := bookings.CreateBooking(service.CreateBookingInput{
+						GuestID:    uuid.NewString(),
+						PropertyID: hotel.propertyID,
+						RoomID:     hotel.roomID,
+						CheckIn:    checkIn,
+						CheckOut:   checkIn.AddDate(0, 0, 2),
+					})
+					mu.Lock()
+					defer mu.Unlock()
+					switch {
+					case err == nil:
+						successes++
+					case errors.Is(err, service.ErrRoomUnavailable):
+						unavailable++
+					default:
+						others = append(others, err)
+					}
+				}()
+			}
+			close(start)
+			wg.Wait()
+
+			if len(others) > 0 {
+				t.Fatalf("unexpected errors: %v", others)
+			}
+			if successes != 1 || unavailable != n-1 {
+				t.Fatalf("got %d successes and %d ErrRoomUnavailable, want 1 and %d", successes, unavailable, n-1)
+			}
+		})
+	}
+}
+
+// TestCancelBookingConcurrent membatalkan booking yang sudah dibayar dari
+// banyak request sekaligus; hanya satu pembatalan dan satu refund yang
+// boleh tercatat.
+func TestCancelBookingConcurrent(t *testing.T) {
+	const n = 10
+	for _, backend := range testBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			gw := gateway.NewMockGateway(gateway.MockConfig{})
+			bookings := newTestBookingService(backend, gw)
+			hotel := newTestHotel(t, backend, 500000)
+			guestID := uuid.NewString()
+			checkIn := stayDate(10)
+			created, err := bookings.CreateBooking(service.CreateBookingInput{
+				GuestID:    guestID,
+				PropertyID: hotel.propertyID,
+				RoomID:     hotel.roomID,
+				CheckIn:    checkIn,
+				CheckOut:   checkIn.AddDate(0, 0, 1),
+			})
+			if err != nil {
+				t.Fatalf("create booking: %v", err)
+			}
+			bookingID := created.Booking.ID.String()
+			if _, err := bookings.StartPayment(guestID, bookingID, "", gateway.MockProvider, time.Now()); err != nil {
+				t.Fatalf("start payment: %v", err)
+			}
+			if _, err := bookings.SyncPayment(guestID, bookingID, time.Now()); err != nil {
+				t.Fatalf("sync payment: %v", err)
+			}
+
+			var (
+				wg        sync.WaitGroup
+				mu        sync.Mutex
+				successes int
+			)
+			start := make(chan struct{})
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					_, _, err := bookings.CancelBooking(guestID, bookingID, time.Now())
+					mu.Lock()
+					defer mu.Unlock()
+					if err == nil {
+						successes++
+					}
+				}()
+			}
+			close(start)
+			wg.Wait()
+
+			if successes != 1 {
+				t.Fatalf("got %d successful cancellations, want 1", successes)
+			}
+			refunds, err := bookings.ListRefunds(guestID, bookingID)
+			if err != nil {
+				t.Fatalf("list refunds: %v", err)
+			}
+			if len(refunds) != 1 {
+				t.Fatalf("got %d refunds, want 1", len(refunds))
+			}
+		})
+	}
+}
+
+// TestModifyBookingConcurrent mengubah lama menginap booking yang sama dari
+// banyak request sekaligus. Perubahan yang kalah balapan ditolak, dan
+// tagihan yang tersisa harus tetap sama dengan total booking terakhir.
+func TestModifyBookingConcurrent(t *testing.T) {
+	const n = 10
+	for _, backend := range testBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			bookings := newTestBookingService(backend)
+			hotel := newTestHotel(t, backend, 500000)
+			guestID := uuid.NewString()
+			checkIn := stayDate(10)
+			created, err := bookings.CreateBooking(service.CreateBookingInput{
+				GuestID:    guestID,
+				PropertyID: hotel.propertyID,
+				RoomID:     hotel.roomID,
+				CheckIn:    checkIn,
+				CheckOut:   checkIn.AddDate(0, 0, 1),
+			})
+			if err != nil {
+				t.Fatalf("create booking: %v", err)
+			}
+			bookingID := created.Booking.ID.String()
+
+			var (
+				wg        sync.WaitGroup
+				mu        sync.Mutex
+				successes int
+				others    []error
+			)
+			start := make(chan struct{})
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func(nights int) {
+					defer wg.Done()
+					<-start
+					_, err := bookings.ModifyBooking(guestID, bookingID, service.ModifyBookingInput{
+						CheckOut: checkIn.AddDate(0, 0, nights),
+					}, service.GuestActor(guestID), time.Now())
+					mu.Lock()
+					defer mu.Unlock()
+					switch {
+					case err == nil:
+						successes++
+					case errors.Is(err, service.ErrBookingStatusConflict):
+					default:
+						others = append(others, err)
+					}
+				}(2 + i%3)
+			}
+			close(start)
+			wg.Wait()
+
+			if len(others) > 0 {
+				t.Fatalf("unexpected errors: %v", others)
+			}
+			if successes == 0 {
+				t.Fatalf("no modification succeeded")
+			}
+			booking, err := bookings.GetBookingByID(bookingID)
+			if err != nil {
+				t.Fatalf("get booking: %v", err)
+			}
+			schedule, err := bookings.GetPaymentSchedule(guestID, bookingID)
+			if err != nil {
+				t.Fatalf("payment schedule: %v", err)
+			}
+			if schedule.Outstanding != booking.TotalPrice {
+				t.Fatalf("got outstanding %v, want booking total %v", schedule.Outstanding, booking.TotalPrice)
+			}
+
+			// Total lama yang basi selalu ditolak, tidak bergantung pada
+			// urutan goroutine di atas
+			stale := *booking
+			stale.CheckOut = checkIn.AddDate(0, 0, 1)
+			if _, err := backend.repos.Booking.UpdateBookingStay(stale, booking.TotalPrice-1); !errors.Is(err, service.ErrBookingStatusConflict) {
+				t.Fatalf("got %v for stale total, want ErrBookingStatusConflict", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultModificationCutoff dipakai jika property belum mengatur
+// modification_cutoff_hours.
+const defaultModificationCutoff = 24 * time.Hour
+
+// ModifyBookingInput berisi bagian booking yang ingin diubah; field kosong
+// (atau nil) berarti tetap. Nomor kamar yang sudah ter-assign dipertahankan
+// selama tipe kamarnya tidak berubah. Children tanpa ChildAges menghapus
+// umur anak yang tersimpan.
+type ModifyBookingInput struct {
+	CheckIn    time.Time
+	CheckOut   time.Time
+	RoomID     string
+	RoomTypeID string
+	RatePlanID string
+	Adults     *int
+	Children   *int
+	ChildAges  []int
+}
+
+// BookingModificationResult berisi booking setelah diubah beserta cicilan
+// terbarunya. Modification.AmountDue adalah tambahan yang harus dibayar,
+// Modification.RefundAmount kelebihan bayar yang dikembalikan.
+type BookingModificationResult struct {
+	Booking      *models.Booking             `json:"booking"`
+	Payments     []models.Payment            `json:"payments,omitempty"`
+	Invoice      *models.Invoice             `json:"invoice,omitempty"`
+	Quote        *BookingQuote               `json:"quote"`
+	Modification *models.BookingModification `json:"modification"`
+}
+
+// ModifyBooking memindahkan tanggal, kamar, rate plan, atau jumlah tamu
+// booking. Stay baru di-quote ulang lewat QuoteBooking, inventori ditukar
+// atomik, dan selisih harga menjadi tagihan tambahan (cicilan Pending
+// terakhir bertambah, atau cicilan adjustment baru jika semua sudah lunas)
+// atau refund sebagian (cicilan yang belum dibayar turun lebih dulu). guestID kosong
+// berarti dipanggil admin, yang tidak terikat batas waktu dan aturan
+// non-refundable property.
+func (s *bookingService) ModifyBooking(guestID, bookingID string, input ModifyBookingInput, actor string, now time.Time) (*BookingModificationResult, error) {
+	booking, err := s.repo.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if guestID != "" && (booking.GuestID == nil || booking.GuestID.String() != guestID) {
+		return nil, fmt.Errorf("booking tidak ditemukan")
+	}
+	if isHoldExpired(booking, now) {
+		return nil, fmt.Errorf("batas waktu pembayaran booking sudah lewat")
+	}
+	if booking.Status != models.BookingStatusNew && booking.Status != models.BookingStatusConfirmed {
+		return nil, fmt.Errorf("booking dengan status %s tidak dapat diubah", booking.Status)
+	}
+
+	target, quoteInput, err := s.modificationTarget(booking, input)
+	if err != nil {
+		return nil, err
+	}
+	if guestID != "" {
+		if err := s.checkModificationPolicy(booking, target.CheckIn, now); err != nil {
+			return nil, err
+		}
+	}
+	occupants, err := s.repo.ListOccupants([]string{bookingID})
+	if err != nil {
+		return nil, err
+	}
+	if len(occupants) > target.Adults+target.Children {
+		return nil, fmt.Errorf("jumlah tamu bernama melebihi jumlah tamu baru, ubah daftar tamu terlebih dahulu")
+	}
+
+	quote, err := s.QuoteBooking(quoteInput)
+	if err != nil {
+		return nil, err
+	}
+	if quote.RatePlan == nil && len(quote.Offers) > 0 {
+		return nil, fmt.Errorf("rate_plan_id wajib diisi")
+	}
+	// Stay lama booking ini ikut terhitung terisi oleh quote; ketersediaan
+	// sebenarnya dicek repository tanpa booking ini
+	if reasons := withoutInventoryReasons(quote.Reasons); len(reasons) > 0 {
+		return nil, quoteUnavailableError(reasons)
+	}
+	target.Nights = quote.Nights
+	target.Adults, target.Children, target.ChildAges = quote.Adults, quote.Children, quote.ChildAges
+	target.TotalPrice = quote.TotalPrice
+	target.TaxAmount = quote.TaxAmount
+	target.RatePlanID = nil
+	if quote.RatePlan != nil {
+		target.RatePlanID = &quote.RatePlan.ID
+	}
+
+	difference := roundAmount(target.TotalPrice - booking.TotalPrice)
+	modification := models.BookingModification{
+		ID:                 uuid.New(),
+		BookingID:          &booking.ID,
+		Actor:              actor,
+		PreviousCheckIn:    booking.CheckIn,
+		PreviousCheckOut:   booking.CheckOut,
+		PreviousRoomTypeID: booking.RoomTypeID,
+		PreviousRoomID:     booking.RoomID,
+		PreviousRatePlanID: booking.RatePlanID,
+		PreviousAdults:     booking.Adults,
+		PreviousChildren:   booking.Children,
+		PreviousTotal:      booking.TotalPrice,
+		NewTotal:           target.TotalPrice,
+		CreatedAt:          now,
+	}
+
+	result := &BookingModificationResult{Quote: quote, Modification: &modification}
+	err = s.uow.Do(func(tx *repository.Repositories) error {
+		// Hold basi yang belum disapu masih mengunci kamar di database
+		if _, err := expireHolds(tx, now); err != nil {
+			return err
+		}
+		var err error
+		// Compare-and-set pada status dan total lama: modifikasi yang balapan
+		// dengan pembatalan atau modifikasi lain gagal dengan
+		// ErrBookingStatusConflict, jadi difference tidak pernah basi
+		if result.Booking, err = tx.Booking.UpdateBookingStay(target, booking.TotalPrice); err != nil {
+			return err
+		}
+		if result.Payments, result.Invoice, err = settleModification(tx, booking, difference, quote.TaxLines, &modification); err != nil {
+			return err
+		}
+		return tx.Booking.CreateModification(modification)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if modification.RefundAmount > 0 {
+		target, err := s.bookingPaymentOwner(result.Booking)
+		if err != nil {
+			return nil, err
+		}
+		// Perubahan tetap berlaku walaupun refund gagal; hasilnya
+		// dikembalikan bersama ErrRefundIncomplete.
+		refunded, err := s.refundAfterCommit(target, &booking.ID, modification.RefundAmount, RefundReasonModification, actor, now)
+		if refunded != nil {
+			result.Payments, result.Invoice = refunded.Payments, refunded.Invoice
+		}
+		return result, err
+	}
+	return result, nil
+}
+
+// GetModifications mengembalikan riwayat perubahan booking, terlama dulu.
+func (s *bookingService) GetModifications(bookingID string) ([]models.BookingModification, error) {
+	if bookingID == "" {
+		return nil, fmt.Errorf("booking_id wajib diisi")
+	}
+	return s.repo.ListModifications(bookingID)
+}
+
+// modificationTarget menggabungkan input dengan booking lama menjadi booking
+// tujuan (harga belum dihitung) dan input quote-nya.
+func (s *bookingService) modificationTarget(booking *models.Booking, input ModifyBookingInput) (models.Booking, QuoteInput, error) {
+	target := *booking
+	if !input.CheckIn.IsZero() {
+		target.CheckIn = input.CheckIn
+	}
+	if !input.CheckOut.IsZero() {
+		target.CheckOut = input.CheckOut
+	}
+	if input.Adults != nil {
+		target.Adults = *input.Adults
+	}
+	if input.Children != nil {
+		target.Children = *input.Children
+		target.ChildAges = nil
+	}
+	if input.ChildAges != nil {
+		target.ChildAges = input.ChildAges
+		if input.Children == nil {
+			target.Children = 0
+		}
+	}
+
+	switch {
+	case input.RoomID != "":
+		room, err := s.propRepo.GetRoomByID(input.RoomID)
+		if err != nil {
+			return models.Booking{}, QuoteInput{}, err
+		}
+		if !sameProperty(room.PropertyID, booking.PropertyID) {
+			return models.Booking{}, QuoteInput{}, fmt.Errorf("room tidak berada di property booking")
+		}
+		target.RoomID, target.RoomTypeID = &room.ID, room.RoomTypeID
+	case input.RoomTypeID != "":
+		roomType, err := s.propRepo.GetRoomTypeByID(input.RoomTypeID)
+		if err != nil {
+			return models.Booking{}, QuoteInput{}, err
+		}
+		if !sameProperty(roomType.PropertyID, booking.PropertyID) {
+			return models.Booking{}, QuoteInput{}, fmt.Errorf("room type tidak berada di property booking")
+		}
+		if booking.RoomTypeID == nil || *booking.RoomTypeID != roomType.ID {
+			target.RoomID = nil
+		}
+		target.RoomTypeID = &roomType.ID
+	}
+
+	quoteInput := QuoteInput{
+		CheckIn:   target.CheckIn,
+		CheckOut:  target.CheckOut,
+		Adults:    target.Adults,
+		Children:  target.Children,
+		ChildAges: target.ChildAges,
+	}
+	if target.RoomID != nil {
+		quoteInput.RoomID = target.RoomID.String()
+	} else if target.RoomTypeID != nil {
+		quoteInput.RoomTypeID = target.RoomTypeID.String()
+	}
+	if input.RatePlanID != "" {
+		quoteInput.RatePlanID = input.RatePlanID
+	} else if booking.RatePlanID != nil {
+		quoteInput.RatePlanID = booking.RatePlanID.String()
+	}
+	return target, quoteInput, nil
+}
+
+// checkModificationPolicy menerapkan aturan property untuk perubahan oleh
+// tamu: booking non-refundable tidak bisa diubah, dan stay lama maupun baru
+// harus dimulai setelah batas waktu perubahan.
+func (s *bookingService) checkModificationPolicy(booking *models.Booking, newCheckIn time.Time, now time.Time) error {
+	policy, _, err := s.cancellationPolicyFor(booking)
+	if err != nil {
+		return err
+	}
+	if policy.NonRefundable {
+		return fmt.Errorf("booking non-refundable tidak dapat diubah")
+	}
+	cutoff := defaultModificationCutoff
+	if booking.PropertyID != nil {
+		property, err := s.propRepo.GetPropertyByID(booking.PropertyID.String())
+		if err != nil {
+			return err
+		}
+		if property.ModificationCutoffHours != nil {
+			cutoff = time.Duration(*property.ModificationCutoffHours) * time.Hour
+		}
+	}
+	for _, checkIn := range []time.Time{booking.CheckIn, newCheckIn} {
+		if now.After(checkIn.Add(-cutoff)) {
+			return fmt.Errorf("booking hanya dapat diubah paling lambat %d jam sebelum check-in", int(cutoff.Hours()))
+		}
+	}
+	return nil
+}
+
+// settleModification memposting selisih harga sebagai koreksi tagihan kamar
+// di folio, lalu menyamakan cicilan dan invoice (milik booking atau
+// reservasinya) dengan tagihan baru. Penurunan yang sudah dibayar dicatat
+// sebagai refund yang dieksekusi setelah transaksi. taxLines adalah rincian
+// pajak stay baru; koreksi membawa selisihnya terhadap pajak kamar di folio.
+func settleModification(tx *repository.Repositories, booking *models.Booking, difference float64, taxLines []models.TaxLine, modification *models.BookingModification) ([]models.Payment, *models.Invoice, error) {
+	target := paymentOwner(booking)
+	if booking.ReservationID != nil {
+		reservation, err := tx.Booking.GetReservationByID(target.reservationID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := tx.Booking.UpdateReservationTotal(target.reservationID, roundAmount(reservation.TotalPrice+difference)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	payments, err := target.payments(tx.Payment)
+	if err != nil {
+		return nil, nil, err
+	}
+	charges, err := tx.Payment.ListFolioCharges(booking.ID.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	var roomCharges []models.FolioCharge
+	for _, charge := range charges {
+		if charge.ChargeCode == models.ChargeCodeRoom {
+			roomCharges = append(roomCharges, charge)
+		}
+	}
+	taxDelta := mergeTaxLines(taxLines, scaleTaxLines(folioTaxLines(roomCharges), -1))
+	if difference != 0 || len(taxDelta) > 0 {
+		inclusive, exclusive := sumTaxLines(taxDelta)
+		adjustment := models.FolioCharge{
+			ID:          uuid.New(),
+			BookingID:   &booking.ID,
+			Kind:        models.FolioChargeKindAdjustment,
+			ChargeCode:  models.ChargeCodeRoom,
+			Description: "Perubahan booking",
+			Quantity:    1,
+			UnitPrice:   roundAmount(difference - exclusive),
+			TaxAmount:   roundAmount(inclusive + exclusive),
+			TaxLines:    taxDelta,
+			Amount:      difference,
+			Status:      models.FolioChargeStatusPosted,
+			PostedBy:    modification.Actor,
+			PostedAt:    modification.CreatedAt,
+		}
+		if err := tx.Payment.CreateFolioCharge(adjustment); err != nil {
+			return nil, nil, err
+		}
+	}
+	if difference > 0 && netPaid(payments) > 0 {
+		modification.AmountDue = difference
+	}
+	if modification.RefundAmount, err = settlePayments(tx, booking, difference, modification.CreatedAt); err != nil {
+		return nil, nil, err
+	}
+
+	if err := syncInvoiceAmount(tx, target); err != nil {
+		return nil, nil, err
+	}
+	invoice, err := target.syncInvoice(tx.Payment)
+	if err != nil {
+		return nil, nil, err
+	}
+	if payments, err = target.payments(tx.Payment); err != nil {
+		return nil, nil, err
+	}
+	return payments, invoice, nil
+}
+
+// lastPendingPayment adalah cicilan Pending yang jatuh tempo paling akhir.
+func lastPendingPayment(payments []models.Payment) *models.Payment {
+	var last *models.Payment
+	for i := range payments {
+		payment := &payments[i]
+		if payment.Status != models.PaymentStatusPending {
+			continue
+		}
+		if last == nil || !dueBefore(payment, last) {
+			last = payment
+		}
+	}
+	return last
+}
+
+// withoutInventoryReasons membuang alasan kamar/tipe kamar penuh dari quote.
+func withoutInventoryReasons(reasons []QuoteReason) []QuoteReason {
+	kept := make([]QuoteReason, 0, len(reasons))
+	for _, reason := range reasons {
+		if reason.Code != ReasonRoomBooked && reason.Code != ReasonNoRoomsLeft {
+			kept = append(kept, reason)
+		}
+	}
+	return kept
+}
+
+func sameProperty(a, b *uuid.UUID) bool {
+	return a != nil && b != nil && *a == *b
+}
@@ -1,259 +1,502 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"hotelbooking/internal/gateway"
 	"hotelbooking/internal/models"
 	"hotelbooking/internal/repository"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type NightlyRate struct {
-	Date string  `json:"date"`
-	Rate float64 `json:"rate"`
+	Date      string                `json:"date"`
+	Rate      float64               `json:"rate"`
+	Breakdown *NightlyRateBreakdown `json:"breakdown,omitempty"`
 }
 
+// QuoteInput adalah parameter penghitungan harga. Adults minimal 1; jika
+// kosong dianggap 1 tamu dewasa. RatePlanID kosong berarti harga dasar kamar (rate tanpa rate plan).
+// Isi RoomID untuk nomor kamar tertentu, atau RoomTypeID untuk tipe kamar.
+// ChildAges opsional; jika diisi, umur anak ikut menentukan harga dan kapasitas.
+type QuoteInput struct {
+	RoomID     string
+	RoomTypeID string
+	RatePlanID string
+	CheckIn    time.Time
+	CheckOut   time.Time
+	Adults     int
+	Children   int
+	ChildAges  []int
+}
+
+type CreateBookingInput struct {
+	GuestID    string
+	PropertyID string
+	RoomID     string
+	RoomTypeID string
+	RatePlanID string
+	CheckIn    time.Time
+	CheckOut   time.Time
+	Adults     int
+	Children   int
+	ChildAges  []int
+	Occupants  []OccupantInput
+}
+
+// BookingQuote berisi harga untuk rate plan yang diminta (atau harga dasar),
+// ditambah satu offer untuk setiap rate plan aktif di property. TotalPrice
+// sudah termasuk pajak property; NetPrice adalah harga sebelum pajak dan
+// TaxLines rincian pajaknya.
 type BookingQuote struct {
-	Available    bool          `json:"available"`
-	Nights       int           `json:"nights"`
-	TotalPrice   float64       `json:"total_price"`
-	NightlyRates []NightlyRate `json:"nightly_rates"`
-	Currency     string        `json:"currency,omitempty"`
+	Available    bool             `json:"available"`
+	Reasons      []QuoteReason    `json:"reasons"`
+	RatePlan     *models.RatePlan `json:"rate_plan,omitempty"`
+	RoomsLeft    *int             `json:"rooms_left,omitempty"`
+	Nights       int              `json:"nights"`
+	Adults       int              `json:"adults"`
+	Children     int              `json:"children"`
+	ChildAges    []int            `json:"child_ages,omitempty"`
+	TotalPrice   float64          `json:"total_price"`
+	NetPrice     float64          `json:"net_price"`
+	TaxAmount    float64          `json:"tax_amount"`
+	TaxLines     []models.TaxLine `json:"tax_lines,omitempty"`
+	NightlyRates []NightlyRate    `json:"nightly_rates"`
+	Currency     string           `json:"currency,omitempty"`
+	Offers       []RateOffer      `json:"offers"`
 }
 
 type BookingCreateResult struct {
-	Booking *models.Booking `json:"booking"`
-	Payment *models.Payment `json:"payment"`
-	Invoice *models.Invoice `json:"invoice"`
-	Quote   *BookingQuote   `json:"quote,omitempty"`
+	Booking   *models.Booking          `json:"booking"`
+	Payments  []models.Payment         `json:"payments"`
+	Invoice   *models.Invoice          `json:"invoice"`
+	Quote     *BookingQuote            `json:"quote,omitempty"`
+	Occupants []models.BookingOccupant `json:"occupants,omitempty"`
 }
 
 type BookingService interface {
-	QuoteBooking(roomID string, checkIn, checkOut time.Time) (*BookingQuote, error)
-	CreateBooking(guestID, propertyID, roomID string, checkIn, checkOut time.Time) (*BookingCreateResult, error)
-	MarkPaymentPaid(guestID, bookingID, provider, reference string) (*models.Payment, *models.Invoice, error)
-	CancelBooking(guestID, bookingID string, now time.Time) (*models.Booking, *models.Payment, error)
+	QuoteBooking(input QuoteInput) (*BookingQuote, error)
+	GetCalendar(input CalendarInput) (*AvailabilityCalendar, error)
+	CreateBooking(input CreateBookingInput) (*BookingCreateResult, error)
+	StartPayment(guestID, bookingID, paymentID, provider string, now time.Time) (*PaymentSession, error)
+	SyncPayment(guestID, bookingID string, now time.Time) (*PaymentSession, error)
+	HandlePaymentWebhook(provider string, headers http.Header, payload []byte, now time.Time) (*models.PaymentWebhookEvent, error)
+	CancelBooking(guestID, bookingID string, now time.Time) (*models.Booking, []models.Payment, error)
+	IssueRefund(bookingID string, input RefundInput, actor string, now time.Time) (*RefundResult, error)
+	ListRefunds(guestID, bookingID string) ([]models.Refund, error)
+	PreviewCancellation(guestID, bookingID string, now time.Time) (*CancellationPreview, error)
+	ModifyBooking(guestID, bookingID string, input ModifyBookingInput, actor string, now time.Time) (*BookingModificationResult, error)
+	GetModifications(bookingID string) ([]models.BookingModification, error)
 	GetInvoice(guestID, bookingID string) (*models.Invoice, error)
-	GetPayment(guestID, bookingID string) (*models.Payment, error)
+	GetPaymentSchedule(guestID, bookingID string) (*PaymentSchedule, error)
+	SplitPayment(guestID, paymentID string, amounts []float64) (*PaymentSchedule, error)
+	GetFolio(guestID, bookingID string) (*Folio, error)
+	PostFolioCharge(bookingID string, input FolioChargeInput, actor string, now time.Time) (*FolioPostingResult, error)
+	AdjustFolioCharge(bookingID string, input FolioAdjustmentInput, actor string, now time.Time) (*FolioPostingResult, error)
+	VoidFolioCharge(bookingID, chargeID, reason, actor string, now time.Time) (*FolioPostingResult, error)
 	ListBookings(propertyID, status string, startDate, endDate time.Time) ([]models.Booking, error)
-	UpdateStatus(bookingID string, status models.BookingStatus, note string, refundAmount float64) (*models.Booking, error)
+	UpdateStatus(bookingID string, status models.BookingStatus, note, actor string) (*models.Booking, error)
 	GetBookingByID(bookingID string) (*models.Booking, error)
+	GetStatusHistory(bookingID string) ([]models.BookingStatusHistory, error)
+	SetOccupants(guestID, bookingID string, occupants []OccupantInput) ([]models.BookingOccupant, error)
+	GetOccupants(guestID, bookingID string) ([]models.BookingOccupant, error)
+	ExpireHolds(now time.Time) (int, error)
+	ProcessPaymentSchedules(now time.Time) (*PaymentScheduleRun, error)
+	AssignRoom(bookingID, roomID string) (*models.Booking, error)
+	CreateReservation(input CreateReservationInput) (*ReservationDetail, error)
+	GetReservation(guestID, reservationID string) (*ReservationDetail, error)
+	GetReservationByCode(code string) (*ReservationDetail, error)
+	StartReservationPayment(guestID, reservationID, paymentID, provider string, now time.Time) (*PaymentSession, error)
+	SyncReservationPayment(guestID, reservationID string, now time.Time) (*PaymentSession, error)
+	CancelReservationRoom(guestID, reservationID, bookingID string, now time.Time) (*ReservationDetail, error)
 }
 
+// ErrRoomUnavailable menandakan kamar sudah dialokasikan ke booking lain
+// pada tanggal yang beririsan (termasuk kalah balapan dengan request paralel).
+var ErrRoomUnavailable = repository.ErrRoomUnavailable
+
+// ErrBookingStatusConflict menandakan status booking diubah proses lain
+// (pembatalan, konfirmasi payment, atau sweeper hold) di tengah request.
+var ErrBookingStatusConflict = repository.ErrBookingStatusConflict
+
+// ErrRateNotAvailable menandakan stay tidak punya harga untuk tanggal yang
+// diminta, misalnya karena tidak ada rate maupun harga dasar.
+var ErrRateNotAvailable = errors.New("rate tidak tersedia untuk tanggal tersebut")
+
 type bookingService struct {
 	repo        repository.BookingRepo
 	propRepo    repository.PropertyRepo
 	paymentRepo repository.PaymentRepo
+	uow         repository.UnitOfWork
+	gateways    *gateway.Registry
+	notifier    PaymentNotifier
+	holdTTL     time.Duration
 }
 
-func NewBookingService(repo repository.BookingRepo, propRepo repository.PropertyRepo, paymentRepo repository.PaymentRepo) BookingService {
+// NewBookingService membuat booking service. holdTTL adalah lama kamar ditahan
+// untuk booking yang belum dibayar; 0 berarti tanpa batas waktu. notifier
+// mengirim pengingat cicilan yang mendekati jatuh tempo.
+func NewBookingService(repo repository.BookingRepo, propRepo repository.PropertyRepo, paymentRepo repository.PaymentRepo, uow repository.UnitOfWork, gateways *gateway.Registry, notifier PaymentNotifier, holdTTL time.Duration) BookingService {
 	return &bookingService{
 		repo:        repo,
 		propRepo:    propRepo,
 		paymentRepo: paymentRepo,
+		uow:         uow,
+		gateways:    gateways,
+		notifier:    notifier,
+		holdTTL:     holdTTL,
 	}
 }
 
-func (s *bookingService) QuoteBooking(roomID string, checkIn, checkOut time.Time) (*BookingQuote, error) {
+func (s *bookingService) QuoteBooking(input QuoteInput) (*BookingQuote, error) {
+	checkIn, checkOut := input.CheckIn, input.CheckOut
 	nights, err := validateStay(checkIn, checkOut)
 	if err != nil {
 		return nil, err
 	}
-	if roomID == "" {
-		return nil, fmt.Errorf("room_id wajib diisi")
+	if input.RoomID == "" && input.RoomTypeID == "" {
+		return nil, fmt.Errorf("room_id atau room_type_id wajib diisi")
 	}
-
-	room, err := s.propRepo.GetRoomByID(roomID)
+	mix, err := normalizeParty(input.Adults, input.Children, input.ChildAges)
 	if err != nil {
 		return nil, err
 	}
 
-	basePrice := 0.0
-	if room.RoomTypeID != nil {
-		roomType, err := s.propRepo.GetRoomTypeByID(room.RoomTypeID.String())
-		if err != nil {
-			return nil, err
-		}
-		basePrice = roomType.BasePrice
+	// Baris tanggal check-out ikut diambil untuk mengecek closed-to-departure
+	startStr := checkIn.Format("2006-01-02")
+	endStr := checkOut.Format("2006-01-02")
+	inventory, err := s.loadQuoteInventory(input, startStr, endStr)
+	if err != nil {
+		return nil, err
 	}
 
-	startStr := checkIn.Format("2006-01-02")
-	endStr := checkOut.AddDate(0, 0, -1).Format("2006-01-02")
-	rates, err := s.propRepo.ListRoomRates(roomID, startStr, endStr)
+	plans, err := s.activeRatePlans(inventory.propertyID)
 	if err != nil {
 		return nil, err
 	}
-	rateMap := make(map[string]models.RoomRate, len(rates))
-	for _, rate := range rates {
-		rateMap[rate.Date.Format("2006-01-02")] = rate
+	taxRules, err := s.propertyTaxRules(inventory.propertyID)
+	if err != nil {
+		return nil, err
 	}
+	return quoteStay(input, nights, mix, inventory, plans, taxRules)
+}
 
-	available := true
-	total := 0.0
-	nightlyRates := make([]NightlyRate, 0, nights)
-
-	for day := checkIn; day.Before(checkOut); day = day.AddDate(0, 0, 1) {
-		dateStr := day.Format("2006-01-02")
-		roomRate, hasRate := rateMap[dateStr]
-		rate := basePrice
-
-		if hasRate {
-			if roomRate.LinearRate != nil {
-				rate = *roomRate.LinearRate
-			}
-			if roomRate.StopSell || roomRate.AvailableRooms <= 0 {
-				available = false
-			}
-			if roomRate.CloseOnArrival && dateStr == startStr {
-				available = false
-			}
-			if roomRate.MinNights > 0 && nights < roomRate.MinNights {
-				available = false
-			}
-			if roomRate.MaxNights > 0 && nights > roomRate.MaxNights {
-				available = false
+// quoteStay menghitung quote dari inventori yang sudah dimuat. Dipisah dari
+// QuoteBooking supaya pencarian hotel bisa memakai inventori yang dimuat
+// sekali per property.
+func quoteStay(input QuoteInput, nights int, mix guestMix, inventory *quoteInventory, plans []models.RatePlan, taxRules []models.TaxRule) (*BookingQuote, error) {
+	pricedAdults, pricedChildren := mix.priced()
+	var selected *models.RatePlan
+	if input.RatePlanID != "" {
+		for i := range plans {
+			if plans[i].ID.String() == input.RatePlanID {
+				selected = &plans[i]
 			}
 		}
+		if selected == nil {
+			return nil, fmt.Errorf("rate plan tidak ditemukan untuk kamar ini")
+		}
+	}
 
-		nightlyRates = append(nightlyRates, NightlyRate{
-			Date: dateStr,
-			Rate: rate,
-		})
-		total += rate
+	stay := stayPricing{
+		rates:       inventory.rates,
+		basePrice:   inventory.basePrice,
+		checkIn:     input.CheckIn,
+		checkOut:    input.CheckOut,
+		nights:      nights,
+		adults:      pricedAdults,
+		children:    pricedChildren,
+		unavailable: inventory.unavailable,
+		taxRules:    taxRules,
+	}
+	if inventory.capacity > 0 && mix.headcount() > inventory.capacity {
+		stay.overCapacity = true
 	}
 
-	if total == 0 {
-		return nil, fmt.Errorf("rate tidak tersedia untuk tanggal tersebut")
+	offers := make([]RateOffer, 0, len(plans))
+	for i := range plans {
+		offer, err := stay.price(&plans[i])
+		if err != nil {
+			return nil, err
+		}
+		offers = append(offers, *offer)
 	}
 
-	ok, err := s.repo.CheckAvailability(roomID, checkIn.Format("2006-01-02"), checkOut.Format("2006-01-02"))
+	quote, err := stay.price(selected)
 	if err != nil {
 		return nil, err
 	}
-	if !ok {
-		available = false
+	if quote.TotalPrice == 0 {
+		return nil, ErrRateNotAvailable
 	}
 
 	return &BookingQuote{
-		Available:    available,
+		Available:    quote.Available,
+		Reasons:      quote.Reasons,
+		RatePlan:     selected,
+		RoomsLeft:    inventory.roomsLeft,
 		Nights:       nights,
-		TotalPrice:   total,
-		NightlyRates: nightlyRates,
+		Adults:       mix.adults,
+		Children:     mix.children,
+		ChildAges:    mix.childAges,
+		TotalPrice:   quote.TotalPrice,
+		NetPrice:     quote.NetPrice,
+		TaxAmount:    quote.TaxAmount,
+		TaxLines:     quote.TaxLines,
+		NightlyRates: quote.NightlyRates,
 		Currency:     "IDR",
+		Offers:       offers,
 	}, nil
 }
 
-func (s *bookingService) CreateBooking(guestID, propertyID, roomID string, checkIn, checkOut time.Time) (*BookingCreateResult, error) {
-	quote, err := s.QuoteBooking(roomID, checkIn, checkOut)
+// quoteInventory adalah sumber harga dan ketersediaan untuk quote, baik per
+// nomor kamar maupun per tipe kamar.
+type quoteInventory struct {
+	propertyID  *uuid.UUID
+	roomTypeID  *uuid.UUID
+	basePrice   float64
+	capacity    int
+	rates       []models.RoomRate
+	roomsLeft   *int
+	unavailable string
+}
+
+// loadQuoteInventory memakai rate dan ketersediaan kamar jika RoomID diisi,
+// selain itu rate level tipe kamar dan sisa unit tipe tersebut.
+func (s *bookingService) loadQuoteInventory(input QuoteInput, startStr, endStr string) (*quoteInventory, error) {
+	if input.RoomID != "" {
+		room, err := s.propRepo.GetRoomByID(input.RoomID)
+		if err != nil {
+			return nil, err
+		}
+		inventory := &quoteInventory{propertyID: room.PropertyID, roomTypeID: room.RoomTypeID}
+		if room.RoomTypeID != nil {
+			roomType, err := s.propRepo.GetRoomTypeByID(room.RoomTypeID.String())
+			if err != nil {
+				return nil, err
+			}
+			inventory.basePrice = roomType.BasePrice
+			inventory.capacity = roomType.Capacity
+		}
+		inventory.rates, err = s.propRepo.ListRoomRates(input.RoomID, startStr, endStr)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := s.repo.CheckAvailability(input.RoomID, startStr, endStr)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			inventory.unavailable = ReasonRoomBooked
+		}
+		return inventory, nil
+	}
+
+	roomType, err := s.propRepo.GetRoomTypeByID(input.RoomTypeID)
 	if err != nil {
 		return nil, err
 	}
-	if !quote.Available {
-		return nil, fmt.Errorf("kamar tidak tersedia pada tanggal tersebut")
-	}
-
-	room, err := s.propRepo.GetRoomByID(roomID)
+	inventory := &quoteInventory{propertyID: roomType.PropertyID, roomTypeID: &roomType.ID, basePrice: roomType.BasePrice, capacity: roomType.Capacity}
+	inventory.rates, err = s.propRepo.ListRoomTypeRates(input.RoomTypeID, startStr, endStr)
 	if err != nil {
 		return nil, err
 	}
-	if propertyID == "" {
-		return nil, fmt.Errorf("property_id wajib diisi")
+	remaining, err := s.repo.CheckRoomTypeAvailability(input.RoomTypeID, startStr, endStr)
+	if err != nil {
+		return nil, err
 	}
-	if room.PropertyID == nil || room.PropertyID.String() != propertyID {
-		return nil, fmt.Errorf("property_id tidak sesuai dengan room")
+	if remaining <= 0 {
+		remaining = 0
+		inventory.unavailable = ReasonNoRoomsLeft
 	}
+	inventory.roomsLeft = &remaining
+	return inventory, nil
+}
 
-	guestUUID, err := uuid.Parse(guestID)
-	if err != nil {
-		return nil, fmt.Errorf("invalid guest id")
-	}
-	propertyUUID, err := uuid.Parse(propertyID)
-	if err != nil {
-		return nil, fmt.Errorf("invalid property id")
+// activeRatePlans mengembalikan rate plan aktif milik property kamar.
+func (s *bookingService) activeRatePlans(propertyID *uuid.UUID) ([]models.RatePlan, error) {
+	if propertyID == nil {
+		return nil, nil
 	}
-	roomUUID, err := uuid.Parse(roomID)
+	plans, err := s.propRepo.ListRatePlans(propertyID.String())
 	if err != nil {
-		return nil, fmt.Errorf("invalid room id")
+		return nil, err
 	}
+	return activePlans(plans), nil
+}
 
-	newBooking := models.Booking{
-		ID:         uuid.New(),
-		GuestID:    &guestUUID,
-		PropertyID: &propertyUUID,
-		RoomID:     &roomUUID,
-		CheckIn:    checkIn,
-		CheckOut:   checkOut,
-		Nights:     quote.Nights,
-		TotalPrice: quote.TotalPrice,
-		Status:     models.BookingStatusNew,
-		CreatedAt:  time.Now(),
+func activePlans(plans []models.RatePlan) []models.RatePlan {
+	active := make([]models.RatePlan, 0, len(plans))
+	for _, plan := range plans {
+		if plan.IsActive {
+			active = append(active, plan)
+		}
 	}
+	return active
+}
 
-	if err := s.repo.CreateBooking(newBooking); err != nil {
+func (s *bookingService) CreateBooking(input CreateBookingInput) (*BookingCreateResult, error) {
+	newBooking, quote, err := s.prepareBooking(input, time.Now())
+	if err != nil {
 		return nil, err
 	}
-
-	payment := models.Payment{
-		ID:        uuid.New(),
-		BookingID: &newBooking.ID,
-		Amount:    newBooking.TotalPrice,
-		Status:    models.PaymentStatusPending,
-		CreatedAt: time.Now(),
-	}
-	if err := s.paymentRepo.CreatePayment(payment); err != nil {
+	guestID := input.GuestID
+	property, err := s.propRepo.GetPropertyByID(input.PropertyID)
+	if err != nil {
 		return nil, err
 	}
 
+	// Tagihan dibagi deposit dan pelunasan jika property memakai jadwal pembayaran
+	payments := planPayments(property.PaymentSchedule, &newBooking.ID, nil, newBooking.TotalPrice, newBooking.CheckIn, time.Now(), newBooking.HoldExpiresAt)
+	roomCharge := roomFolioCharge(&newBooking, quote.TaxLines, GuestActor(guestID))
 	invoice := models.Invoice{
 		ID:            uuid.New(),
 		BookingID:     &newBooking.ID,
 		InvoiceNumber: buildInvoiceNumber(newBooking.ID, newBooking.CreatedAt),
-		Amount:        newBooking.TotalPrice,
+		Amount:        roomCharge.Amount,
+		NetAmount:     roundAmount(roomCharge.Amount - roomCharge.TaxAmount),
+		TaxAmount:     roomCharge.TaxAmount,
+		TaxLines:      roomCharge.TaxLines,
 		Status:        models.PaymentStatusPending,
 		IssuedAt:      time.Now(),
 	}
-	if err := s.paymentRepo.CreateInvoice(invoice); err != nil {
+
+	// Booking, folio, cicilan payment, dan invoice dibuat dalam satu transaksi supaya tidak ada data yatim
+	var occupants []models.BookingOccupant
+	err = s.uow.Do(func(tx *repository.Repositories) error {
+		// Hold basi yang belum disapu masih mengunci kamar di database
+		if _, err := expireHolds(tx, newBooking.CreatedAt); err != nil {
+			return err
+		}
+		if err := tx.Booking.CreateBooking(newBooking); err != nil {
+			return err
+		}
+		if err := recordStatusHistory(tx, newBooking.ID, "", newBooking.Status, GuestActor(guestID), "", newBooking.CreatedAt); err != nil {
+			return err
+		}
+		var err error
+		if occupants, err = saveOccupants(tx, &newBooking, input.Occupants, guestID); err != nil {
+			return err
+		}
+		if err := tx.Payment.CreateFolioCharge(roomCharge); err != nil {
+			return err
+		}
+		for _, payment := range payments {
+			if err := tx.Payment.CreatePayment(payment); err != nil {
+				return err
+			}
+		}
+		return tx.Payment.CreateInvoice(invoice)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return &BookingCreateResult{
-		Booking: &newBooking,
-		Payment: &payment,
-		Invoice: &invoice,
-		Quote:   quote,
+		Booking:   &newBooking,
+		Payments:  payments,
+		Invoice:   &invoice,
+		Quote:     quote,
+		Occupants: occupants,
 	}, nil
 }
 
-func (s *bookingService) MarkPaymentPaid(guestID, bookingID, provider, reference string) (*models.Payment, *models.Invoice, error) {
-	booking, err := s.repo.GetBookingByID(bookingID)
+// prepareBooking memvalidasi input dan quote lalu menyusun booking New yang
+// siap disimpan; dipakai booking tunggal maupun setiap kamar reservasi.
+func (s *bookingService) prepareBooking(input CreateBookingInput, now time.Time) (models.Booking, *BookingQuote, error) {
+	guestID, propertyID, roomID := input.GuestID, input.PropertyID, input.RoomID
+	checkIn, checkOut := input.CheckIn, input.CheckOut
+	quote, err := s.QuoteBooking(QuoteInput{
+		RoomID:     roomID,
+		RoomTypeID: input.RoomTypeID,
+		RatePlanID: input.RatePlanID,
+		CheckIn:    checkIn,
+		CheckOut:   checkOut,
+		Adults:     input.Adults,
+		Children:   input.Children,
+		ChildAges:  input.ChildAges,
+	})
 	if err != nil {
-		return nil, nil, err
+		return models.Booking{}, nil, err
 	}
-	if booking.GuestID == nil || booking.GuestID.String() != guestID {
-		return nil, nil, fmt.Errorf("booking tidak ditemukan")
+	// Property yang menjual rate plan tidak menerima booking harga dasar
+	if quote.RatePlan == nil && len(quote.Offers) > 0 {
+		return models.Booking{}, nil, fmt.Errorf("rate_plan_id wajib diisi")
+	}
+	if !quote.Available {
+		return models.Booking{}, nil, quoteUnavailableError(quote.Reasons)
 	}
 
-	payment, err := s.paymentRepo.UpdatePaymentStatus(bookingID, models.PaymentStatusPaid, provider, reference)
-	if err != nil {
-		return nil, nil, err
+	if propertyID == "" {
+		return models.Booking{}, nil, fmt.Errorf("property_id wajib diisi")
+	}
+	// Booking selalu memegang satu unit tipe kamar; nomor kamar opsional dan
+	// bisa di-assign belakangan
+	var roomUUID, roomTypeUUID *uuid.UUID
+	if roomID != "" {
+		room, err := s.propRepo.GetRoomByID(roomID)
+		if err != nil {
+			return models.Booking{}, nil, err
+		}
+		if room.PropertyID == nil || room.PropertyID.String() != propertyID {
+			return models.Booking{}, nil, fmt.Errorf("property_id tidak sesuai dengan room")
+		}
+		roomUUID, roomTypeUUID = &room.ID, room.RoomTypeID
+	} else {
+		roomType, err := s.propRepo.GetRoomTypeByID(input.RoomTypeID)
+		if err != nil {
+			return models.Booking{}, nil, err
+		}
+		if roomType.PropertyID == nil || roomType.PropertyID.String() != propertyID {
+			return models.Booking{}, nil, fmt.Errorf("property_id tidak sesuai dengan room type")
+		}
+		roomTypeUUID = &roomType.ID
 	}
 
-	invoice, err := s.paymentRepo.UpdateInvoiceStatus(bookingID, models.PaymentStatusPaid)
+	guestUUID, err := uuid.Parse(guestID)
 	if err != nil {
-		return nil, nil, err
+		return models.Booking{}, nil, fmt.Errorf("invalid guest id")
 	}
-
-	if booking.Status == models.BookingStatusNew {
-		if _, err := s.repo.UpdateBookingStatus(bookingID, models.BookingStatusConfirmed, "", 0); err != nil {
-			return nil, nil, err
-		}
+	propertyUUID, err := uuid.Parse(propertyID)
+	if err != nil {
+		return models.Booking{}, nil, fmt.Errorf("invalid property id")
 	}
 
-	return payment, invoice, nil
+	newBooking := models.Booking{
+		ID:         uuid.New(),
+		GuestID:    &guestUUID,
+		PropertyID: &propertyUUID,
+		RoomID:     roomUUID,
+		RoomTypeID: roomTypeUUID,
+		CheckIn:    checkIn,
+		CheckOut:   checkOut,
+		Nights:     quote.Nights,
+		Adults:     quote.Adults,
+		Children:   quote.Children,
+		ChildAges:  quote.ChildAges,
+		TotalPrice: quote.TotalPrice,
+		TaxAmount:  quote.TaxAmount,
+		Status:     models.BookingStatusNew,
+		CreatedAt:  now,
+	}
+	if quote.RatePlan != nil {
+		newBooking.RatePlanID = &quote.RatePlan.ID
+	}
+	if s.holdTTL > 0 {
+		holdExpiresAt := newBooking.CreatedAt.Add(s.holdTTL)
+		newBooking.HoldExpiresAt = &holdExpiresAt
+	}
+	return newBooking, quote, nil
 }
 
-func (s *bookingService) CancelBooking(guestID, bookingID string, now time.Time) (*models.Booking, *models.Payment, error) {
+// CancelBooking membatalkan booking milik tamu. Cicilan yang belum dibayar
+// di-void; dana yang sudah dibayar dikembalikan lewat gateway sesuai aturan
+// pembatalan.
+func (s *bookingService) CancelBooking(guestID, bookingID string, now time.Time) (*models.Booking, []models.Payment, error) {
 	booking, err := s.repo.GetBookingByID(bookingID)
 	if err != nil {
 		return nil, nil, err
@@ -261,43 +504,74 @@ func (s *bookingService) CancelBooking(guestID, bookingID string, now time.Time)
 	if booking.GuestID == nil || booking.GuestID.String() != guestID {
 		return nil, nil, fmt.Errorf("booking tidak ditemukan")
 	}
-	if booking.Status == models.BookingStatusCancel || booking.Status == models.BookingStatusCheckedOut {
-		return nil, nil, fmt.Errorf("booking tidak dapat dibatalkan")
+	// Kamar milik reservasi dibatalkan sendiri-sendiri tanpa menyentuh kamar lain
+	if booking.ReservationID != nil {
+		detail, err := s.CancelReservationRoom(guestID, booking.ReservationID.String(), bookingID, now)
+		if detail == nil {
+			return nil, nil, err
+		}
+		for i := range detail.Bookings {
+			if detail.Bookings[i].ID == booking.ID {
+				return &detail.Bookings[i], detail.Payments, err
+			}
+		}
+		return nil, detail.Payments, err
 	}
-
-	refundAmount := calculateRefund(booking, now)
-	updated, err := s.repo.UpdateBookingStatus(bookingID, models.BookingStatusCancel, "cancelled_by_guest", refundAmount)
+	updated, cancelErr := s.cancelBooking(booking, GuestActor(guestID), "cancelled_by_guest", now)
+	if updated == nil {
+		return nil, nil, cancelErr
+	}
+	payments, err := s.paymentRepo.ListPaymentsByBookingID(bookingID)
 	if err != nil {
 		return nil, nil, err
 	}
+	return updated, payments, cancelErr
+}
 
-	payment, err := s.paymentRepo.GetPaymentByBookingID(bookingID)
+// cancelBooking membatalkan booking tunggal: cicilan yang belum dibayar
+// ditutup dalam transaksi yang sama, lalu dana bersih yang melebihi denda
+// dikembalikan lewat gateway.
+func (s *bookingService) cancelBooking(booking *models.Booking, actor, note string, now time.Time) (*models.Booking, error) {
+	if !canTransition(booking.Status, models.BookingStatusCancel) {
+		return nil, fmt.Errorf("booking tidak dapat dibatalkan")
+	}
+	target := paymentOwner(booking)
+	payments, err := target.payments(s.paymentRepo)
 	if err != nil {
-		return updated, nil, nil
+		return nil, err
 	}
-	if payment.Status != models.PaymentStatusRefunded {
-		payment, err = s.paymentRepo.UpdatePaymentStatus(bookingID, models.PaymentStatusRefunded, payment.Provider, payment.Reference)
-		if err != nil {
-			return updated, nil, err
-		}
-		_, _ = s.paymentRepo.UpdateInvoiceStatus(bookingID, models.PaymentStatusRefunded)
+	// Refund hanya dari dana yang benar-benar sudah dibayar, dipotong denda.
+	preview, err := s.cancellationPreview(booking, cancellationPaid(booking, payments), now)
+	if err != nil {
+		return nil, err
 	}
+	refundAmount := preview.RefundAmount
 
-	return updated, payment, nil
-}
-
-func (s *bookingService) GetInvoice(guestID, bookingID string) (*models.Invoice, error) {
-	booking, err := s.repo.GetBookingByID(bookingID)
+	var updated *models.Booking
+	err = s.uow.Do(func(tx *repository.Repositories) error {
+		var err error
+		updated, err = transitionBooking(tx, booking, models.BookingStatusCancel, actor, note, refundAmount, now)
+		if err != nil {
+			return err
+		}
+		return closePendingPayments(tx.Payment, target)
+	})
 	if err != nil {
 		return nil, err
 	}
-	if booking.GuestID == nil || booking.GuestID.String() != guestID {
-		return nil, fmt.Errorf("booking tidak ditemukan")
+
+	// Refund dieksekusi setelah pembatalan tersimpan. Jika refund gagal,
+	// pembatalan tetap berlaku dan booking dikembalikan bersama
+	// ErrRefundIncomplete.
+	if refundAmount > 0 {
+		if _, err := s.refundAfterCommit(target, &booking.ID, refundAmount, RefundReasonCancellation, actor, now); err != nil {
+			return updated, err
+		}
 	}
-	return s.paymentRepo.GetInvoiceByBookingID(bookingID)
+	return updated, nil
 }
 
-func (s *bookingService) GetPayment(guestID, bookingID string) (*models.Payment, error) {
+func (s *bookingService) GetInvoice(guestID, bookingID string) (*models.Invoice, error) {
 	booking, err := s.repo.GetBookingByID(bookingID)
 	if err != nil {
 		return nil, err
@@ -305,7 +579,10 @@ func (s *bookingService) GetPayment(guestID, bookingID string) (*models.Payment,
 	if booking.GuestID == nil || booking.GuestID.String() != guestID {
 		return nil, fmt.Errorf("booking tidak ditemukan")
 	}
-	return s.paymentRepo.GetPaymentByBookingID(bookingID)
+	if booking.ReservationID != nil {
+		return s.paymentRepo.GetInvoiceByReservationID(booking.ReservationID.String())
+	}
+	return s.paymentRepo.GetInvoiceByBookingID(bookingID)
 }
 
 func (s *bookingService) ListBookings(propertyID, status string, startDate, endDate time.Time) ([]models.Booking, error) {
@@ -319,11 +596,66 @@ func (s *bookingService) ListBookings(propertyID, status string, startDate, endD
 	return s.repo.ListBookings(propertyID, status, startStr, endStr)
 }
 
-func (s *bookingService) UpdateStatus(bookingID string, status models.BookingStatus, note string, refundAmount float64) (*models.Booking, error) {
+// UpdateStatus mengubah status booking atas permintaan admin. Pembatalan
+// memakai jalur yang sama dengan pembatalan tamu (denda, refund, cicilan);
+// status akhir lain mem-void cicilan yang masih Pending.
+func (s *bookingService) UpdateStatus(bookingID string, status models.BookingStatus, note, actor string) (*models.Booking, error) {
 	if bookingID == "" {
 		return nil, fmt.Errorf("booking_id wajib diisi")
 	}
-	return s.repo.UpdateBookingStatus(bookingID, status, note, refundAmount)
+	now := time.Now()
+	if status == models.BookingStatusCancel {
+		return s.adminCancel(bookingID, actor, note, now)
+	}
+	var updated *models.Booking
+	err := s.uow.Do(func(tx *repository.Repositories) error {
+		booking, err := tx.Booking.GetBookingByID(bookingID)
+		if err != nil {
+			return err
+		}
+		// Booking tipe kamar yang belum punya nomor kamar di-assign saat check-in
+		if status == models.BookingStatusCheckedIn && booking.RoomID == nil {
+			if err := checkTransition(tx, booking, status, now); err != nil {
+				return err
+			}
+			if booking, err = assignRoom(tx, booking, ""); err != nil {
+				return err
+			}
+		}
+		if updated, err = transitionBooking(tx, booking, status, actor, note, 0, now); err != nil {
+			return err
+		}
+		if isFinalStatus(status) {
+			return closeBookingPayments(tx, updated)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// adminCancel membatalkan booking lewat cancelBooking atau, untuk kamar
+// reservasi, cancelReservationRoom. Booking yang sudah batal dikembalikan
+// bersama ErrRefundIncomplete jika refund otomatisnya gagal.
+func (s *bookingService) adminCancel(bookingID, actor, note string, now time.Time) (*models.Booking, error) {
+	booking, err := s.repo.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.ReservationID == nil {
+		return s.cancelBooking(booking, actor, note, now)
+	}
+	cancelErr := s.cancelReservationRoom(booking.ReservationID.String(), bookingID, actor, note, now)
+	if cancelErr != nil && !errors.Is(cancelErr, ErrRefundIncomplete) {
+		return nil, cancelErr
+	}
+	updated, err := s.repo.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	return updated, cancelErr
 }
 
 func (s *bookingService) GetBookingByID(bookingID string) (*models.Booking, error) {
@@ -333,6 +665,86 @@ func (s *bookingService) GetBookingByID(bookingID string) (*models.Booking, erro
 	return s.repo.GetBookingByID(bookingID)
 }
 
+func (s *bookingService) GetStatusHistory(bookingID string) ([]models.BookingStatusHistory, error) {
+	if bookingID == "" {
+		return nil, fmt.Errorf("booking_id wajib diisi")
+	}
+	return s.repo.ListStatusHistory(bookingID)
+}
+
+// ExpireHolds melepas semua booking New yang batas bayarnya lewat: status
+// menjadi Expired, sedangkan cicilan yang masih Pending di-void.
+func (s *bookingService) ExpireHolds(now time.Time) (int, error) {
+	var count int
+	err := s.uow.Do(func(tx *repository.Repositories) error {
+		var err error
+		count, err = expireHolds(tx, now)
+		return err
+	})
+	return count, err
+}
+
+func expireHolds(tx *repository.Repositories, now time.Time) (int, error) {
+	expired, err := tx.Booking.ExpireHolds(now)
+	if err != nil {
+		return 0, err
+	}
+	reservations := make(map[uuid.UUID]bool)
+	for _, booking := range expired {
+		bookingID := booking.ID.String()
+		if err := recordStatusHistory(tx, booking.ID, models.BookingStatusNew, models.BookingStatusExpired, ActorSystem, "hold_expired", now); err != nil {
+			return 0, err
+		}
+		if booking.ReservationID != nil {
+			reservations[*booking.ReservationID] = true
+			continue
+		}
+		if err := closePendingPayments(tx.Payment, paymentTarget{bookingID: bookingID}); err != nil {
+			return 0, err
+		}
+	}
+	for reservationID := range reservations {
+		if err := closeReservationPayments(tx, reservationID.String()); err != nil {
+			return 0, err
+		}
+	}
+	return len(expired), nil
+}
+
+// isHoldExpired true jika booking sudah Expired atau masih New tetapi batas
+// bayarnya lewat (belum sempat disapu sweeper).
+func isHoldExpired(booking *models.Booking, now time.Time) bool {
+	if booking.Status == models.BookingStatusExpired {
+		return true
+	}
+	return booking.Status == models.BookingStatusNew && booking.HoldExpiresAt != nil && !booking.HoldExpiresAt.After(now)
+}
+
+// quoteUnavailableError memetakan alasan quote ke error CreateBooking.
+// Bentrok dengan booking lain atau tipe kamar penuh tetap ErrRoomUnavailable
+// (HTTP 409).
+func quoteUnavailableError(reasons []QuoteReason) error {
+	codes := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		if reason.Code == ReasonRoomBooked || reason.Code == ReasonNoRoomsLeft {
+			return ErrRoomUnavailable
+		}
+		codes = append(codes, reason.Code)
+	}
+	return fmt.Errorf("kamar tidak dapat dipesan untuk tanggal tersebut: %s", strings.Join(codes, ", "))
+}
+
+// normalizeGuests mengisi default 1 dewasa dan menolak jumlah negatif.
+func normalizeGuests(adults, children int) (int, int, error) {
+	if adults == 0 {
+		adults = 1
+	}
+	if adults < 1 || children < 0 {
+		return 0, 0, fmt.Errorf("jumlah tamu tidak valid")
+	}
+	return adults, children, nil
+}
+
 func validateStay(checkIn, checkOut time.Time) (int, error) {
 	if checkIn.After(checkOut) {
 		return 0, fmt.Errorf("tanggal check-in tidak boleh setelah check-out")
@@ -344,20 +756,6 @@ func validateStay(checkIn, checkOut time.Time) (int, error) {
 	return nights, nil
 }
 
-func calculateRefund(booking *models.Booking, now time.Time) float64 {
-	if booking == nil {
-		return 0
-	}
-	if now.After(booking.CheckIn) {
-		return 0
-	}
-	cutoff := booking.CheckIn.Add(-24 * time.Hour)
-	if now.Before(cutoff) {
-		return booking.TotalPrice
-	}
-	return booking.TotalPrice * 0.5
-}
-
 func buildInvoiceNumber(bookingID uuid.UUID, createdAt time.Time) string {
 	date := createdAt.Format("20060102")
 	return fmt.Sprintf("INV-%s-%s", date, bookingID.String()[:8])
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ActorSystem dipakai untuk perubahan status yang dilakukan job otomatis
+// (misalnya sweeper hold).
+const ActorSystem = "system"
+
+// GuestActor, AdminActor dan GatewayActor membentuk nilai actor pada riwayat status.
+func GuestActor(guestID string) string    { return "guest:" + guestID }
+func AdminActor(adminID string) string    { return "admin:" + adminID }
+func GatewayActor(provider string) string { return "gateway:" + provider }
+
+// bookingTransitions adalah daftar perpindahan status yang diizinkan.
+// Status yang tidak punya entri (CheckedOut, Cancelled, NoShow, Expired)
+// adalah status akhir.
+var bookingTransitions = map[models.BookingStatus][]models.BookingStatus{
+	models.BookingStatusNew: {
+		models.BookingStatusConfirmed,
+		models.BookingStatusCancel,
+		models.BookingStatusExpired,
+	},
+	models.BookingStatusConfirmed: {
+		models.BookingStatusCheckedIn,
+		models.BookingStatusCancel,
+		models.BookingStatusNoShow,
+	},
+	models.BookingStatusCheckedIn: {
+		models.BookingStatusCheckedOut,
+	},
+}
+
+func isKnownBookingStatus(status models.BookingStatus) bool {
+	switch status {
+	case models.BookingStatusNew, models.BookingStatusConfirmed, models.BookingStatusCancel,
+		models.BookingStatusCheckedIn, models.BookingStatusCheckedOut, models.BookingStatusNoShow,
+		models.BookingStatusExpired:
+		return true
+	}
+	return false
+}
+
+// isFinalStatus mengecek status akhir, yaitu status tanpa perpindahan lanjut.
+func isFinalStatus(status models.BookingStatus) bool {
+	return len(bookingTransitions[status]) == 0
+}
+
+func canTransition(from, to models.BookingStatus) bool {
+	for _, next := range bookingTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTransition memvalidasi perpindahan status beserta guard-nya.
+func checkTransition(tx *repository.Repositories, booking *models.Booking, to models.BookingStatus, now time.Time) error {
+	if !isKnownBookingStatus(to) {
+		return fmt.Errorf("status booking tidak valid: %s", to)
+	}
+	if !canTransition(booking.Status, to) {
+		return fmt.Errorf("status booking tidak dapat diubah dari %s ke %s", booking.Status, to)
+	}
+
+	switch to {
+	case models.BookingStatusCheckedIn:
+		if now.Format("2006-01-02") < booking.CheckIn.Format("2006-01-02") {
+			return fmt.Errorf("check-in belum bisa dilakukan sebelum tanggal %s", booking.CheckIn.Format("2006-01-02"))
+		}
+		// Semua cicilan, termasuk pelunasan dan tambahan tagihan, harus lunas
+		payments, err := paymentOwner(booking).payments(tx.Payment)
+		if err != nil {
+			return err
+		}
+		if !paymentsSettled(payments) {
+			return fmt.Errorf("check-in membutuhkan pembayaran yang sudah lunas")
+		}
+	case models.BookingStatusCheckedOut:
+		// Tagihan tambahan selama menginap harus lunas sebelum tamu keluar
+		payments, err := paymentOwner(booking).payments(tx.Payment)
+		if err != nil {
+			return err
+		}
+		if !paymentsSettled(payments) {
+			return fmt.Errorf("check-out membutuhkan folio yang sudah lunas")
+		}
+	case models.BookingStatusNoShow:
+		if now.Format("2006-01-02") < booking.CheckIn.Format("2006-01-02") {
+			return fmt.Errorf("no-show belum bisa ditandai sebelum tanggal %s", booking.CheckIn.Format("2006-01-02"))
+		}
+	case models.BookingStatusConfirmed:
+		if isHoldExpired(booking, now) {
+			return fmt.Errorf("batas waktu pembayaran booking sudah lewat")
+		}
+	}
+	return nil
+}
+
+// transitionBooking memvalidasi, mengubah status, dan mencatat riwayatnya
+// dalam transaksi tx yang sama. booking boleh dibaca di luar tx: update
+// hanya berhasil jika status di database masih booking.Status, selain itu
+// ErrBookingStatusConflict dan transaksi dibatalkan.
+func transitionBooking(tx *repository.Repositories, booking *models.Booking, to models.BookingStatus, actor, note string, refundAmount float64, now time.Time) (*models.Booking, error) {
+	if err := checkTransition(tx, booking, to, now); err != nil {
+		return nil, err
+	}
+	updated, err := tx.Booking.UpdateBookingStatus(booking.ID.String(), booking.Status, to, note, refundAmount)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordStatusHistory(tx, booking.ID, booking.Status, to, actor, note, now); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// closeBookingPayments mem-void cicilan Pending milik booking yang sudah
+// berstatus akhir. Cicilan reservasi baru di-void jika semua kamarnya sudah
+// tidak menginap.
+func closeBookingPayments(tx *repository.Repositories, booking *models.Booking) error {
+	if booking.ReservationID != nil {
+		return closeReservationPayments(tx, booking.ReservationID.String())
+	}
+	return closePendingPayments(tx.Payment, paymentTarget{bookingID: booking.ID.String()})
+}
+
+func recordStatusHistory(tx *repository.Repositories, bookingID uuid.UUID, from, to models.BookingStatus, actor, note string, now time.Time) error {
+	return tx.Booking.CreateStatusHistory(models.BookingStatusHistory{
+		ID:         uuid.New(),
+		BookingID:  &bookingID,
+		FromStatus: from,
+		ToStatus:   to,
+		Actor:      actor,
+		Note:       note,
+		CreatedAt:  now,
+	})
+}
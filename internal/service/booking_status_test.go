@@ -0,0 +1,68 @@
+package service_test
+
+import (
+	"testing"
+
+	"hotelbooking/internal/gateway"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// TestUpdateStatusSettlesPayments memastikan perubahan status oleh admin ke
+// status akhir ikut menutup pembayaran: pembatalan me-refund dana yang sudah
+// dibayar, status akhir lain mem-void cicilan yang masih Pending.
+func TestUpdateStatusSettlesPayments(t *testing.T) {
+	for _, backend := range testBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			bookings := newTestBookingService(backend, gateway.NewMockGateway(gateway.MockConfig{}))
+			hotel := newTestHotel(t, backend, 500000)
+			admin := service.AdminActor(uuid.NewString())
+
+			guestID, bookingID := paidBooking(t, bookings, hotel)
+			cancelled, err := bookings.UpdateStatus(bookingID, models.BookingStatusCancel, "permintaan tamu", admin)
+			if err != nil {
+				t.Fatalf("cancel: %v", err)
+			}
+			if cancelled.Status != models.BookingStatusCancel {
+				t.Fatalf("got status %s, want Cancelled", cancelled.Status)
+			}
+			refunds, err := bookings.ListRefunds(guestID, bookingID)
+			if err != nil {
+				t.Fatalf("list refunds: %v", err)
+			}
+			if len(refunds) != 1 || refunds[0].Status != models.RefundStatusSucceeded || refunds[0].Amount != 500000 {
+				t.Fatalf("got refunds %+v, want one succeeded refund of 500000", refunds)
+			}
+
+			checkIn := stayDate(20)
+			created, err := bookings.CreateBooking(service.CreateBookingInput{
+				GuestID:    uuid.NewString(),
+				PropertyID: hotel.propertyID,
+				RoomID:     hotel.roomID,
+				CheckIn:    checkIn,
+				CheckOut:   checkIn.AddDate(0, 0, 1),
+			})
+			if err != nil {
+				t.Fatalf("create booking: %v", err)
+			}
+			unpaidID := created.Booking.ID.String()
+			if _, err := bookings.UpdateStatus(unpaidID, models.BookingStatusExpired, "", admin); err != nil {
+				t.Fatalf("expire: %v", err)
+			}
+			schedule, err := bookings.GetPaymentSchedule("", unpaidID)
+			if err != nil {
+				t.Fatalf("payment schedule: %v", err)
+			}
+			for _, payment := range schedule.Payments {
+				if payment.Status != models.PaymentStatusVoid {
+					t.Fatalf("payment %s still %s after expiry", payment.ID, payment.Status)
+				}
+			}
+			if schedule.Outstanding != 0 {
+				t.Fatalf("got outstanding %v after expiry, want 0", schedule.Outstanding)
+			}
+		})
+	}
+}
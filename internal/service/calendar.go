@@ -0,0 +1,319 @@
+package service
+
+import (
+	"fmt"
+	"hotelbooking/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxCalendarDays membatasi rentang kalender agar satu request tidak
+// menghitung inventori bertahun-tahun.
+const maxCalendarDays = 366
+
+// CalendarInput adalah parameter kalender ketersediaan. From dan To inklusif;
+// RoomTypeID kosong berarti semua tipe kamar di property. Adults/Children
+// dipakai untuk harga occupancy, default 1 dewasa.
+type CalendarInput struct {
+	PropertyID string
+	RoomTypeID string
+	From       time.Time
+	To         time.Time
+	Adults     int
+	Children   int
+}
+
+// CalendarDay adalah ketersediaan, harga termurah satu malam dan restriction
+// satu tipe kamar pada satu tanggal. Harga hanya diambil dari opsi yang bisa
+// dibooking: rate plan aktif (atau harga dasar jika property tidak punya rate
+// plan), di level tipe kamar maupun kamar yang masih kosong. LowestPrice
+// sudah termasuk pajak property, sama seperti total_price QuoteBooking.
+// Restriction diambil dari opsi termurah, atau dari rate tipe kamar jika
+// tidak ada yang bisa dijual.
+type CalendarDay struct {
+	Date              string     `json:"date"`
+	Available         bool       `json:"available"`
+	RoomsLeft         int        `json:"rooms_left"`
+	LowestPrice       *float64   `json:"lowest_price,omitempty"`
+	RatePlanID        *uuid.UUID `json:"rate_plan_id,omitempty"`
+	StopSell          bool       `json:"stop_sell"`
+	ClosedToArrival   bool       `json:"closed_to_arrival"`
+	ClosedToDeparture bool       `json:"closed_to_departure"`
+	MinNights         int        `json:"min_nights"`
+	MaxNights         int        `json:"max_nights"`
+}
+
+// RoomTypeCalendar berisi satu CalendarDay per tanggal untuk satu tipe kamar.
+type RoomTypeCalendar struct {
+	RoomTypeID uuid.UUID     `json:"room_type_id"`
+	Name       string        `json:"name"`
+	Capacity   int           `json:"capacity"`
+	Days       []CalendarDay `json:"days"`
+}
+
+type AvailabilityCalendar struct {
+	PropertyID string             `json:"property_id"`
+	From       string             `json:"from"`
+	To         string             `json:"to"`
+	Adults     int                `json:"adults"`
+	Children   int                `json:"children"`
+	Currency   string             `json:"currency"`
+	RoomTypes  []RoomTypeCalendar `json:"room_types"`
+}
+
+// GetCalendar menghitung kalender ketersediaan property sekaligus untuk
+// seluruh rentang: satu query booking aktif, satu query kamar, satu query
+// rate tipe kamar dan satu query rate kamar, lalu setiap malam dihitung di
+// memori.
+func (s *bookingService) GetCalendar(input CalendarInput) (*AvailabilityCalendar, error) {
+	from, to := input.From, input.To
+	if to.Before(from) {
+		return nil, fmt.Errorf("to tidak boleh sebelum from")
+	}
+	days := int(to.Sub(from).Hours()/24) + 1
+	if days > maxCalendarDays {
+		return nil, fmt.Errorf("rentang kalender maksimal %d hari", maxCalendarDays)
+	}
+	adults, children, err := normalizeGuests(input.Adults, input.Children)
+	if err != nil {
+		return nil, err
+	}
+	property, err := s.propRepo.GetPropertyByID(input.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	roomTypes, err := s.propRepo.ListRoomTypes(input.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+	if input.RoomTypeID != "" {
+		filtered := roomTypes[:0]
+		for _, roomType := range roomTypes {
+			if roomType.ID.String() == input.RoomTypeID {
+				filtered = append(filtered, roomType)
+			}
+		}
+		if len(filtered) == 0 {
+			return nil, fmt.Errorf("tipe kamar tidak ditemukan di property ini")
+		}
+		roomTypes = filtered
+	}
+
+	rooms, err := s.propRepo.ListRooms(input.PropertyID, input.RoomTypeID)
+	if err != nil {
+		return nil, err
+	}
+	sellable := make(map[uuid.UUID]int)
+	roomsByType := make(map[uuid.UUID][]models.Room)
+	roomIDs := make([]string, 0, len(rooms))
+	for _, room := range rooms {
+		if room.RoomTypeID != nil && room.Status != models.RoomStatusOutOfOrder {
+			sellable[*room.RoomTypeID]++
+			roomsByType[*room.RoomTypeID] = append(roomsByType[*room.RoomTypeID], room)
+			roomIDs = append(roomIDs, room.ID.String())
+		}
+	}
+
+	// Malam terakhir adalah To, jadi booking yang check-in sebelum To+1 ikut dihitung
+	startStr := from.Format("2006-01-02")
+	endStr := to.AddDate(0, 0, 1).Format("2006-01-02")
+	bookings, err := s.repo.ListActiveBookings(input.PropertyID, startStr, endStr)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[uuid.UUID]map[string]int)
+	roomTaken := make(map[uuid.UUID]map[string]bool)
+	for _, booking := range bookings {
+		if booking.RoomID != nil {
+			nights := roomTaken[*booking.RoomID]
+			if nights == nil {
+				nights = make(map[string]bool)
+				roomTaken[*booking.RoomID] = nights
+			}
+			for night := booking.CheckIn; night.Before(booking.CheckOut); night = night.AddDate(0, 0, 1) {
+				nights[night.Format("2006-01-02")] = true
+			}
+		}
+		if booking.RoomTypeID == nil {
+			continue
+		}
+		nights := taken[*booking.RoomTypeID]
+		if nights == nil {
+			nights = make(map[string]int)
+			taken[*booking.RoomTypeID] = nights
+		}
+		for night := booking.CheckIn; night.Before(booking.CheckOut); night = night.AddDate(0, 0, 1) {
+			nights[night.Format("2006-01-02")]++
+		}
+	}
+
+	var propertyID *uuid.UUID
+	if len(roomTypes) > 0 {
+		propertyID = roomTypes[0].PropertyID
+	}
+	plans, err := s.activeRatePlans(propertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	roomTypeIDs := make([]string, 0, len(roomTypes))
+	for _, roomType := range roomTypes {
+		roomTypeIDs = append(roomTypeIDs, roomType.ID.String())
+	}
+	lastNight := to.Format("2006-01-02")
+	typeRates, err := s.propRepo.ListRoomTypesRates(roomTypeIDs, startStr, lastNight)
+	if err != nil {
+		return nil, err
+	}
+	ratesByType := make(map[uuid.UUID][]models.RoomRate)
+	for _, rate := range typeRates {
+		if rate.RoomTypeID != nil {
+			ratesByType[*rate.RoomTypeID] = append(ratesByType[*rate.RoomTypeID], rate)
+		}
+	}
+	roomRates, err := s.propRepo.ListRoomsRates(roomIDs, startStr, lastNight)
+	if err != nil {
+		return nil, err
+	}
+	ratesByRoom := make(map[uuid.UUID][]models.RoomRate)
+	for _, rate := range roomRates {
+		if rate.RoomID != nil {
+			ratesByRoom[*rate.RoomID] = append(ratesByRoom[*rate.RoomID], rate)
+		}
+	}
+
+	calendar := &AvailabilityCalendar{
+		PropertyID: input.PropertyID,
+		From:       startStr,
+		To:         lastNight,
+		Adults:     adults,
+		Children:   children,
+		Currency:   "IDR",
+		RoomTypes:  make([]RoomTypeCalendar, 0, len(roomTypes)),
+	}
+	for _, roomType := range roomTypes {
+		stay := stayPricing{rates: ratesByType[roomType.ID], basePrice: roomType.BasePrice, adults: adults, children: children}
+		options := calendarOptions(stay, plans, nil)
+		// Kamar tertentu bisa dibooking dengan rate level kamarnya sendiri
+		for _, room := range roomsByType[roomType.ID] {
+			if len(ratesByRoom[room.ID]) == 0 {
+				continue
+			}
+			roomStay := stay
+			roomStay.rates = ratesByRoom[room.ID]
+			options = append(options, calendarOptions(roomStay, plans, roomTaken[room.ID])...)
+		}
+
+		entry := RoomTypeCalendar{
+			RoomTypeID: roomType.ID,
+			Name:       roomType.Name,
+			Capacity:   roomType.Capacity,
+			Days:       make([]CalendarDay, 0, days),
+		}
+		for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+			date := day.Format("2006-01-02")
+			roomsLeft := sellable[roomType.ID] - taken[roomType.ID][date]
+			if roomsLeft < 0 {
+				roomsLeft = 0
+			}
+			taxRules := applicableTaxRules(property.TaxRules, models.ChargeCodeRoom, day)
+			cell, err := calendarDay(date, roomsLeft, options, stay, taxRules)
+			if err != nil {
+				return nil, err
+			}
+			entry.Days = append(entry.Days, cell)
+		}
+		calendar.RoomTypes = append(calendar.RoomTypes, entry)
+	}
+	return calendar, nil
+}
+
+// calendarOption adalah baris rate per tanggal untuk harga dasar (plan nil)
+// atau satu rate plan aktif. taken berisi malam saat kamar opsi level kamar
+// sudah terisi; nil untuk opsi level tipe kamar.
+type calendarOption struct {
+	plan  *models.RatePlan
+	rates map[string]models.RoomRate
+	taken map[string]bool
+}
+
+// calendarOptions membentuk opsi yang bisa dibooking dengan cara yang sama
+// seperti CreateBooking: harga dasar hanya dijual jika property tidak punya
+// rate plan aktif.
+func calendarOptions(stay stayPricing, plans []models.RatePlan, taken map[string]bool) []calendarOption {
+	if len(plans) == 0 {
+		return []calendarOption{{rates: stay.rateMap(nil), taken: taken}}
+	}
+	options := make([]calendarOption, 0, len(plans))
+	for i := range plans {
+		options = append(options, calendarOption{plan: &plans[i], rates: stay.rateMap(&plans[i]), taken: taken})
+	}
+	return options
+}
+
+// calendarDay memilih opsi termurah yang bisa dijual pada tanggal tersebut.
+// Harga memakai aturan yang sama dengan QuoteBooking untuk stay satu malam,
+// termasuk pajak taxRules yang berlaku pada tanggal itu.
+func calendarDay(date string, roomsLeft int, options []calendarOption, stay stayPricing, taxRules []models.TaxRule) (CalendarDay, error) {
+	cell := CalendarDay{Date: date, RoomsLeft: roomsLeft}
+	var (
+		best    *calendarOption
+		bestRow models.RoomRate
+		lowest  float64
+	)
+	for i := range options {
+		option := &options[i]
+		if option.taken[date] {
+			continue
+		}
+		row, hasRate := option.rates[date]
+		rate, source := stay.basePrice, RateSourceBasePrice
+		var nonLinear *models.NonLinearRate
+		if hasRate {
+			if row.StopSell || row.AvailableRooms <= 0 {
+				continue
+			}
+			if row.LinearRate != nil {
+				rate, source = *row.LinearRate, RateSourceLinearRate
+			}
+			var err error
+			nonLinear, err = parseNonLinearRate(row.NonLinearRate)
+			if err != nil {
+				return cell, err
+			}
+		}
+		price, _ := priceNight(rate, source, nonLinear, stay.adults, stay.children, 1)
+		if price <= 0 {
+			continue
+		}
+		if best == nil || price < lowest {
+			best, bestRow, lowest = option, row, price
+		}
+	}
+
+	if best == nil {
+		// Tidak ada opsi yang bisa dijual; restriction diambil dari opsi tipe
+		// kamar pertama supaya frontend tetap tahu alasannya (stop-sell, sold out)
+		row := options[0].rates[date]
+		cell.StopSell = row.StopSell
+		cell.ClosedToArrival = row.CloseOnArrival
+		cell.ClosedToDeparture = row.CloseOnDeparture
+		cell.MinNights = row.MinNights
+		cell.MaxNights = row.MaxNights
+		return cell, nil
+	}
+
+	price := computeTaxes(taxRules, lowest, 1).total
+	cell.LowestPrice = &price
+	if best.plan != nil {
+		cell.RatePlanID = &best.plan.ID
+	}
+	cell.ClosedToArrival = bestRow.CloseOnArrival
+	cell.ClosedToDeparture = bestRow.CloseOnDeparture
+	cell.MinNights = bestRow.MinNights
+	cell.MaxNights = bestRow.MaxNights
+	cell.Available = roomsLeft > 0
+	return cell, nil
+}
@@ -0,0 +1,87 @@
+package service_test
+
+import (
+	"testing"
+
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// TestCalendarPricesBookableOptions memastikan kalender tidak menawarkan
+// harga dasar saat property menjual rate plan, ikut memperhitungkan rate
+// level kamar, dan menampilkan harga setelah pajak.
+func TestCalendarPricesBookableOptions(t *testing.T) {
+	for _, backend := range testBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			hotel := newTestHotel(t, backend, 500000)
+			inventory := service.NewInventoryService(backend.repos.Property, service.NewSearchIndex(backend.repos.Property))
+			plan, err := inventory.CreateRatePlan(service.RatePlanInput{
+				PropertyID: hotel.propertyID,
+				Code:       "BAR",
+				Name:       "Best Available Rate",
+				RateType:   models.RateTypeRefundableRoomOnly,
+			})
+			if err != nil {
+				t.Fatalf("create rate plan: %v", err)
+			}
+			roomTypeID, roomID := uuid.MustParse(hotel.roomTypeID), uuid.MustParse(hotel.roomID)
+			date := stayDate(5)
+			typeRate, roomRate := 700000.0, 600000.0
+
+			lowest := func() float64 {
+				t.Helper()
+				calendar, err := newTestBookingService(backend).GetCalendar(service.CalendarInput{
+					PropertyID: hotel.propertyID,
+					From:       date,
+					To:         date,
+				})
+				if err != nil {
+					t.Fatalf("get calendar: %v", err)
+				}
+				day := calendar.RoomTypes[0].Days[0]
+				if day.LowestPrice == nil {
+					t.Fatalf("no price on %s", day.Date)
+				}
+				if day.RatePlanID == nil || *day.RatePlanID != plan.ID {
+					t.Fatalf("price %v not from rate plan", *day.LowestPrice)
+				}
+				return *day.LowestPrice
+			}
+
+			err = inventory.SetRoomRates([]models.RoomRate{{
+				ID: uuid.New(), RoomTypeID: &roomTypeID, RatePlanID: &plan.ID,
+				Date: date, AvailableRooms: 1, LinearRate: &typeRate,
+			}})
+			if err != nil {
+				t.Fatalf("set room type rate: %v", err)
+			}
+			if got := lowest(); got != typeRate {
+				t.Fatalf("got lowest price %v, want rate plan price %v", got, typeRate)
+			}
+
+			err = inventory.SetRoomRates([]models.RoomRate{{
+				ID: uuid.New(), RoomID: &roomID, RatePlanID: &plan.ID,
+				Date: date, AvailableRooms: 1, LinearRate: &roomRate,
+			}})
+			if err != nil {
+				t.Fatalf("set room rate: %v", err)
+			}
+			if got := lowest(); got != roomRate {
+				t.Fatalf("got lowest price %v, want room rate %v", got, roomRate)
+			}
+
+			// Harga kalender sama dengan total quote, jadi pajak eksklusif ikut dihitung
+			_, err = inventory.SetPropertyTaxRules(hotel.propertyID, []models.TaxRule{{
+				Code: "PB1", Name: "Pajak hotel", Type: models.TaxTypePercent, Value: 10,
+			}})
+			if err != nil {
+				t.Fatalf("set tax rules: %v", err)
+			}
+			if got, want := lowest(), roomRate*1.1; got != want {
+				t.Fatalf("got lowest price %v, want taxed room rate %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"fmt"
+	"hotelbooking/internal/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// Asal aturan pembatalan yang dipakai sebuah booking.
+const (
+	PolicySourceRatePlan = "rate_plan"
+	PolicySourceProperty = "property"
+	PolicySourceDefault  = "default"
+)
+
+// defaultCancellationPolicy dipakai jika property maupun rate plan belum
+// punya aturan: refund penuh sampai 24 jam sebelum check-in, 50% setelahnya,
+// dan tidak ada refund setelah tanggal check-in.
+var defaultCancellationPolicy = models.CancellationPolicy{
+	Tiers: []models.CancellationTier{
+		{HoursBeforeCheckIn: 24, PenaltyType: models.CancellationPenaltyPercent, PenaltyValue: 0},
+		{HoursBeforeCheckIn: 0, PenaltyType: models.CancellationPenaltyPercent, PenaltyValue: 50},
+	},
+}
+
+// CancellationPreview menunjukkan refund jika booking dibatalkan sekarang,
+// untuk ditampilkan sebelum tamu mengonfirmasi pembatalan.
+type CancellationPreview struct {
+	BookingID          string                    `json:"booking_id"`
+	Cancellable        bool                      `json:"cancellable"`
+	Policy             models.CancellationPolicy `json:"policy"`
+	PolicySource       string                    `json:"policy_source"`
+	AppliedTier        *models.CancellationTier  `json:"applied_tier,omitempty"`
+	HoursBeforeCheckIn float64                   `json:"hours_before_check_in"`
+	TotalPrice         float64                   `json:"total_price"`
+	Paid               float64                   `json:"paid"`
+	Penalty            float64                   `json:"penalty"`
+	RefundAmount       float64                   `json:"refund_amount"`
+}
+
+// normalizeCancellationPolicy memvalidasi aturan dan mengurutkan tier dari
+// batas jam terbesar. nil tetap nil (kembali ke aturan di atasnya).
+func normalizeCancellationPolicy(policy *models.CancellationPolicy) (*models.CancellationPolicy, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	out := &models.CancellationPolicy{NonRefundable: policy.NonRefundable}
+	if policy.NonRefundable {
+		return out, nil
+	}
+	if len(policy.Tiers) == 0 {
+		return nil, fmt.Errorf("aturan pembatalan membutuhkan minimal satu tier atau non_refundable")
+	}
+	seen := make(map[int]bool, len(policy.Tiers))
+	for i, tier := range policy.Tiers {
+		if tier.HoursBeforeCheckIn < 0 {
+			return nil, fmt.Errorf("tiers[%d]: hours_before_check_in tidak boleh negatif", i)
+		}
+		if seen[tier.HoursBeforeCheckIn] {
+			return nil, fmt.Errorf("tiers[%d]: hours_before_check_in %d duplikat", i, tier.HoursBeforeCheckIn)
+		}
+		seen[tier.HoursBeforeCheckIn] = true
+		if tier.PenaltyValue < 0 {
+			return nil, fmt.Errorf("tiers[%d]: penalty_value tidak boleh negatif", i)
+		}
+		switch tier.PenaltyType {
+		case models.CancellationPenaltyPercent:
+			if tier.PenaltyValue > 100 {
+				return nil, fmt.Errorf("tiers[%d]: persen denda maksimal 100", i)
+			}
+		case models.CancellationPenaltyNights:
+			if tier.PenaltyValue != math.Trunc(tier.PenaltyValue) {
+				return nil, fmt.Errorf("tiers[%d]: jumlah malam denda harus bilangan bulat", i)
+			}
+		case models.CancellationPenaltyFixed:
+		default:
+			return nil, fmt.Errorf("tiers[%d]: penalty_type tidak valid", i)
+		}
+		out.Tiers = append(out.Tiers, tier)
+	}
+	sort.Slice(out.Tiers, func(i, j int) bool {
+		return out.Tiers[i].HoursBeforeCheckIn > out.Tiers[j].HoursBeforeCheckIn
+	})
+	return out, nil
+}
+
+// cancellationPenalty menghitung denda pembatalan pada waktu now. Tier yang
+// dipakai adalah tier pertama (batas jam terbesar) yang masih terpenuhi;
+// tanpa tier yang cocok seluruh total menjadi denda.
+func cancellationPenalty(policy models.CancellationPolicy, booking *models.Booking, now time.Time) (float64, *models.CancellationTier) {
+	total := booking.TotalPrice
+	if policy.NonRefundable {
+		return total, nil
+	}
+	hoursBefore := booking.CheckIn.Sub(now).Hours()
+	for i := range policy.Tiers {
+		tier := policy.Tiers[i]
+		if hoursBefore < float64(tier.HoursBeforeCheckIn) {
+			continue
+		}
+		var penalty float64
+		switch tier.PenaltyType {
+		case models.CancellationPenaltyPercent:
+			penalty = total * tier.PenaltyValue / 100
+		case models.CancellationPenaltyNights:
+			nights := math.Min(tier.PenaltyValue, float64(booking.Nights))
+			if booking.Nights > 0 {
+				penalty = total / float64(booking.Nights) * nights
+			}
+		case models.CancellationPenaltyFixed:
+			penalty = tier.PenaltyValue
+		}
+		return roundAmount(math.Min(penalty, total)), &tier
+	}
+	return total, nil
+}
+
+// cancellationPolicyFor memilih aturan booking: rate plan non-refundable,
+// lalu aturan rate plan, aturan property, dan terakhir aturan default.
+func (s *bookingService) cancellationPolicyFor(booking *models.Booking) (models.CancellationPolicy, string, error) {
+	if booking.RatePlanID != nil {
+		plan, err := s.propRepo.GetRatePlanByID(booking.RatePlanID.String())
+		if err != nil {
+			return models.CancellationPolicy{}, "", err
+		}
+		if !plan.Refundable {
+			return models.CancellationPolicy{NonRefundable: true}, PolicySourceRatePlan, nil
+		}
+		if plan.CancellationRules != nil {
+			return *plan.CancellationRules, PolicySourceRatePlan, nil
+		}
+	}
+	if booking.PropertyID != nil {
+		property, err := s.propRepo.GetPropertyByID(booking.PropertyID.String())
+		if err != nil {
+			return models.CancellationPolicy{}, "", err
+		}
+		if property.CancellationRules != nil {
+			return *property.CancellationRules, PolicySourceProperty, nil
+		}
+	}
+	return defaultCancellationPolicy, PolicySourceDefault, nil
+}
+
+// cancellationPreview menghitung denda dan refund pembatalan. paid adalah
+// dana booking yang sudah masuk (lihat cancellationPaid); refund tidak
+// pernah melebihi dana itu.
+func (s *bookingService) cancellationPreview(booking *models.Booking, paid float64, now time.Time) (*CancellationPreview, error) {
+	policy, source, err := s.cancellationPolicyFor(booking)
+	if err != nil {
+		return nil, err
+	}
+	penalty, tier := cancellationPenalty(policy, booking, now)
+	return &CancellationPreview{
+		BookingID:          booking.ID.String(),
+		Cancellable:        canTransition(booking.Status, models.BookingStatusCancel),
+		Policy:             policy,
+		PolicySource:       source,
+		AppliedTier:        tier,
+		HoursBeforeCheckIn: math.Round(booking.CheckIn.Sub(now).Hours()*100) / 100,
+		TotalPrice:         booking.TotalPrice,
+		Paid:               roundAmount(paid),
+		Penalty:            penalty,
+		RefundAmount:       math.Max(0, roundAmount(paid-penalty)),
+	}, nil
+}
+
+// cancellationPaid adalah dana booking yang sudah masuk. Untuk kamar dalam
+// reservasi, yaitu bagian harga kamar yang tidak bisa dipotong dari cicilan
+// reservasi yang belum dibayar.
+func cancellationPaid(booking *models.Booking, payments []models.Payment) float64 {
+	if booking.ReservationID != nil {
+		_, paidPortion := planReduction(payments, booking.TotalPrice)
+		return paidPortion
+	}
+	return netPaid(payments)
+}
+
+// PreviewCancellation menghitung refund jika booking dibatalkan pada now
+// tanpa mengubah apa pun. guestID kosong berarti dipanggil admin.
+func (s *bookingService) PreviewCancellation(guestID, bookingID string, now time.Time) (*CancellationPreview, error) {
+	booking, err := s.repo.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if guestID != "" && (booking.GuestID == nil || booking.GuestID.String() != guestID) {
+		return nil, fmt.Errorf("booking tidak ditemukan")
+	}
+	payments, err := paymentOwner(booking).payments(s.paymentRepo)
+	if err != nil {
+		return nil, err
+	}
+	return s.cancellationPreview(booking, cancellationPaid(booking, payments), now)
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"hotelbooking/internal/models"
+)
+
+func TestCancellationPenalty(t *testing.T) {
+	checkIn := time.Date(2026, 11, 10, 14, 0, 0, 0, time.UTC)
+	booking := &models.Booking{CheckIn: checkIn, Nights: 4, TotalPrice: 2000000}
+	tiered := models.CancellationPolicy{Tiers: []models.CancellationTier{
+		{HoursBeforeCheckIn: 168, PenaltyType: models.CancellationPenaltyPercent, PenaltyValue: 0},
+		{HoursBeforeCheckIn: 72, PenaltyType: models.CancellationPenaltyNights, PenaltyValue: 1},
+		{HoursBeforeCheckIn: 24, PenaltyType: models.CancellationPenaltyFixed, PenaltyValue: 1500000},
+		{HoursBeforeCheckIn: 0, PenaltyType: models.CancellationPenaltyNights, PenaltyValue: 10},
+	}}
+
+	tests := []struct {
+		name        string
+		policy      models.CancellationPolicy
+		hoursBefore float64
+		want        float64
+		wantTier    bool
+	}{
+		{name: "non refundable", policy: models.CancellationPolicy{NonRefundable: true}, hoursBefore: 500, want: 2000000},
+		{name: "free cancellation", policy: tiered, hoursBefore: 200, want: 0, wantTier: true},
+		{name: "tier boundary is inclusive", policy: tiered, hoursBefore: 168, want: 0, wantTier: true},
+		{name: "one night", policy: tiered, hoursBefore: 100, want: 500000, wantTier: true},
+		{name: "fixed", policy: tiered, hoursBefore: 30, want: 1500000, wantTier: true},
+		{name: "nights capped at stay", policy: tiered, hoursBefore: 2, want: 2000000, wantTier: true},
+		{name: "after check-in", policy: tiered, hoursBefore: -1, want: 2000000},
+		{name: "default full refund", policy: defaultCancellationPolicy, hoursBefore: 48, want: 0, wantTier: true},
+		{name: "default half", policy: defaultCancellationPolicy, hoursBefore: 12, want: 1000000, wantTier: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := checkIn.Add(-time.Duration(tt.hoursBefore * float64(time.Hour)))
+			got, tier := cancellationPenalty(tt.policy, booking, now)
+			if got != tt.want {
+				t.Fatalf("got penalty %v, want %v", got, tt.want)
+			}
+			if (tier != nil) != tt.wantTier {
+				t.Fatalf("got tier %+v, want tier %v", tier, tt.wantTier)
+			}
+		})
+	}
+}
+
+func TestCancellationPenaltyFixedCappedAtTotal(t *testing.T) {
+	booking := &models.Booking{CheckIn: time.Date(2026, 11, 10, 0, 0, 0, 0, time.UTC), Nights: 1, TotalPrice: 300000}
+	policy := models.CancellationPolicy{Tiers: []models.CancellationTier{
+		{HoursBeforeCheckIn: 0, PenaltyType: models.CancellationPenaltyFixed, PenaltyValue: 500000},
+	}}
+	if got, _ := cancellationPenalty(policy, booking, booking.CheckIn.Add(-time.Hour)); got != 300000 {
+		t.Fatalf("got penalty %v, want total 300000", got)
+	}
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"hotelbooking/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// validateDerivation memeriksa definisi plan turunan sebelum disimpan.
+func validateDerivation(derivationType models.DerivationType, value float64) error {
+	switch derivationType {
+	case models.DerivationPercent:
+		if value <= -100 {
+			return fmt.Errorf("derivation_value persen harus lebih dari -100")
+		}
+	case models.DerivationFixed, models.DerivationPerPerson:
+	default:
+		return fmt.Errorf("derivation_type harus percent, fixed, atau per_person")
+	}
+	return nil
+}
+
+// deriveRoomRate menghitung baris rate plan turunan dari baris parent.
+// basePrice dipakai jika parent tidak punya LinearRate. Restriction dan
+// allotment ikut parent; hanya harga yang digeser.
+func deriveRoomRate(parent models.RoomRate, plan *models.RatePlan, basePrice float64) (models.RoomRate, error) {
+	linear := basePrice
+	if parent.LinearRate != nil {
+		linear = *parent.LinearRate
+	}
+	nonLinear, err := parseNonLinearRate(parent.NonLinearRate)
+	if err != nil {
+		return models.RoomRate{}, err
+	}
+	if nonLinear == nil {
+		nonLinear = &models.NonLinearRate{}
+	}
+	occ := nonLinear.Occupancy
+
+	value := plan.DerivationValue
+	switch plan.DerivationType {
+	case models.DerivationPercent:
+		factor := 1 + value/100
+		linear = roundAmount(linear * factor)
+		if occ != nil {
+			occ.OneAdult = roundAmount(occ.OneAdult * factor)
+			occ.TwoAdults = roundAmount(occ.TwoAdults * factor)
+			occ.ExtraAdult = roundAmount(occ.ExtraAdult * factor)
+			occ.ExtraChild = roundAmount(occ.ExtraChild * factor)
+		}
+	case models.DerivationFixed:
+		linear += value
+		if occ != nil {
+			occ.OneAdult += value
+			occ.TwoAdults += value
+		}
+	case models.DerivationPerPerson:
+		// Harga per orang butuh harga occupancy; rate flat parent diubah
+		// menjadi occupancy dengan harga kamar yang sama untuk 1-2 dewasa.
+		if occ == nil {
+			occ = &models.OccupancyRate{OneAdult: linear, TwoAdults: linear}
+			nonLinear.Occupancy = occ
+		}
+		occ.OneAdult += value
+		occ.TwoAdults += 2 * value
+		occ.ExtraAdult += value
+		occ.ExtraChild += value
+		linear += value
+	default:
+		return models.RoomRate{}, fmt.Errorf("derivation_type tidak valid")
+	}
+
+	if linear <= 0 || (occ != nil && (occ.OneAdult <= 0 || occ.TwoAdults <= 0 || occ.ExtraAdult < 0 || occ.ExtraChild < 0)) {
+		return models.RoomRate{}, fmt.Errorf("harga rate plan %s menjadi tidak valid untuk tanggal %s", plan.Code, parent.Date.Format("2006-01-02"))
+	}
+
+	derived := parent
+	derived.ID = uuid.New()
+	derived.RatePlanID = &plan.ID
+	derived.LinearRate = &linear
+	derived.NonLinearRate = nil
+	derived.Derived = true
+	derived.CreatedAt = time.Now()
+	if nonLinear.Occupancy != nil || len(nonLinear.LengthOfStay) > 0 {
+		raw, err := json.Marshal(nonLinear)
+		if err != nil {
+			return models.RoomRate{}, err
+		}
+		derived.NonLinearRate = raw
+	}
+	return derived, nil
+}
+
+// childRatePlans mengembalikan plan yang diturunkan langsung dari parentID.
+func childRatePlans(plans []models.RatePlan, parentID uuid.UUID) []*models.RatePlan {
+	children := make([]*models.RatePlan, 0)
+	for i := range plans {
+		if plans[i].ParentRatePlanID != nil && *plans[i].ParentRatePlanID == parentID {
+			children = append(children, &plans[i])
+		}
+	}
+	return children
+}
+
+// derivedRates menghitung baris semua plan turunan (berjenjang) dari baris
+// rate parent. Hasilnya disimpan bersama baris parent dalam satu upsert.
+func (s *inventoryService) derivedRates(plans []models.RatePlan, parentRates []models.RoomRate) ([]models.RoomRate, error) {
+	scopes := make(map[uuid.UUID]rateScope)
+	all := make([]models.RoomRate, 0)
+	for len(parentRates) > 0 {
+		next := make([]models.RoomRate, 0)
+		for _, rate := range parentRates {
+			if rate.RatePlanID == nil {
+				continue
+			}
+			children := childRatePlans(plans, *rate.RatePlanID)
+			if len(children) == 0 {
+				continue
+			}
+			scope, err := s.rateScopeOf(rate, scopes)
+			if err != nil {
+				return nil, err
+			}
+			for _, child := range children {
+				derived, err := deriveRoomRate(rate, child, scope.basePrice)
+				if err != nil {
+					return nil, err
+				}
+				next = append(next, derived)
+			}
+		}
+		all = append(all, next...)
+		parentRates = next
+	}
+	return all, nil
+}
+
+// rebuildDerivedRates menghitung ulang rate plan turunan dari seluruh rate
+// parent yang sudah tersimpan, dipakai saat definisi turunan berubah.
+func (s *inventoryService) rebuildDerivedRates(plan *models.RatePlan) error {
+	if plan.PropertyID == nil || plan.ParentRatePlanID == nil {
+		return nil
+	}
+	plans, err := s.repo.ListRatePlans(plan.PropertyID.String())
+	if err != nil {
+		return err
+	}
+	rooms, err := s.repo.ListRooms(plan.PropertyID.String(), "")
+	if err != nil {
+		return err
+	}
+	roomTypes, err := s.repo.ListRoomTypes(plan.PropertyID.String())
+	if err != nil {
+		return err
+	}
+	stored := make([]models.RoomRate, 0)
+	for _, room := range rooms {
+		rates, err := s.repo.ListRoomRates(room.ID.String(), "", "")
+		if err != nil {
+			return err
+		}
+		stored = append(stored, rates...)
+	}
+	for _, roomType := range roomTypes {
+		rates, err := s.repo.ListRoomTypeRates(roomType.ID.String(), "", "")
+		if err != nil {
+			return err
+		}
+		stored = append(stored, rates...)
+	}
+	parentRates := make([]models.RoomRate, 0)
+	for _, rate := range stored {
+		if rate.RatePlanID != nil && *rate.RatePlanID == *plan.ParentRatePlanID {
+			parentRates = append(parentRates, rate)
+		}
+	}
+	// Hanya cabang milik plan ini yang dihitung ulang, saudara-saudaranya tetap
+	scoped := make([]models.RatePlan, 0, len(plans))
+	for _, candidate := range plans {
+		if candidate.ParentRatePlanID == nil || *candidate.ParentRatePlanID != *plan.ParentRatePlanID || candidate.ID == plan.ID {
+			scoped = append(scoped, candidate)
+		}
+	}
+	derived, err := s.derivedRates(scoped, parentRates)
+	if err != nil || len(derived) == 0 {
+		return err
+	}
+	return s.repo.UpsertRoomRates(derived)
+}
+
+// checkRatePlanParent memastikan parent berada di property yang sama dan
+// tidak membentuk siklus turunan.
+func (s *inventoryService) checkRatePlanParent(planID uuid.UUID, propertyID string, parentID uuid.UUID) error {
+	plans, err := s.repo.ListRatePlans(propertyID)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uuid.UUID]models.RatePlan, len(plans))
+	for _, plan := range plans {
+		byID[plan.ID] = plan
+	}
+	if _, ok := byID[parentID]; !ok {
+		return fmt.Errorf("parent rate plan tidak ditemukan di property ini")
+	}
+	for current := parentID; ; {
+		if current == planID {
+			return fmt.Errorf("parent rate plan membentuk siklus")
+		}
+		parent := byID[current]
+		if parent.ParentRatePlanID == nil {
+			return nil
+		}
+		current = *parent.ParentRatePlanID
+	}
+}
+
+// rateScope adalah pemilik baris rate: property dan base price dari kamar
+// (rate per kamar) atau tipe kamar (rate per tipe).
+type rateScope struct {
+	propertyID *uuid.UUID
+	basePrice  float64
+}
+
+func (s *inventoryService) rateScopeOf(rate models.RoomRate, cache map[uuid.UUID]rateScope) (rateScope, error) {
+	var roomTypeID *uuid.UUID
+	var key uuid.UUID
+	var propertyID *uuid.UUID
+	switch {
+	case rate.RoomID != nil:
+		key = *rate.RoomID
+		if scope, ok := cache[key]; ok {
+			return scope, nil
+		}
+		room, err := s.repo.GetRoomByID(rate.RoomID.String())
+		if err != nil {
+			return rateScope{}, err
+		}
+		propertyID, roomTypeID = room.PropertyID, room.RoomTypeID
+	case rate.RoomTypeID != nil:
+		key = *rate.RoomTypeID
+		if scope, ok := cache[key]; ok {
+			return scope, nil
+		}
+		roomTypeID = rate.RoomTypeID
+	default:
+		return rateScope{}, fmt.Errorf("room_id atau room_type_id wajib diisi")
+	}
+
+	scope := rateScope{propertyID: propertyID}
+	if roomTypeID != nil {
+		roomType, err := s.repo.GetRoomTypeByID(roomTypeID.String())
+		if err != nil {
+			return rateScope{}, err
+		}
+		scope.basePrice = roomType.BasePrice
+		if scope.propertyID == nil {
+			scope.propertyID = roomType.PropertyID
+		}
+	}
+	cache[key] = scope
+	return scope, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"hotelbooking/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestDeriveRoomRate(t *testing.T) {
+	linear := 500000.0
+	occupancy, err := json.Marshal(models.NonLinearRate{Occupancy: &models.OccupancyRate{
+		OneAdult: 400000, TwoAdults: 500000, ExtraAdult: 100000, ExtraChild: 50000,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		parent     models.RoomRate
+		derivation models.DerivationType
+		value      float64
+		wantLinear float64
+		wantOcc    *models.OccupancyRate
+		wantErr    bool
+	}{
+		{name: "percent discount", parent: models.RoomRate{LinearRate: &linear}, derivation: models.DerivationPercent, value: -10, wantLinear: 450000},
+		{name: "percent markup", parent: models.RoomRate{LinearRate: &linear}, derivation: models.DerivationPercent, value: 15, wantLinear: 575000},
+		{name: "fixed amount", parent: models.RoomRate{LinearRate: &linear}, derivation: models.DerivationFixed, value: -50000, wantLinear: 450000},
+		{name: "base price fallback", parent: models.RoomRate{}, derivation: models.DerivationFixed, value: 25000, wantLinear: 325000},
+		{
+			name: "percent scales occupancy", parent: models.RoomRate{LinearRate: &linear, NonLinearRate: occupancy},
+			derivation: models.DerivationPercent, value: -10, wantLinear: 450000,
+			wantOcc: &models.OccupancyRate{OneAdult: 360000, TwoAdults: 450000, ExtraAdult: 90000, ExtraChild: 45000},
+		},
+		{
+			name: "fixed shifts room prices only", parent: models.RoomRate{LinearRate: &linear, NonLinearRate: occupancy},
+			derivation: models.DerivationFixed, value: 20000, wantLinear: 520000,
+			wantOcc: &models.OccupancyRate{OneAdult: 420000, TwoAdults: 520000, ExtraAdult: 100000, ExtraChild: 50000},
+		},
+		{
+			name: "per person on flat rate", parent: models.RoomRate{LinearRate: &linear},
+			derivation: models.DerivationPerPerson, value: 30000, wantLinear: 530000,
+			wantOcc: &models.OccupancyRate{OneAdult: 530000, TwoAdults: 560000, ExtraAdult: 30000, ExtraChild: 30000},
+		},
+		{name: "discount below zero", parent: models.RoomRate{LinearRate: &linear}, derivation: models.DerivationFixed, value: -600000, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &models.RatePlan{ID: uuid.New(), Code: "DRV", DerivationType: tt.derivation, DerivationValue: tt.value}
+			tt.parent.Date = time.Date(2026, 11, 10, 0, 0, 0, 0, time.UTC)
+			derived, err := deriveRoomRate(tt.parent, plan, 300000)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if derived.LinearRate == nil || *derived.LinearRate != tt.wantLinear {
+				t.Fatalf("got linear rate %v, want %v", derived.LinearRate, tt.wantLinear)
+			}
+			if !derived.Derived || derived.RatePlanID == nil || *derived.RatePlanID != plan.ID {
+				t.Fatalf("derived row not linked to plan: %+v", derived)
+			}
+			rate, err := parseNonLinearRate(derived.NonLinearRate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotOcc *models.OccupancyRate
+			if rate != nil {
+				gotOcc = rate.Occupancy
+			}
+			if (gotOcc == nil) != (tt.wantOcc == nil) || (gotOcc != nil && *gotOcc != *tt.wantOcc) {
+				t.Fatalf("got occupancy %+v, want %+v", gotOcc, tt.wantOcc)
+			}
+		})
+	}
+}
+
+func TestValidateDerivation(t *testing.T) {
+	tests := []struct {
+		derivation models.DerivationType
+		value      float64
+		wantErr    bool
+	}{
+		{models.DerivationPercent, -99, false},
+		{models.DerivationPercent, -100, true},
+		{models.DerivationFixed, -100, false},
+		{models.DerivationPerPerson, 50000, false},
+		{"weekly", 10, true},
+	}
+	for _, tt := range tests {
+		if err := validateDerivation(tt.derivation, tt.value); (err != nil) != tt.wantErr {
+			t.Errorf("validateDerivation(%s, %v) = %v, want error %v", tt.derivation, tt.value, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,428 @@
+package service
+
+import (
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Folio adalah semua baris tagihan satu booking beserta cicilan dan invoice
+// pemilik payment-nya. Balance adalah jumlah baris yang masih Posted; untuk
+// booking reservasi, nominal invoice menjumlah balance semua kamarnya.
+type Folio struct {
+	BookingID string               `json:"booking_id"`
+	Charges   []models.FolioCharge `json:"charges"`
+	Balance   float64              `json:"balance"`
+	Payments  []models.Payment     `json:"payments"`
+	Invoice   *models.Invoice      `json:"invoice"`
+}
+
+// FolioChargeInput adalah tagihan tambahan yang diposting front desk.
+// Description kosong diisi dari charge code. Pajak dihitung dari aturan
+// pajak property yang berlaku saat tagihan diposting.
+type FolioChargeInput struct {
+	ChargeCode  models.ChargeCode `json:"charge_code"`
+	Description string            `json:"description"`
+	Quantity    int               `json:"quantity"`
+	UnitPrice   float64           `json:"unit_price"`
+}
+
+// FolioAdjustmentInput mengoreksi nominal satu baris folio. Amount negatif
+// mengurangi tagihan, positif menambahnya.
+type FolioAdjustmentInput struct {
+	ChargeID string  `json:"charge_id"`
+	Amount   float64 `json:"amount"`
+	Reason   string  `json:"reason"`
+}
+
+// FolioPostingResult adalah baris yang baru diposting atau di-void beserta
+// folio setelahnya. RefundAmount adalah dana yang sudah dibayar untuk
+// tagihan yang dikurangi dan dikembalikan lewat gateway.
+type FolioPostingResult struct {
+	Charge       *models.FolioCharge `json:"charge"`
+	Folio        *Folio              `json:"folio"`
+	RefundAmount float64             `json:"refund_amount,omitempty"`
+}
+
+var chargeCodeLabels = map[models.ChargeCode]string{
+	models.ChargeCodeRoom:         "Kamar",
+	models.ChargeCodeMinibar:      "Minibar",
+	models.ChargeCodeRestaurant:   "Restoran",
+	models.ChargeCodeLaundry:      "Laundry",
+	models.ChargeCodeLateCheckout: "Late check-out",
+	models.ChargeCodeExtraBed:     "Extra bed",
+	models.ChargeCodeOther:        "Lain-lain",
+}
+
+// folioBalance menjumlah baris folio yang masih Posted.
+func folioBalance(charges []models.FolioCharge) float64 {
+	total := 0.0
+	for _, charge := range charges {
+		if charge.Status == models.FolioChargeStatusPosted {
+			total += charge.Amount
+		}
+	}
+	return roundAmount(total)
+}
+
+// roomFolioCharge adalah baris tagihan kamar yang diposting saat booking
+// dibuat. TotalPrice booking sudah termasuk pajak; UnitPrice adalah harga
+// sebelum pajak eksklusif.
+func roomFolioCharge(booking *models.Booking, taxLines []models.TaxLine, actor string) models.FolioCharge {
+	inclusive, exclusive := sumTaxLines(taxLines)
+	return models.FolioCharge{
+		ID:         uuid.New(),
+		BookingID:  &booking.ID,
+		Kind:       models.FolioChargeKindCharge,
+		ChargeCode: models.ChargeCodeRoom,
+		Description: fmt.Sprintf("Kamar %s - %s",
+			booking.CheckIn.Format("2006-01-02"), booking.CheckOut.Format("2006-01-02")),
+		Quantity:  1,
+		UnitPrice: roundAmount(booking.TotalPrice - exclusive),
+		TaxAmount: roundAmount(inclusive + exclusive),
+		TaxLines:  taxLines,
+		Amount:    booking.TotalPrice,
+		Status:    models.FolioChargeStatusPosted,
+		PostedBy:  actor,
+		PostedAt:  booking.CreatedAt,
+	}
+}
+
+// folioTaxLines menggabungkan rincian pajak baris folio yang masih Posted.
+func folioTaxLines(charges []models.FolioCharge) []models.TaxLine {
+	var lines []models.TaxLine
+	for _, charge := range charges {
+		if charge.Status == models.FolioChargeStatusPosted {
+			lines = mergeTaxLines(lines, charge.TaxLines)
+		}
+	}
+	return lines
+}
+
+// syncInvoiceAmount menurunkan nominal dan rincian pajak invoice dari folio
+// semua booking milik target.
+func syncInvoiceAmount(tx *repository.Repositories, target paymentTarget) error {
+	bookingIDs := []string{target.bookingID}
+	if target.reservationID != "" {
+		bookings, err := tx.Booking.ListReservationBookings(target.reservationID)
+		if err != nil {
+			return err
+		}
+		bookingIDs = bookingIDs[:0]
+		for _, booking := range bookings {
+			bookingIDs = append(bookingIDs, booking.ID.String())
+		}
+	}
+	var totals models.InvoiceTotals
+	for _, bookingID := range bookingIDs {
+		charges, err := tx.Payment.ListFolioCharges(bookingID)
+		if err != nil {
+			return err
+		}
+		totals.Amount += folioBalance(charges)
+		totals.TaxLines = mergeTaxLines(totals.TaxLines, folioTaxLines(charges))
+	}
+	inclusive, exclusive := sumTaxLines(totals.TaxLines)
+	totals.Amount = roundAmount(totals.Amount)
+	totals.TaxAmount = roundAmount(inclusive + exclusive)
+	totals.NetAmount = roundAmount(totals.Amount - totals.TaxAmount)
+	return target.updateInvoiceTotals(tx.Payment, totals)
+}
+
+// settlePayments menyamakan cicilan dengan perubahan tagihan. Kenaikan
+// ditambahkan ke cicilan Pending yang jatuh tempo paling akhir, atau menjadi
+// cicilan adjustment baru jika semua cicilan sudah lunas. Penurunan dipotong
+// dari cicilan yang belum dibayar; sisanya (dana yang sudah masuk)
+// dikembalikan ke caller untuk di-refund setelah transaksi.
+func settlePayments(tx *repository.Repositories, booking *models.Booking, difference float64, now time.Time) (float64, error) {
+	target := paymentOwner(booking)
+	payments, err := target.payments(tx.Payment)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case difference > 0:
+		if last := lastPendingPayment(payments); last != nil {
+			_, err := tx.Payment.UpdatePaymentAmount(last.ID.String(), roundAmount(last.Amount+difference))
+			return 0, err
+		}
+		dueAt := now
+		adjustment := models.Payment{
+			ID:        uuid.New(),
+			BookingID: &booking.ID,
+			Kind:      models.PaymentKindAdjustment,
+			Amount:    difference,
+			Status:    models.PaymentStatusPending,
+			DueAt:     &dueAt,
+			CreatedAt: now,
+		}
+		if booking.ReservationID != nil {
+			adjustment.BookingID, adjustment.ReservationID = nil, booking.ReservationID
+		}
+		return 0, tx.Payment.CreatePayment(adjustment)
+	case difference < 0:
+		changes, leftover := planReduction(payments, -difference)
+		return leftover, applyReductions(tx.Payment, changes)
+	}
+	return 0, nil
+}
+
+// GetFolio mengembalikan folio booking. guestID kosong berarti dipanggil admin.
+func (s *bookingService) GetFolio(guestID, bookingID string) (*Folio, error) {
+	booking, err := s.repo.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if guestID != "" && (booking.GuestID == nil || booking.GuestID.String() != guestID) {
+		return nil, fmt.Errorf("booking tidak ditemukan")
+	}
+	return s.folio(booking)
+}
+
+func (s *bookingService) folio(booking *models.Booking) (*Folio, error) {
+	charges, err := s.paymentRepo.ListFolioCharges(booking.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.bookingPaymentOwner(booking)
+	if err != nil {
+		return nil, err
+	}
+	folio := &Folio{BookingID: booking.ID.String(), Charges: charges, Balance: folioBalance(charges)}
+	if folio.Payments, err = target.payments(s.paymentRepo); err != nil {
+		return nil, err
+	}
+	if folio.Invoice, err = target.invoice(s.paymentRepo); err != nil {
+		return nil, err
+	}
+	return folio, nil
+}
+
+// openFolio mengambil booking yang folionya masih bisa diubah: sudah
+// dikonfirmasi dan belum check-out.
+func (s *bookingService) openFolio(bookingID string) (*models.Booking, error) {
+	booking, err := s.repo.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status != models.BookingStatusConfirmed && booking.Status != models.BookingStatusCheckedIn {
+		return nil, fmt.Errorf("folio booking berstatus %s tidak dapat diubah", booking.Status)
+	}
+	return booking, nil
+}
+
+// PostFolioCharge memposting tagihan tambahan ke folio booking. Tagihan
+// kamar hanya diposting sistem dari harga booking.
+func (s *bookingService) PostFolioCharge(bookingID string, input FolioChargeInput, actor string, now time.Time) (*FolioPostingResult, error) {
+	label, ok := chargeCodeLabels[input.ChargeCode]
+	if !ok {
+		return nil, fmt.Errorf("charge_code tidak valid: %s", input.ChargeCode)
+	}
+	if input.ChargeCode == models.ChargeCodeRoom {
+		return nil, fmt.Errorf("tagihan kamar hanya berubah lewat perubahan atau pembatalan booking")
+	}
+	if input.Quantity <= 0 {
+		return nil, fmt.Errorf("quantity harus lebih dari 0")
+	}
+	if input.UnitPrice <= 0 {
+		return nil, fmt.Errorf("unit_price harus lebih dari 0")
+	}
+	booking, err := s.openFolio(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.bookingTaxRules(booking)
+	if err != nil {
+		return nil, err
+	}
+	taxes := computeTaxes(applicableTaxRules(rules, input.ChargeCode, now),
+		float64(input.Quantity)*input.UnitPrice, input.Quantity)
+	description := strings.TrimSpace(input.Description)
+	if description == "" {
+		description = label
+	}
+	charge := models.FolioCharge{
+		ID:          uuid.New(),
+		BookingID:   &booking.ID,
+		Kind:        models.FolioChargeKindCharge,
+		ChargeCode:  input.ChargeCode,
+		Description: description,
+		Quantity:    input.Quantity,
+		UnitPrice:   roundAmount(input.UnitPrice),
+		TaxAmount:   taxes.tax,
+		TaxLines:    taxes.lines,
+		Amount:      taxes.total,
+		Status:      models.FolioChargeStatusPosted,
+		PostedBy:    actor,
+		PostedAt:    now,
+	}
+	return s.changeFolio(booking, &charge, charge.Amount, actor, now, func(tx *repository.Repositories) error {
+		return tx.Payment.CreateFolioCharge(charge)
+	})
+}
+
+// AdjustFolioCharge memposting koreksi atas satu baris tagihan. Koreksi
+// negatif tidak boleh melebihi nominal baris setelah koreksi sebelumnya.
+func (s *bookingService) AdjustFolioCharge(bookingID string, input FolioAdjustmentInput, actor string, now time.Time) (*FolioPostingResult, error) {
+	amount := roundAmount(input.Amount)
+	if amount == 0 {
+		return nil, fmt.Errorf("amount tidak boleh 0")
+	}
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" {
+		return nil, fmt.Errorf("reason wajib diisi")
+	}
+	booking, err := s.openFolio(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	charges, err := s.paymentRepo.ListFolioCharges(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	original := findFolioCharge(charges, input.ChargeID)
+	if original == nil || original.Status != models.FolioChargeStatusPosted || original.Kind != models.FolioChargeKindCharge {
+		return nil, fmt.Errorf("tagihan folio tidak ditemukan")
+	}
+	if net := adjustedAmount(charges, original); amount < 0 && -amount > net {
+		return nil, fmt.Errorf("koreksi melebihi nominal tagihan (%.2f)", net)
+	}
+	adjustment := models.FolioCharge{
+		ID:          uuid.New(),
+		BookingID:   &booking.ID,
+		Kind:        models.FolioChargeKindAdjustment,
+		ChargeCode:  original.ChargeCode,
+		Description: reason,
+		Quantity:    1,
+		UnitPrice:   amount,
+		Amount:      amount,
+		AdjustsID:   &original.ID,
+		Status:      models.FolioChargeStatusPosted,
+		PostedBy:    actor,
+		PostedAt:    now,
+	}
+	// Koreksi ikut mengoreksi pajak baris asal secara proporsional
+	if original.Amount != 0 {
+		adjustment.TaxLines = scaleTaxLines(original.TaxLines, amount/original.Amount)
+		inclusive, exclusive := sumTaxLines(adjustment.TaxLines)
+		adjustment.TaxAmount = roundAmount(inclusive + exclusive)
+		adjustment.UnitPrice = roundAmount(amount - exclusive)
+	}
+	return s.changeFolio(booking, &adjustment, amount, actor, now, func(tx *repository.Repositories) error {
+		return tx.Payment.CreateFolioCharge(adjustment)
+	})
+}
+
+// VoidFolioCharge membatalkan satu baris folio beserta koreksinya. Tagihan
+// kamar dan koreksi dari perubahan booking tidak bisa di-void.
+func (s *bookingService) VoidFolioCharge(bookingID, chargeID, reason, actor string, now time.Time) (*FolioPostingResult, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("reason wajib diisi")
+	}
+	booking, err := s.openFolio(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	charges, err := s.paymentRepo.ListFolioCharges(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	charge := findFolioCharge(charges, chargeID)
+	if charge == nil || charge.Status != models.FolioChargeStatusPosted {
+		return nil, fmt.Errorf("tagihan folio tidak ditemukan")
+	}
+	if charge.ChargeCode == models.ChargeCodeRoom && charge.AdjustsID == nil {
+		return nil, fmt.Errorf("tagihan kamar hanya berubah lewat perubahan atau pembatalan booking")
+	}
+	voids := []models.FolioCharge{*charge}
+	for _, other := range charges {
+		if other.Status == models.FolioChargeStatusPosted && other.AdjustsID != nil && *other.AdjustsID == charge.ID {
+			voids = append(voids, other)
+		}
+	}
+	difference := -folioBalance(voids)
+
+	var voided *models.FolioCharge
+	result, err := s.changeFolio(booking, nil, difference, actor, now, func(tx *repository.Repositories) error {
+		for _, line := range voids {
+			updated, err := tx.Payment.VoidFolioCharge(line.ID.String(), actor, reason, now)
+			if err != nil {
+				return err
+			}
+			if line.ID == charge.ID {
+				voided = updated
+			}
+		}
+		return nil
+	})
+	if result != nil {
+		result.Charge = voided
+	}
+	return result, err
+}
+
+// changeFolio menjalankan perubahan baris folio, menyamakan cicilan dan
+// invoice dalam transaksi yang sama, lalu me-refund dana yang sudah dibayar
+// untuk tagihan yang berkurang.
+func (s *bookingService) changeFolio(booking *models.Booking, charge *models.FolioCharge, difference float64, actor string, now time.Time, post func(tx *repository.Repositories) error) (*FolioPostingResult, error) {
+	target := paymentOwner(booking)
+	var leftover float64
+	err := s.uow.Do(func(tx *repository.Repositories) error {
+		if err := post(tx); err != nil {
+			return err
+		}
+		var err error
+		if leftover, err = settlePayments(tx, booking, difference, now); err != nil {
+			return err
+		}
+		if err := syncInvoiceAmount(tx, target); err != nil {
+			return err
+		}
+		_, err = target.syncInvoice(tx.Payment)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := &FolioPostingResult{Charge: charge, RefundAmount: math.Max(0, leftover)}
+	var refundErr error
+	if leftover > 0 {
+		owner, err := s.bookingPaymentOwner(booking)
+		if err != nil {
+			return nil, err
+		}
+		// Koreksi tetap berlaku walaupun refund gagal; hasilnya dikembalikan
+		// bersama ErrRefundIncomplete.
+		_, refundErr = s.refundAfterCommit(owner, &booking.ID, leftover, RefundReasonFolio, actor, now)
+	}
+	if result.Folio, err = s.folio(booking); err != nil {
+		return nil, err
+	}
+	return result, refundErr
+}
+
+func findFolioCharge(charges []models.FolioCharge, chargeID string) *models.FolioCharge {
+	for i := range charges {
+		if charges[i].ID.String() == chargeID {
+			return &charges[i]
+		}
+	}
+	return nil
+}
+
+// adjustedAmount adalah nominal baris setelah semua koreksi yang masih Posted.
+func adjustedAmount(charges []models.FolioCharge, original *models.FolioCharge) float64 {
+	net := original.Amount
+	for _, charge := range charges {
+		if charge.Status == models.FolioChargeStatusPosted && charge.AdjustsID != nil && *charge.AdjustsID == original.ID {
+			net += charge.Amount
+		}
+	}
+	return roundAmount(net)
+}
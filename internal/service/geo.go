@@ -0,0 +1,82 @@
+package service
+
+import (
+	"fmt"
+	"hotelbooking/internal/repository"
+	"math"
+)
+
+const earthRadiusKm = 6371.0
+
+// GeoPoint adalah titik koordinat WGS84 dalam derajat.
+type GeoPoint struct {
+	Lat float64
+	Lng float64
+}
+
+// validateCoordinates memastikan koordinat diisi berpasangan dan berada di
+// rentang yang valid. Keduanya boleh kosong.
+func validateCoordinates(latitude, longitude *float64) error {
+	if (latitude == nil) != (longitude == nil) {
+		return fmt.Errorf("latitude dan longitude harus diisi bersamaan")
+	}
+	if latitude == nil {
+		return nil
+	}
+	return validatePoint(GeoPoint{Lat: *latitude, Lng: *longitude})
+}
+
+func validatePoint(p GeoPoint) error {
+	if math.IsNaN(p.Lat) || p.Lat < -90 || p.Lat > 90 {
+		return fmt.Errorf("latitude harus antara -90 dan 90")
+	}
+	if math.IsNaN(p.Lng) || p.Lng < -180 || p.Lng > 180 {
+		return fmt.Errorf("longitude harus antara -180 dan 180")
+	}
+	return nil
+}
+
+// haversineKm menghitung jarak lingkaran besar antara dua titik.
+func haversineKm(a, b GeoPoint) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// boundsAround adalah kotak yang memuat lingkaran radiusKm di sekitar titik.
+// Dipakai sebagai pra-filter di repository sebelum jarak dihitung persis.
+func boundsAround(center GeoPoint, radiusKm float64) repository.GeoBounds {
+	dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+	bounds := repository.GeoBounds{
+		South: math.Max(-90, center.Lat-dLat),
+		North: math.Min(90, center.Lat+dLat),
+		West:  -180,
+		East:  180,
+	}
+	// Dekat kutub lingkarannya mencakup semua garis bujur
+	if bounds.South == -90 || bounds.North == 90 {
+		return bounds
+	}
+	ratio := math.Sin(radiusKm/earthRadiusKm) / math.Cos(center.Lat*math.Pi/180)
+	if radiusKm/earthRadiusKm >= math.Pi/2 || ratio >= 1 {
+		return bounds
+	}
+	dLng := math.Asin(ratio) * 180 / math.Pi
+	bounds.West = normalizeLng(center.Lng - dLng)
+	bounds.East = normalizeLng(center.Lng + dLng)
+	return bounds
+}
+
+func normalizeLng(lng float64) float64 {
+	if lng < -180 {
+		return lng + 360
+	}
+	if lng > 180 {
+		return lng - 360
+	}
+	return lng
+}
@@ -24,7 +24,8 @@ type RegisterGuestInput struct {
 type GuestService interface {
 	RegisterGuest(input RegisterGuestInput) (*models.Guest, error)
 	LoginGuest(login, password string) (*types.TokenResponse, error)
-	SearchHotels(city string) ([]models.Properties, error)
+	SearchHotels(input HotelSearchInput) (*HotelSearchPage, error)
+	Autocomplete(query string, limit int) (*AutocompleteResult, error)
 	GetHotelDetails(propertyID string) (*models.PropertyDetailResponse, error)
 	GetMyBookings(guestID string) ([]models.Booking, error)
 	GetMyProfile(guestID string) (*models.Guest, error)
@@ -34,17 +35,20 @@ type guestService struct {
 	guestRepo repository.GuestRepo
 	propRepo  repository.PropertyRepo
 	bookRepo  repository.BookingRepo
+	index     *SearchIndex
 }
 
 func NewGuestService(
 	guestRepo repository.GuestRepo,
 	propRepo repository.PropertyRepo,
 	bookRepo repository.BookingRepo,
+	index *SearchIndex,
 ) GuestService {
 	return &guestService{
 		guestRepo: guestRepo,
 		propRepo:  propRepo,
 		bookRepo:  bookRepo,
+		index:     index,
 	}
 }
 
@@ -126,10 +130,6 @@ func isNumeric(s string) bool {
 
 // --------------- EXPERIENCE -----------------
 
-func (s *guestService) SearchHotels(city string) ([]models.Properties, error) {
-	return s.propRepo.SearchProperties(city)
-}
-
 func (s *guestService) GetHotelDetails(propertyID string) (*models.PropertyDetailResponse, error) {
 	property, err := s.propRepo.GetPropertyByID(propertyID)
 	if err != nil {
@@ -0,0 +1,98 @@
+package service_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"hotelbooking/internal/gateway"
+	"hotelbooking/internal/repository"
+	"hotelbooking/internal/repository/memory"
+	"hotelbooking/internal/repository/postgres"
+	"hotelbooking/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/supabase-community/supabase-go"
+)
+
+// testBackend adalah satu backend storage yang dipakai test service.
+type testBackend struct {
+	name  string
+	repos *repository.Repositories
+	uow   repository.UnitOfWork
+}
+
+// testBackends selalu mengembalikan backend memori, ditambah Postgres jika
+// DATABASE_URL diisi dan Supabase jika SUPABASE_TEST_URL serta
+// SUPABASE_TEST_KEY diisi (project terpisah yang sudah menjalankan
+// supabase/migrations).
+func testBackends(t *testing.T) []testBackend {
+	t.Helper()
+	store := memory.NewStore()
+	backends := []testBackend{{name: "memory", repos: memory.NewRepositories(store), uow: memory.NewUnitOfWork(store)}}
+
+	if dsn := os.Getenv("DATABASE_URL"); dsn != "" {
+		ctx := context.Background()
+		pool, err := pgxpool.New(ctx, dsn)
+		if err != nil {
+			t.Fatalf("postgres: %v", err)
+		}
+		t.Cleanup(pool.Close)
+		if err := postgres.Migrate(ctx, pool); err != nil {
+			t.Fatalf("postgres migrate: %v", err)
+		}
+		backends = append(backends, testBackend{name: "postgres", repos: postgres.NewRepositories(pool), uow: postgres.NewUnitOfWork(pool)})
+	}
+
+	url, key := os.Getenv("SUPABASE_TEST_URL"), os.Getenv("SUPABASE_TEST_KEY")
+	if url != "" && key != "" {
+		client, err := supabase.NewClient(url, key, &supabase.ClientOptions{})
+		if err != nil {
+			t.Fatalf("supabase: %v", err)
+		}
+		repos := repository.NewSupabaseRepositories(client)
+		backends = append(backends, testBackend{name: "supabase", repos: repos, uow: repository.NewSupabaseUnitOfWork(repos)})
+	}
+	return backends
+}
+
+// testHotel adalah property dengan satu tipe kamar dan satu nomor kamar.
+type testHotel struct {
+	propertyID string
+	roomTypeID string
+	roomID     string
+}
+
+func newTestHotel(t *testing.T, backend testBackend, price float64) testHotel {
+	t.Helper()
+	inventory := service.NewInventoryService(backend.repos.Property, service.NewSearchIndex(backend.repos.Property))
+	code := strings.ToUpper(uuid.NewString()[:8])
+	property, err := inventory.CreateHotel("Hotel "+code, "Jl. Malioboro", "Yogyakarta", code, nil, nil)
+	if err != nil {
+		t.Fatalf("create hotel: %v", err)
+	}
+	roomType, err := inventory.CreateRoomType(property.ID.String(), "Deluxe", "", price, 2, nil)
+	if err != nil {
+		t.Fatalf("create room type: %v", err)
+	}
+	room, err := inventory.CreateRoom(property.ID.String(), roomType.ID.String(), "101")
+	if err != nil {
+		t.Fatalf("create room: %v", err)
+	}
+	return testHotel{propertyID: property.ID.String(), roomTypeID: roomType.ID.String(), roomID: room.ID.String()}
+}
+
+func newTestBookingService(backend testBackend, gateways ...gateway.PaymentGateway) service.BookingService {
+	return service.NewBookingService(backend.repos.Booking, backend.repos.Property, backend.repos.Payment,
+		backend.uow, gateway.NewRegistry(gateways...), service.LogPaymentNotifier{}, 15*time.Minute)
+}
+
+// stayDate adalah tanggal menginap yang cukup jauh supaya tidak terkena
+// batas waktu check-in.
+func stayDate(daysAhead int) time.Time {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	return today.AddDate(0, 2, daysAhead)
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StartHoldSweeper menjalankan ExpireHolds secara berkala sampai ctx selesai,
+// sehingga kamar dari booking yang tidak dibayar kembali tersedia. Putaran
+// yang sama memproses jadwal pembayaran: pengingat pelunasan dan pembatalan
+// otomatis untuk pelunasan yang terlewat.
+func StartHoldSweeper(ctx context.Context, svc BookingService, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				expired, err := svc.ExpireHolds(now)
+				if err != nil {
+					log.Printf("hold sweeper: %v", err)
+				} else if expired > 0 {
+					log.Printf("hold sweeper: %d booking expired", expired)
+				}
+				run, err := svc.ProcessPaymentSchedules(now)
+				if err != nil {
+					log.Printf("payment schedule: %v", err)
+					continue
+				}
+				if run.Reminded > 0 || run.Cancelled > 0 {
+					log.Printf("payment schedule: %d pengingat, %d booking dibatalkan", run.Reminded, run.Cancelled)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,674 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Urutan hasil pencarian hotel.
+const (
+	SortPriceAsc   = "price_asc"
+	SortPriceDesc  = "price_desc"
+	SortRating     = "rating"
+	SortDistance   = "distance"
+	SortPopularity = "popularity"
+	SortRelevance  = "relevance"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+
+	defaultAutocompleteLimit = 5
+	maxAutocompleteLimit     = 20
+
+	// popularityWindow adalah rentang booking yang dihitung untuk sort popularity
+	popularityWindow = 90 * 24 * time.Hour
+)
+
+// HotelSearchInput adalah parameter pencarian hotel. Tanpa tanggal, pencarian
+// hanya memfilter atribut property dan tidak menghitung harga.
+type HotelSearchInput struct {
+	// Query adalah teks bebas (nama hotel, kota, alamat, fasilitas) yang
+	// dicocokkan lewat SearchIndex dengan toleransi typo.
+	Query          string
+	City           string
+	CheckIn        time.Time
+	CheckOut       time.Time
+	Adults         int
+	Children       int
+	Rooms          int
+	MinPrice       *float64
+	MaxPrice       *float64
+	Facilities     []string
+	RoomFacilities []string
+	MinRating      float64
+	Sort           string
+	Page           int
+	Limit          int
+
+	// Near adalah titik acuan jarak; RadiusKm > 0 membatasi hasil ke radius
+	// tersebut. Bounds membatasi hasil ke kotak peta.
+	Near     *GeoPoint
+	RadiusKm float64
+	Bounds   *repository.GeoBounds
+}
+
+// HotelSearchResult adalah property hasil pencarian beserta penawaran
+// termurahnya untuk tanggal dan jumlah tamu yang diminta.
+type HotelSearchResult struct {
+	models.Properties
+	LowestPrice *float64         `json:"lowest_price,omitempty"`
+	Currency    string           `json:"currency,omitempty"`
+	Nights      int              `json:"nights,omitempty"`
+	RoomType    *models.RoomType `json:"room_type,omitempty"`
+	RatePlan    *models.RatePlan `json:"rate_plan,omitempty"`
+	DistanceKm  *float64         `json:"distance_km,omitempty"`
+
+	roomFacilities []string
+	relevance      float64
+}
+
+// PriceRange adalah harga termurah dan termahal di antara hasil pencarian.
+type PriceRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// HotelSearchFacets menghitung jumlah hasil per nilai filter, dihitung dari
+// semua hasil yang lolos filter sebelum dipaging.
+type HotelSearchFacets struct {
+	PropertyFacilities map[string]int `json:"property_facilities"`
+	RoomFacilities     map[string]int `json:"room_facilities"`
+	Cities             map[string]int `json:"cities"`
+	Ratings            map[string]int `json:"ratings"`
+	Price              *PriceRange    `json:"price,omitempty"`
+}
+
+type HotelSearchPage struct {
+	Results []HotelSearchResult `json:"results"`
+	Facets  HotelSearchFacets   `json:"facets"`
+	Total   int                 `json:"total"`
+	Page    int                 `json:"page"`
+	Limit   int                 `json:"limit"`
+}
+
+// occupancy adalah jumlah tamu di satu kamar.
+type occupancy struct {
+	adults   int
+	children int
+}
+
+// searchProperties menjalankan filter di repository. Filter kota tetap
+// memakai ilike repository; index hanya menambah property yang kotanya
+// cocok lewat alias atau typo, tetap dengan filter lain dari repository.
+func (s *guestService) searchProperties(filter repository.PropertySearchFilter) ([]models.Properties, error) {
+	properties, err := s.propRepo.SearchProperties(filter)
+	if err != nil || s.index == nil || strings.TrimSpace(filter.City) == "" {
+		return properties, err
+	}
+	aliases := s.index.MatchCity(filter.City)
+	for _, p := range properties {
+		delete(aliases, p.ID)
+	}
+	if len(aliases) == 0 {
+		return properties, nil
+	}
+	filter.City = ""
+	others, err := s.propRepo.SearchProperties(filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range others {
+		if aliases[p.ID] {
+			properties = append(properties, p)
+		}
+	}
+	return properties, nil
+}
+
+func (s *guestService) SearchHotels(input HotelSearchInput) (*HotelSearchPage, error) {
+	dated := !input.CheckIn.IsZero() || !input.CheckOut.IsZero()
+	if err := validateSearchInput(&input, dated); err != nil {
+		return nil, err
+	}
+	if input.Query != "" && s.index == nil {
+		return nil, fmt.Errorf("pencarian teks tidak tersedia")
+	}
+
+	filter := repository.PropertySearchFilter{
+		City:       input.City,
+		Facilities: input.Facilities,
+		MinRating:  input.MinRating,
+		Bounds:     input.Bounds,
+	}
+	if filter.Bounds == nil && input.RadiusKm > 0 {
+		bounds := boundsAround(*input.Near, input.RadiusKm)
+		filter.Bounds = &bounds
+	}
+
+	var relevance map[uuid.UUID]float64
+	if input.Query != "" {
+		relevance = make(map[uuid.UUID]float64)
+		for _, hit := range s.index.Search(input.Query) {
+			relevance[hit.PropertyID] = hit.Score
+		}
+	}
+	properties, err := s.searchProperties(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		nights int
+		rooms  []occupancy
+	)
+	if dated {
+		if nights, err = validateStay(input.CheckIn, input.CheckOut); err != nil {
+			return nil, err
+		}
+		if rooms, err = splitGuests(input.Adults, input.Children, input.Rooms); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]HotelSearchResult, 0, len(properties))
+	for _, p := range properties {
+		if relevance != nil && relevance[p.ID] == 0 {
+			continue
+		}
+		distance := distanceFrom(input.Near, p)
+		if input.RadiusKm > 0 && (distance == nil || *distance > input.RadiusKm) {
+			continue
+		}
+
+		roomTypes, err := s.propRepo.ListRoomTypes(p.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		candidates := make([]models.RoomType, 0, len(roomTypes))
+		for _, roomType := range roomTypes {
+			if hasFacilities(roomType.Facilities, input.RoomFacilities) {
+				candidates = append(candidates, roomType)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		result := &HotelSearchResult{Properties: p}
+		if dated {
+			if result, err = s.cheapestStay(p, candidates, input, nights, rooms); err != nil {
+				return nil, err
+			}
+			if result == nil || !withinPrice(*result.LowestPrice, input.MinPrice, input.MaxPrice) {
+				continue
+			}
+			result.Nights = nights
+		}
+		result.DistanceKm = distance
+		result.relevance = relevance[p.ID]
+		result.roomFacilities = roomTypeFacilities(roomTypes)
+		results = append(results, *result)
+	}
+
+	if err := s.sortSearchResults(results, input.Sort); err != nil {
+		return nil, err
+	}
+
+	page := &HotelSearchPage{
+		Facets: searchFacets(results),
+		Total:  len(results),
+		Page:   input.Page,
+		Limit:  input.Limit,
+	}
+	start := (input.Page - 1) * input.Limit
+	if start > len(results) {
+		start = len(results)
+	}
+	end := start + input.Limit
+	if end > len(results) {
+		end = len(results)
+	}
+	page.Results = results[start:end]
+	return page, nil
+}
+
+func (s *guestService) Autocomplete(query string, limit int) (*AutocompleteResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("parameter q wajib diisi")
+	}
+	if limit == 0 {
+		limit = defaultAutocompleteLimit
+	}
+	if limit < 1 || limit > maxAutocompleteLimit {
+		return nil, fmt.Errorf("limit harus antara 1 dan %d", maxAutocompleteLimit)
+	}
+	if s.index == nil {
+		return nil, fmt.Errorf("pencarian teks tidak tersedia")
+	}
+	result := s.index.Autocomplete(query, limit)
+	return &result, nil
+}
+
+// validateSearchInput mengisi nilai default paging dan sort lalu menolak
+// kombinasi yang tidak bisa dilayani.
+func validateSearchInput(input *HotelSearchInput, dated bool) error {
+	if input.Page == 0 {
+		input.Page = 1
+	}
+	if input.Limit == 0 {
+		input.Limit = defaultSearchLimit
+	}
+	if input.Page < 1 || input.Limit < 1 || input.Limit > maxSearchLimit {
+		return fmt.Errorf("page minimal 1 dan limit antara 1 dan %d", maxSearchLimit)
+	}
+	if input.MinRating < 0 || input.MinRating > 5 {
+		return fmt.Errorf("rating harus antara 0 dan 5")
+	}
+	if input.MinPrice != nil && input.MaxPrice != nil && *input.MinPrice > *input.MaxPrice {
+		return fmt.Errorf("min_price tidak boleh lebih besar dari max_price")
+	}
+	if input.Near != nil {
+		if err := validatePoint(*input.Near); err != nil {
+			return err
+		}
+	}
+	if input.RadiusKm < 0 || (input.RadiusKm > 0 && input.Near == nil) {
+		return fmt.Errorf("radius membutuhkan lat dan lng dan tidak boleh negatif")
+	}
+	if b := input.Bounds; b != nil {
+		if err := validatePoint(GeoPoint{Lat: b.South, Lng: b.West}); err != nil {
+			return err
+		}
+		if err := validatePoint(GeoPoint{Lat: b.North, Lng: b.East}); err != nil {
+			return err
+		}
+		if b.South > b.North {
+			return fmt.Errorf("batas selatan bbox tidak boleh di utara batas utara")
+		}
+	}
+
+	if input.Sort == "" && input.Query != "" {
+		input.Sort = SortRelevance
+	}
+	if input.Sort == "" && dated {
+		input.Sort = SortPriceAsc
+	}
+	switch input.Sort {
+	case "", SortRating, SortPopularity:
+	case SortRelevance:
+		if input.Query == "" {
+			return fmt.Errorf("sort relevance membutuhkan q")
+		}
+	case SortPriceAsc, SortPriceDesc:
+		if !dated {
+			return fmt.Errorf("sort harga membutuhkan check_in dan check_out")
+		}
+	case SortDistance:
+		if input.Near == nil {
+			return fmt.Errorf("sort distance membutuhkan lat dan lng")
+		}
+	default:
+		return fmt.Errorf("sort tidak dikenal: %s", input.Sort)
+	}
+	if !dated && (input.MinPrice != nil || input.MaxPrice != nil) {
+		return fmt.Errorf("filter harga membutuhkan check_in dan check_out")
+	}
+	return nil
+}
+
+func (s *guestService) sortSearchResults(results []HotelSearchResult, order string) error {
+	byPrice := func(i, j int) bool { return *results[i].LowestPrice < *results[j].LowestPrice }
+	switch order {
+	case SortPriceAsc:
+		sort.SliceStable(results, byPrice)
+	case SortPriceDesc:
+		sort.SliceStable(results, func(i, j int) bool { return byPrice(j, i) })
+	case SortDistance:
+		// Property tanpa koordinat diletakkan paling akhir
+		sort.SliceStable(results, func(i, j int) bool {
+			a, b := results[i].DistanceKm, results[j].DistanceKm
+			return a != nil && (b == nil || *a < *b)
+		})
+	case SortRelevance:
+		sort.SliceStable(results, func(i, j int) bool { return results[i].relevance > results[j].relevance })
+	case SortRating:
+		sort.SliceStable(results, func(i, j int) bool { return results[i].Rating > results[j].Rating })
+	case SortPopularity:
+		counts, err := s.bookRepo.CountBookingsByProperty(time.Now().Add(-popularityWindow))
+		if err != nil {
+			return err
+		}
+		sort.SliceStable(results, func(i, j int) bool {
+			return counts[results[i].ID.String()] > counts[results[j].ID.String()]
+		})
+	}
+	return nil
+}
+
+func searchFacets(results []HotelSearchResult) HotelSearchFacets {
+	facets := HotelSearchFacets{
+		PropertyFacilities: make(map[string]int),
+		RoomFacilities:     make(map[string]int),
+		Cities:             make(map[string]int),
+		Ratings:            make(map[string]int),
+	}
+	for _, result := range results {
+		for _, facility := range uniqueValues(result.Facilities) {
+			facets.PropertyFacilities[facility]++
+		}
+		for _, facility := range result.roomFacilities {
+			facets.RoomFacilities[facility]++
+		}
+		if result.City != "" {
+			facets.Cities[result.City]++
+		}
+		// Rating 4.5 masuk bucket "4", sama seperti filter bintang di front-end
+		facets.Ratings[strconv.Itoa(int(math.Floor(result.Rating)))]++
+
+		if result.LowestPrice == nil {
+			continue
+		}
+		price := *result.LowestPrice
+		if facets.Price == nil {
+			facets.Price = &PriceRange{Min: price, Max: price}
+		}
+		facets.Price.Min = math.Min(facets.Price.Min, price)
+		facets.Price.Max = math.Max(facets.Price.Max, price)
+	}
+	return facets
+}
+
+// searchInventory adalah rate plan aktif, rate tipe kamar, dan sisa unit
+// satu property untuk tanggal pencarian. Dimuat sekali per property lalu
+// dipakai semua tipe kamarnya.
+type searchInventory struct {
+	plans     []models.RatePlan
+	rates     map[uuid.UUID][]models.RoomRate
+	remaining map[uuid.UUID]int
+}
+
+func (s *guestService) loadSearchInventory(property models.Properties, roomTypes []models.RoomType, input HotelSearchInput) (*searchInventory, error) {
+	propertyID := property.ID.String()
+	plans, err := s.propRepo.ListRatePlans(propertyID)
+	if err != nil {
+		return nil, err
+	}
+	inventory := &searchInventory{
+		plans:     activePlans(plans),
+		rates:     make(map[uuid.UUID][]models.RoomRate),
+		remaining: make(map[uuid.UUID]int),
+	}
+
+	// Baris tanggal check-out ikut diambil untuk mengecek closed-to-departure
+	startStr := input.CheckIn.Format("2006-01-02")
+	endStr := input.CheckOut.Format("2006-01-02")
+	ids := make([]string, 0, len(roomTypes))
+	for _, roomType := range roomTypes {
+		ids = append(ids, roomType.ID.String())
+	}
+	rates, err := s.propRepo.ListRoomTypesRates(ids, startStr, endStr)
+	if err != nil {
+		return nil, err
+	}
+	for _, rate := range rates {
+		if rate.RoomTypeID != nil {
+			inventory.rates[*rate.RoomTypeID] = append(inventory.rates[*rate.RoomTypeID], rate)
+		}
+	}
+
+	rooms, err := s.propRepo.ListRooms(propertyID, "")
+	if err != nil {
+		return nil, err
+	}
+	sellable := make(map[uuid.UUID]int)
+	for _, room := range rooms {
+		if room.RoomTypeID != nil && room.Status != models.RoomStatusOutOfOrder {
+			sellable[*room.RoomTypeID]++
+		}
+	}
+	bookings, err := s.bookRepo.ListActiveBookings(propertyID, startStr, endStr)
+	if err != nil {
+		return nil, err
+	}
+	overlapping := make(map[uuid.UUID][]models.Booking)
+	for _, booking := range bookings {
+		if booking.RoomTypeID != nil {
+			overlapping[*booking.RoomTypeID] = append(overlapping[*booking.RoomTypeID], booking)
+		}
+	}
+	for _, roomType := range roomTypes {
+		inventory.remaining[roomType.ID] = repository.RemainingRooms(sellable[roomType.ID], overlapping[roomType.ID], input.CheckIn, input.CheckOut)
+	}
+	return inventory, nil
+}
+
+// cheapestStay mencari tipe kamar termurah yang masih punya cukup unit dan
+// kapasitas untuk semua kamar yang diminta. Hasil nil berarti property tidak
+// bisa dijual untuk pencarian ini.
+func (s *guestService) cheapestStay(property models.Properties, roomTypes []models.RoomType, input HotelSearchInput, nights int, rooms []occupancy) (*HotelSearchResult, error) {
+	fitting := make([]models.RoomType, 0, len(roomTypes))
+	for _, roomType := range roomTypes {
+		if fitsCapacity(roomType, rooms) {
+			fitting = append(fitting, roomType)
+		}
+	}
+	if len(fitting) == 0 {
+		return nil, nil
+	}
+	inventory, err := s.loadSearchInventory(property, fitting, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *HotelSearchResult
+	for i := range fitting {
+		roomType := fitting[i]
+		total, plan, ok, err := priceRoomType(property, roomType, inventory, input, nights, rooms)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if best != nil && *best.LowestPrice <= total {
+			continue
+		}
+		best = &HotelSearchResult{
+			Properties:  property,
+			LowestPrice: &total,
+			Currency:    "IDR",
+			RoomType:    &roomType,
+			RatePlan:    plan,
+		}
+	}
+	return best, nil
+}
+
+// priceRoomType menjumlahkan harga semua kamar dengan satu rate plan yang
+// sama. Quote dihitung sekali per komposisi tamu karena kamar dengan
+// okupansi sama pasti berharga sama. Tipe kamar tanpa cukup unit atau tanpa
+// rate dilewati; error lain dikembalikan.
+func priceRoomType(property models.Properties, roomType models.RoomType, inventory *searchInventory, input HotelSearchInput, nights int, rooms []occupancy) (float64, *models.RatePlan, bool, error) {
+	remaining := inventory.remaining[roomType.ID]
+	if remaining < len(rooms) {
+		return 0, nil, false, nil
+	}
+	stock := &quoteInventory{
+		propertyID: roomType.PropertyID,
+		roomTypeID: &roomType.ID,
+		basePrice:  roomType.BasePrice,
+		capacity:   roomType.Capacity,
+		rates:      inventory.rates[roomType.ID],
+		roomsLeft:  &remaining,
+	}
+
+	quotes := make(map[occupancy]*BookingQuote)
+	totals := make(map[string]float64)
+	plans := make(map[string]*models.RatePlan)
+	counts := make(map[string]int)
+
+	for _, room := range rooms {
+		quote, cached := quotes[room]
+		if !cached {
+			mix, err := normalizeParty(room.adults, room.children, nil)
+			if err != nil {
+				return 0, nil, false, err
+			}
+			quote, err = quoteStay(QuoteInput{
+				RoomTypeID: roomType.ID.String(),
+				CheckIn:    input.CheckIn,
+				CheckOut:   input.CheckOut,
+				Adults:     room.adults,
+				Children:   room.children,
+			}, nights, mix, stock, inventory.plans, property.TaxRules)
+			if errors.Is(err, ErrRateNotAvailable) {
+				return 0, nil, false, nil
+			}
+			if err != nil {
+				return 0, nil, false, err
+			}
+			quotes[room] = quote
+		}
+
+		// Property yang menjual rate plan tidak menerima booking harga dasar
+		if len(quote.Offers) == 0 {
+			if quote.Available {
+				totals[""] += quote.TotalPrice
+				counts[""]++
+			}
+			continue
+		}
+		for _, offer := range quote.Offers {
+			if !offer.Available {
+				continue
+			}
+			key := offer.RatePlan.ID.String()
+			totals[key] += offer.TotalPrice
+			plans[key] = offer.RatePlan
+			counts[key]++
+		}
+	}
+
+	var (
+		best     float64
+		bestKey  string
+		bestPlan *models.RatePlan
+		found    bool
+	)
+	for key, total := range totals {
+		if counts[key] != len(rooms) {
+			continue
+		}
+		if !found || total < best || (total == best && key < bestKey) {
+			best, bestKey, bestPlan, found = total, key, plans[key], true
+		}
+	}
+	return roundAmount(best), bestPlan, found, nil
+}
+
+// splitGuests membagi tamu serata mungkin ke jumlah kamar yang diminta.
+// Setiap kamar wajib berisi minimal satu dewasa.
+func splitGuests(adults, children, rooms int) ([]occupancy, error) {
+	adults, children, err := normalizeGuests(adults, children)
+	if err != nil {
+		return nil, err
+	}
+	if rooms == 0 {
+		rooms = 1
+	}
+	if rooms < 1 {
+		return nil, fmt.Errorf("jumlah kamar tidak valid")
+	}
+	if adults < rooms {
+		return nil, fmt.Errorf("jumlah dewasa minimal sama dengan jumlah kamar")
+	}
+
+	out := make([]occupancy, rooms)
+	for i := range out {
+		out[i].adults = adults / rooms
+		if i < adults%rooms {
+			out[i].adults++
+		}
+		// Sisa anak diberikan mulai dari kamar terakhir yang dewasanya lebih sedikit
+		out[i].children = children / rooms
+		if rooms-1-i < children%rooms {
+			out[i].children++
+		}
+	}
+	return out, nil
+}
+
+func fitsCapacity(roomType models.RoomType, rooms []occupancy) bool {
+	for _, room := range rooms {
+		if room.adults+room.children > roomType.Capacity {
+			return false
+		}
+	}
+	return true
+}
+
+// distanceFrom bernilai nil jika titik acuan atau koordinat property kosong.
+func distanceFrom(point *GeoPoint, property models.Properties) *float64 {
+	if point == nil || property.Latitude == nil || property.Longitude == nil {
+		return nil
+	}
+	distance := math.Round(haversineKm(*point, GeoPoint{Lat: *property.Latitude, Lng: *property.Longitude})*100) / 100
+	return &distance
+}
+
+func withinPrice(price float64, min, max *float64) bool {
+	if min != nil && price < *min {
+		return false
+	}
+	return max == nil || price <= *max
+}
+
+// hasFacilities bernilai true jika semua fasilitas yang diminta tersedia.
+func hasFacilities(facilities, wanted []string) bool {
+	for _, w := range wanted {
+		found := false
+		for _, f := range facilities {
+			if f == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// roomTypeFacilities menggabungkan fasilitas semua tipe kamar property.
+func roomTypeFacilities(roomTypes []models.RoomType) []string {
+	var all []string
+	for _, roomType := range roomTypes {
+		all = append(all, roomType.Facilities...)
+	}
+	return uniqueValues(all)
+}
+
+func uniqueValues(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	return out
+}
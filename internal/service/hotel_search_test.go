@@ -0,0 +1,128 @@
+package service_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/service"
+
+	"github.com/google/uuid"
+)
+
+func containsHotel(page *service.HotelSearchPage, propertyID string) bool {
+	return findHotel(page, propertyID) != nil
+}
+
+func findHotel(page *service.HotelSearchPage, propertyID string) *service.HotelSearchResult {
+	for i := range page.Results {
+		if page.Results[i].ID.String() == propertyID {
+			return &page.Results[i]
+		}
+	}
+	return nil
+}
+
+// TestSearchHotelsCityFilter memastikan filter kota repository tetap
+// berlaku walaupun index belum memuat property, dan index menambah hasil
+// lewat alias kota.
+func TestSearchHotelsCityFilter(t *testing.T) {
+	for _, backend := range testBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			index := service.NewSearchIndex(backend.repos.Property)
+			guests := service.NewGuestService(backend.repos.Guest, backend.repos.Property, backend.repos.Booking, index)
+			hotel := newTestHotel(t, backend, 500000)
+
+			page, err := guests.SearchHotels(service.HotelSearchInput{City: "Yogya", Limit: 50})
+			if err != nil {
+				t.Fatalf("search before index refresh: %v", err)
+			}
+			if !containsHotel(page, hotel.propertyID) {
+				t.Fatalf("hotel missing from city search while index is stale")
+			}
+
+			if err := index.Rebuild(); err != nil {
+				t.Fatalf("rebuild index: %v", err)
+			}
+			page, err = guests.SearchHotels(service.HotelSearchInput{City: "jogja", Limit: 50})
+			if err != nil {
+				t.Fatalf("search by alias: %v", err)
+			}
+			if !containsHotel(page, hotel.propertyID) {
+				t.Fatalf("hotel missing from city alias search")
+			}
+		})
+	}
+}
+
+// TestSearchHotelsPricing memastikan pencarian bertanggal memakai rate dan
+// sisa unit yang dimuat per property, dan error rate yang rusak tidak
+// disembunyikan sebagai hotel penuh.
+func TestSearchHotelsPricing(t *testing.T) {
+	for _, backend := range testBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			guests := service.NewGuestService(backend.repos.Guest, backend.repos.Property, backend.repos.Booking, nil)
+			inventory := service.NewInventoryService(backend.repos.Property, service.NewSearchIndex(backend.repos.Property))
+			hotel := newTestHotel(t, backend, 500000)
+			checkIn := stayDate(20)
+			search := service.HotelSearchInput{
+				City:     "Yogyakarta",
+				CheckIn:  checkIn,
+				CheckOut: checkIn.AddDate(0, 0, 2),
+				Adults:   1,
+				Limit:    100,
+			}
+
+			roomTypeID := uuid.MustParse(hotel.roomTypeID)
+			rate := 700000.0
+			err := inventory.SetRoomRates([]models.RoomRate{{
+				ID: uuid.New(), RoomTypeID: &roomTypeID,
+				Date: checkIn, AvailableRooms: 1, LinearRate: &rate,
+			}})
+			if err != nil {
+				t.Fatalf("set room type rate: %v", err)
+			}
+			page, err := guests.SearchHotels(search)
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			result := findHotel(page, hotel.propertyID)
+			if result == nil {
+				t.Fatalf("hotel missing from dated search")
+			}
+			if want := rate + 500000; result.LowestPrice == nil || *result.LowestPrice != want {
+				t.Fatalf("got lowest price %v, want %v", result.LowestPrice, want)
+			}
+
+			_, err = newTestBookingService(backend).CreateBooking(service.CreateBookingInput{
+				GuestID:    uuid.NewString(),
+				PropertyID: hotel.propertyID,
+				RoomTypeID: hotel.roomTypeID,
+				CheckIn:    checkIn.AddDate(0, 0, 1),
+				CheckOut:   checkIn.AddDate(0, 0, 2),
+			})
+			if err != nil {
+				t.Fatalf("create booking: %v", err)
+			}
+			if page, err = guests.SearchHotels(search); err != nil {
+				t.Fatalf("search after booking: %v", err)
+			}
+			if containsHotel(page, hotel.propertyID) {
+				t.Fatalf("sold out hotel still listed")
+			}
+
+			broken := newTestHotel(t, backend, 500000)
+			brokenTypeID := uuid.MustParse(broken.roomTypeID)
+			err = backend.repos.Property.UpsertRoomRates([]models.RoomRate{{
+				ID: uuid.New(), RoomTypeID: &brokenTypeID,
+				Date: checkIn, AvailableRooms: 1, NonLinearRate: json.RawMessage(`{"unknown": 1}`),
+			}})
+			if err != nil {
+				t.Fatalf("store broken rate: %v", err)
+			}
+			if _, err := guests.SearchHotels(search); err == nil {
+				t.Fatalf("search with broken rate succeeded, want error")
+			}
+		})
+	}
+}
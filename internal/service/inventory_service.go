@@ -4,14 +4,15 @@ import (
 	"fmt"
 	"hotelbooking/internal/models"
 	"hotelbooking/internal/repository"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type InventoryService interface {
-	CreateHotel(name, address, city, hotelCode string) (*models.Properties, error)
-	UpdateHotel(id, name, address, city string, facilities []string, checkIn, checkOut, cancelPolicy string) (*models.Properties, error)
+	CreateHotel(name, address, city, hotelCode string, latitude, longitude *float64) (*models.Properties, error)
+	UpdateHotel(id, name, address, city string, facilities []string, checkIn, checkOut, cancelPolicy string, modificationCutoffHours *int, rating float64, latitude, longitude *float64) (*models.Properties, error)
 	DeleteHotel(id string) error
 	ListHotels(city string) ([]models.Properties, error)
 	GetHotelByID(id string) (*models.Properties, error)
@@ -24,7 +25,17 @@ type InventoryService interface {
 	DeleteRoom(id string) error
 	ListRooms(propertyID, roomTypeID string) ([]models.Room, error)
 	SetRoomRates(rates []models.RoomRate) error
-	GetRoomRates(roomID, startDate, endDate string) ([]models.RoomRate, error)
+	GetRoomRates(roomID, ratePlanID, startDate, endDate string) ([]models.RoomRate, error)
+	GetRoomTypeRates(roomTypeID, ratePlanID, startDate, endDate string) ([]models.RoomRate, error)
+	CreateRatePlan(input RatePlanInput) (*models.RatePlan, error)
+	UpdateRatePlan(id string, input RatePlanInput) (*models.RatePlan, error)
+	DeleteRatePlan(id string) error
+	ListRatePlans(propertyID string) ([]models.RatePlan, error)
+	GetRatePlanByID(id string) (*models.RatePlan, error)
+	SetPropertyCancellationPolicy(propertyID string, policy *models.CancellationPolicy) (*models.Properties, error)
+	SetRatePlanCancellationPolicy(planID string, policy *models.CancellationPolicy) (*models.RatePlan, error)
+	SetPropertyPaymentSchedule(propertyID string, policy *models.PaymentSchedulePolicy) (*models.Properties, error)
+	SetPropertyTaxRules(propertyID string, rules []models.TaxRule) (*models.Properties, error)
 	GetRoomByID(id string) (*models.Room, error)
 	GetRoomTypeByID(id string) (*models.RoomType, error)
 	GetPropertyPhotoByID(id string) (*models.PropertyPhoto, error)
@@ -38,18 +49,35 @@ type InventoryService interface {
 }
 
 type inventoryService struct {
-	repo repository.PropertyRepo
+	repo  repository.PropertyRepo
+	index *SearchIndex
 }
 
-func NewInventoryService(repo repository.PropertyRepo) InventoryService {
-	return &inventoryService{repo: repo}
+// NewInventoryService menerima index pencarian yang diperbarui setiap kali
+// property atau tipe kamarnya berubah; index boleh nil.
+func NewInventoryService(repo repository.PropertyRepo, index *SearchIndex) InventoryService {
+	return &inventoryService{repo: repo, index: index}
 }
 
-func (s *inventoryService) CreateHotel(name, address, city, hotelCode string) (*models.Properties, error) {
+// reindex dipanggil setelah mutasi property. Kegagalan hanya dicatat karena
+// data utama sudah tersimpan; indeks akan benar lagi pada rebuild berikutnya.
+func (s *inventoryService) reindex(propertyID *uuid.UUID) {
+	if s.index == nil || propertyID == nil {
+		return
+	}
+	if err := s.index.Refresh(propertyID.String()); err != nil {
+		log.Printf("search index: gagal memperbarui property %s: %v", propertyID, err)
+	}
+}
+
+func (s *inventoryService) CreateHotel(name, address, city, hotelCode string, latitude, longitude *float64) (*models.Properties, error) {
 	// 1. Validasi Input Sederhana
 	if name == "" || hotelCode == "" {
 		return nil, fmt.Errorf("nama hotel dan kode hotel wajib diisi")
 	}
+	if err := validateCoordinates(latitude, longitude); err != nil {
+		return nil, err
+	}
 
 	// 2. Siapkan Model Data
 	newProperty := models.Properties{
@@ -60,6 +88,8 @@ func (s *inventoryService) CreateHotel(name, address, city, hotelCode string) (*
 		Name:      name,
 		Address:   address,
 		City:      city,
+		Latitude:  latitude,
+		Longitude: longitude,
 		CreatedAt: time.Now(),
 	}
 
@@ -68,6 +98,7 @@ func (s *inventoryService) CreateHotel(name, address, city, hotelCode string) (*
 	if err != nil {
 		return nil, err
 	}
+	s.reindex(&newProperty.ID)
 
 	return &newProperty, nil
 }
@@ -104,6 +135,7 @@ func (s *inventoryService) CreateRoomType(propertyID, name, description string,
 	if err := s.repo.CreateRoomType(newRoomType); err != nil {
 		return nil, err
 	}
+	s.reindex(newRoomType.PropertyID)
 
 	return &newRoomType, nil
 }
@@ -130,7 +162,7 @@ func (s *inventoryService) UpdateRoomType(id, propertyID, name, description stri
 		}
 		propUUID = &pid
 	}
-	return s.repo.UpdateRoomType(models.RoomType{
+	updated, err := s.repo.UpdateRoomType(models.RoomType{
 		ID:          roomTypeID,
 		PropertyID:  propUUID,
 		Name:        name,
@@ -139,10 +171,23 @@ func (s *inventoryService) UpdateRoomType(id, propertyID, name, description stri
 		Capacity:    capacity,
 		Facilities:  facilities,
 	})
+	if err != nil {
+		return nil, err
+	}
+	s.reindex(updated.PropertyID)
+	return updated, nil
 }
 
 func (s *inventoryService) DeleteRoomType(id string) error {
-	return s.repo.DeleteRoomType(id)
+	roomType, err := s.repo.GetRoomTypeByID(id)
+	if err != nil {
+		return s.repo.DeleteRoomType(id)
+	}
+	if err := s.repo.DeleteRoomType(id); err != nil {
+		return err
+	}
+	s.reindex(roomType.PropertyID)
+	return nil
 }
 
 func (s *inventoryService) ListRoomTypes(propertyID string) ([]models.RoomType, error) {
@@ -224,14 +269,67 @@ func (s *inventoryService) SetRoomRates(rates []models.RoomRate) error {
 	if len(rates) == 0 {
 		return fmt.Errorf("rates tidak boleh kosong")
 	}
-	return s.repo.UpsertRoomRates(rates)
+	for _, rate := range rates {
+		if err := validateNonLinearRate(rate.NonLinearRate); err != nil {
+			return err
+		}
+	}
+	properties, err := s.validateRatePlanRates(rates)
+	if err != nil {
+		return err
+	}
+	// Plan turunan mengikuti parent-nya setiap kali harga parent diubah
+	all := append([]models.RoomRate{}, rates...)
+	for propertyID := range properties {
+		plans, err := s.repo.ListRatePlans(propertyID.String())
+		if err != nil {
+			return err
+		}
+		derived, err := s.derivedRates(plans, rates)
+		if err != nil {
+			return err
+		}
+		all = append(all, derived...)
+	}
+	return s.repo.UpsertRoomRates(all)
 }
 
-func (s *inventoryService) GetRoomRates(roomID, startDate, endDate string) ([]models.RoomRate, error) {
+// GetRoomRates mengembalikan semua rate kamar jika ratePlanID kosong; jika
+// diisi hanya rate milik plan tersebut. Baris plan turunan ditandai Derived.
+func (s *inventoryService) GetRoomRates(roomID, ratePlanID, startDate, endDate string) ([]models.RoomRate, error) {
 	if roomID == "" {
 		return nil, fmt.Errorf("room_id wajib diisi")
 	}
-	return s.repo.ListRoomRates(roomID, startDate, endDate)
+	rates, err := s.repo.ListRoomRates(roomID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return filterRatesByPlan(rates, ratePlanID), nil
+}
+
+// GetRoomTypeRates sama seperti GetRoomRates untuk rate level tipe kamar.
+func (s *inventoryService) GetRoomTypeRates(roomTypeID, ratePlanID, startDate, endDate string) ([]models.RoomRate, error) {
+	if roomTypeID == "" {
+		return nil, fmt.Errorf("room_type_id wajib diisi")
+	}
+	rates, err := s.repo.ListRoomTypeRates(roomTypeID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return filterRatesByPlan(rates, ratePlanID), nil
+}
+
+func filterRatesByPlan(rates []models.RoomRate, ratePlanID string) []models.RoomRate {
+	if ratePlanID == "" {
+		return rates
+	}
+	filtered := make([]models.RoomRate, 0, len(rates))
+	for _, rate := range rates {
+		if rate.RatePlanID != nil && rate.RatePlanID.String() == ratePlanID {
+			filtered = append(filtered, rate)
+		}
+	}
+	return filtered
 }
 
 func (s *inventoryService) GetRoomByID(id string) (*models.Room, error) {
@@ -337,28 +435,52 @@ func (s *inventoryService) DeleteRoomPhoto(id string) error {
 	return s.repo.DeleteRoomPhoto(id)
 }
 
-func (s *inventoryService) UpdateHotel(id, name, address, city string, facilities []string, checkIn, checkOut, cancelPolicy string) (*models.Properties, error) {
+func (s *inventoryService) UpdateHotel(id, name, address, city string, facilities []string, checkIn, checkOut, cancelPolicy string, modificationCutoffHours *int, rating float64, latitude, longitude *float64) (*models.Properties, error) {
 	if name == "" {
 		return nil, fmt.Errorf("nama hotel wajib diisi")
 	}
+	if modificationCutoffHours != nil && *modificationCutoffHours < 0 {
+		return nil, fmt.Errorf("modification_cutoff_hours tidak boleh negatif")
+	}
+	if rating < 0 || rating > 5 {
+		return nil, fmt.Errorf("rating harus antara 0 dan 5")
+	}
+	if err := validateCoordinates(latitude, longitude); err != nil {
+		return nil, err
+	}
 	propID, err := uuid.Parse(id)
 	if err != nil {
 		return nil, fmt.Errorf("invalid property id")
 	}
-	return s.repo.UpdateProperty(models.Properties{
-		ID:                  propID,
-		Name:                name,
-		Address:             address,
-		City:                city,
-		Facilities:          facilities,
-		CheckInTime:         checkIn,
-		CheckOutTime:        checkOut,
-		CancellationPolicy:  cancelPolicy,
+	updated, err := s.repo.UpdateProperty(models.Properties{
+		ID:                      propID,
+		Name:                    name,
+		Address:                 address,
+		City:                    city,
+		Facilities:              facilities,
+		CheckInTime:             checkIn,
+		CheckOutTime:            checkOut,
+		CancellationPolicy:      cancelPolicy,
+		ModificationCutoffHours: modificationCutoffHours,
+		Rating:                  rating,
+		Latitude:                latitude,
+		Longitude:               longitude,
 	})
+	if err != nil {
+		return nil, err
+	}
+	s.reindex(&updated.ID)
+	return updated, nil
 }
 
 func (s *inventoryService) DeleteHotel(id string) error {
-	return s.repo.DeleteProperty(id)
+	if err := s.repo.DeleteProperty(id); err != nil {
+		return err
+	}
+	if propID, err := uuid.Parse(id); err == nil && s.index != nil {
+		s.index.Remove(propID)
+	}
+	return nil
 }
 
 func (s *inventoryService) ListHotels(city string) ([]models.Properties, error) {
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Aturan umur anak yang dipakai harga dan kapasitas.
+const (
+	// maxChildAge adalah umur tertinggi yang masih dihitung anak.
+	maxChildAge = 17
+	// infantMaxAge: bayi sampai umur ini tidak dihitung ke kapasitas kamar
+	// dan tidak dikenakan biaya.
+	infantMaxAge = 1
+	// adultRateAge: anak mulai umur ini dikenakan tarif dewasa.
+	adultRateAge = 12
+)
+
+// ReasonOverCapacity dipakai jika jumlah tamu melebihi kapasitas tipe kamar.
+const ReasonOverCapacity = "over_capacity"
+
+// OccupantInput adalah satu tamu bernama. Isi GuestID untuk tamu terdaftar
+// (nama diambil dari profil jika kosong) atau FirstName untuk tamu bebas.
+// Tamu hanya boleh mengisi GuestID dengan akunnya sendiri; GuestID tamu lain
+// hanya bisa diisi admin.
+type OccupantInput struct {
+	GuestID        string `json:"guest_id"`
+	FirstName      string `json:"first_name"`
+	LastName       string `json:"last_name"`
+	Nationality    string `json:"nationality"`
+	DocumentType   string `json:"document_type"`
+	DocumentNumber string `json:"document_number"`
+	Age            *int   `json:"age"`
+}
+
+// guestMix adalah komposisi tamu satu kamar. childAges boleh kosong jika
+// tamu tidak menyebutkan umur anak; semua anak lalu dihitung tarif anak.
+type guestMix struct {
+	adults    int
+	children  int
+	childAges []int
+}
+
+// normalizeParty mengisi default 1 dewasa dan memvalidasi umur anak.
+// Jika childAges diisi, jumlahnya menjadi jumlah anak.
+func normalizeParty(adults, children int, childAges []int) (guestMix, error) {
+	adults, children, err := normalizeGuests(adults, children)
+	if err != nil {
+		return guestMix{}, err
+	}
+	if len(childAges) > 0 {
+		if children != 0 && children != len(childAges) {
+			return guestMix{}, fmt.Errorf("jumlah child_ages harus sama dengan children")
+		}
+		children = len(childAges)
+		for _, age := range childAges {
+			if age < 0 || age > maxChildAge {
+				return guestMix{}, fmt.Errorf("umur anak harus antara 0 dan %d", maxChildAge)
+			}
+		}
+	}
+	return guestMix{adults: adults, children: children, childAges: childAges}, nil
+}
+
+// headcount adalah jumlah tamu yang dihitung ke kapasitas (bayi tidak).
+func (m guestMix) headcount() int {
+	return m.adults + m.children - m.count(func(age int) bool { return age <= infantMaxAge })
+}
+
+// priced mengembalikan jumlah dewasa dan anak untuk harga: anak yang sudah
+// mencapai adultRateAge dihitung dewasa, bayi gratis.
+func (m guestMix) priced() (int, int) {
+	older := m.count(func(age int) bool { return age >= adultRateAge })
+	infants := m.count(func(age int) bool { return age <= infantMaxAge })
+	return m.adults + older, m.children - older - infants
+}
+
+func (m guestMix) count(match func(age int) bool) int {
+	n := 0
+	for _, age := range m.childAges {
+		if match(age) {
+			n++
+		}
+	}
+	return n
+}
+
+// buildOccupants memvalidasi dan menyusun tamu bernama untuk booking.
+// Jumlahnya tidak boleh melebihi tamu booking; data tamu terdaftar disalin
+// dari profilnya lewat repository transaksi. callerID adalah tamu yang
+// mengirim daftar ini, kosong untuk admin: tamu tidak boleh menautkan profil
+// tamu lain karena nama dan kewarganegaraannya ikut tersalin.
+func buildOccupants(tx *repository.Repositories, booking *models.Booking, inputs []OccupantInput, callerID string, now time.Time) ([]models.BookingOccupant, error) {
+	if len(inputs) > booking.Adults+booking.Children {
+		return nil, fmt.Errorf("jumlah tamu bernama melebihi jumlah tamu booking")
+	}
+	occupants := make([]models.BookingOccupant, 0, len(inputs))
+	for i, input := range inputs {
+		occupant := models.BookingOccupant{
+			ID:             uuid.New(),
+			BookingID:      &booking.ID,
+			FirstName:      strings.TrimSpace(input.FirstName),
+			LastName:       strings.TrimSpace(input.LastName),
+			Nationality:    strings.TrimSpace(input.Nationality),
+			DocumentType:   strings.TrimSpace(input.DocumentType),
+			DocumentNumber: strings.TrimSpace(input.DocumentNumber),
+			Age:            input.Age,
+			CreatedAt:      now.Add(time.Duration(i) * time.Microsecond),
+		}
+		if input.Age != nil && (*input.Age < 0 || *input.Age > 150) {
+			return nil, fmt.Errorf("occupants[%d]: umur tidak valid", i)
+		}
+		if input.GuestID != "" {
+			if callerID != "" && input.GuestID != callerID {
+				return nil, fmt.Errorf("occupants[%d]: guest_id hanya boleh berisi akun sendiri, isi first_name untuk tamu lain", i)
+			}
+			guest, err := tx.Guest.GetGuestByID(input.GuestID)
+			if err != nil {
+				return nil, fmt.Errorf("occupants[%d]: tamu tidak ditemukan", i)
+			}
+			occupant.GuestID = &guest.ID
+			if occupant.FirstName == "" {
+				occupant.FirstName, occupant.LastName = guest.FirstName, guest.LastName
+			}
+			if occupant.Nationality == "" {
+				occupant.Nationality = guest.Nationality
+			}
+		}
+		if occupant.FirstName == "" {
+			return nil, fmt.Errorf("occupants[%d]: guest_id atau first_name wajib diisi", i)
+		}
+		occupants = append(occupants, occupant)
+	}
+	return occupants, nil
+}
+
+// saveOccupants menyimpan tamu bernama booking baru yang dibuat callerID;
+// tanpa input tidak ada yang ditulis.
+func saveOccupants(tx *repository.Repositories, booking *models.Booking, inputs []OccupantInput, callerID string) ([]models.BookingOccupant, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	occupants, err := buildOccupants(tx, booking, inputs, callerID, booking.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Booking.ReplaceOccupants(booking.ID.String(), occupants); err != nil {
+		return nil, err
+	}
+	return occupants, nil
+}
+
+// SetOccupants mengganti daftar tamu bernama sebuah booking, misalnya saat
+// tamu melengkapi data sebelum datang atau front desk saat check-in.
+// guestID kosong berarti dipanggil admin.
+func (s *bookingService) SetOccupants(guestID, bookingID string, inputs []OccupantInput) ([]models.BookingOccupant, error) {
+	booking, err := s.repo.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if guestID != "" && (booking.GuestID == nil || booking.GuestID.String() != guestID) {
+		return nil, fmt.Errorf("booking tidak ditemukan")
+	}
+	switch booking.Status {
+	case models.BookingStatusCancel, models.BookingStatusExpired, models.BookingStatusCheckedOut, models.BookingStatusNoShow:
+		return nil, fmt.Errorf("tamu booking dengan status %s tidak dapat diubah", booking.Status)
+	}
+
+	var occupants []models.BookingOccupant
+	err = s.uow.Do(func(tx *repository.Repositories) error {
+		var err error
+		occupants, err = buildOccupants(tx, booking, inputs, guestID, time.Now())
+		if err != nil {
+			return err
+		}
+		return tx.Booking.ReplaceOccupants(bookingID, occupants)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return occupants, nil
+}
+
+// GetOccupants mengembalikan tamu bernama booking; guestID kosong untuk admin.
+func (s *bookingService) GetOccupants(guestID, bookingID string) ([]models.BookingOccupant, error) {
+	booking, err := s.repo.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if guestID != "" && (booking.GuestID == nil || booking.GuestID.String() != guestID) {
+		return nil, fmt.Errorf("booking tidak ditemukan")
+	}
+	return s.repo.ListOccupants([]string{bookingID})
+}
@@ -0,0 +1,59 @@
+package service_test
+
+import (
+	"testing"
+
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// TestSetOccupantsRejectsOtherGuestProfile memastikan tamu tidak bisa
+// menautkan profil tamu lain sebagai occupant, sedangkan admin boleh.
+func TestSetOccupantsRejectsOtherGuestProfile(t *testing.T) {
+	for _, backend := range testBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			hotel := newTestHotel(t, backend, 500000)
+			bookings := newTestBookingService(backend)
+			other := models.Guest{ID: uuid.New(), FirstName: "Sari", Nationality: "ID"}
+			if err := backend.repos.Guest.CreateProfile(other); err != nil {
+				t.Fatalf("create guest: %v", err)
+			}
+
+			guestID := uuid.NewString()
+			checkIn := stayDate(10)
+			created, err := bookings.CreateBooking(service.CreateBookingInput{
+				GuestID:    guestID,
+				PropertyID: hotel.propertyID,
+				RoomID:     hotel.roomID,
+				CheckIn:    checkIn,
+				CheckOut:   checkIn.AddDate(0, 0, 1),
+				Adults:     2,
+			})
+			if err != nil {
+				t.Fatalf("create booking: %v", err)
+			}
+			bookingID := created.Booking.ID.String()
+			inputs := []service.OccupantInput{{GuestID: other.ID.String()}}
+
+			if _, err := bookings.SetOccupants(guestID, bookingID, inputs); err == nil {
+				t.Fatalf("guest linked another guest's profile")
+			}
+			occupants, err := bookings.SetOccupants(guestID, bookingID, []service.OccupantInput{{FirstName: "Sari"}})
+			if err != nil {
+				t.Fatalf("set free-text occupant: %v", err)
+			}
+			if occupants[0].GuestID != nil || occupants[0].Nationality != "" {
+				t.Fatalf("free-text occupant carries profile data: %+v", occupants[0])
+			}
+			occupants, err = bookings.SetOccupants("", bookingID, inputs)
+			if err != nil {
+				t.Fatalf("admin set occupants: %v", err)
+			}
+			if occupants[0].GuestID == nil || *occupants[0].GuestID != other.ID {
+				t.Fatalf("admin occupant not linked to profile: %+v", occupants[0])
+			}
+		})
+	}
+}
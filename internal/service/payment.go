@@ -0,0 +1,425 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hotelbooking/internal/gateway"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentSession adalah satu cicilan payment booking/reservasi beserta
+// charge yang sedang terbuka di gateway. Tamu menyelesaikan pembayaran
+// lewat Charge.RedirectURL atau Charge.VANumber.
+type PaymentSession struct {
+	Payment  *models.Payment  `json:"payment"`
+	Invoice  *models.Invoice  `json:"invoice"`
+	Charge   *gateway.Charge  `json:"charge,omitempty"`
+	Schedule *PaymentSchedule `json:"schedule,omitempty"`
+}
+
+// paymentTarget adalah pemilik payment: satu booking atau satu reservasi
+// beserta kamar-kamarnya.
+type paymentTarget struct {
+	bookingID     string
+	reservationID string
+	bookings      []models.Booking
+}
+
+func (t paymentTarget) payments(repo repository.PaymentRepo) ([]models.Payment, error) {
+	if t.reservationID != "" {
+		return repo.ListPaymentsByReservationID(t.reservationID)
+	}
+	return repo.ListPaymentsByBookingID(t.bookingID)
+}
+
+func (t paymentTarget) invoice(repo repository.PaymentRepo) (*models.Invoice, error) {
+	if t.reservationID != "" {
+		return repo.GetInvoiceByReservationID(t.reservationID)
+	}
+	return repo.GetInvoiceByBookingID(t.bookingID)
+}
+
+func (t paymentTarget) markInvoice(repo repository.PaymentRepo, status models.PaymentStatus) (*models.Invoice, error) {
+	if t.reservationID != "" {
+		return repo.UpdateReservationInvoiceStatus(t.reservationID, status)
+	}
+	return repo.UpdateInvoiceStatus(t.bookingID, status)
+}
+
+func (t paymentTarget) updateInvoiceTotals(repo repository.PaymentRepo, totals models.InvoiceTotals) error {
+	if t.reservationID != "" {
+		return repo.UpdateReservationInvoiceTotals(t.reservationID, totals)
+	}
+	return repo.UpdateInvoiceTotals(t.bookingID, totals)
+}
+
+// syncInvoice menyamakan status invoice dengan gabungan status cicilannya.
+func (t paymentTarget) syncInvoice(repo repository.PaymentRepo) (*models.Invoice, error) {
+	payments, err := t.payments(repo)
+	if err != nil {
+		return nil, err
+	}
+	return t.markInvoice(repo, invoiceStatus(payments))
+}
+
+func (t paymentTarget) description() string {
+	if t.reservationID != "" {
+		return "Reservasi " + t.reservationID
+	}
+	return "Booking " + t.bookingID
+}
+
+// confirmable bernilai true jika masih ada kamar yang boleh dikonfirmasi
+// oleh pembayaran atau tamunya sedang menginap (tagihan folio). Dana charge
+// tidak ditarik untuk booking yang sudah batal atau hold-nya habis.
+func (t paymentTarget) confirmable(now time.Time) bool {
+	for i := range t.bookings {
+		booking := &t.bookings[i]
+		if booking.Status == models.BookingStatusConfirmed || booking.Status == models.BookingStatusCheckedIn ||
+			(booking.Status == models.BookingStatusNew && !isHoldExpired(booking, now)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *bookingService) bookingPaymentTarget(guestID, bookingID string) (paymentTarget, error) {
+	booking, err := s.repo.GetBookingByID(bookingID)
+	if err != nil {
+		return paymentTarget{}, err
+	}
+	if booking.GuestID == nil || booking.GuestID.String() != guestID {
+		return paymentTarget{}, fmt.Errorf("booking tidak ditemukan")
+	}
+	if booking.ReservationID != nil {
+		return paymentTarget{}, fmt.Errorf("booking bagian dari reservasi, bayar melalui reservasi")
+	}
+	return paymentTarget{bookingID: bookingID, bookings: []models.Booking{*booking}}, nil
+}
+
+func (s *bookingService) reservationPaymentTarget(guestID, reservationID string) (paymentTarget, error) {
+	if _, err := s.guestReservation(guestID, reservationID); err != nil {
+		return paymentTarget{}, err
+	}
+	bookings, err := s.repo.ListReservationBookings(reservationID)
+	if err != nil {
+		return paymentTarget{}, err
+	}
+	return paymentTarget{reservationID: reservationID, bookings: bookings}, nil
+}
+
+// paymentTargetFor membentuk pemilik payment tanpa cek tamu; dipakai saat
+// konfirmasi datang dari gateway.
+func (s *bookingService) paymentTargetFor(payment *models.Payment) (paymentTarget, error) {
+	if payment.ReservationID != nil {
+		reservationID := payment.ReservationID.String()
+		bookings, err := s.repo.ListReservationBookings(reservationID)
+		if err != nil {
+			return paymentTarget{}, err
+		}
+		return paymentTarget{reservationID: reservationID, bookings: bookings}, nil
+	}
+	if payment.BookingID == nil {
+		return paymentTarget{}, fmt.Errorf("payment tidak terhubung ke booking")
+	}
+	booking, err := s.repo.GetBookingByID(payment.BookingID.String())
+	if err != nil {
+		return paymentTarget{}, err
+	}
+	return paymentTarget{bookingID: booking.ID.String(), bookings: []models.Booking{*booking}}, nil
+}
+
+func (s *bookingService) gatewayFor(provider string) (gateway.PaymentGateway, error) {
+	if s.gateways == nil {
+		return nil, fmt.Errorf("payment gateway belum dikonfigurasi")
+	}
+	if provider == "" {
+		return nil, fmt.Errorf("provider wajib diisi")
+	}
+	return s.gateways.Get(provider)
+}
+
+// StartPayment membuat charge di gateway untuk sisa tagihan satu cicilan
+// booking. paymentID kosong berarti cicilan berikutnya yang jatuh tempo.
+// Cicilan baru lunas setelah gateway mengonfirmasi charge tersebut.
+func (s *bookingService) StartPayment(guestID, bookingID, paymentID, provider string, now time.Time) (*PaymentSession, error) {
+	target, err := s.bookingPaymentTarget(guestID, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	booking := &target.bookings[0]
+	if isHoldExpired(booking, now) {
+		return nil, fmt.Errorf("batas waktu pembayaran booking sudah lewat")
+	}
+	if booking.Status != models.BookingStatusNew && booking.Status != models.BookingStatusConfirmed && booking.Status != models.BookingStatusCheckedIn {
+		return nil, fmt.Errorf("booking dengan status %s tidak dapat dibayar", booking.Status)
+	}
+	return s.startCharge(target, paymentID, provider, now)
+}
+
+// StartReservationPayment membuat charge untuk satu cicilan payment
+// gabungan reservasi.
+func (s *bookingService) StartReservationPayment(guestID, reservationID, paymentID, provider string, now time.Time) (*PaymentSession, error) {
+	target, err := s.reservationPaymentTarget(guestID, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range target.bookings {
+		if isHoldExpired(&target.bookings[i], now) {
+			return nil, fmt.Errorf("batas waktu pembayaran reservasi sudah lewat")
+		}
+	}
+	if !target.confirmable(now) {
+		return nil, fmt.Errorf("reservasi tidak memiliki kamar yang dapat dibayar")
+	}
+	return s.startCharge(target, paymentID, provider, now)
+}
+
+// SyncPayment menanyakan status charge terbuka ke gateway dan menerapkan
+// hasilnya: charge authorized di-capture, capture membuat cicilan lunas,
+// dan booking terkonfirmasi begitu deposit (atau pembayaran penuh) lunas.
+func (s *bookingService) SyncPayment(guestID, bookingID string, now time.Time) (*PaymentSession, error) {
+	target, err := s.bookingPaymentTarget(guestID, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	return s.syncCharges(target, now)
+}
+
+func (s *bookingService) SyncReservationPayment(guestID, reservationID string, now time.Time) (*PaymentSession, error) {
+	target, err := s.reservationPaymentTarget(guestID, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	return s.syncCharges(target, now)
+}
+
+func (s *bookingService) startCharge(target paymentTarget, paymentID, provider string, now time.Time) (*PaymentSession, error) {
+	gw, err := s.gatewayFor(provider)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := target.payments(s.paymentRepo)
+	if err != nil {
+		return nil, err
+	}
+	current, err := selectPayment(payments, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status != models.PaymentStatusPending {
+		return nil, fmt.Errorf("payment berstatus %s, tidak ada tagihan", current.Status)
+	}
+	due := amountDue(current)
+	if due <= 0 {
+		return nil, fmt.Errorf("tidak ada tagihan yang perlu dibayar")
+	}
+
+	// Charge yang masih berjalan dipakai ulang supaya tamu tidak membayar
+	// dua kali; charge yang sudah authorized langsung diproses.
+	if current.Reference != "" {
+		latest, charge, err := s.syncOne(target, current, now)
+		if errors.Is(err, gateway.ErrChargeNotFound) {
+			latest, charge, err = s.releaseStaleCharge(current)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if latest.Status != models.PaymentStatusPending {
+			return s.session(target, latest, charge)
+		}
+		if charge != nil && charge.Status == gateway.ChargePending && charge.Provider == gw.Name() {
+			return s.session(target, latest, charge)
+		}
+		if due = amountDue(latest); due <= 0 {
+			return s.session(target, latest, charge)
+		}
+	}
+
+	charge, err := gw.CreateCharge(gateway.ChargeRequest{
+		OrderID:     current.ID.String(),
+		Amount:      due,
+		Currency:    "IDR",
+		Description: target.description(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat charge: %v", err)
+	}
+	updated, err := s.paymentRepo.SetPaymentCharge(current.ID.String(), gw.Name(), charge.Reference)
+	if err != nil {
+		return nil, err
+	}
+	return s.session(target, updated, charge)
+}
+
+// selectPayment memilih cicilan yang diminta, atau cicilan berikutnya yang
+// jatuh tempo jika paymentID kosong.
+func selectPayment(payments []models.Payment, paymentID string) (*models.Payment, error) {
+	if paymentID == "" {
+		if next := nextDuePayment(payments); next != nil {
+			return next, nil
+		}
+		return nil, fmt.Errorf("tidak ada tagihan yang perlu dibayar")
+	}
+	for i := range payments {
+		if payments[i].ID.String() == paymentID {
+			return &payments[i], nil
+		}
+	}
+	return nil, fmt.Errorf("payment tidak ditemukan")
+}
+
+// amountDue adalah tagihan yang belum tertutup dana bersih (dana yang
+// di-capture dikurangi refund).
+func amountDue(payment *models.Payment) float64 {
+	return roundAmount(payment.Amount - (payment.AmountPaid - payment.AmountRefunded))
+}
+
+// syncCharges menyinkronkan setiap cicilan Pending yang punya charge
+// terbuka. Session menunjuk cicilan yang charge-nya masih berjalan, lalu
+// cicilan yang baru saja lunas, lalu cicilan berikutnya yang jatuh tempo.
+func (s *bookingService) syncCharges(target paymentTarget, now time.Time) (*PaymentSession, error) {
+	payments, err := target.payments(s.paymentRepo)
+	if err != nil {
+		return nil, err
+	}
+	if len(payments) == 0 {
+		return nil, fmt.Errorf("payment tidak ditemukan")
+	}
+	var focus, synced *models.Payment
+	var focusCharge, syncedCharge *gateway.Charge
+	for i := range payments {
+		if payments[i].Status != models.PaymentStatusPending || payments[i].Reference == "" {
+			continue
+		}
+		latest, charge, err := s.syncOne(target, &payments[i], now)
+		if errors.Is(err, gateway.ErrChargeNotFound) {
+			latest, charge, err = s.releaseStaleCharge(&payments[i])
+		}
+		if err != nil {
+			return nil, err
+		}
+		if focus == nil && latest.Status == models.PaymentStatusPending && latest.Reference != "" {
+			focus, focusCharge = latest, charge
+		}
+		synced, syncedCharge = latest, charge
+	}
+	if focus == nil && synced != nil {
+		focus, focusCharge = synced, syncedCharge
+	}
+	if focus == nil {
+		if payments, err = target.payments(s.paymentRepo); err != nil {
+			return nil, err
+		}
+		if focus = nextDuePayment(payments); focus == nil {
+			focus = &payments[len(payments)-1]
+		}
+	}
+	return s.session(target, focus, focusCharge)
+}
+
+// syncOne menanyakan status charge satu cicilan ke gateway lalu menerapkan
+// capture-nya.
+func (s *bookingService) syncOne(target paymentTarget, payment *models.Payment, now time.Time) (*models.Payment, *gateway.Charge, error) {
+	gw, err := s.gatewayFor(payment.Provider)
+	if err != nil {
+		return nil, nil, err
+	}
+	charge, err := gw.Status(payment.Reference)
+	if err != nil {
+		return nil, nil, fmt.Errorf("gagal mengambil status charge: %w", err)
+	}
+	if payment.Status == models.PaymentStatusPending && charge.Status == gateway.ChargeAuthorized && target.confirmable(now) {
+		if charge, err = gw.Capture(charge.Reference, charge.Amount); err != nil {
+			return nil, nil, fmt.Errorf("gagal capture charge: %v", err)
+		}
+	}
+	if payment.Status == models.PaymentStatusPending && charge.Status == gateway.ChargeCaptured {
+		if err := s.applyCapture(target, payment.ID.String(), charge, now); err != nil {
+			return nil, nil, err
+		}
+	}
+	latest, err := s.paymentRepo.GetPaymentByID(payment.ID.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	return latest, charge, nil
+}
+
+// releaseStaleCharge melepas reference charge yang tidak lagi dikenal
+// gateway (misalnya charge mock setelah server restart) supaya cicilan bisa
+// dibayar dengan charge baru.
+func (s *bookingService) releaseStaleCharge(payment *models.Payment) (*models.Payment, *gateway.Charge, error) {
+	latest, err := s.paymentRepo.SetPaymentCharge(payment.ID.String(), "", "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return latest, nil, nil
+}
+
+func (s *bookingService) session(target paymentTarget, payment *models.Payment, charge *gateway.Charge) (*PaymentSession, error) {
+	schedule, err := s.paymentSchedule(target)
+	if err != nil {
+		return nil, err
+	}
+	return &PaymentSession{Payment: payment, Invoice: schedule.Invoice, Charge: charge, Schedule: schedule}, nil
+}
+
+// applyCapture mencatat dana charge yang sudah di-capture. Charge yang
+// sudah diterapkan (payment tidak lagi Pending atau sudah menunjuk charge
+// lain) diabaikan sehingga aman dipanggil berulang.
+func (s *bookingService) applyCapture(target paymentTarget, paymentID string, charge *gateway.Charge, now time.Time) error {
+	return s.uow.Do(func(tx *repository.Repositories) error {
+		latest, err := tx.Payment.GetPaymentByID(paymentID)
+		if err != nil {
+			return err
+		}
+		if latest.Status != models.PaymentStatusPending || latest.Reference != charge.Reference {
+			return nil
+		}
+		updated, err := tx.Payment.RecordPaymentCapture(paymentID, models.PaymentCharge{
+			ID:         uuid.New(),
+			PaymentID:  latest.ID,
+			Provider:   charge.Provider,
+			Reference:  charge.Reference,
+			Amount:     charge.CapturedAmount,
+			CapturedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+		if updated.Status != models.PaymentStatusPaid {
+			// Tagihan naik selama charge berjalan: sisa tagihan butuh charge
+			// baru. Charge ini tetap tercatat di payment_charges untuk refund.
+			_, err := tx.Payment.SetPaymentCharge(paymentID, updated.Provider, "")
+			return err
+		}
+		payments, err := target.payments(tx.Payment)
+		if err != nil {
+			return err
+		}
+		if _, err := target.markInvoice(tx.Payment, invoiceStatus(payments)); err != nil {
+			return err
+		}
+		if !confirmationPaid(payments) {
+			return nil
+		}
+		for i := range target.bookings {
+			booking := &target.bookings[i]
+			if booking.Status != models.BookingStatusNew || isHoldExpired(booking, now) {
+				continue
+			}
+			_, err := transitionBooking(tx, booking, models.BookingStatusConfirmed, GatewayActor(charge.Provider), "payment_received", 0, now)
+			// Booking yang lebih dulu di-expire atau dibatalkan proses lain
+			// tidak dikonfirmasi; dana tetap tercatat
+			if err != nil && !errors.Is(err, ErrBookingStatusConflict) {
+				return err
+			}
+		}
+		return nil
+	})
+}
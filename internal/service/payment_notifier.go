@@ -0,0 +1,33 @@
+package service
+
+import (
+	"hotelbooking/internal/models"
+	"log"
+)
+
+// PaymentReminder adalah pengingat satu cicilan yang mendekati jatuh tempo
+// beserta kamar yang ditagihnya.
+type PaymentReminder struct {
+	Payment  models.Payment
+	Bookings []models.Booking
+}
+
+// PaymentNotifier mengirim pengingat cicilan ke tamu. Cicilan baru ditandai
+// sudah diingatkan jika NotifyPaymentDue berhasil, jadi pengiriman yang gagal
+// dicoba lagi pada putaran sweeper berikutnya.
+type PaymentNotifier interface {
+	NotifyPaymentDue(reminder PaymentReminder) error
+}
+
+// LogPaymentNotifier menulis pengingat ke log server. Dipakai selama belum
+// ada kanal pengiriman (email/WhatsApp) yang dikonfigurasi.
+type LogPaymentNotifier struct{}
+
+func (LogPaymentNotifier) NotifyPaymentDue(reminder PaymentReminder) error {
+	payment := reminder.Payment
+	for _, booking := range reminder.Bookings {
+		log.Printf("pengingat cicilan %s booking %s guest %v: %.2f jatuh tempo %v",
+			payment.ID, booking.ID, booking.GuestID, payment.Amount, payment.DueAt)
+	}
+	return nil
+}
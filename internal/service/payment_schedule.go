@@ -0,0 +1,497 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultReminderLeadHours dipakai jika property tidak mengatur
+// reminder_hours_before_due.
+const defaultReminderLeadHours = 24
+
+// maxReminderLeadHours membatasi jarak pengingat dari jatuh tempo; sweeper
+// hanya melihat cicilan yang jatuh tempo dalam rentang ini.
+const maxReminderLeadHours = 720
+
+// PaymentSchedule adalah semua cicilan booking/reservasi beserta saldo
+// tagihannya. Outstanding adalah tagihan cicilan Pending yang belum tertutup
+// dana; NextDue cicilan berikutnya yang perlu dibayar.
+type PaymentSchedule struct {
+	Payments    []models.Payment `json:"payments"`
+	Invoice     *models.Invoice  `json:"invoice"`
+	Total       float64          `json:"total"`
+	Paid        float64          `json:"paid"`
+	Refunded    float64          `json:"refunded"`
+	Outstanding float64          `json:"outstanding"`
+	NextDue     *models.Payment  `json:"next_due,omitempty"`
+}
+
+// PaymentScheduleRun adalah hasil satu putaran ProcessPaymentSchedules.
+type PaymentScheduleRun struct {
+	Reminded  int `json:"reminded"`
+	Cancelled int `json:"cancelled"`
+}
+
+// paymentReduction adalah perubahan satu cicilan Pending saat tagihan turun.
+type paymentReduction struct {
+	paymentID string
+	amount    float64
+	status    models.PaymentStatus
+}
+
+// normalizePaymentSchedulePolicy memvalidasi jadwal pembayaran property.
+// nil tetap nil (booking dibayar penuh sekaligus).
+func normalizePaymentSchedulePolicy(policy *models.PaymentSchedulePolicy) (*models.PaymentSchedulePolicy, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	if policy.DepositPercent <= 0 || policy.DepositPercent >= 100 {
+		return nil, fmt.Errorf("deposit_percent harus lebih dari 0 dan kurang dari 100")
+	}
+	if policy.BalanceDueDays < 0 {
+		return nil, fmt.Errorf("balance_due_days tidak boleh negatif")
+	}
+	if lead := policy.ReminderHoursBeforeDue; lead != nil && (*lead < 0 || *lead > maxReminderLeadHours) {
+		return nil, fmt.Errorf("reminder_hours_before_due harus antara 0 dan %d", maxReminderLeadHours)
+	}
+	if policy.AutoCancelAfterHours != nil && *policy.AutoCancelAfterHours < 0 {
+		return nil, fmt.Errorf("auto_cancel_after_hours tidak boleh negatif")
+	}
+	out := *policy
+	return &out, nil
+}
+
+// planPayments menyusun cicilan untuk tagihan baru. Tanpa jadwal, atau jika
+// tanggal pelunasan tidak lebih lambat dari batas bayar deposit, tagihan
+// dibayar penuh dalam satu payment yang jatuh tempo bersama hold.
+func planPayments(policy *models.PaymentSchedulePolicy, bookingID, reservationID *uuid.UUID, total float64, checkIn, now time.Time, holdDue *time.Time) []models.Payment {
+	newPayment := func(kind models.PaymentKind, amount float64, dueAt *time.Time, offset int) models.Payment {
+		return models.Payment{
+			ID:            uuid.New(),
+			BookingID:     bookingID,
+			ReservationID: reservationID,
+			Kind:          kind,
+			Amount:        amount,
+			Status:        models.PaymentStatusPending,
+			DueAt:         dueAt,
+			// Selisih satu mikrodetik menjaga urutan cicilan
+			CreatedAt: now.Add(time.Duration(offset) * time.Microsecond),
+		}
+	}
+	full := []models.Payment{newPayment(models.PaymentKindFull, total, holdDue, 0)}
+	if policy == nil {
+		return full
+	}
+	balanceDue := checkIn.AddDate(0, 0, -policy.BalanceDueDays)
+	depositDue := now
+	if holdDue != nil {
+		depositDue = *holdDue
+	}
+	deposit := roundAmount(total * policy.DepositPercent / 100)
+	if !balanceDue.After(depositDue) || deposit <= 0 || deposit >= total {
+		return full
+	}
+	return []models.Payment{
+		newPayment(models.PaymentKindDeposit, deposit, holdDue, 0),
+		newPayment(models.PaymentKindBalance, roundAmount(total-deposit), &balanceDue, 1),
+	}
+}
+
+// invoiceStatus menurunkan status invoice dari cicilannya: Pending selama
+// ada cicilan yang belum lunas, Void jika semua cicilan di-void.
+func invoiceStatus(payments []models.Payment) models.PaymentStatus {
+	active := false
+	var paid, refunded float64
+	for _, payment := range payments {
+		if payment.Status == models.PaymentStatusVoid {
+			continue
+		}
+		if payment.Status == models.PaymentStatusPending {
+			return models.PaymentStatusPending
+		}
+		active = true
+		paid += payment.AmountPaid
+		refunded += payment.AmountRefunded
+	}
+	switch {
+	case !active:
+		return models.PaymentStatusVoid
+	case paid > 0 && roundAmount(refunded) >= roundAmount(paid):
+		return models.PaymentStatusRefunded
+	case refunded > 0:
+		return models.PaymentStatusPartiallyRefunded
+	}
+	return models.PaymentStatusPaid
+}
+
+// confirmationPaid bernilai true jika semua cicilan deposit (atau
+// pembayaran penuh) sudah lunas; pelunasan dan tambahan tagihan tidak
+// menahan konfirmasi.
+func confirmationPaid(payments []models.Payment) bool {
+	found := false
+	for _, payment := range payments {
+		if payment.Status == models.PaymentStatusVoid ||
+			payment.Kind == models.PaymentKindBalance || payment.Kind == models.PaymentKindAdjustment {
+			continue
+		}
+		if payment.Status == models.PaymentStatusPending {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// paymentsSettled bernilai true jika ada cicilan aktif dan tidak ada yang
+// masih Pending.
+func paymentsSettled(payments []models.Payment) bool {
+	found := false
+	for _, payment := range payments {
+		switch payment.Status {
+		case models.PaymentStatusVoid:
+			continue
+		case models.PaymentStatusPending:
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// nextDuePayment adalah cicilan Pending dengan jatuh tempo paling awal;
+// cicilan tanpa jatuh tempo dianggap sudah jatuh tempo.
+func nextDuePayment(payments []models.Payment) *models.Payment {
+	var next *models.Payment
+	for i := range payments {
+		payment := &payments[i]
+		if payment.Status != models.PaymentStatusPending || amountDue(payment) <= 0 {
+			continue
+		}
+		if next == nil || dueBefore(payment, next) {
+			next = payment
+		}
+	}
+	return next
+}
+
+func dueBefore(a, b *models.Payment) bool {
+	if a.DueAt == nil || b.DueAt == nil {
+		return a.DueAt == nil && b.DueAt != nil
+	}
+	return a.DueAt.Before(*b.DueAt)
+}
+
+// netPaid adalah dana yang sudah di-capture dikurangi refund.
+func netPaid(payments []models.Payment) float64 {
+	total := 0.0
+	for _, payment := range payments {
+		total += payment.AmountPaid - payment.AmountRefunded
+	}
+	return roundAmount(total)
+}
+
+// outstanding adalah tagihan cicilan Pending yang belum tertutup dana.
+func outstanding(payments []models.Payment) float64 {
+	total := 0.0
+	for i := range payments {
+		if payments[i].Status == models.PaymentStatusPending {
+			total += math.Max(0, amountDue(&payments[i]))
+		}
+	}
+	return roundAmount(total)
+}
+
+// planReduction menurunkan tagihan sebesar amount mulai dari cicilan Pending
+// yang jatuh tempo paling akhir. Cicilan tidak pernah turun di bawah dana
+// yang sudah masuk: cicilan tanpa dana di-void, cicilan yang tertutup
+// dananya menjadi Paid. leftover adalah bagian yang sudah dibayar dan
+// harus dikembalikan lewat refund.
+func planReduction(payments []models.Payment, amount float64) ([]paymentReduction, float64) {
+	pending := make([]*models.Payment, 0, len(payments))
+	for i := range payments {
+		if payments[i].Status == models.PaymentStatusPending {
+			pending = append(pending, &payments[i])
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool { return dueBefore(pending[j], pending[i]) })
+
+	remaining := roundAmount(amount)
+	var changes []paymentReduction
+	for _, payment := range pending {
+		if remaining <= 0 {
+			break
+		}
+		reducible := amountDue(payment)
+		if reducible <= 0 {
+			continue
+		}
+		take := math.Min(remaining, reducible)
+		remaining = roundAmount(remaining - take)
+		change := paymentReduction{paymentID: payment.ID.String(), amount: roundAmount(payment.Amount - take)}
+		if take == reducible {
+			change.status = models.PaymentStatusPaid
+			if payment.AmountPaid-payment.AmountRefunded <= 0 {
+				change.amount, change.status = payment.Amount, models.PaymentStatusVoid
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, math.Max(0, remaining)
+}
+
+func applyReductions(repo repository.PaymentRepo, changes []paymentReduction) error {
+	for _, change := range changes {
+		if _, err := repo.UpdatePaymentAmount(change.paymentID, change.amount); err != nil {
+			return err
+		}
+		if change.status == "" {
+			continue
+		}
+		if _, err := repo.SetPaymentStatus(change.paymentID, change.status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closePendingPayments menutup semua cicilan yang belum lunas setelah tidak
+// ada lagi kamar yang ditagih, lalu menyamakan status invoice.
+func closePendingPayments(repo repository.PaymentRepo, target paymentTarget) error {
+	payments, err := target.payments(repo)
+	if err != nil {
+		return err
+	}
+	changes, _ := planReduction(payments, outstanding(payments))
+	if err := applyReductions(repo, changes); err != nil {
+		return err
+	}
+	if len(payments) == 0 {
+		return nil
+	}
+	_, err = target.syncInvoice(repo)
+	return err
+}
+
+// paymentOwner membentuk pemilik payment sebuah booking di dalam transaksi:
+// booking itu sendiri atau reservasinya.
+func paymentOwner(booking *models.Booking) paymentTarget {
+	if booking.ReservationID != nil {
+		return paymentTarget{reservationID: booking.ReservationID.String(), bookings: []models.Booking{*booking}}
+	}
+	return paymentTarget{bookingID: booking.ID.String(), bookings: []models.Booking{*booking}}
+}
+
+func (s *bookingService) paymentSchedule(target paymentTarget) (*PaymentSchedule, error) {
+	payments, err := target.payments(s.paymentRepo)
+	if err != nil {
+		return nil, err
+	}
+	invoice, err := target.invoice(s.paymentRepo)
+	if err != nil {
+		return nil, err
+	}
+	schedule := &PaymentSchedule{
+		Payments:    payments,
+		Invoice:     invoice,
+		Total:       invoice.Amount,
+		Outstanding: outstanding(payments),
+		NextDue:     nextDuePayment(payments),
+	}
+	for _, payment := range payments {
+		schedule.Paid += payment.AmountPaid
+		schedule.Refunded += payment.AmountRefunded
+	}
+	schedule.Paid, schedule.Refunded = roundAmount(schedule.Paid), roundAmount(schedule.Refunded)
+	return schedule, nil
+}
+
+// GetPaymentSchedule mengembalikan cicilan booking (atau reservasinya)
+// beserta saldo tagihan. guestID kosong berarti dipanggil admin.
+func (s *bookingService) GetPaymentSchedule(guestID, bookingID string) (*PaymentSchedule, error) {
+	booking, err := s.repo.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if guestID != "" && (booking.GuestID == nil || booking.GuestID.String() != guestID) {
+		return nil, fmt.Errorf("booking tidak ditemukan")
+	}
+	target, err := s.bookingPaymentOwner(booking)
+	if err != nil {
+		return nil, err
+	}
+	return s.paymentSchedule(target)
+}
+
+// SplitPayment memecah satu cicilan Pending yang belum dibayar menjadi
+// beberapa payment, misalnya tagihan grup yang dibayar dengan beberapa
+// kartu. Bagian pertama tetap di payment asal; bagian lain menjadi payment
+// baru dengan jenis dan jatuh tempo yang sama.
+func (s *bookingService) SplitPayment(guestID, paymentID string, amounts []float64) (*PaymentSchedule, error) {
+	if len(amounts) < 2 {
+		return nil, fmt.Errorf("amounts minimal berisi 2 bagian")
+	}
+	payment, err := s.paymentRepo.GetPaymentByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.paymentTargetFor(payment)
+	if err != nil {
+		return nil, err
+	}
+	if !ownsPaymentTarget(target, guestID) {
+		return nil, fmt.Errorf("payment tidak ditemukan")
+	}
+	if payment.Status != models.PaymentStatusPending {
+		return nil, fmt.Errorf("payment berstatus %s tidak dapat dipecah", payment.Status)
+	}
+	if payment.AmountPaid > 0 || payment.Reference != "" {
+		return nil, fmt.Errorf("payment yang sudah memiliki charge tidak dapat dipecah")
+	}
+	total := 0.0
+	for i, amount := range amounts {
+		if amount <= 0 {
+			return nil, fmt.Errorf("amounts[%d] harus lebih dari 0", i)
+		}
+		amounts[i] = roundAmount(amount)
+		total += amounts[i]
+	}
+	if math.Abs(total-payment.Amount) > 0.01 {
+		return nil, fmt.Errorf("jumlah amounts (%.2f) harus sama dengan nominal payment (%.2f)", total, payment.Amount)
+	}
+
+	now := time.Now()
+	err = s.uow.Do(func(tx *repository.Repositories) error {
+		if _, err := tx.Payment.UpdatePaymentAmount(paymentID, amounts[0]); err != nil {
+			return err
+		}
+		for i, amount := range amounts[1:] {
+			part := models.Payment{
+				ID:            uuid.New(),
+				BookingID:     payment.BookingID,
+				ReservationID: payment.ReservationID,
+				Kind:          payment.Kind,
+				Amount:        amount,
+				Status:        models.PaymentStatusPending,
+				DueAt:         payment.DueAt,
+				CreatedAt:     now.Add(time.Duration(i) * time.Microsecond),
+			}
+			if err := tx.Payment.CreatePayment(part); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.paymentSchedule(target)
+}
+
+// ownsPaymentTarget memastikan semua kamar pemilik payment milik tamu.
+func ownsPaymentTarget(target paymentTarget, guestID string) bool {
+	if len(target.bookings) == 0 {
+		return false
+	}
+	for _, booking := range target.bookings {
+		if booking.GuestID == nil || booking.GuestID.String() != guestID {
+			return false
+		}
+	}
+	return true
+}
+
+// ProcessPaymentSchedules mengirim pengingat untuk pelunasan yang mendekati
+// jatuh tempo dan membatalkan booking yang pelunasannya lewat batas
+// auto_cancel_after_hours. Pembatalan otomatis memakai aturan pembatalan
+// biasa, sehingga deposit hanya dikembalikan sejauh aturan mengizinkan.
+// Kegagalan satu booking dicatat di log tanpa menghentikan booking lain.
+func (s *bookingService) ProcessPaymentSchedules(now time.Time) (*PaymentScheduleRun, error) {
+	due, err := s.paymentRepo.ListDuePayments(now.Add(maxReminderLeadHours * time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	run := &PaymentScheduleRun{}
+	policies := make(map[uuid.UUID]*models.PaymentSchedulePolicy)
+	for i := range due {
+		payment := &due[i]
+		if payment.Kind != models.PaymentKindBalance {
+			continue
+		}
+		cancelled, reminded, err := s.processInstallment(payment, policies, now)
+		if err != nil {
+			log.Printf("payment schedule: payment %s: %v", payment.ID, err)
+			continue
+		}
+		run.Cancelled += cancelled
+		if reminded {
+			run.Reminded++
+		}
+	}
+	return run, nil
+}
+
+func (s *bookingService) processInstallment(payment *models.Payment, policies map[uuid.UUID]*models.PaymentSchedulePolicy, now time.Time) (int, bool, error) {
+	target, err := s.paymentTargetFor(payment)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(target.bookings) == 0 || target.bookings[0].PropertyID == nil {
+		return 0, false, nil
+	}
+	propertyID := *target.bookings[0].PropertyID
+	policy, ok := policies[propertyID]
+	if !ok {
+		property, err := s.propRepo.GetPropertyByID(propertyID.String())
+		if err != nil {
+			return 0, false, err
+		}
+		policy = property.PaymentSchedule
+		policies[propertyID] = policy
+	}
+
+	if policy != nil && policy.AutoCancelAfterHours != nil &&
+		!now.Before(payment.DueAt.Add(time.Duration(*policy.AutoCancelAfterHours)*time.Hour)) {
+		cancelled := 0
+		for i := range target.bookings {
+			booking := &target.bookings[i]
+			if !canTransition(booking.Status, models.BookingStatusCancel) {
+				continue
+			}
+			if booking.ReservationID != nil {
+				err = s.cancelReservationRoom(booking.ReservationID.String(), booking.ID.String(), ActorSystem, "installment_missed", now)
+			} else {
+				_, err = s.cancelBooking(booking, ActorSystem, "installment_missed", now)
+			}
+			// Refund yang gagal sudah dicatat di log; pembatalannya tetap
+			// berlaku.
+			if err != nil && !errors.Is(err, ErrRefundIncomplete) {
+				return cancelled, false, err
+			}
+			cancelled++
+		}
+		return cancelled, false, nil
+	}
+
+	lead := defaultReminderLeadHours
+	if policy != nil && policy.ReminderHoursBeforeDue != nil {
+		lead = *policy.ReminderHoursBeforeDue
+	}
+	if payment.ReminderSentAt != nil || now.Before(payment.DueAt.Add(-time.Duration(lead)*time.Hour)) {
+		return 0, false, nil
+	}
+	if err := s.notifier.NotifyPaymentDue(PaymentReminder{Payment: *payment, Bookings: target.bookings}); err != nil {
+		return 0, false, fmt.Errorf("gagal mengirim pengingat: %v", err)
+	}
+	if _, err := s.paymentRepo.MarkPaymentReminded(payment.ID.String(), now); err != nil {
+		return 0, false, err
+	}
+	return 0, true, nil
+}
@@ -0,0 +1,182 @@
+package service_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"hotelbooking/internal/gateway"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// recordingNotifier mencatat pengingat yang berhasil dikirim; err diisi
+// untuk mensimulasikan kanal pengiriman yang gagal.
+type recordingNotifier struct {
+	mu   sync.Mutex
+	err  error
+	sent []service.PaymentReminder
+}
+
+func (n *recordingNotifier) NotifyPaymentDue(reminder service.PaymentReminder) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, reminder)
+	return nil
+}
+
+func (n *recordingNotifier) sentFor(paymentID uuid.UUID) []service.PaymentReminder {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var out []service.PaymentReminder
+	for _, reminder := range n.sent {
+		if reminder.Payment.ID == paymentID {
+			out = append(out, reminder)
+		}
+	}
+	return out
+}
+
+// TestPaymentReminderNotifiesBeforeMarking memastikan pengingat cicilan
+// dikirim lewat notifier, dan cicilan hanya ditandai sudah diingatkan jika
+// pengiriman berhasil.
+func TestPaymentReminderNotifiesBeforeMarking(t *testing.T) {
+	for _, backend := range testBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			hotel := newTestHotel(t, backend, 500000)
+			inventory := service.NewInventoryService(backend.repos.Property, service.NewSearchIndex(backend.repos.Property))
+			_, err := inventory.SetPropertyPaymentSchedule(hotel.propertyID, &models.PaymentSchedulePolicy{
+				DepositPercent: 30,
+				BalanceDueDays: 7,
+			})
+			if err != nil {
+				t.Fatalf("set payment schedule: %v", err)
+			}
+			notifier := &recordingNotifier{err: errors.New("kanal pengiriman mati")}
+			bookings := service.NewBookingService(backend.repos.Booking, backend.repos.Property, backend.repos.Payment,
+				backend.uow, gateway.NewRegistry(), notifier, 15*time.Minute)
+
+			checkIn := stayDate(10)
+			created, err := bookings.CreateBooking(service.CreateBookingInput{
+				GuestID:    uuid.NewString(),
+				PropertyID: hotel.propertyID,
+				RoomID:     hotel.roomID,
+				CheckIn:    checkIn,
+				CheckOut:   checkIn.AddDate(0, 0, 1),
+			})
+			if err != nil {
+				t.Fatalf("create booking: %v", err)
+			}
+			bookingID := created.Booking.ID.String()
+			balance := func() models.Payment {
+				t.Helper()
+				schedule, err := bookings.GetPaymentSchedule("", bookingID)
+				if err != nil {
+					t.Fatalf("get payment schedule: %v", err)
+				}
+				for _, payment := range schedule.Payments {
+					if payment.Kind == models.PaymentKindBalance {
+						return payment
+					}
+				}
+				t.Fatalf("booking has no balance installment")
+				return models.Payment{}
+			}
+			now := balance().DueAt.Add(-time.Hour)
+
+			if _, err := bookings.ProcessPaymentSchedules(now); err != nil {
+				t.Fatalf("process schedules: %v", err)
+			}
+			if payment := balance(); payment.ReminderSentAt != nil {
+				t.Fatalf("installment marked reminded although notification failed")
+			}
+
+			notifier.mu.Lock()
+			notifier.err = nil
+			notifier.mu.Unlock()
+			for i := 0; i < 2; i++ {
+				if _, err := bookings.ProcessPaymentSchedules(now); err != nil {
+					t.Fatalf("process schedules: %v", err)
+				}
+			}
+			payment := balance()
+			if payment.ReminderSentAt == nil {
+				t.Fatalf("installment not marked reminded after notification")
+			}
+			sent := notifier.sentFor(payment.ID)
+			if len(sent) != 1 {
+				t.Fatalf("got %d reminders, want 1", len(sent))
+			}
+			if len(sent[0].Bookings) != 1 || sent[0].Bookings[0].ID != created.Booking.ID {
+				t.Fatalf("reminder does not carry the booking")
+			}
+		})
+	}
+}
+
+// TestPaymentReminderZeroLeadTime memastikan reminder_hours_before_due 0
+// berarti pengingat dikirim tepat saat jatuh tempo, bukan default 24 jam.
+func TestPaymentReminderZeroLeadTime(t *testing.T) {
+	for _, backend := range testBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			hotel := newTestHotel(t, backend, 500000)
+			inventory := service.NewInventoryService(backend.repos.Property, service.NewSearchIndex(backend.repos.Property))
+			lead := 0
+			_, err := inventory.SetPropertyPaymentSchedule(hotel.propertyID, &models.PaymentSchedulePolicy{
+				DepositPercent:         30,
+				BalanceDueDays:         7,
+				ReminderHoursBeforeDue: &lead,
+			})
+			if err != nil {
+				t.Fatalf("set payment schedule: %v", err)
+			}
+			notifier := &recordingNotifier{}
+			bookings := service.NewBookingService(backend.repos.Booking, backend.repos.Property, backend.repos.Payment,
+				backend.uow, gateway.NewRegistry(), notifier, 15*time.Minute)
+
+			checkIn := stayDate(10)
+			created, err := bookings.CreateBooking(service.CreateBookingInput{
+				GuestID:    uuid.NewString(),
+				PropertyID: hotel.propertyID,
+				RoomID:     hotel.roomID,
+				CheckIn:    checkIn,
+				CheckOut:   checkIn.AddDate(0, 0, 1),
+			})
+			if err != nil {
+				t.Fatalf("create booking: %v", err)
+			}
+			schedule, err := bookings.GetPaymentSchedule("", created.Booking.ID.String())
+			if err != nil {
+				t.Fatalf("get payment schedule: %v", err)
+			}
+			var balance models.Payment
+			for _, payment := range schedule.Payments {
+				if payment.Kind == models.PaymentKindBalance {
+					balance = payment
+				}
+			}
+			if balance.DueAt == nil {
+				t.Fatalf("booking has no balance installment")
+			}
+
+			if _, err := bookings.ProcessPaymentSchedules(balance.DueAt.Add(-time.Hour)); err != nil {
+				t.Fatalf("process schedules: %v", err)
+			}
+			if sent := notifier.sentFor(balance.ID); len(sent) != 0 {
+				t.Fatalf("reminder sent an hour before due with zero lead time")
+			}
+			if _, err := bookings.ProcessPaymentSchedules(*balance.DueAt); err != nil {
+				t.Fatalf("process schedules: %v", err)
+			}
+			if sent := notifier.sentFor(balance.ID); len(sent) != 1 {
+				t.Fatalf("got %d reminders at due time, want 1", len(sent))
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hotelbooking/internal/gateway"
+	"hotelbooking/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidWebhookPayload menandakan webhook bertanda tangan benar tetapi
+// isinya tidak bisa diurai.
+var ErrInvalidWebhookPayload = errors.New("payload webhook tidak valid")
+
+// HandlePaymentWebhook memverifikasi signature webhook provider, menyimpan
+// payload mentahnya, lalu memproses event tepat sekali per event id.
+//
+// Isi event tidak dipercaya begitu saja: event hanya memicu pengecekan
+// status charge ke gateway (seperti SyncPayment), sehingga event yang datang
+// tidak berurutan atau dikirim ulang tidak bisa memundurkan status payment
+// atau mencatat dana dua kali. Event untuk charge yang bukan charge aktif
+// payment dicatat sebagai ignored.
+func (s *bookingService) HandlePaymentWebhook(provider string, headers http.Header, payload []byte, now time.Time) (*models.PaymentWebhookEvent, error) {
+	gw, err := s.gatewayFor(provider)
+	if err != nil {
+		return nil, err
+	}
+	receiver, ok := gw.(gateway.WebhookReceiver)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s tidak mendukung webhook", gateway.ErrUnknownProvider, provider)
+	}
+	parsed, err := receiver.ParseWebhook(headers, payload, now)
+	if err != nil {
+		if errors.Is(err, gateway.ErrInvalidSignature) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhookPayload, err)
+	}
+
+	event, created, err := s.paymentRepo.CreateWebhookEvent(models.PaymentWebhookEvent{
+		ID:              uuid.New(),
+		Provider:        gw.Name(),
+		EventID:         parsed.ID,
+		EventType:       parsed.Type,
+		ChargeReference: parsed.ChargeReference,
+		Payload:         string(payload),
+		Status:          models.WebhookEventReceived,
+		ReceivedAt:      now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Event yang sudah selesai tidak diproses ulang; event yang gagal atau
+	// terputus di tengah jalan boleh dicoba lagi oleh gateway.
+	if !created && (event.Status == models.WebhookEventProcessed || event.Status == models.WebhookEventIgnored) {
+		return event, nil
+	}
+
+	status, note, paymentID, err := s.processWebhook(gw, parsed, now)
+	if err != nil {
+		if _, updateErr := s.paymentRepo.UpdateWebhookEventStatus(event.ID.String(), models.WebhookEventFailed, err.Error(), paymentID, now); updateErr != nil {
+			return nil, updateErr
+		}
+		return nil, err
+	}
+	return s.paymentRepo.UpdateWebhookEventStatus(event.ID.String(), status, note, paymentID, now)
+}
+
+func (s *bookingService) processWebhook(gw gateway.PaymentGateway, event *gateway.WebhookEvent, now time.Time) (models.WebhookEventStatus, string, string, error) {
+	if event.ChargeReference == "" {
+		return models.WebhookEventIgnored, "event tidak menyebut charge", "", nil
+	}
+	if _, err := uuid.Parse(event.OrderID); err != nil {
+		return models.WebhookEventIgnored, "order_id tidak dikenal", "", nil
+	}
+	payment, err := s.paymentRepo.GetPaymentByID(event.OrderID)
+	if err != nil {
+		return "", "", "", err
+	}
+	paymentID := payment.ID.String()
+	if payment.Provider != gw.Name() || payment.Reference != event.ChargeReference {
+		return models.WebhookEventIgnored, "charge bukan charge aktif payment", paymentID, nil
+	}
+
+	target, err := s.paymentTargetFor(payment)
+	if err != nil {
+		return "", "", paymentID, err
+	}
+	latest, charge, err := s.syncOne(target, payment, now)
+	if err != nil {
+		return "", "", paymentID, err
+	}
+	note := "charge " + string(charge.Status) + ", payment " + string(latest.Status)
+	return models.WebhookEventProcessed, note, paymentID, nil
+}
@@ -0,0 +1,187 @@
+package service_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"hotelbooking/internal/gateway"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/service"
+
+	"github.com/google/uuid"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// webhookFixture adalah booking dengan charge mock yang sudah dibuat.
+type webhookFixture struct {
+	backend  testBackend
+	gateway  *gateway.MockGateway
+	bookings service.BookingService
+	session  *service.PaymentSession
+}
+
+func newWebhookFixture(t *testing.T) webhookFixture {
+	t.Helper()
+	backend := testBackends(t)[0]
+	gw := gateway.NewMockGateway(gateway.MockConfig{WebhookSecret: testWebhookSecret})
+	bookings := newTestBookingService(backend, gw)
+	hotel := newTestHotel(t, backend, 750000)
+	guestID := uuid.NewString()
+	checkIn := stayDate(5)
+	created, err := bookings.CreateBooking(service.CreateBookingInput{
+		GuestID:    guestID,
+		PropertyID: hotel.propertyID,
+		RoomID:     hotel.roomID,
+		CheckIn:    checkIn,
+		CheckOut:   checkIn.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+	session, err := bookings.StartPayment(guestID, created.Booking.ID.String(), "", gateway.MockProvider, time.Now())
+	if err != nil {
+		t.Fatalf("start payment: %v", err)
+	}
+	return webhookFixture{backend: backend, gateway: gw, bookings: bookings, session: session}
+}
+
+func signedHeaders(payload []byte, at time.Time) http.Header {
+	headers := http.Header{}
+	headers.Set(gateway.MockSignatureHeader, gateway.NewSigner(testWebhookSecret, 0).Sign(payload, at))
+	return headers
+}
+
+// chargeEvent menyusun payload webhook mock untuk status charge tertentu,
+// termasuk status yang tidak sesuai dengan gateway (event terlambat).
+func (f webhookFixture) chargeEvent(t *testing.T, status gateway.ChargeStatus) []byte {
+	t.Helper()
+	payload, err := json.Marshal(map[string]any{
+		"id":         "evt_" + uuid.NewString(),
+		"type":       "charge." + string(status),
+		"created_at": time.Now(),
+		"data": map[string]any{
+			"reference": f.session.Charge.Reference,
+			"order_id":  f.session.Payment.ID.String(),
+			"status":    status,
+			"amount":    f.session.Charge.Amount,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func (f webhookFixture) payment(t *testing.T) *models.Payment {
+	t.Helper()
+	payment, err := f.backend.repos.Payment.GetPaymentByID(f.session.Payment.ID.String())
+	if err != nil {
+		t.Fatalf("get payment: %v", err)
+	}
+	return payment
+}
+
+func TestHandlePaymentWebhookValidSignature(t *testing.T) {
+	f := newWebhookFixture(t)
+	payload, header, err := f.gateway.Webhook(f.session.Charge.Reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers := http.Header{}
+	headers.Set(gateway.MockSignatureHeader, header)
+
+	event, err := f.bookings.HandlePaymentWebhook(gateway.MockProvider, headers, payload, time.Now())
+	if err != nil {
+		t.Fatalf("HandlePaymentWebhook() error = %v", err)
+	}
+	if event.Status != models.WebhookEventProcessed {
+		t.Fatalf("event status = %s, want %s", event.Status, models.WebhookEventProcessed)
+	}
+	if payment := f.payment(t); payment.Status != models.PaymentStatusPaid {
+		t.Fatalf("payment status = %s, want %s", payment.Status, models.PaymentStatusPaid)
+	}
+}
+
+func TestHandlePaymentWebhookRejectsBadSignature(t *testing.T) {
+	f := newWebhookFixture(t)
+	payload := f.chargeEvent(t, gateway.ChargeCaptured)
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		headers http.Header
+		payload []byte
+	}{
+		{name: "tampered body", headers: signedHeaders(payload, now), payload: f.chargeEvent(t, gateway.ChargeCaptured)},
+		{name: "wrong secret", headers: http.Header{gateway.MockSignatureHeader: {gateway.NewSigner("whsec_other", 0).Sign(payload, now)}}, payload: payload},
+		{name: "missing signature", headers: http.Header{}, payload: payload},
+		{name: "stale timestamp", headers: signedHeaders(payload, now.Add(-gateway.DefaultWebhookTolerance-time.Minute)), payload: payload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.bookings.HandlePaymentWebhook(gateway.MockProvider, tt.headers, tt.payload, now)
+			if !errors.Is(err, gateway.ErrInvalidSignature) {
+				t.Fatalf("HandlePaymentWebhook() error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+	if payment := f.payment(t); payment.Status != models.PaymentStatusPending || payment.AmountPaid != 0 {
+		t.Fatalf("payment = %s paid %.2f, want Pending without funds", payment.Status, payment.AmountPaid)
+	}
+}
+
+func TestHandlePaymentWebhookReplayProcessedOnce(t *testing.T) {
+	f := newWebhookFixture(t)
+	payload, header, err := f.gateway.Webhook(f.session.Charge.Reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers := http.Header{}
+	headers.Set(gateway.MockSignatureHeader, header)
+
+	first, err := f.bookings.HandlePaymentWebhook(gateway.MockProvider, headers, payload, time.Now())
+	if err != nil {
+		t.Fatalf("first delivery: %v", err)
+	}
+	second, err := f.bookings.HandlePaymentWebhook(gateway.MockProvider, headers, payload, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("replayed delivery: %v", err)
+	}
+	if first.ID != second.ID || first.ProcessedAt == nil || second.ProcessedAt == nil || !first.ProcessedAt.Equal(*second.ProcessedAt) {
+		t.Fatalf("replayed event was processed again: first %+v, second %+v", first, second)
+	}
+	payment := f.payment(t)
+	if payment.AmountPaid != payment.Amount {
+		t.Fatalf("amount paid = %.2f, want %.2f captured once", payment.AmountPaid, payment.Amount)
+	}
+}
+
+func TestHandlePaymentWebhookOutOfOrderDoesNotDowngrade(t *testing.T) {
+	f := newWebhookFixture(t)
+	now := time.Now()
+	captured := f.chargeEvent(t, gateway.ChargeAuthorized)
+	if _, err := f.bookings.HandlePaymentWebhook(gateway.MockProvider, signedHeaders(captured, now), captured, now); err != nil {
+		t.Fatalf("captured event: %v", err)
+	}
+	if payment := f.payment(t); payment.Status != models.PaymentStatusPaid {
+		t.Fatalf("payment status = %s, want %s", payment.Status, models.PaymentStatusPaid)
+	}
+
+	// Event failed yang terlambat tiba tidak boleh memundurkan status
+	failed := f.chargeEvent(t, gateway.ChargeFailed)
+	event, err := f.bookings.HandlePaymentWebhook(gateway.MockProvider, signedHeaders(failed, now), failed, now)
+	if err != nil {
+		t.Fatalf("late failed event: %v", err)
+	}
+	if event.Status != models.WebhookEventProcessed {
+		t.Fatalf("late event status = %s, want %s", event.Status, models.WebhookEventProcessed)
+	}
+	payment := f.payment(t)
+	if payment.Status != models.PaymentStatusPaid || payment.AmountPaid != payment.Amount {
+		t.Fatalf("payment = %s paid %.2f, want Paid %.2f", payment.Status, payment.AmountPaid, payment.Amount)
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"hotelbooking/internal/models"
+	"time"
+)
+
+// ReasonRateNotSet dipakai pada offer rate plan yang tidak punya harga
+// sama sekali untuk stay tersebut.
+const ReasonRateNotSet = "rate_not_set"
+
+// RateOffer adalah harga satu rate plan untuk stay yang di-quote.
+// TotalPrice sudah termasuk pajak; NightlyRates adalah harga rate per malam
+// sebelum pajak eksklusif.
+type RateOffer struct {
+	RatePlan     *models.RatePlan `json:"rate_plan,omitempty"`
+	Available    bool             `json:"available"`
+	Reasons      []QuoteReason    `json:"reasons"`
+	TotalPrice   float64          `json:"total_price"`
+	NetPrice     float64          `json:"net_price"`
+	TaxAmount    float64          `json:"tax_amount"`
+	TaxLines     []models.TaxLine `json:"tax_lines,omitempty"`
+	NightlyRates []NightlyRate    `json:"nightly_rates"`
+}
+
+// stayPricing menyimpan semua bahan quote satu kamar supaya setiap rate plan
+// dihitung dari data yang sama tanpa query ulang.
+type stayPricing struct {
+	rates     []models.RoomRate
+	basePrice float64
+	checkIn   time.Time
+	checkOut  time.Time
+	nights    int
+	adults    int
+	children  int
+	// unavailable berisi kode alasan jika kamar/tipe kamar sudah penuh
+	unavailable string
+	// taxRules adalah aturan pajak property yang diterapkan per malam
+	taxRules []models.TaxRule
+	// overCapacity true jika jumlah tamu melebihi kapasitas tipe kamar
+	overCapacity bool
+}
+
+// rateMap menggabungkan baris rate per tanggal: baris tanpa rate plan menjadi
+// dasar, lalu ditimpa baris milik plan (jika plan tidak nil).
+func (p stayPricing) rateMap(plan *models.RatePlan) map[string]models.RoomRate {
+	rateMap := make(map[string]models.RoomRate, len(p.rates))
+	for _, rate := range p.rates {
+		if rate.RatePlanID == nil {
+			rateMap[rate.Date.Format("2006-01-02")] = rate
+		}
+	}
+	if plan == nil {
+		return rateMap
+	}
+	for _, rate := range p.rates {
+		if rate.RatePlanID != nil && *rate.RatePlanID == plan.ID {
+			rateMap[rate.Date.Format("2006-01-02")] = rate
+		}
+	}
+	return rateMap
+}
+
+// price menghitung harga dan restriction stay untuk satu rate plan; plan nil
+// berarti harga dasar kamar.
+func (p stayPricing) price(plan *models.RatePlan) (*RateOffer, error) {
+	rateMap := p.rateMap(plan)
+	reasons := evaluateRestrictions(rateMap, p.checkIn, p.checkOut, p.nights)
+	total := 0.0
+	nightlyRates := make([]NightlyRate, 0, p.nights)
+
+	for day := p.checkIn; day.Before(p.checkOut); day = day.AddDate(0, 0, 1) {
+		dateStr := day.Format("2006-01-02")
+		roomRate, hasRate := rateMap[dateStr]
+		rate, source := p.basePrice, RateSourceBasePrice
+		var nonLinear *models.NonLinearRate
+
+		if hasRate {
+			if roomRate.LinearRate != nil {
+				rate, source = *roomRate.LinearRate, RateSourceLinearRate
+			}
+			var err error
+			nonLinear, err = parseNonLinearRate(roomRate.NonLinearRate)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		rate, breakdown := priceNight(rate, source, nonLinear, p.adults, p.children, p.nights)
+		nightlyRates = append(nightlyRates, NightlyRate{
+			Date:      dateStr,
+			Rate:      rate,
+			Breakdown: &breakdown,
+		})
+		total += rate
+	}
+
+	if total == 0 {
+		reasons = append(reasons, QuoteReason{Code: ReasonRateNotSet})
+	}
+	if p.unavailable != "" {
+		reasons = append(reasons, QuoteReason{Code: p.unavailable})
+	}
+	if p.overCapacity {
+		reasons = append(reasons, QuoteReason{Code: ReasonOverCapacity})
+	}
+
+	taxes := stayTaxes(p.taxRules, nightlyRates)
+	return &RateOffer{
+		RatePlan:     plan,
+		Available:    len(reasons) == 0,
+		Reasons:      reasons,
+		TotalPrice:   taxes.total,
+		NetPrice:     taxes.net,
+		TaxAmount:    taxes.tax,
+		TaxLines:     taxes.lines,
+		NightlyRates: nightlyRates,
+	}, nil
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"fmt"
+	"hotelbooking/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RatePlanInput dipakai untuk membuat maupun memperbarui rate plan. Sarapan
+// dan refundability diturunkan dari RateType; IsActive nil berarti aktif.
+// ParentRatePlanID menjadikan plan turunan dengan DerivationType/Value.
+type RatePlanInput struct {
+	PropertyID         string
+	Code               string
+	Name               string
+	RateType           models.RateType
+	CancellationPolicy string
+	IsActive           *bool
+	ParentRatePlanID   string
+	DerivationType     models.DerivationType
+	DerivationValue    float64
+}
+
+// ratePlanFlags memetakan RateType ke (sarapan, refundable).
+func ratePlanFlags(rateType models.RateType) (bool, bool, error) {
+	switch rateType {
+	case models.RateTypeNonRefundableRoomOnly:
+		return false, false, nil
+	case models.RateTypeNonRefundableWithBreakfast:
+		return true, false, nil
+	case models.RateTypeRefundableRoomOnly:
+		return false, true, nil
+	case models.RateTypeRefundableWithBreakfast:
+		return true, true, nil
+	}
+	return false, false, fmt.Errorf("rate_type tidak valid")
+}
+
+func buildRatePlan(input RatePlanInput) (models.RatePlan, error) {
+	code := strings.TrimSpace(input.Code)
+	if code == "" || strings.TrimSpace(input.Name) == "" {
+		return models.RatePlan{}, fmt.Errorf("kode dan nama rate plan wajib diisi")
+	}
+	breakfast, refundable, err := ratePlanFlags(input.RateType)
+	if err != nil {
+		return models.RatePlan{}, err
+	}
+	isActive := true
+	if input.IsActive != nil {
+		isActive = *input.IsActive
+	}
+	plan := models.RatePlan{
+		Code:               code,
+		Name:               input.Name,
+		RateType:           input.RateType,
+		BreakfastIncluded:  breakfast,
+		Refundable:         refundable,
+		CancellationPolicy: input.CancellationPolicy,
+		IsActive:           isActive,
+	}
+	if input.ParentRatePlanID != "" {
+		parentID, err := uuid.Parse(input.ParentRatePlanID)
+		if err != nil {
+			return models.RatePlan{}, fmt.Errorf("parent_rate_plan_id tidak valid")
+		}
+		if err := validateDerivation(input.DerivationType, input.DerivationValue); err != nil {
+			return models.RatePlan{}, err
+		}
+		plan.ParentRatePlanID = &parentID
+		plan.DerivationType = input.DerivationType
+		plan.DerivationValue = input.DerivationValue
+	}
+	return plan, nil
+}
+
+func (s *inventoryService) CreateRatePlan(input RatePlanInput) (*models.RatePlan, error) {
+	propUUID, err := uuid.Parse(input.PropertyID)
+	if err != nil {
+		return nil, fmt.Errorf("property_id tidak valid")
+	}
+	plan, err := buildRatePlan(input)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureUniqueRatePlanCode(input.PropertyID, plan.Code, uuid.Nil); err != nil {
+		return nil, err
+	}
+	plan.ID = uuid.New()
+	plan.PropertyID = &propUUID
+	plan.CreatedAt = time.Now()
+	if plan.ParentRatePlanID != nil {
+		if err := s.checkRatePlanParent(plan.ID, input.PropertyID, *plan.ParentRatePlanID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.CreateRatePlan(plan); err != nil {
+		return nil, err
+	}
+	if err := s.rebuildDerivedRates(&plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (s *inventoryService) UpdateRatePlan(id string, input RatePlanInput) (*models.RatePlan, error) {
+	planID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate plan id")
+	}
+	existing, err := s.repo.GetRatePlanByID(id)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := buildRatePlan(input)
+	if err != nil {
+		return nil, err
+	}
+	if existing.PropertyID != nil {
+		if err := s.ensureUniqueRatePlanCode(existing.PropertyID.String(), plan.Code, planID); err != nil {
+			return nil, err
+		}
+		if plan.ParentRatePlanID != nil {
+			if err := s.checkRatePlanParent(planID, existing.PropertyID.String(), *plan.ParentRatePlanID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	plan.ID = planID
+	updated, err := s.repo.UpdateRatePlan(plan)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.rebuildDerivedRates(updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// ensureUniqueRatePlanCode memberi pesan yang jelas sebelum unique constraint
+// (property_id, code) di database menolak.
+func (s *inventoryService) ensureUniqueRatePlanCode(propertyID, code string, self uuid.UUID) error {
+	plans, err := s.repo.ListRatePlans(propertyID)
+	if err != nil {
+		return err
+	}
+	for _, plan := range plans {
+		if plan.ID != self && strings.EqualFold(plan.Code, code) {
+			return fmt.Errorf("kode rate plan %s sudah dipakai", code)
+		}
+	}
+	return nil
+}
+
+func (s *inventoryService) DeleteRatePlan(id string) error {
+	plan, err := s.repo.GetRatePlanByID(id)
+	if err != nil {
+		return err
+	}
+	if plan.PropertyID != nil {
+		plans, err := s.repo.ListRatePlans(plan.PropertyID.String())
+		if err != nil {
+			return err
+		}
+		if len(childRatePlans(plans, plan.ID)) > 0 {
+			return fmt.Errorf("rate plan masih menjadi parent rate plan lain")
+		}
+	}
+	return s.repo.DeleteRatePlan(id)
+}
+
+func (s *inventoryService) ListRatePlans(propertyID string) ([]models.RatePlan, error) {
+	if propertyID == "" {
+		return nil, fmt.Errorf("property_id wajib diisi")
+	}
+	return s.repo.ListRatePlans(propertyID)
+}
+
+func (s *inventoryService) GetRatePlanByID(id string) (*models.RatePlan, error) {
+	if id == "" {
+		return nil, fmt.Errorf("rate_plan_id wajib diisi")
+	}
+	return s.repo.GetRatePlanByID(id)
+}
+
+// SetPropertyCancellationPolicy memasang aturan pembatalan property; nil
+// kembali ke aturan default.
+func (s *inventoryService) SetPropertyCancellationPolicy(propertyID string, policy *models.CancellationPolicy) (*models.Properties, error) {
+	policy, err := normalizeCancellationPolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.SetPropertyCancellationRules(propertyID, policy)
+}
+
+// SetPropertyPaymentSchedule memasang jadwal deposit/pelunasan property;
+// nil berarti booking dibayar penuh sekaligus.
+func (s *inventoryService) SetPropertyPaymentSchedule(propertyID string, policy *models.PaymentSchedulePolicy) (*models.Properties, error) {
+	policy, err := normalizePaymentSchedulePolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.SetPropertyPaymentSchedule(propertyID, policy)
+}
+
+// SetPropertyTaxRules mengganti seluruh aturan pajak dan service charge
+// property. Daftar kosong berarti harga tidak dipajaki; booking yang sudah
+// dibuat tetap memakai pajak saat booking.
+func (s *inventoryService) SetPropertyTaxRules(propertyID string, rules []models.TaxRule) (*models.Properties, error) {
+	rules, err := normalizeTaxRules(rules)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.SetPropertyTaxRules(propertyID, rules)
+}
+
+// SetRatePlanCancellationPolicy memasang aturan pembatalan khusus rate plan;
+// nil berarti mengikuti aturan property. Rate plan non-refundable selalu
+// tanpa refund apa pun aturannya.
+func (s *inventoryService) SetRatePlanCancellationPolicy(planID string, policy *models.CancellationPolicy) (*models.RatePlan, error) {
+	policy, err := normalizeCancellationPolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.SetRatePlanCancellationRules(planID, policy)
+}
+
+// validateRatePlanRates memastikan setiap rate yang memakai rate plan menunjuk
+// plan milik property yang sama dengan kamarnya dan bukan plan turunan.
+// Property yang ratenya memakai rate plan dikembalikan untuk propagasi.
+func (s *inventoryService) validateRatePlanRates(rates []models.RoomRate) (map[uuid.UUID]bool, error) {
+	properties := make(map[uuid.UUID]bool)
+	plans := make(map[uuid.UUID]*models.RatePlan)
+	scopes := make(map[uuid.UUID]rateScope)
+	for _, rate := range rates {
+		scope, err := s.rateScopeOf(rate, scopes)
+		if err != nil {
+			return nil, err
+		}
+		if rate.RatePlanID == nil {
+			continue
+		}
+		plan, ok := plans[*rate.RatePlanID]
+		if !ok {
+			plan, err = s.repo.GetRatePlanByID(rate.RatePlanID.String())
+			if err != nil {
+				return nil, fmt.Errorf("rate plan tidak ditemukan")
+			}
+			plans[*rate.RatePlanID] = plan
+		}
+		if plan.PropertyID == nil || scope.propertyID == nil || *plan.PropertyID != *scope.propertyID {
+			return nil, fmt.Errorf("rate plan bukan milik property kamar")
+		}
+		if plan.ParentRatePlanID != nil {
+			return nil, fmt.Errorf("rate plan %s adalah turunan, harganya dihitung dari parent", plan.Code)
+		}
+		properties[*plan.PropertyID] = true
+	}
+	return properties, nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hotelbooking/internal/models"
+	"math"
+)
+
+const (
+	RateSourceBasePrice  = "base_price"
+	RateSourceLinearRate = "linear_rate"
+	RateSourceOccupancy  = "occupancy"
+)
+
+// NightlyRateBreakdown menjelaskan asal harga satu malam.
+type NightlyRateBreakdown struct {
+	Source               string  `json:"source"`
+	BaseAmount           float64 `json:"base_amount"`
+	ExtraAdultAmount     float64 `json:"extra_adult_amount,omitempty"`
+	ExtraChildAmount     float64 `json:"extra_child_amount,omitempty"`
+	LengthOfStayDiscount float64 `json:"length_of_stay_discount,omitempty"`
+}
+
+// parseNonLinearRate membaca kolom non_linear_rate. Nilai kosong/null berarti
+// rate tidak memakai skema non-linear.
+func parseNonLinearRate(raw json.RawMessage) (*models.NonLinearRate, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil, nil
+	}
+	var rate models.NonLinearRate
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rate); err != nil {
+		return nil, fmt.Errorf("non_linear_rate tidak valid: %v", err)
+	}
+	return &rate, nil
+}
+
+// validateNonLinearRate dipakai SetRoomRates agar hanya skema yang bisa
+// dievaluasi QuoteBooking yang tersimpan.
+func validateNonLinearRate(raw json.RawMessage) error {
+	rate, err := parseNonLinearRate(raw)
+	if err != nil || rate == nil {
+		return err
+	}
+	if rate.Occupancy == nil && len(rate.LengthOfStay) == 0 {
+		return fmt.Errorf("non_linear_rate harus berisi occupancy atau length_of_stay")
+	}
+	if occ := rate.Occupancy; occ != nil {
+		if occ.OneAdult <= 0 || occ.TwoAdults <= 0 {
+			return fmt.Errorf("non_linear_rate.occupancy: one_adult dan two_adults wajib lebih dari 0")
+		}
+		if occ.ExtraAdult < 0 || occ.ExtraChild < 0 {
+			return fmt.Errorf("non_linear_rate.occupancy: extra_adult dan extra_child tidak boleh negatif")
+		}
+	}
+	seen := make(map[int]bool, len(rate.LengthOfStay))
+	for _, tier := range rate.LengthOfStay {
+		if tier.MinNights < 1 {
+			return fmt.Errorf("non_linear_rate.length_of_stay: min_nights minimal 1")
+		}
+		if seen[tier.MinNights] {
+			return fmt.Errorf("non_linear_rate.length_of_stay: min_nights %d duplikat", tier.MinNights)
+		}
+		seen[tier.MinNights] = true
+		if tier.DiscountPercent < 0 || tier.DiscountPercent > 100 {
+			return fmt.Errorf("non_linear_rate.length_of_stay: discount_percent harus antara 0 dan 100")
+		}
+	}
+	return nil
+}
+
+// priceNight menghitung harga satu malam. base adalah LinearRate (atau
+// base price tipe kamar), yang dipakai jika tidak ada harga occupancy.
+func priceNight(base float64, source string, rate *models.NonLinearRate, adults, children, nights int) (float64, NightlyRateBreakdown) {
+	breakdown := NightlyRateBreakdown{Source: source, BaseAmount: base}
+	if rate == nil {
+		return base, breakdown
+	}
+
+	if occ := rate.Occupancy; occ != nil {
+		breakdown.Source = RateSourceOccupancy
+		if adults <= 1 {
+			breakdown.BaseAmount = occ.OneAdult
+		} else {
+			breakdown.BaseAmount = occ.TwoAdults
+			breakdown.ExtraAdultAmount = float64(adults-2) * occ.ExtraAdult
+		}
+		breakdown.ExtraChildAmount = float64(children) * occ.ExtraChild
+	}
+	subtotal := breakdown.BaseAmount + breakdown.ExtraAdultAmount + breakdown.ExtraChildAmount
+
+	var tier *models.LengthOfStayTier
+	for i := range rate.LengthOfStay {
+		candidate := &rate.LengthOfStay[i]
+		if candidate.MinNights <= nights && (tier == nil || candidate.MinNights > tier.MinNights) {
+			tier = candidate
+		}
+	}
+	if tier != nil {
+		breakdown.LengthOfStayDiscount = roundAmount(subtotal * tier.DiscountPercent / 100)
+	}
+
+	return subtotal - breakdown.LengthOfStayDiscount, breakdown
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
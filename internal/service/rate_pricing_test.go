@@ -0,0 +1,69 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"hotelbooking/internal/models"
+)
+
+func TestPriceNight(t *testing.T) {
+	occupancy := &models.OccupancyRate{OneAdult: 400000, TwoAdults: 500000, ExtraAdult: 150000, ExtraChild: 100000}
+	losTiers := []models.LengthOfStayTier{{MinNights: 3, DiscountPercent: 10}, {MinNights: 7, DiscountPercent: 20}}
+
+	tests := []struct {
+		name       string
+		rate       *models.NonLinearRate
+		adults     int
+		children   int
+		nights     int
+		want       float64
+		wantSource string
+	}{
+		{name: "linear rate", rate: nil, adults: 2, nights: 1, want: 300000, wantSource: RateSourceLinearRate},
+		{name: "one adult", rate: &models.NonLinearRate{Occupancy: occupancy}, adults: 1, nights: 1, want: 400000, wantSource: RateSourceOccupancy},
+		{name: "two adults", rate: &models.NonLinearRate{Occupancy: occupancy}, adults: 2, nights: 1, want: 500000, wantSource: RateSourceOccupancy},
+		{name: "extra adult and child", rate: &models.NonLinearRate{Occupancy: occupancy}, adults: 3, children: 2, nights: 1, want: 850000, wantSource: RateSourceOccupancy},
+		{name: "los below first tier", rate: &models.NonLinearRate{LengthOfStay: losTiers}, adults: 2, nights: 2, want: 300000, wantSource: RateSourceLinearRate},
+		{name: "los first tier", rate: &models.NonLinearRate{LengthOfStay: losTiers}, adults: 2, nights: 3, want: 270000, wantSource: RateSourceLinearRate},
+		{name: "los highest tier wins", rate: &models.NonLinearRate{LengthOfStay: losTiers}, adults: 2, nights: 10, want: 240000, wantSource: RateSourceLinearRate},
+		{name: "occupancy with los", rate: &models.NonLinearRate{Occupancy: occupancy, LengthOfStay: losTiers}, adults: 2, nights: 7, want: 400000, wantSource: RateSourceOccupancy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, breakdown := priceNight(300000, RateSourceLinearRate, tt.rate, tt.adults, tt.children, tt.nights)
+			if got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			if breakdown.Source != tt.wantSource {
+				t.Fatalf("got source %s, want %s", breakdown.Source, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestParseNonLinearRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "empty", raw: "", wantNil: true},
+		{name: "null", raw: " null ", wantNil: true},
+		{name: "occupancy", raw: `{"occupancy":{"one_adult":1,"two_adults":2}}`},
+		{name: "unknown field", raw: `{"occupancy":{"one_adult":1,"two_adults":2},"weekend":5}`, wantErr: true},
+		{name: "malformed", raw: `{"occupancy":`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := parseNonLinearRate(json.RawMessage(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (rate == nil) != tt.wantNil {
+				t.Fatalf("got rate %+v, want nil %v", rate, tt.wantNil)
+			}
+		})
+	}
+}
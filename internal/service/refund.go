@@ -0,0 +1,289 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Alasan refund yang dibuat otomatis oleh sistem.
+const (
+	RefundReasonCancellation = "cancellation"
+	RefundReasonModification = "modification"
+	RefundReasonFolio        = "folio_adjustment"
+)
+
+// ErrRefundIncomplete berarti perubahan booking sudah tersimpan tetapi refund
+// otomatisnya gagal atau tidak dapat dibuat, sehingga dana perlu
+// dikembalikan admin lewat IssueRefund.
+var ErrRefundIncomplete = errors.New("perubahan tersimpan, tetapi refund belum berhasil")
+
+// RefundInput adalah refund diskresioner dari admin, di luar aturan
+// pembatalan. PaymentID opsional; kosong berarti refund dibagi ke cicilan
+// yang sudah dibayar, mulai dari yang terakhir.
+type RefundInput struct {
+	PaymentID string  `json:"payment_id,omitempty"`
+	Amount    float64 `json:"amount"`
+	Reason    string  `json:"reason"`
+}
+
+// RefundResult adalah refund yang dieksekusi beserta cicilan dan invoice
+// setelahnya.
+type RefundResult struct {
+	Refunds  []models.Refund  `json:"refunds"`
+	Payments []models.Payment `json:"payments,omitempty"`
+	Invoice  *models.Invoice  `json:"invoice,omitempty"`
+}
+
+// bookingPaymentOwner membentuk pemilik payment sebuah booking tanpa cek
+// tamu: booking itu sendiri atau reservasinya.
+func (s *bookingService) bookingPaymentOwner(booking *models.Booking) (paymentTarget, error) {
+	if booking.ReservationID == nil {
+		return paymentTarget{bookingID: booking.ID.String(), bookings: []models.Booking{*booking}}, nil
+	}
+	reservationID := booking.ReservationID.String()
+	bookings, err := s.repo.ListReservationBookings(reservationID)
+	if err != nil {
+		return paymentTarget{}, err
+	}
+	return paymentTarget{reservationID: reservationID, bookings: bookings}, nil
+}
+
+// IssueRefund mengembalikan dana booking (atau reservasinya) lewat gateway
+// atas permintaan admin. Nominal dibatasi dana yang sudah di-capture
+// dikurangi refund sebelumnya.
+func (s *bookingService) IssueRefund(bookingID string, input RefundInput, actor string, now time.Time) (*RefundResult, error) {
+	if input.Reason == "" {
+		return nil, fmt.Errorf("reason wajib diisi")
+	}
+	booking, err := s.repo.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.bookingPaymentOwner(booking)
+	if err != nil {
+		return nil, err
+	}
+	if input.PaymentID == "" {
+		return s.refundAcross(target, &booking.ID, input.Amount, input.Reason, actor, now)
+	}
+	payments, err := target.payments(s.paymentRepo)
+	if err != nil {
+		return nil, err
+	}
+	payment, err := selectPayment(payments, input.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.refundPayments(target, []models.Payment{*payment}, &booking.ID, input.Amount, input.Reason, actor, now)
+}
+
+// ListRefunds mengembalikan riwayat refund semua cicilan booking, terlama
+// dulu. guestID kosong berarti dipanggil admin.
+func (s *bookingService) ListRefunds(guestID, bookingID string) ([]models.Refund, error) {
+	booking, err := s.repo.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if guestID != "" && (booking.GuestID == nil || booking.GuestID.String() != guestID) {
+		return nil, fmt.Errorf("booking tidak ditemukan")
+	}
+	target, err := s.bookingPaymentOwner(booking)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := target.payments(s.paymentRepo)
+	if err != nil {
+		return nil, err
+	}
+	refunds := []models.Refund{}
+	for _, payment := range payments {
+		list, err := s.paymentRepo.ListRefunds(payment.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, list...)
+	}
+	sort.SliceStable(refunds, func(i, j int) bool { return refunds[i].CreatedAt.Before(refunds[j].CreatedAt) })
+	return refunds, nil
+}
+
+// chargeBalance adalah dana satu charge payment yang masih bisa
+// dikembalikan.
+type chargeBalance struct {
+	charge    models.PaymentCharge
+	available float64
+}
+
+// refundableCharges mengembalikan charge payment yang masih punya dana untuk
+// dikembalikan, terbaru dulu. Refund yang masih Pending ikut dihitung supaya
+// dana tidak dikembalikan dua kali.
+func (s *bookingService) refundableCharges(payment *models.Payment) ([]chargeBalance, error) {
+	charges, err := s.paymentRepo.ListPaymentCharges(payment.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	refunds, err := s.paymentRepo.ListRefunds(payment.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	var out []chargeBalance
+	for i := len(charges) - 1; i >= 0; i-- {
+		available := repository.ChargeRefundable(charges, refunds, charges[i].Reference)
+		if available > 0 {
+			out = append(out, chargeBalance{charge: charges[i], available: available})
+		}
+	}
+	return out, nil
+}
+
+// refundAcross membagi refund ke semua cicilan target, mulai dari cicilan
+// terakhir.
+func (s *bookingService) refundAcross(target paymentTarget, bookingID *uuid.UUID, amount float64, reason, actor string, now time.Time) (*RefundResult, error) {
+	payments, err := target.payments(s.paymentRepo)
+	if err != nil {
+		return nil, err
+	}
+	return s.refundPayments(target, payments, bookingID, amount, reason, actor, now)
+}
+
+// refundPayments membagi refund ke charge yang sudah di-capture pada
+// payments, mulai dari cicilan dan charge terakhir. Setiap bagian
+// dieksekusi sebagai refund sendiri atas charge-nya; bagian yang ditolak
+// gateway tetap tercatat Failed.
+func (s *bookingService) refundPayments(target paymentTarget, payments []models.Payment, bookingID *uuid.UUID, amount float64, reason, actor string, now time.Time) (*RefundResult, error) {
+	amount = roundAmount(amount)
+	if amount <= 0 {
+		return nil, fmt.Errorf("nominal refund harus lebih dari 0")
+	}
+	type refundPart struct {
+		payment *models.Payment
+		charge  models.PaymentCharge
+		amount  float64
+	}
+	var parts []refundPart
+	remaining := amount
+	for i := len(payments) - 1; i >= 0 && remaining > 0; i-- {
+		balances, err := s.refundableCharges(&payments[i])
+		if err != nil {
+			return nil, err
+		}
+		for _, balance := range balances {
+			if remaining <= 0 {
+				break
+			}
+			part := math.Min(balance.available, remaining)
+			parts = append(parts, refundPart{payment: &payments[i], charge: balance.charge, amount: part})
+			remaining = roundAmount(remaining - part)
+		}
+	}
+	if remaining > 0 {
+		return nil, fmt.Errorf("nominal refund melebihi dana yang dapat dikembalikan (%.2f)", roundAmount(amount-remaining))
+	}
+
+	result := &RefundResult{}
+	var failed error
+	for _, part := range parts {
+		refund, err := s.issueRefund(target, part.payment, part.charge, bookingID, part.amount, reason, actor, now)
+		if refund == nil {
+			return nil, err
+		}
+		result.Refunds = append(result.Refunds, *refund)
+		if err != nil && failed == nil {
+			failed = err
+		}
+	}
+	var err error
+	if result.Payments, err = target.payments(s.paymentRepo); err != nil {
+		return nil, err
+	}
+	if result.Invoice, err = target.invoice(s.paymentRepo); err != nil {
+		return nil, err
+	}
+	return result, failed
+}
+
+// refundAfterCommit menjalankan refund otomatis setelah perubahan booking
+// tersimpan. Perubahannya tetap berlaku; kegagalan refund dicatat di log dan
+// dikembalikan sebagai ErrRefundIncomplete bersama hasil yang sempat dibuat.
+func (s *bookingService) refundAfterCommit(target paymentTarget, bookingID *uuid.UUID, amount float64, reason, actor string, now time.Time) (*RefundResult, error) {
+	result, err := s.refundAcross(target, bookingID, amount, reason, actor, now)
+	if err != nil {
+		log.Printf("refund %s booking %s sebesar %.2f gagal: %v", reason, bookingID, amount, err)
+		return result, fmt.Errorf("%w: %v", ErrRefundIncomplete, err)
+	}
+	return result, nil
+}
+
+// issueRefund mencatat refund Pending atas charge payment, mengeksekusinya
+// di gateway, lalu mencatat hasilnya. Repository menolak refund Pending yang
+// melebihi sisa dana charge, jadi refund paralel tidak bisa sama-sama lolos.
+// Refund yang ditolak gateway tetap tersimpan sebagai Failed dan
+// dikembalikan bersama error-nya.
+func (s *bookingService) issueRefund(target paymentTarget, payment *models.Payment, charge models.PaymentCharge, bookingID *uuid.UUID, amount float64, reason, actor string, now time.Time) (*models.Refund, error) {
+	amount = roundAmount(amount)
+	if amount <= 0 {
+		return nil, fmt.Errorf("nominal refund harus lebih dari 0")
+	}
+
+	refund := models.Refund{
+		ID:              uuid.New(),
+		PaymentID:       &payment.ID,
+		BookingID:       bookingID,
+		Amount:          amount,
+		Reason:          reason,
+		Status:          models.RefundStatusPending,
+		Provider:        charge.Provider,
+		ChargeReference: charge.Reference,
+		InitiatedBy:     actor,
+		CreatedAt:       now,
+	}
+	if err := s.paymentRepo.CreateRefund(refund); err != nil {
+		return nil, err
+	}
+
+	gatewayRef, gatewayErr := s.executeRefund(charge, amount)
+	if gatewayErr != nil {
+		failed, err := s.paymentRepo.CompleteRefund(refund.ID.String(), models.RefundStatusFailed, "", gatewayErr.Error(), now)
+		if err != nil {
+			return nil, err
+		}
+		return failed, fmt.Errorf("refund gagal: %v", gatewayErr)
+	}
+
+	var completed *models.Refund
+	err := s.uow.Do(func(tx *repository.Repositories) error {
+		var err error
+		if completed, err = tx.Payment.CompleteRefund(refund.ID.String(), models.RefundStatusSucceeded, gatewayRef, "", now); err != nil {
+			return err
+		}
+		if _, err := tx.Payment.RecordPaymentRefund(payment.ID.String(), amount); err != nil {
+			return err
+		}
+		_, err = target.syncInvoice(tx.Payment)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return completed, nil
+}
+
+func (s *bookingService) executeRefund(charge models.PaymentCharge, amount float64) (string, error) {
+	gw, err := s.gatewayFor(charge.Provider)
+	if err != nil {
+		return "", err
+	}
+	executed, err := gw.Refund(charge.Reference, amount)
+	if err != nil {
+		return "", err
+	}
+	return executed.Reference, nil
+}
@@ -0,0 +1,290 @@
+package service_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"hotelbooking/internal/gateway"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"hotelbooking/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// TestCancelBookingReportsFailedRefund memastikan refund pembatalan yang
+// ditolak gateway tidak ditelan: pembatalan tetap berlaku, refund tercatat
+// Failed, dan caller menerima ErrRefundIncomplete.
+func TestCancelBookingReportsFailedRefund(t *testing.T) {
+	for _, backend := range testBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			hotel := newTestHotel(t, backend, 500000)
+			paid := newTestBookingService(backend, gateway.NewMockGateway(gateway.MockConfig{}))
+			guestID, bookingID := paidBooking(t, paid, hotel)
+
+			// Gateway baru tidak mengenal charge sebelumnya sehingga refund ditolak.
+			bookings := newTestBookingService(backend, gateway.NewMockGateway(gateway.MockConfig{}))
+			cancelled, _, err := bookings.CancelBooking(guestID, bookingID, time.Now())
+			if !errors.Is(err, service.ErrRefundIncomplete) {
+				t.Fatalf("got error %v, want ErrRefundIncomplete", err)
+			}
+			if cancelled == nil || cancelled.Status != models.BookingStatusCancel {
+				t.Fatalf("booking not cancelled: %+v", cancelled)
+			}
+			refunds, err := bookings.ListRefunds(guestID, bookingID)
+			if err != nil {
+				t.Fatalf("list refunds: %v", err)
+			}
+			if len(refunds) != 1 || refunds[0].Status != models.RefundStatusFailed {
+				t.Fatalf("got refunds %+v, want one failed refund", refunds)
+			}
+		})
+	}
+}
+
+// TestPreviewCancellationMatchesRefund memastikan preview pembatalan
+// menghitung refund dari dana yang sudah dibayar, sama dengan refund yang
+// benar-benar dikirim saat booking dibatalkan.
+func TestPreviewCancellationMatchesRefund(t *testing.T) {
+	for _, backend := range testBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			hotel := newTestHotel(t, backend, 500000)
+			bookings := newTestBookingService(backend, gateway.NewMockGateway(gateway.MockConfig{}))
+
+			guestID := uuid.NewString()
+			checkIn := stayDate(20)
+			created, err := bookings.CreateBooking(service.CreateBookingInput{
+				GuestID:    guestID,
+				PropertyID: hotel.propertyID,
+				RoomID:     hotel.roomID,
+				CheckIn:    checkIn,
+				CheckOut:   checkIn.AddDate(0, 0, 1),
+			})
+			if err != nil {
+				t.Fatalf("create booking: %v", err)
+			}
+			unpaid, err := bookings.PreviewCancellation(guestID, created.Booking.ID.String(), time.Now())
+			if err != nil {
+				t.Fatalf("preview unpaid: %v", err)
+			}
+			if unpaid.RefundAmount != 0 {
+				t.Fatalf("got refund %v for unpaid booking, want 0", unpaid.RefundAmount)
+			}
+
+			guestID, bookingID := paidBooking(t, bookings, hotel)
+			preview, err := bookings.PreviewCancellation(guestID, bookingID, time.Now())
+			if err != nil {
+				t.Fatalf("preview paid: %v", err)
+			}
+			if preview.Paid != 500000 || preview.RefundAmount != 500000-preview.Penalty {
+				t.Fatalf("got paid %v refund %v penalty %v, want refund of paid minus penalty", preview.Paid, preview.RefundAmount, preview.Penalty)
+			}
+			if _, _, err := bookings.CancelBooking(guestID, bookingID, time.Now()); err != nil {
+				t.Fatalf("cancel: %v", err)
+			}
+			refunds, err := bookings.ListRefunds(guestID, bookingID)
+			if err != nil {
+				t.Fatalf("list refunds: %v", err)
+			}
+			refunded := 0.0
+			for _, refund := range refunds {
+				refunded += refund.Amount
+			}
+			if refunded != preview.RefundAmount {
+				t.Fatalf("got refunded %v, preview promised %v", refunded, preview.RefundAmount)
+			}
+		})
+	}
+}
+
+// paidBooking membuat booking satu malam yang sudah dibayar lunas lewat gw.
+func paidBooking(t *testing.T, bookings service.BookingService, hotel testHotel) (guestID, bookingID string) {
+	t.Helper()
+	guestID = uuid.NewString()
+	checkIn := stayDate(10)
+	created, err := bookings.CreateBooking(service.CreateBookingInput{
+		GuestID:    guestID,
+		PropertyID: hotel.propertyID,
+		RoomID:     hotel.roomID,
+		CheckIn:    checkIn,
+		CheckOut:   checkIn.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+	bookingID = created.Booking.ID.String()
+	if _, err := bookings.StartPayment(guestID, bookingID, "", gateway.MockProvider, time.Now()); err != nil {
+		t.Fatalf("start payment: %v", err)
+	}
+	if _, err := bookings.SyncPayment(guestID, bookingID, time.Now()); err != nil {
+		t.Fatalf("sync payment: %v", err)
+	}
+	return guestID, bookingID
+}
+
+// TestIssueRefundConcurrent menjalankan beberapa refund penuh sekaligus;
+// hanya satu yang boleh tercatat supaya dana tidak dikembalikan melebihi
+// yang dibayar.
+func TestIssueRefundConcurrent(t *testing.T) {
+	const n = 10
+	for _, backend := range testBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			bookings := newTestBookingService(backend, gateway.NewMockGateway(gateway.MockConfig{}))
+			hotel := newTestHotel(t, backend, 500000)
+			guestID, bookingID := paidBooking(t, bookings, hotel)
+
+			var (
+				wg        sync.WaitGroup
+				mu        sync.Mutex
+				successes int
+			)
+			start := make(chan struct{})
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					_, err := bookings.IssueRefund(bookingID, service.RefundInput{Amount: 500000, Reason: "goodwill"}, "admin:test", time.Now())
+					mu.Lock()
+					defer mu.Unlock()
+					if err == nil {
+						successes++
+					}
+				}()
+			}
+			close(start)
+			wg.Wait()
+
+			if successes != 1 {
+				t.Fatalf("got %d successful refunds, want 1", successes)
+			}
+			refunds, err := bookings.ListRefunds(guestID, bookingID)
+			if err != nil {
+				t.Fatalf("list refunds: %v", err)
+			}
+			total := 0.0
+			for _, refund := range refunds {
+				total += refund.Amount
+			}
+			if len(refunds) != 1 || total != 500000 {
+				t.Fatalf("got refunds %+v, want one refund of 500000", refunds)
+			}
+		})
+	}
+}
+
+// TestRecordPaymentRefundConcurrent menambah dana refund dari banyak request
+// sekaligus langsung di repository; penambahannya harus atomik dan berhenti
+// tepat di dana yang di-capture.
+func TestRecordPaymentRefundConcurrent(t *testing.T) {
+	const n = 10
+	for _, backend := range testBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			bookings := newTestBookingService(backend, gateway.NewMockGateway(gateway.MockConfig{}))
+			hotel := newTestHotel(t, backend, 500000)
+			_, bookingID := paidBooking(t, bookings, hotel)
+			payments, err := backend.repos.Payment.ListPaymentsByBookingID(bookingID)
+			if err != nil || len(payments) != 1 {
+				t.Fatalf("list payments: %v (%d payments)", err, len(payments))
+			}
+			paymentID := payments[0].ID.String()
+
+			var (
+				wg       sync.WaitGroup
+				mu       sync.Mutex
+				recorded int
+			)
+			start := make(chan struct{})
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					_, err := backend.repos.Payment.RecordPaymentRefund(paymentID, 100000)
+					mu.Lock()
+					defer mu.Unlock()
+					if err == nil {
+						recorded++
+					} else if !errors.Is(err, repository.ErrRefundExceedsCharge) {
+						t.Errorf("record refund: %v", err)
+					}
+				}()
+			}
+			close(start)
+			wg.Wait()
+
+			payment, err := backend.repos.Payment.GetPaymentByID(paymentID)
+			if err != nil {
+				t.Fatalf("get payment: %v", err)
+			}
+			if recorded != 5 || payment.AmountRefunded != 500000 || payment.Status != models.PaymentStatusRefunded {
+				t.Fatalf("got %d refunds, refunded %v, status %s; want 5, 500000, Refunded",
+					recorded, payment.AmountRefunded, payment.Status)
+			}
+		})
+	}
+}
+
+// TestIssueRefundAcrossPartialCaptures memastikan dana charge yang
+// di-capture sebagian (tagihan naik selama charge berjalan) tetap bisa
+// dikembalikan setelah payment menunjuk charge berikutnya.
+func TestIssueRefundAcrossPartialCaptures(t *testing.T) {
+	for _, backend := range testBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			clock := time.Now()
+			gw := gateway.NewMockGateway(gateway.MockConfig{Delay: time.Hour, Now: func() time.Time { return clock }})
+			bookings := newTestBookingService(backend, gw)
+			hotel := newTestHotel(t, backend, 500000)
+			guestID := uuid.NewString()
+			checkIn := stayDate(10)
+			created, err := bookings.CreateBooking(service.CreateBookingInput{
+				GuestID:    guestID,
+				PropertyID: hotel.propertyID,
+				RoomID:     hotel.roomID,
+				CheckIn:    checkIn,
+				CheckOut:   checkIn.AddDate(0, 0, 1),
+			})
+			if err != nil {
+				t.Fatalf("create booking: %v", err)
+			}
+			bookingID := created.Booking.ID.String()
+			if _, err := bookings.StartPayment(guestID, bookingID, "", gateway.MockProvider, time.Now()); err != nil {
+				t.Fatalf("start payment: %v", err)
+			}
+			// Tambah satu malam selagi charge pertama masih berjalan.
+			if _, err := bookings.ModifyBooking(guestID, bookingID, service.ModifyBookingInput{CheckOut: checkIn.AddDate(0, 0, 2)}, service.GuestActor(guestID), time.Now()); err != nil {
+				t.Fatalf("modify booking: %v", err)
+			}
+			clock = clock.Add(2 * time.Hour)
+			if _, err := bookings.SyncPayment(guestID, bookingID, time.Now()); err != nil {
+				t.Fatalf("sync first charge: %v", err)
+			}
+			gw.SetConfig(gateway.MockConfig{Now: func() time.Time { return clock }})
+			if _, err := bookings.StartPayment(guestID, bookingID, "", gateway.MockProvider, time.Now()); err != nil {
+				t.Fatalf("start second payment: %v", err)
+			}
+			session, err := bookings.SyncPayment(guestID, bookingID, time.Now())
+			if err != nil {
+				t.Fatalf("sync second charge: %v", err)
+			}
+			if session.Payment.Status != models.PaymentStatusPaid || session.Payment.AmountPaid != 1000000 {
+				t.Fatalf("payment not fully paid: %+v", session.Payment)
+			}
+
+			result, err := bookings.IssueRefund(bookingID, service.RefundInput{Amount: 1000000, Reason: "goodwill"}, "admin:test", time.Now())
+			if err != nil {
+				t.Fatalf("issue refund: %v", err)
+			}
+			if len(result.Refunds) != 2 {
+				t.Fatalf("got %d refunds, want one per charge", len(result.Refunds))
+			}
+			for _, refund := range result.Refunds {
+				if refund.Status != models.RefundStatusSucceeded {
+					t.Fatalf("refund %s on %s: %s %s", refund.ID, refund.ChargeReference, refund.Status, refund.FailureReason)
+				}
+			}
+		})
+	}
+}
@@ -5,30 +5,60 @@ import (
 	"hotelbooking/internal/models"
 	"hotelbooking/internal/repository"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+// ReportSummary adalah ringkasan kamar terjual. Revenue sudah termasuk pajak
+// kamar; NetRevenue adalah pendapatan sebelum pajak, dipakai untuk ADR dan
+// RevPAR, dan Taxes pajak yang dipungut untuk pihak ketiga.
 type ReportSummary struct {
-	TotalBookings int     `json:"total_bookings"`
-	Revenue       float64 `json:"revenue"`
-	Occupancy     float64 `json:"occupancy"`
-	ADR           float64 `json:"adr"`
-	RevPAR        float64 `json:"revpar"`
+	TotalBookings   int                `json:"total_bookings"`
+	Revenue         float64            `json:"revenue"`
+	NetRevenue      float64            `json:"net_revenue"`
+	Taxes           float64            `json:"taxes"`
+	Occupancy       float64            `json:"occupancy"`
+	ADR             float64            `json:"adr"`
+	RevPAR          float64            `json:"revpar"`
 	OccupancyByDate map[string]float64 `json:"occupancy_by_date"`
+	Adults          int                `json:"adults"`
+	Children        int                `json:"children"`
+	GuestNights     int                `json:"guest_nights"`
+}
+
+// PoliceRegistrationRow adalah satu tamu yang menginap pada malam laporan.
+// Booking tanpa tamu bernama diwakili oleh profil pemesan.
+type PoliceRegistrationRow struct {
+	BookingID      string `json:"booking_id"`
+	RoomNumber     string `json:"room_number"`
+	FirstName      string `json:"first_name"`
+	LastName       string `json:"last_name"`
+	Nationality    string `json:"nationality"`
+	DocumentType   string `json:"document_type"`
+	DocumentNumber string `json:"document_number"`
+	Age            *int   `json:"age,omitempty"`
+	CheckIn        string `json:"check_in"`
+	CheckOut       string `json:"check_out"`
+	Adults         int    `json:"adults"`
+	Children       int    `json:"children"`
 }
 
 type ReportService interface {
 	GetSummary(propertyID string, start, end time.Time) (*ReportSummary, error)
+	PoliceRegistration(propertyID string, date time.Time) ([]PoliceRegistrationRow, error)
 }
 
 type reportService struct {
 	bookingRepo repository.BookingRepo
 	propRepo    repository.PropertyRepo
+	guestRepo   repository.GuestRepo
 }
 
-func NewReportService(bookingRepo repository.BookingRepo, propRepo repository.PropertyRepo) ReportService {
+func NewReportService(bookingRepo repository.BookingRepo, propRepo repository.PropertyRepo, guestRepo repository.GuestRepo) ReportService {
 	return &reportService{
 		bookingRepo: bookingRepo,
 		propRepo:    propRepo,
+		guestRepo:   guestRepo,
 	}
 }
 
@@ -66,19 +96,30 @@ func (s *reportService) GetSummary(propertyID string, start, end time.Time) (*Re
 		roomCount = 1
 	}
 
-	var totalNights int
-	var revenue float64
+	var totalNights, adults, children, guestNights int
+	var revenue, netRevenue, taxes float64
 	occupancyByDate := make(map[string]float64)
 	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
 		occupancyByDate[day.Format("2006-01-02")] = 0
 	}
 
 	for _, b := range bookings {
-		if b.Status == models.BookingStatusCancel || b.Status == models.BookingStatusNew {
+		if b.Status == models.BookingStatusCancel || b.Status == models.BookingStatusNew || b.Status == models.BookingStatusExpired {
 			continue
 		}
 		totalNights += b.Nights
-		revenue += b.TotalPrice - b.RefundAmount
+		adults += b.Adults
+		children += b.Children
+		guestNights += (b.Adults + b.Children) * b.Nights
+		collected := b.TotalPrice - b.RefundAmount
+		revenue += collected
+		// Refund mengurangi pajak dan pendapatan bersih secara proporsional
+		tax := 0.0
+		if b.TotalPrice > 0 {
+			tax = collected * b.TaxAmount / b.TotalPrice
+		}
+		taxes += tax
+		netRevenue += collected - tax
 		for day := b.CheckIn; day.Before(b.CheckOut); day = day.AddDate(0, 0, 1) {
 			key := day.Format("2006-01-02")
 			if _, ok := occupancyByDate[key]; ok {
@@ -97,16 +138,104 @@ func (s *reportService) GetSummary(propertyID string, start, end time.Time) (*Re
 
 	adr := 0.0
 	if totalNights > 0 {
-		adr = revenue / float64(totalNights)
+		adr = netRevenue / float64(totalNights)
 	}
-	revpar := revenue / float64(roomCount*days)
+	revpar := netRevenue / float64(roomCount*days)
 
 	return &ReportSummary{
-		TotalBookings: len(bookings),
-		Revenue:       revenue,
-		Occupancy:     occupancy,
-		ADR:           adr,
-		RevPAR:        revpar,
+		TotalBookings:   len(bookings),
+		Revenue:         revenue,
+		NetRevenue:      roundAmount(netRevenue),
+		Taxes:           roundAmount(taxes),
+		Occupancy:       occupancy,
+		ADR:             adr,
+		RevPAR:          revpar,
 		OccupancyByDate: occupancyByDate,
+		Adults:          adults,
+		Children:        children,
+		GuestNights:     guestNights,
 	}, nil
 }
+
+// PoliceRegistration menyusun daftar tamu yang sudah check-in dan menginap
+// pada malam date, untuk laporan tamu asing/wajib lapor ke kepolisian.
+func (s *reportService) PoliceRegistration(propertyID string, date time.Time) ([]PoliceRegistrationRow, error) {
+	if propertyID == "" {
+		return nil, fmt.Errorf("property_id wajib diisi")
+	}
+	if date.IsZero() {
+		return nil, fmt.Errorf("date wajib diisi")
+	}
+	day := date.Format("2006-01-02")
+	bookings, err := s.bookingRepo.ListActiveBookings(propertyID, day, date.AddDate(0, 0, 1).Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	var staying []models.Booking
+	ids := make([]string, 0, len(bookings))
+	for _, b := range bookings {
+		if b.Status != models.BookingStatusCheckedIn && b.Status != models.BookingStatusCheckedOut {
+			continue
+		}
+		staying = append(staying, b)
+		ids = append(ids, b.ID.String())
+	}
+	rows := make([]PoliceRegistrationRow, 0, len(staying))
+	if len(staying) == 0 {
+		return rows, nil
+	}
+
+	occupants, err := s.bookingRepo.ListOccupants(ids)
+	if err != nil {
+		return nil, err
+	}
+	byBooking := make(map[uuid.UUID][]models.BookingOccupant)
+	for _, o := range occupants {
+		if o.BookingID != nil {
+			byBooking[*o.BookingID] = append(byBooking[*o.BookingID], o)
+		}
+	}
+	rooms, err := s.propRepo.ListRooms(propertyID, "")
+	if err != nil {
+		return nil, err
+	}
+	roomNumbers := make(map[uuid.UUID]string, len(rooms))
+	for _, room := range rooms {
+		roomNumbers[room.ID] = room.RoomNumber
+	}
+
+	for _, b := range staying {
+		base := PoliceRegistrationRow{
+			BookingID: b.ID.String(),
+			CheckIn:   b.CheckIn.Format("2006-01-02"),
+			CheckOut:  b.CheckOut.Format("2006-01-02"),
+			Adults:    b.Adults,
+			Children:  b.Children,
+		}
+		if b.RoomID != nil {
+			base.RoomNumber = roomNumbers[*b.RoomID]
+		}
+		named := byBooking[b.ID]
+		if len(named) == 0 {
+			if b.GuestID == nil {
+				continue
+			}
+			guest, err := s.guestRepo.GetGuestByID(b.GuestID.String())
+			if err != nil {
+				return nil, err
+			}
+			row := base
+			row.FirstName, row.LastName, row.Nationality = guest.FirstName, guest.LastName, guest.Nationality
+			rows = append(rows, row)
+			continue
+		}
+		for _, o := range named {
+			row := base
+			row.FirstName, row.LastName, row.Nationality = o.FirstName, o.LastName, o.Nationality
+			row.DocumentType, row.DocumentNumber, row.Age = o.DocumentType, o.DocumentNumber, o.Age
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxReservationRooms membatasi jumlah kamar dalam satu reservasi grup.
+const maxReservationRooms = 20
+
+// confirmationAlphabet tanpa I, O, 0 dan 1 supaya kode mudah dibacakan lewat
+// telepon; panjangnya 32 sehingga setiap byte acak dipetakan tanpa bias.
+const confirmationAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// ReservationRoomInput adalah satu kamar dalam reservasi. Setiap kamar boleh
+// berbeda tipe, rate plan, tanggal, dan jumlah tamu.
+type ReservationRoomInput struct {
+	RoomID     string
+	RoomTypeID string
+	RatePlanID string
+	CheckIn    time.Time
+	CheckOut   time.Time
+	Adults     int
+	Children   int
+	ChildAges  []int
+	Occupants  []OccupantInput
+}
+
+type CreateReservationInput struct {
+	GuestID    string
+	PropertyID string
+	Rooms      []ReservationRoomInput
+}
+
+// ReservationDetail adalah reservasi beserta booking kamar dan cicilan
+// payment/invoice gabungannya. Quotes hanya diisi saat reservasi dibuat.
+type ReservationDetail struct {
+	Reservation *models.Reservation `json:"reservation"`
+	Bookings    []models.Booking    `json:"bookings"`
+	Payments    []models.Payment    `json:"payments"`
+	Invoice     *models.Invoice     `json:"invoice"`
+	Quotes      []*BookingQuote     `json:"quotes,omitempty"`
+}
+
+// CreateReservation membuat satu reservasi berisi beberapa booking kamar.
+// Semua kamar dialokasikan dalam satu transaksi: jika satu kamar gagal
+// (misalnya tipe kamar habis), tidak ada kamar lain yang tersimpan.
+func (s *bookingService) CreateReservation(input CreateReservationInput) (*ReservationDetail, error) {
+	if len(input.Rooms) == 0 {
+		return nil, fmt.Errorf("rooms wajib diisi")
+	}
+	if len(input.Rooms) > maxReservationRooms {
+		return nil, fmt.Errorf("maksimal %d kamar per reservasi", maxReservationRooms)
+	}
+	if input.PropertyID == "" {
+		return nil, fmt.Errorf("property_id wajib diisi")
+	}
+	guestUUID, err := uuid.Parse(input.GuestID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid guest id")
+	}
+	propertyUUID, err := uuid.Parse(input.PropertyID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid property id")
+	}
+	code, err := newConfirmationCode()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	reservation := models.Reservation{
+		ID:               uuid.New(),
+		ConfirmationCode: code,
+		GuestID:          &guestUUID,
+		PropertyID:       &propertyUUID,
+		CreatedAt:        now,
+	}
+	bookings := make([]models.Booking, 0, len(input.Rooms))
+	quotes := make([]*BookingQuote, 0, len(input.Rooms))
+	for i, room := range input.Rooms {
+		// Selisih satu mikrodetik menjaga urutan kamar sesuai request
+		booking, quote, err := s.prepareBooking(CreateBookingInput{
+			GuestID:    input.GuestID,
+			PropertyID: input.PropertyID,
+			RoomID:     room.RoomID,
+			RoomTypeID: room.RoomTypeID,
+			RatePlanID: room.RatePlanID,
+			CheckIn:    room.CheckIn,
+			CheckOut:   room.CheckOut,
+			Adults:     room.Adults,
+			Children:   room.Children,
+			ChildAges:  room.ChildAges,
+		}, now.Add(time.Duration(i)*time.Microsecond))
+		if err != nil {
+			return nil, fmt.Errorf("kamar ke-%d: %w", i+1, err)
+		}
+		booking.ReservationID = &reservation.ID
+		reservation.TotalPrice += booking.TotalPrice
+		bookings = append(bookings, booking)
+		quotes = append(quotes, quote)
+	}
+	reservation.TotalPrice = roundAmount(reservation.TotalPrice)
+
+	property, err := s.propRepo.GetPropertyByID(input.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+	// Deposit jatuh tempo bersama hold; pelunasan mengikuti kamar yang
+	// check-in paling awal
+	checkIn, holdDue := bookings[0].CheckIn, bookings[0].HoldExpiresAt
+	for _, booking := range bookings[1:] {
+		if booking.CheckIn.Before(checkIn) {
+			checkIn = booking.CheckIn
+		}
+	}
+	payments := planPayments(property.PaymentSchedule, nil, &reservation.ID, reservation.TotalPrice, checkIn, now, holdDue)
+	invoice := models.Invoice{
+		ID:            uuid.New(),
+		ReservationID: &reservation.ID,
+		InvoiceNumber: buildInvoiceNumber(reservation.ID, now),
+		Amount:        reservation.TotalPrice,
+		Status:        models.PaymentStatusPending,
+		IssuedAt:      now,
+	}
+	for _, quote := range quotes {
+		invoice.TaxLines = mergeTaxLines(invoice.TaxLines, quote.TaxLines)
+		invoice.TaxAmount += quote.TaxAmount
+	}
+	invoice.TaxAmount = roundAmount(invoice.TaxAmount)
+	invoice.NetAmount = roundAmount(invoice.Amount - invoice.TaxAmount)
+
+	err = s.uow.Do(func(tx *repository.Repositories) error {
+		if _, err := expireHolds(tx, now); err != nil {
+			return err
+		}
+		if err := tx.Booking.CreateReservation(reservation); err != nil {
+			return err
+		}
+		for i := range bookings {
+			booking := &bookings[i]
+			if err := tx.Booking.CreateBooking(*booking); err != nil {
+				return err
+			}
+			if err := recordStatusHistory(tx, booking.ID, "", booking.Status, GuestActor(input.GuestID), "", booking.CreatedAt); err != nil {
+				return err
+			}
+			if _, err := saveOccupants(tx, booking, input.Rooms[i].Occupants, input.GuestID); err != nil {
+				return fmt.Errorf("kamar ke-%d: %w", i+1, err)
+			}
+			if err := tx.Payment.CreateFolioCharge(roomFolioCharge(booking, quotes[i].TaxLines, GuestActor(input.GuestID))); err != nil {
+				return err
+			}
+		}
+		for _, payment := range payments {
+			if err := tx.Payment.CreatePayment(payment); err != nil {
+				return err
+			}
+		}
+		return tx.Payment.CreateInvoice(invoice)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReservationDetail{
+		Reservation: &reservation,
+		Bookings:    bookings,
+		Payments:    payments,
+		Invoice:     &invoice,
+		Quotes:      quotes,
+	}, nil
+}
+
+// GetReservation mengembalikan reservasi milik tamu beserta kamar dan tagihannya.
+func (s *bookingService) GetReservation(guestID, reservationID string) (*ReservationDetail, error) {
+	reservation, err := s.guestReservation(guestID, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reservationDetail(reservation)
+}
+
+// GetReservationByCode dipakai front desk untuk mencari reservasi dari kode
+// konfirmasi.
+func (s *bookingService) GetReservationByCode(code string) (*ReservationDetail, error) {
+	if code == "" {
+		return nil, fmt.Errorf("confirmation_code wajib diisi")
+	}
+	reservation, err := s.repo.GetReservationByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	return s.reservationDetail(reservation)
+}
+
+// CancelReservationRoom membatalkan satu kamar dan mempertahankan kamar lain.
+// Total reservasi menjadi jumlah kamar yang tersisa: harga kamar dikurangi
+// dari cicilan yang belum dibayar (pelunasan lebih dulu), dan bagian yang
+// sudah dibayar dikembalikan lewat gateway sesuai aturan pembatalan lalu
+// dicatat di booking-nya. Jika semua kamar batal, cicilan yang belum
+// dibayar di-void.
+func (s *bookingService) CancelReservationRoom(guestID, reservationID, bookingID string, now time.Time) (*ReservationDetail, error) {
+	reservation, err := s.guestReservation(guestID, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	cancelErr := s.cancelReservationRoom(reservationID, bookingID, GuestActor(guestID), "cancelled_by_guest", now)
+	if cancelErr != nil && !errors.Is(cancelErr, ErrRefundIncomplete) {
+		return nil, cancelErr
+	}
+	if reservation, err = s.repo.GetReservationByID(reservationID); err != nil {
+		return nil, err
+	}
+	detail, err := s.reservationDetail(reservation)
+	if err != nil {
+		return nil, err
+	}
+	return detail, cancelErr
+}
+
+func (s *bookingService) cancelReservationRoom(reservationID, bookingID, actor, note string, now time.Time) error {
+	bookings, err := s.repo.ListReservationBookings(reservationID)
+	if err != nil {
+		return err
+	}
+	var target *models.Booking
+	for i := range bookings {
+		if bookings[i].ID.String() == bookingID {
+			target = &bookings[i]
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("kamar tidak ditemukan di reservasi ini")
+	}
+	if !canTransition(target.Status, models.BookingStatusCancel) {
+		return fmt.Errorf("booking tidak dapat dibatalkan")
+	}
+	owner := paymentTarget{reservationID: reservationID, bookings: bookings}
+	payments, err := owner.payments(s.paymentRepo)
+	if err != nil {
+		return err
+	}
+	// Bagian harga kamar yang tidak bisa dipotong dari cicilan belum dibayar
+	// adalah dana yang sudah masuk; dana itu yang dikembalikan, dipotong denda.
+	changes, paidPortion := planReduction(payments, target.TotalPrice)
+	preview, err := s.cancellationPreview(target, paidPortion, now)
+	if err != nil {
+		return err
+	}
+	refundAmount := preview.RefundAmount
+
+	remaining := 0.0
+	for _, booking := range bookings {
+		if booking.ID != target.ID && booking.Status != models.BookingStatusCancel && booking.Status != models.BookingStatusExpired {
+			remaining += booking.TotalPrice
+		}
+	}
+	remaining = roundAmount(remaining)
+
+	var cancelled *models.Booking
+	err = s.uow.Do(func(tx *repository.Repositories) error {
+		var err error
+		if cancelled, err = transitionBooking(tx, target, models.BookingStatusCancel, actor, note, refundAmount, now); err != nil {
+			return err
+		}
+		if _, err := tx.Booking.UpdateReservationTotal(reservationID, remaining); err != nil {
+			return err
+		}
+		// Kamar yang batal tidak lagi ditagih; tagihan tambahan yang sudah
+		// diposting tetap ada di folionya.
+		if err := voidRoomCharges(tx, target, actor, note, now); err != nil {
+			return err
+		}
+		if remaining <= 0 {
+			return closeReservationPayments(tx, reservationID)
+		}
+		if err := applyReductions(tx.Payment, changes); err != nil {
+			return err
+		}
+		if err := syncInvoiceAmount(tx, owner); err != nil {
+			return err
+		}
+		_, err = owner.syncInvoice(tx.Payment)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	// Refund kamar yang dibatalkan dieksekusi setelah pembatalan tersimpan;
+	// kegagalannya dikembalikan sebagai ErrRefundIncomplete.
+	if refundAmount > 0 {
+		if _, err := s.refundAfterCommit(owner, &cancelled.ID, refundAmount, RefundReasonCancellation, actor, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// guestReservation memastikan reservasi milik tamu yang meminta.
+func (s *bookingService) guestReservation(guestID, reservationID string) (*models.Reservation, error) {
+	reservation, err := s.repo.GetReservationByID(reservationID)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.GuestID == nil || reservation.GuestID.String() != guestID {
+		return nil, fmt.Errorf("reservasi tidak ditemukan")
+	}
+	return reservation, nil
+}
+
+func (s *bookingService) reservationDetail(reservation *models.Reservation) (*ReservationDetail, error) {
+	reservationID := reservation.ID.String()
+	bookings, err := s.repo.ListReservationBookings(reservationID)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := s.paymentRepo.ListPaymentsByReservationID(reservationID)
+	if err != nil {
+		return nil, err
+	}
+	invoice, err := s.paymentRepo.GetInvoiceByReservationID(reservationID)
+	if err != nil {
+		return nil, err
+	}
+	return &ReservationDetail{
+		Reservation: reservation,
+		Bookings:    bookings,
+		Payments:    payments,
+		Invoice:     invoice,
+	}, nil
+}
+
+// closeReservationPayments menutup cicilan gabungan yang masih Pending
+// setelah tidak ada lagi kamar reservasi yang memegang inventori.
+func closeReservationPayments(tx *repository.Repositories, reservationID string) error {
+	bookings, err := tx.Booking.ListReservationBookings(reservationID)
+	if err != nil {
+		return err
+	}
+	for _, booking := range bookings {
+		switch booking.Status {
+		case models.BookingStatusCancel, models.BookingStatusExpired, models.BookingStatusNoShow:
+		default:
+			return nil
+		}
+	}
+	return closePendingPayments(tx.Payment, paymentTarget{reservationID: reservationID})
+}
+
+// voidRoomCharges membatalkan tagihan kamar (beserta koreksinya) di folio
+// booking.
+func voidRoomCharges(tx *repository.Repositories, booking *models.Booking, actor, reason string, now time.Time) error {
+	charges, err := tx.Payment.ListFolioCharges(booking.ID.String())
+	if err != nil {
+		return err
+	}
+	for _, charge := range charges {
+		if charge.ChargeCode != models.ChargeCodeRoom || charge.Status != models.FolioChargeStatusPosted {
+			continue
+		}
+		if _, err := tx.Payment.VoidFolioCharge(charge.ID.String(), actor, reason, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newConfirmationCode menghasilkan kode 8 karakter seperti "K7MXQ2PA".
+func newConfirmationCode() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("gagal membuat kode konfirmasi: %v", err)
+	}
+	for i, b := range buf {
+		buf[i] = confirmationAlphabet[int(b)%len(confirmationAlphabet)]
+	}
+	return string(buf), nil
+}
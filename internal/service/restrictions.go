@@ -0,0 +1,66 @@
+package service
+
+import (
+	"hotelbooking/internal/models"
+	"time"
+)
+
+// Kode alasan kenapa sebuah stay tidak bisa dijual. Nilainya stabil dan
+// ditujukan untuk dibaca mesin (frontend, channel manager).
+const (
+	ReasonStopSell          = "stop_sell"
+	ReasonSoldOut           = "sold_out"
+	ReasonMinNightsNotMet   = "min_nights_not_met"
+	ReasonMaxNightsExceeded = "max_nights_exceeded"
+	ReasonClosedToArrival   = "closed_to_arrival"
+	ReasonClosedToDeparture = "closed_to_departure"
+	ReasonRoomBooked        = "room_already_booked"
+	ReasonNoRoomsLeft       = "no_rooms_left"
+)
+
+// QuoteReason menjelaskan satu pembatasan yang gagal. Date kosong jika
+// alasannya tidak terikat pada satu tanggal.
+type QuoteReason struct {
+	Code string `json:"code"`
+	Date string `json:"date,omitempty"`
+}
+
+// evaluateRestrictions menerapkan aturan restriction standar industri:
+// min/max LOS dan CTA dibaca dari baris tanggal kedatangan, CTD dari baris
+// tanggal kepulangan, sedangkan stop-sell/sold out berlaku di setiap malam.
+// rateMap berisi baris rate per tanggal "YYYY-MM-DD" termasuk tanggal check-out.
+func evaluateRestrictions(rateMap map[string]models.RoomRate, checkIn, checkOut time.Time, nights int) []QuoteReason {
+	reasons := make([]QuoteReason, 0)
+	arrival := checkIn.Format("2006-01-02")
+	departure := checkOut.Format("2006-01-02")
+
+	if rate, ok := rateMap[arrival]; ok {
+		if rate.CloseOnArrival {
+			reasons = append(reasons, QuoteReason{Code: ReasonClosedToArrival, Date: arrival})
+		}
+		if rate.MinNights > 0 && nights < rate.MinNights {
+			reasons = append(reasons, QuoteReason{Code: ReasonMinNightsNotMet, Date: arrival})
+		}
+		if rate.MaxNights > 0 && nights > rate.MaxNights {
+			reasons = append(reasons, QuoteReason{Code: ReasonMaxNightsExceeded, Date: arrival})
+		}
+	}
+
+	for day := checkIn; day.Before(checkOut); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		rate, ok := rateMap[date]
+		if !ok {
+			continue
+		}
+		if rate.StopSell {
+			reasons = append(reasons, QuoteReason{Code: ReasonStopSell, Date: date})
+		} else if rate.AvailableRooms <= 0 {
+			reasons = append(reasons, QuoteReason{Code: ReasonSoldOut, Date: date})
+		}
+	}
+
+	if rate, ok := rateMap[departure]; ok && rate.CloseOnDeparture {
+		reasons = append(reasons, QuoteReason{Code: ReasonClosedToDeparture, Date: departure})
+	}
+	return reasons
+}
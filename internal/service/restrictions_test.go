@@ -0,0 +1,84 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"hotelbooking/internal/models"
+)
+
+func TestEvaluateRestrictions(t *testing.T) {
+	checkIn := time.Date(2026, 11, 10, 0, 0, 0, 0, time.UTC)
+	checkOut := checkIn.AddDate(0, 0, 2)
+	arrival, middle, departure := "2026-11-10", "2026-11-11", "2026-11-12"
+	open := models.RoomRate{AvailableRooms: 1}
+	with := func(change func(*models.RoomRate)) models.RoomRate {
+		rate := open
+		change(&rate)
+		return rate
+	}
+
+	tests := []struct {
+		name  string
+		rates map[string]models.RoomRate
+		want  []QuoteReason
+	}{
+		{
+			name:  "open",
+			rates: map[string]models.RoomRate{arrival: open, middle: open, departure: open},
+			want:  []QuoteReason{},
+		},
+		{
+			name:  "closed to arrival",
+			rates: map[string]models.RoomRate{arrival: with(func(r *models.RoomRate) { r.CloseOnArrival = true })},
+			want:  []QuoteReason{{Code: ReasonClosedToArrival, Date: arrival}},
+		},
+		{
+			name:  "closed to arrival only on arrival date",
+			rates: map[string]models.RoomRate{arrival: open, middle: with(func(r *models.RoomRate) { r.CloseOnArrival = true })},
+			want:  []QuoteReason{},
+		},
+		{
+			name:  "closed to departure",
+			rates: map[string]models.RoomRate{arrival: open, departure: with(func(r *models.RoomRate) { r.CloseOnDeparture = true })},
+			want:  []QuoteReason{{Code: ReasonClosedToDeparture, Date: departure}},
+		},
+		{
+			name:  "min nights from arrival",
+			rates: map[string]models.RoomRate{arrival: with(func(r *models.RoomRate) { r.MinNights = 3 })},
+			want:  []QuoteReason{{Code: ReasonMinNightsNotMet, Date: arrival}},
+		},
+		{
+			name:  "min nights on later night ignored",
+			rates: map[string]models.RoomRate{arrival: open, middle: with(func(r *models.RoomRate) { r.MinNights = 3 })},
+			want:  []QuoteReason{},
+		},
+		{
+			name:  "max nights",
+			rates: map[string]models.RoomRate{arrival: with(func(r *models.RoomRate) { r.MaxNights = 1 })},
+			want:  []QuoteReason{{Code: ReasonMaxNightsExceeded, Date: arrival}},
+		},
+		{
+			name: "stop sell and sold out per night",
+			rates: map[string]models.RoomRate{
+				arrival: with(func(r *models.RoomRate) { r.StopSell = true }),
+				middle:  with(func(r *models.RoomRate) { r.AvailableRooms = 0 }),
+			},
+			want: []QuoteReason{{Code: ReasonStopSell, Date: arrival}, {Code: ReasonSoldOut, Date: middle}},
+		},
+		{
+			name:  "departure night is not sold",
+			rates: map[string]models.RoomRate{departure: with(func(r *models.RoomRate) { r.StopSell = true })},
+			want:  []QuoteReason{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluateRestrictions(tt.rates, checkIn, checkOut, 2)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"fmt"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"sort"
+)
+
+// AssignRoom memberi nomor kamar ke booking tipe kamar. roomID kosong berarti
+// kamar dipilih otomatis oleh assignRoom.
+func (s *bookingService) AssignRoom(bookingID, roomID string) (*models.Booking, error) {
+	if bookingID == "" {
+		return nil, fmt.Errorf("booking_id wajib diisi")
+	}
+	var updated *models.Booking
+	err := s.uow.Do(func(tx *repository.Repositories) error {
+		booking, err := tx.Booking.GetBookingByID(bookingID)
+		if err != nil {
+			return err
+		}
+		updated, err = assignRoom(tx, booking, roomID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// assignRoom memvalidasi kamar pilihan admin atau memilih kamar terbaik:
+// kamar tipe yang sama, tidak OutOfOrder, kosong sepanjang stay, dengan
+// kamar yang sudah bersih didahulukan lalu nomor kamar terkecil.
+func assignRoom(tx *repository.Repositories, booking *models.Booking, roomID string) (*models.Booking, error) {
+	switch booking.Status {
+	case models.BookingStatusNew, models.BookingStatusConfirmed, models.BookingStatusCheckedIn:
+	default:
+		return nil, fmt.Errorf("booking dengan status %s tidak dapat di-assign kamar", booking.Status)
+	}
+	if booking.RoomTypeID == nil {
+		return nil, fmt.Errorf("booking tidak memiliki tipe kamar")
+	}
+	checkIn, checkOut := booking.CheckIn.Format("2006-01-02"), booking.CheckOut.Format("2006-01-02")
+
+	if roomID != "" {
+		room, err := tx.Property.GetRoomByID(roomID)
+		if err != nil {
+			return nil, err
+		}
+		if room.RoomTypeID == nil || *room.RoomTypeID != *booking.RoomTypeID {
+			return nil, fmt.Errorf("kamar bukan tipe kamar yang dipesan")
+		}
+		if room.Status == models.RoomStatusOutOfOrder {
+			return nil, fmt.Errorf("kamar sedang out of order")
+		}
+		return tx.Booking.AssignRoom(booking.ID.String(), roomID)
+	}
+
+	if booking.RoomID != nil {
+		return booking, nil
+	}
+	propertyID := ""
+	if booking.PropertyID != nil {
+		propertyID = booking.PropertyID.String()
+	}
+	rooms, err := tx.Property.ListRooms(propertyID, booking.RoomTypeID.String())
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(rooms, func(i, j int) bool {
+		if ready := isRoomReady(rooms[i]); ready != isRoomReady(rooms[j]) {
+			return ready
+		}
+		return rooms[i].RoomNumber < rooms[j].RoomNumber
+	})
+	for _, room := range rooms {
+		if room.Status == models.RoomStatusOutOfOrder {
+			continue
+		}
+		free, err := tx.Booking.CheckAvailability(room.ID.String(), checkIn, checkOut)
+		if err != nil {
+			return nil, err
+		}
+		if free {
+			return tx.Booking.AssignRoom(booking.ID.String(), room.ID.String())
+		}
+	}
+	return nil, fmt.Errorf("tidak ada kamar kosong untuk tipe kamar ini sepanjang stay")
+}
+
+func isRoomReady(room models.Room) bool {
+	return room.HousekeepingStatus == models.HousekeepingStatusClean || room.HousekeepingStatus == models.HousekeepingStatusInspected
+}
@@ -0,0 +1,477 @@
+package service
+
+import (
+	"errors"
+	"hotelbooking/internal/models"
+	"hotelbooking/internal/repository"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// cityAliases memetakan nama lain kota ke nama kanonisnya. Kota di data
+// property maupun di query dinormalisasi lewat tabel ini sehingga "jogja"
+// menemukan hotel yang kotanya ditulis "Yogyakarta" dan sebaliknya.
+var cityAliases = map[string]string{
+	"jogja":         "yogyakarta",
+	"jogjakarta":    "yogyakarta",
+	"yogya":         "yogyakarta",
+	"jogya":         "yogyakarta",
+	"djogja":        "yogyakarta",
+	"jkt":           "jakarta",
+	"batavia":       "jakarta",
+	"bdg":           "bandung",
+	"sby":           "surabaya",
+	"smg":           "semarang",
+	"mlg":           "malang",
+	"solo":          "surakarta",
+	"dps":           "denpasar",
+	"makasar":       "makassar",
+	"ujung pandang": "makassar",
+}
+
+const (
+	// minTrigramSimilarity adalah batas bawah kemiripan token yang dianggap typo
+	minTrigramSimilarity = 0.3
+
+	weightName     = 3.0
+	weightCity     = 2.0
+	weightAddress  = 1.0
+	weightFacility = 1.0
+	weightRoomType = 0.5
+)
+
+// SearchHit adalah property yang cocok dengan query beserta skornya.
+type SearchHit struct {
+	PropertyID uuid.UUID
+	Score      float64
+}
+
+type CitySuggestion struct {
+	Name   string `json:"name"`
+	Hotels int    `json:"hotels"`
+}
+
+type HotelSuggestion struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	City string    `json:"city,omitempty"`
+}
+
+type AutocompleteResult struct {
+	Cities []CitySuggestion  `json:"cities"`
+	Hotels []HotelSuggestion `json:"hotels"`
+}
+
+// SearchIndex adalah indeks teks di memori untuk pencarian hotel. Setiap
+// token dipecah menjadi trigram sehingga query dengan typo tetap menemukan
+// token yang mirip. Indeks dibangun sekali saat startup lalu diperbarui per
+// property oleh InventoryService.
+type SearchIndex struct {
+	repo repository.PropertyRepo
+
+	mu       sync.RWMutex
+	docs     map[uuid.UUID]*indexDoc
+	postings map[string]map[uuid.UUID]float64
+	trigrams map[string]map[string]bool
+}
+
+type indexDoc struct {
+	property models.Properties
+	city     string
+	tokens   map[string]float64
+}
+
+func NewSearchIndex(repo repository.PropertyRepo) *SearchIndex {
+	return &SearchIndex{
+		repo:     repo,
+		docs:     make(map[uuid.UUID]*indexDoc),
+		postings: make(map[string]map[uuid.UUID]float64),
+		trigrams: make(map[string]map[string]bool),
+	}
+}
+
+// Rebuild memuat ulang semua property dari repository.
+func (idx *SearchIndex) Rebuild() error {
+	properties, err := idx.repo.ListProperties("")
+	if err != nil {
+		return err
+	}
+	docs := make([]*indexDoc, 0, len(properties))
+	for _, property := range properties {
+		roomTypes, err := idx.repo.ListRoomTypes(property.ID.String())
+		if err != nil {
+			return err
+		}
+		docs = append(docs, newIndexDoc(property, roomTypes))
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.docs = make(map[uuid.UUID]*indexDoc)
+	idx.postings = make(map[string]map[uuid.UUID]float64)
+	idx.trigrams = make(map[string]map[string]bool)
+	for _, doc := range docs {
+		idx.add(doc)
+	}
+	return nil
+}
+
+// Refresh membaca ulang satu property beserta tipe kamarnya. Property yang
+// sudah tidak ada dikeluarkan dari indeks; error lain dikembalikan dan
+// dokumen lama tetap dipakai.
+func (idx *SearchIndex) Refresh(propertyID string) error {
+	id, err := uuid.Parse(propertyID)
+	if err != nil {
+		return err
+	}
+	property, err := idx.repo.GetPropertyByID(propertyID)
+	if errors.Is(err, repository.ErrPropertyNotFound) {
+		idx.Remove(id)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	roomTypes, err := idx.repo.ListRoomTypes(propertyID)
+	if err != nil {
+		return err
+	}
+	doc := newIndexDoc(*property, roomTypes)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+	idx.add(doc)
+	return nil
+}
+
+func (idx *SearchIndex) Remove(propertyID uuid.UUID) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(propertyID)
+}
+
+// Search mengembalikan property yang cocok dengan semua kata di query,
+// diurutkan dari skor tertinggi. Kata juga dicocokkan sebagai prefix
+// supaya bisa dipakai saat pengguna masih mengetik.
+func (idx *SearchIndex) Search(query string) []SearchHit {
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var scores map[uuid.UUID]float64
+	for _, term := range terms {
+		termScores := make(map[uuid.UUID]float64)
+		for token, sim := range idx.similarTokens(term) {
+			for docID, weight := range idx.postings[token] {
+				if s := sim * weight; s > termScores[docID] {
+					termScores[docID] = s
+				}
+			}
+		}
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for docID := range scores {
+			if termScores[docID] == 0 {
+				delete(scores, docID)
+				continue
+			}
+			scores[docID] += termScores[docID]
+		}
+	}
+
+	hits := make([]SearchHit, 0, len(scores))
+	for docID, score := range scores {
+		hits = append(hits, SearchHit{PropertyID: docID, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return idx.docs[hits[i].PropertyID].property.Name < idx.docs[hits[j].PropertyID].property.Name
+	})
+	return hits
+}
+
+// MatchCity mengembalikan property yang kotanya cocok dengan city, termasuk
+// alias dan typo ringan.
+func (idx *SearchIndex) MatchCity(city string) map[uuid.UUID]bool {
+	wanted := canonicalCity(city)
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	out := make(map[uuid.UUID]bool)
+	for id, doc := range idx.docs {
+		if doc.city == "" {
+			continue
+		}
+		if strings.Contains(doc.city, wanted) || similarity(doc.city, wanted) >= minTrigramSimilarity {
+			out[id] = true
+		}
+	}
+	return out
+}
+
+// Autocomplete menyarankan kota (dengan jumlah hotelnya) dan nama hotel.
+func (idx *SearchIndex) Autocomplete(query string, limit int) AutocompleteResult {
+	result := AutocompleteResult{Cities: []CitySuggestion{}, Hotels: []HotelSuggestion{}}
+	normalized := strings.Join(tokenize(query), " ")
+	if normalized == "" {
+		return result
+	}
+	canonical := canonicalCity(normalized)
+
+	idx.mu.RLock()
+	type cityMatch struct {
+		CitySuggestion
+		score float64
+	}
+	cities := make(map[string]*cityMatch)
+	for _, doc := range idx.docs {
+		if doc.city == "" {
+			continue
+		}
+		score := similarity(doc.city, canonical)
+		if cityHasPrefix(doc.city, normalized) {
+			score = 1
+		}
+		if score < minTrigramSimilarity {
+			continue
+		}
+		match, ok := cities[doc.city]
+		if !ok {
+			match = &cityMatch{CitySuggestion: CitySuggestion{Name: doc.property.City}, score: score}
+			cities[doc.city] = match
+		}
+		// Tampilkan nama kanonis jika ada hotel yang menulisnya, bukan aliasnya
+		if canonicalName := strings.Join(tokenize(doc.property.City), " "); canonicalName == doc.city {
+			match.Name = doc.property.City
+		}
+		match.Hotels++
+	}
+	idx.mu.RUnlock()
+
+	cityList := make([]*cityMatch, 0, len(cities))
+	for _, match := range cities {
+		cityList = append(cityList, match)
+	}
+	sort.Slice(cityList, func(i, j int) bool {
+		if cityList[i].score != cityList[j].score {
+			return cityList[i].score > cityList[j].score
+		}
+		if cityList[i].Hotels != cityList[j].Hotels {
+			return cityList[i].Hotels > cityList[j].Hotels
+		}
+		return cityList[i].Name < cityList[j].Name
+	})
+	for i := 0; i < len(cityList) && i < limit; i++ {
+		result.Cities = append(result.Cities, cityList[i].CitySuggestion)
+	}
+
+	hits := idx.Search(query)
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	for i := 0; i < len(hits) && i < limit; i++ {
+		doc, ok := idx.docs[hits[i].PropertyID]
+		if !ok {
+			continue
+		}
+		result.Hotels = append(result.Hotels, HotelSuggestion{ID: doc.property.ID, Name: doc.property.Name, City: doc.property.City})
+	}
+	return result
+}
+
+// cityHasPrefix bernilai true jika prefix adalah awal nama kota atau awal
+// salah satu aliasnya, misalnya "jog" untuk yogyakarta.
+func cityHasPrefix(city, prefix string) bool {
+	if strings.HasPrefix(city, canonicalCity(prefix)) {
+		return true
+	}
+	for alias, canonical := range cityAliases {
+		if canonical == city && strings.HasPrefix(alias, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func newIndexDoc(property models.Properties, roomTypes []models.RoomType) *indexDoc {
+	doc := &indexDoc{
+		property: property,
+		city:     canonicalCity(property.City),
+		tokens:   make(map[string]float64),
+	}
+	put := func(text string, weight float64) {
+		for _, token := range tokenize(text) {
+			if weight > doc.tokens[token] {
+				doc.tokens[token] = weight
+			}
+			if canonical, ok := cityAliases[token]; ok && weight > doc.tokens[canonical] {
+				doc.tokens[canonical] = weight
+			}
+		}
+	}
+	put(property.Name, weightName)
+	put(property.City, weightCity)
+	put(doc.city, weightCity)
+	put(property.Address, weightAddress)
+	for _, facility := range property.Facilities {
+		put(facility, weightFacility)
+	}
+	for _, roomType := range roomTypes {
+		put(roomType.Name, weightRoomType)
+		put(roomType.Description, weightRoomType)
+		for _, facility := range roomType.Facilities {
+			put(facility, weightRoomType)
+		}
+	}
+	return doc
+}
+
+// add dan remove harus dipanggil dengan idx.mu terkunci.
+func (idx *SearchIndex) add(doc *indexDoc) {
+	idx.docs[doc.property.ID] = doc
+	for token, weight := range doc.tokens {
+		if idx.postings[token] == nil {
+			idx.postings[token] = make(map[uuid.UUID]float64)
+			for _, gram := range trigramsOf(token) {
+				if idx.trigrams[gram] == nil {
+					idx.trigrams[gram] = make(map[string]bool)
+				}
+				idx.trigrams[gram][token] = true
+			}
+		}
+		idx.postings[token][doc.property.ID] = weight
+	}
+}
+
+func (idx *SearchIndex) remove(id uuid.UUID) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	delete(idx.docs, id)
+	for token := range doc.tokens {
+		delete(idx.postings[token], id)
+		if len(idx.postings[token]) > 0 {
+			continue
+		}
+		delete(idx.postings, token)
+		for _, gram := range trigramsOf(token) {
+			delete(idx.trigrams[gram], token)
+			if len(idx.trigrams[gram]) == 0 {
+				delete(idx.trigrams, gram)
+			}
+		}
+	}
+}
+
+// similarTokens mencari token indeks yang sama, diawali term, atau cukup
+// mirip secara trigram dengan term. Nilainya adalah kemiripan 0..1.
+func (idx *SearchIndex) similarTokens(term string) map[string]float64 {
+	out := make(map[string]float64)
+	if _, ok := idx.postings[term]; ok {
+		out[term] = 1
+	}
+	candidates := make(map[string]bool)
+	for _, gram := range trigramsOf(term) {
+		for token := range idx.trigrams[gram] {
+			candidates[token] = true
+		}
+	}
+	// "jog" adalah awal alias "jogja", jadi yogyakarta ikut cocok
+	for alias, canonical := range cityAliases {
+		if len(term) >= 2 && strings.HasPrefix(alias, term) {
+			if _, ok := idx.postings[canonical]; ok && out[canonical] < 0.9 {
+				out[canonical] = 0.9
+			}
+		}
+	}
+	for token := range candidates {
+		if token == term {
+			continue
+		}
+		sim := similarity(token, term)
+		if len(term) >= 2 && strings.HasPrefix(token, term) && sim < 0.9 {
+			sim = 0.9
+		}
+		if sim >= minTrigramSimilarity {
+			out[token] = sim
+		}
+	}
+	return out
+}
+
+// queryTerms memecah query dan mengganti alias kota dengan nama kanonisnya.
+func queryTerms(query string) []string {
+	terms := tokenize(query)
+	for i, term := range terms {
+		if canonical, ok := cityAliases[term]; ok {
+			terms[i] = canonical
+		}
+	}
+	return terms
+}
+
+func canonicalCity(city string) string {
+	normalized := strings.Join(tokenize(city), " ")
+	if canonical, ok := cityAliases[normalized]; ok {
+		return canonical
+	}
+	return normalized
+}
+
+// tokenize mengubah teks menjadi kata huruf kecil tanpa tanda baca.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// trigramsOf memakai padding dua spasi di depan dan satu di belakang, sama
+// seperti pg_trgm, sehingga kata pendek tetap punya trigram.
+func trigramsOf(token string) []string {
+	padded := []rune("  " + token + " ")
+	seen := make(map[string]bool, len(padded))
+	out := make([]string, 0, len(padded))
+	for i := 0; i+3 <= len(padded); i++ {
+		gram := string(padded[i : i+3])
+		if !seen[gram] {
+			seen[gram] = true
+			out = append(out, gram)
+		}
+	}
+	return out
+}
+
+// similarity adalah koefisien Jaccard dari trigram kedua teks.
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ga, gb := trigramsOf(a), trigramsOf(b)
+	if len(ga) == 0 || len(gb) == 0 {
+		return 0
+	}
+	set := make(map[string]bool, len(ga))
+	for _, g := range ga {
+		set[g] = true
+	}
+	shared := 0
+	for _, g := range gb {
+		if set[g] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ga)+len(gb)-shared)
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"fmt"
+	"hotelbooking/internal/models"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// taxResult adalah harga yang sudah dipajaki. price adalah harga sesuai
+// rate/tagihan (bisa sudah berisi pajak inklusif); net + tax = total.
+type taxResult struct {
+	price float64
+	net   float64
+	tax   float64
+	total float64
+	lines []models.TaxLine
+}
+
+func (r *taxResult) add(other taxResult) {
+	r.price = roundAmount(r.price + other.price)
+	r.net = roundAmount(r.net + other.net)
+	r.tax = roundAmount(r.tax + other.tax)
+	r.total = roundAmount(r.total + other.total)
+	r.lines = mergeTaxLines(r.lines, other.lines)
+}
+
+// normalizeTaxRules memvalidasi aturan pajak property. Code yang sama boleh
+// muncul lebih dari sekali selama masa berlakunya tidak beririsan.
+func normalizeTaxRules(rules []models.TaxRule) ([]models.TaxRule, error) {
+	out := make([]models.TaxRule, 0, len(rules))
+	for i, rule := range rules {
+		rule.Code = strings.TrimSpace(rule.Code)
+		rule.Name = strings.TrimSpace(rule.Name)
+		if rule.Code == "" {
+			return nil, fmt.Errorf("aturan pajak ke-%d: code wajib diisi", i+1)
+		}
+		if rule.Name == "" {
+			rule.Name = rule.Code
+		}
+		switch rule.Type {
+		case models.TaxTypePercent:
+			if rule.Value <= 0 || rule.Value > 100 {
+				return nil, fmt.Errorf("aturan pajak %s: value persen harus lebih dari 0 dan maksimal 100", rule.Code)
+			}
+		case models.TaxTypeFixed:
+			if rule.Value <= 0 {
+				return nil, fmt.Errorf("aturan pajak %s: value harus lebih dari 0", rule.Code)
+			}
+		default:
+			return nil, fmt.Errorf("aturan pajak %s: type harus percent atau fixed", rule.Code)
+		}
+		if rule.Order < 0 {
+			return nil, fmt.Errorf("aturan pajak %s: order tidak boleh negatif", rule.Code)
+		}
+		if rule.EffectiveFrom != nil && rule.EffectiveTo != nil && rule.EffectiveTo.Before(*rule.EffectiveFrom) {
+			return nil, fmt.Errorf("aturan pajak %s: effective_to tidak boleh sebelum effective_from", rule.Code)
+		}
+		for _, code := range rule.AppliesTo {
+			if _, ok := chargeCodeLabels[code]; !ok {
+				return nil, fmt.Errorf("aturan pajak %s: charge_code tidak valid: %s", rule.Code, code)
+			}
+		}
+		for _, other := range out {
+			if other.Code == rule.Code && effectiveOverlap(other, rule) {
+				return nil, fmt.Errorf("aturan pajak %s: masa berlaku beririsan", rule.Code)
+			}
+		}
+		out = append(out, rule)
+	}
+	return out, nil
+}
+
+func effectiveOverlap(a, b models.TaxRule) bool {
+	if a.EffectiveTo != nil && b.EffectiveFrom != nil && dateOnly(*a.EffectiveTo) < dateOnly(*b.EffectiveFrom) {
+		return false
+	}
+	if b.EffectiveTo != nil && a.EffectiveFrom != nil && dateOnly(*b.EffectiveTo) < dateOnly(*a.EffectiveFrom) {
+		return false
+	}
+	return true
+}
+
+func dateOnly(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// applicableTaxRules memilih aturan yang berlaku untuk charge code pada
+// tanggal tersebut, diurutkan menurut Order.
+func applicableTaxRules(rules []models.TaxRule, code models.ChargeCode, date time.Time) []models.TaxRule {
+	day := dateOnly(date)
+	out := make([]models.TaxRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.EffectiveFrom != nil && day < dateOnly(*rule.EffectiveFrom) {
+			continue
+		}
+		if rule.EffectiveTo != nil && day > dateOnly(*rule.EffectiveTo) {
+			continue
+		}
+		if len(rule.AppliesTo) > 0 && !containsChargeCode(rule.AppliesTo, code) {
+			continue
+		}
+		out = append(out, rule)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Order < out[j].Order })
+	return out
+}
+
+func containsChargeCode(codes []models.ChargeCode, code models.ChargeCode) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// taxChain menghitung pajak setiap aturan dari harga bersih net. Aturan
+// dengan Order sama tidak saling memajaki; aturan Compound memakai net
+// ditambah pajak dari Order yang lebih kecil.
+func taxChain(rules []models.TaxRule, net float64, units int) []float64 {
+	amounts := make([]float64, len(rules))
+	prior := 0.0
+	for i := 0; i < len(rules); {
+		j, group := i, 0.0
+		for ; j < len(rules) && rules[j].Order == rules[i].Order; j++ {
+			rule := rules[j]
+			if rule.Type == models.TaxTypeFixed {
+				amounts[j] = rule.Value * float64(units)
+			} else {
+				base := net
+				if rule.Compound {
+					base += prior
+				}
+				amounts[j] = base * rule.Value / 100
+			}
+			group += amounts[j]
+		}
+		prior += group
+		i = j
+	}
+	return amounts
+}
+
+// computeTaxes memajaki harga price sebanyak units unit (malam atau
+// quantity). rules harus sudah disaring applicableTaxRules. Pajak inklusif
+// dikeluarkan dari price: karena rantai pajak linear terhadap harga bersih,
+// harga bersih dicari dari pajak inklusif pada net 0 dan net 1.
+func computeTaxes(rules []models.TaxRule, price float64, units int) taxResult {
+	price = roundAmount(price)
+	result := taxResult{price: price, net: price, total: price}
+	if len(rules) == 0 || price == 0 {
+		return result
+	}
+	inclusive := func(amounts []float64) float64 {
+		sum := 0.0
+		for i, rule := range rules {
+			if rule.Inclusive {
+				sum += amounts[i]
+			}
+		}
+		return sum
+	}
+	fixed := inclusive(taxChain(rules, 0, units))
+	rate := inclusive(taxChain(rules, 1, units)) - fixed
+	net := math.Max(0, (price-fixed)/(1+rate))
+
+	var inclusiveTax, exclusiveTax float64
+	for i, amount := range taxChain(rules, net, units) {
+		rule := rules[i]
+		line := models.TaxLine{
+			Code:      rule.Code,
+			Name:      rule.Name,
+			Type:      rule.Type,
+			Rate:      rule.Value,
+			Inclusive: rule.Inclusive,
+			Amount:    roundAmount(amount),
+		}
+		if line.Inclusive {
+			inclusiveTax += line.Amount
+		} else {
+			exclusiveTax += line.Amount
+		}
+		result.lines = append(result.lines, line)
+	}
+	// Selisih pembulatan masuk ke harga bersih supaya net + pajak inklusif
+	// tetap sama dengan harga
+	result.net = roundAmount(price - inclusiveTax)
+	result.tax = roundAmount(inclusiveTax + exclusiveTax)
+	result.total = roundAmount(price + exclusiveTax)
+	return result
+}
+
+// stayTaxes memajaki harga kamar per malam memakai aturan yang berlaku pada
+// tanggal menginap tersebut.
+func stayTaxes(rules []models.TaxRule, nightly []NightlyRate) taxResult {
+	var result taxResult
+	for _, night := range nightly {
+		date, err := time.Parse("2006-01-02", night.Date)
+		if err != nil {
+			continue
+		}
+		result.add(computeTaxes(applicableTaxRules(rules, models.ChargeCodeRoom, date), night.Rate, 1))
+	}
+	return result
+}
+
+// propertyTaxRules mengambil aturan pajak property; kamar tanpa property
+// tidak dipajaki.
+func (s *bookingService) propertyTaxRules(propertyID *uuid.UUID) ([]models.TaxRule, error) {
+	if propertyID == nil {
+		return nil, nil
+	}
+	property, err := s.propRepo.GetPropertyByID(propertyID.String())
+	if err != nil {
+		return nil, err
+	}
+	return property.TaxRules, nil
+}
+
+func (s *bookingService) bookingTaxRules(booking *models.Booking) ([]models.TaxRule, error) {
+	return s.propertyTaxRules(booking.PropertyID)
+}
+
+// mergeTaxLines menjumlahkan baris pajak dengan code, tarif, dan jenis yang
+// sama. Baris yang jumlahnya 0 dibuang.
+func mergeTaxLines(a, b []models.TaxLine) []models.TaxLine {
+	out := make([]models.TaxLine, 0, len(a)+len(b))
+	for _, line := range append(append([]models.TaxLine{}, a...), b...) {
+		merged := false
+		for i := range out {
+			if out[i].Code == line.Code && out[i].Name == line.Name && out[i].Type == line.Type &&
+				out[i].Rate == line.Rate && out[i].Inclusive == line.Inclusive {
+				out[i].Amount = roundAmount(out[i].Amount + line.Amount)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			out = append(out, line)
+		}
+	}
+	kept := out[:0]
+	for _, line := range out {
+		if line.Amount != 0 {
+			kept = append(kept, line)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
+
+// scaleTaxLines mengalikan setiap baris pajak dengan ratio; dipakai untuk
+// koreksi sebagian atas tagihan dan selisih harga.
+func scaleTaxLines(lines []models.TaxLine, ratio float64) []models.TaxLine {
+	out := make([]models.TaxLine, 0, len(lines))
+	for _, line := range lines {
+		line.Amount = roundAmount(line.Amount * ratio)
+		out = append(out, line)
+	}
+	return mergeTaxLines(nil, out)
+}
+
+func sumTaxLines(lines []models.TaxLine) (inclusive, exclusive float64) {
+	for _, line := range lines {
+		if line.Inclusive {
+			inclusive += line.Amount
+		} else {
+			exclusive += line.Amount
+		}
+	}
+	return roundAmount(inclusive), roundAmount(exclusive)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"hotelbooking/internal/models"
+)
+
+func TestComputeTaxes(t *testing.T) {
+	serviceCharge := models.TaxRule{Code: "SC", Type: models.TaxTypePercent, Value: 10, Order: 0}
+	pb1 := models.TaxRule{Code: "PB1", Type: models.TaxTypePercent, Value: 10, Order: 1, Compound: true}
+
+	tests := []struct {
+		name      string
+		rules     []models.TaxRule
+		price     float64
+		units     int
+		wantNet   float64
+		wantTax   float64
+		wantTotal float64
+	}{
+		{name: "no rules", price: 100000, units: 1, wantNet: 100000, wantTotal: 100000},
+		{
+			name:  "exclusive percent",
+			rules: []models.TaxRule{{Code: "PB1", Type: models.TaxTypePercent, Value: 10}},
+			price: 100000, units: 1, wantNet: 100000, wantTax: 10000, wantTotal: 110000,
+		},
+		{
+			name:  "inclusive percent",
+			rules: []models.TaxRule{{Code: "PB1", Type: models.TaxTypePercent, Value: 10, Inclusive: true}},
+			price: 110000, units: 1, wantNet: 100000, wantTax: 10000, wantTotal: 110000,
+		},
+		{
+			name:  "fixed per unit",
+			rules: []models.TaxRule{{Code: "CITY", Type: models.TaxTypeFixed, Value: 5000}},
+			price: 200000, units: 2, wantNet: 200000, wantTax: 10000, wantTotal: 210000,
+		},
+		{
+			name:  "compound on service charge",
+			rules: []models.TaxRule{serviceCharge, pb1},
+			price: 100000, units: 1, wantNet: 100000, wantTax: 21000, wantTotal: 121000,
+		},
+		{
+			name:  "inclusive compound chain",
+			rules: []models.TaxRule{withInclusive(serviceCharge), withInclusive(pb1)},
+			price: 121000, units: 1, wantNet: 100000, wantTax: 21000, wantTotal: 121000,
+		},
+		{
+			name:  "inclusive and exclusive",
+			rules: []models.TaxRule{withInclusive(serviceCharge), pb1},
+			price: 110000, units: 1, wantNet: 100000, wantTax: 21000, wantTotal: 121000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeTaxes(tt.rules, tt.price, tt.units)
+			if got.net != tt.wantNet || got.tax != tt.wantTax || got.total != tt.wantTotal {
+				t.Fatalf("got net %v tax %v total %v, want %v %v %v", got.net, got.tax, got.total, tt.wantNet, tt.wantTax, tt.wantTotal)
+			}
+			if got.net+sumInclusive(got.lines) != roundAmount(tt.price) {
+				t.Fatalf("net %v plus inclusive tax does not add up to price %v", got.net, tt.price)
+			}
+		})
+	}
+}
+
+func TestApplicableTaxRules(t *testing.T) {
+	from := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC)
+	rules := []models.TaxRule{
+		{Code: "PB1", Order: 1},
+		{Code: "SC", Order: 0, AppliesTo: []models.ChargeCode{models.ChargeCodeRoom}},
+		{Code: "PROMO", EffectiveFrom: &from, EffectiveTo: &to},
+	}
+
+	tests := []struct {
+		name string
+		code models.ChargeCode
+		date time.Time
+		want []string
+	}{
+		{name: "room in period", code: models.ChargeCodeRoom, date: to, want: []string{"SC", "PROMO", "PB1"}},
+		{name: "room after period", code: models.ChargeCodeRoom, date: to.AddDate(0, 0, 1), want: []string{"SC", "PB1"}},
+		{name: "other charge", code: models.ChargeCodeMinibar, date: from.AddDate(0, 0, -1), want: []string{"PB1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applicableTaxRules(rules, tt.code, tt.date)
+			codes := make([]string, 0, len(got))
+			for _, rule := range got {
+				codes = append(codes, rule.Code)
+			}
+			if len(codes) != len(tt.want) {
+				t.Fatalf("got %v, want %v", codes, tt.want)
+			}
+			for i := range codes {
+				if codes[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", codes, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func withInclusive(rule models.TaxRule) models.TaxRule {
+	rule.Inclusive = true
+	return rule
+}
+
+func sumInclusive(lines []models.TaxLine) float64 {
+	inclusive, _ := sumTaxLines(lines)
+	return inclusive
+}
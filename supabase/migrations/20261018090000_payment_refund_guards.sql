@@ -0,0 +1,76 @@
+-- Backend Supabase memakai skema yang sama dengan
+-- internal/repository/postgres/schema.sql. PostgREST tidak punya transaksi
+-- lintas request, jadi perubahan dana payment yang harus atomik dijalankan
+-- lewat function di bawah ini (dipanggil dengan rpc).
+
+-- Dana yang dikembalikan tidak boleh melebihi dana yang di-capture.
+do $$
+begin
+    if not exists (select 1 from pg_constraint where conname = 'payments_refund_within_paid') then
+        alter table payments
+            add constraint payments_refund_within_paid check (amount_refunded <= amount_paid);
+    end if;
+end
+$$;
+
+-- Mencatat charge yang di-capture lalu menambah amount_paid dalam satu
+-- transaksi. unique (payment_id, reference) menolak charge yang sama dicatat
+-- dua kali.
+create or replace function record_payment_capture(
+    p_payment_id  uuid,
+    p_charge_id   uuid,
+    p_provider    text,
+    p_reference   text,
+    p_amount      numeric,
+    p_captured_at timestamptz
+) returns setof payments
+language sql as $$
+    insert into payment_charges (id, payment_id, provider, reference, amount, captured_at)
+    values (p_charge_id, p_payment_id, p_provider, p_reference, p_amount, p_captured_at);
+
+    update payments
+    set amount_paid = amount_paid + p_amount,
+        status = case when amount_paid + p_amount - amount_refunded >= amount then 'Paid' else status end,
+        paid_at = case when amount_paid + p_amount - amount_refunded >= amount then p_captured_at else paid_at end
+    where id = p_payment_id
+    returning *;
+$$;
+
+-- Menambah amount_refunded secara atomik. Refund yang melebihi dana tersisa
+-- tidak mengubah baris apa pun (hasil kosong).
+create or replace function record_payment_refund(
+    p_payment_id uuid,
+    p_amount     numeric
+) returns setof payments
+language sql as $$
+    update payments
+    set amount_refunded = amount_refunded + p_amount,
+        status = case when amount_refunded + p_amount >= amount_paid then 'Refunded' else 'PartiallyRefunded' end
+    where id = p_payment_id and amount_paid - amount_refunded >= p_amount
+    returning *;
+$$;
+
+-- Mencatat refund Pending atas satu charge. Payment dikunci dulu supaya
+-- refund paralel atas charge yang sama antre dan masing-masing melihat
+-- refund Pending yang lain.
+create or replace function create_refund(p_refund jsonb)
+returns setof refunds
+language plpgsql as $$
+declare
+    refund    refunds := jsonb_populate_record(null::refunds, p_refund);
+    available numeric;
+begin
+    perform 1 from payments where id = refund.payment_id for update;
+    select coalesce((select sum(amount) from payment_charges
+                     where payment_id = refund.payment_id and reference = refund.charge_reference), 0)
+         - coalesce((select sum(amount) from refunds
+                     where payment_id = refund.payment_id and charge_reference = refund.charge_reference
+                       and status <> 'Failed'), 0)
+      into available;
+    if refund.amount > round(available, 2) then
+        raise exception 'refund_exceeds_charge';
+    end if;
+    insert into refunds select (refund).*;
+    return next refund;
+end
+$$;
@@ -0,0 +1,75 @@
+-- Alokasi kamar untuk backend Supabase. Exclusion constraint menjaga nomor
+-- kamar, sedangkan booking per tipe kamar dialokasikan lewat function
+-- create_booking yang mengunci baris room_types seperti backend postgres.
+
+-- Satu kamar tidak boleh dipakai dua booking aktif pada malam yang sama;
+-- booking yang dibatalkan atau kedaluwarsa otomatis melepas kamarnya.
+create extension if not exists btree_gist;
+
+do $$
+begin
+    if exists (select 1 from pg_constraint
+               where conname = 'bookings_room_no_overlap'
+                 and pg_get_constraintdef(oid) not like '%Expired%') then
+        alter table bookings drop constraint bookings_room_no_overlap;
+    end if;
+    if not exists (select 1 from pg_constraint where conname = 'bookings_room_no_overlap') then
+        alter table bookings
+            add constraint bookings_room_no_overlap
+            exclude using gist (room_id with =, daterange(check_in, check_out) with &&)
+            where (booking_status not in ('Cancelled', 'Expired'));
+    end if;
+end
+$$;
+
+-- Membuat booking setelah mengunci tipe kamar lalu kamarnya, sehingga
+-- booking paralel untuk inventori yang sama antre. Booking yang tidak
+-- kebagian unit ditolak dengan exception 'room_unavailable'.
+create or replace function create_booking(p_booking jsonb)
+returns setof bookings
+language plpgsql as $$
+declare
+    booking   bookings := jsonb_populate_record(null::bookings, p_booking);
+    remaining bigint;
+begin
+    if booking.room_type_id is not null then
+        perform 1 from room_types where id = booking.room_type_id for update;
+    end if;
+    if booking.room_id is not null then
+        perform 1 from rooms where id = booking.room_id for update;
+        if exists (
+            select 1 from bookings
+            where room_id = booking.room_id
+              and booking_status not in ('Cancelled', 'Expired')
+              and (booking_status <> 'New' or hold_expires_at is null or hold_expires_at > now())
+              and check_in < booking.check_out
+              and check_out > booking.check_in
+        ) then
+            raise exception 'room_unavailable';
+        end if;
+    end if;
+    if booking.room_type_id is not null then
+        select (select count(*) from rooms where room_type_id = booking.room_type_id and status <> 'OutOfOrder')
+             - coalesce(max(taken.n), 0)
+          into remaining
+        from generate_series(booking.check_in, booking.check_out - 1, interval '1 day') as night(d)
+        cross join lateral (
+            select count(*) as n from bookings
+            where room_type_id = booking.room_type_id
+              and booking_status not in ('Cancelled', 'Expired')
+              and (booking_status <> 'New' or hold_expires_at is null or hold_expires_at > now())
+              and check_in <= night.d::date
+              and check_out > night.d::date
+        ) taken;
+        if remaining <= 0 then
+            raise exception 'room_unavailable';
+        end if;
+    end if;
+
+    booking.refund_amount := coalesce(booking.refund_amount, 0);
+    booking.note := coalesce(booking.note, '');
+    booking.created_at := coalesce(booking.created_at, now());
+    insert into bookings select (booking).*;
+    return next booking;
+end
+$$;